@@ -2,11 +2,11 @@ package main
 
 import (
 	//"encoding/json"
-	"errors"
+	//"errors"
 	//"fmt"
 	"strconv"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
 //Entity names
@@ -30,23 +30,23 @@ func createAccountTable(stub shim.ChaincodeStubInterface) error {
 
 func addAccount(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	if len(args) != len(A_ColumnNames)-1 {
-		return nil, errors.New("Incorrect number of arguments. Expecting " + strconv.Itoa(len(A_ColumnNames)-1))
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting " + strconv.Itoa(len(A_ColumnNames)-1))
 	}
 	err := addRow(stub, AccountsTableName, args, false)
 	return nil, err
 }
 
 func getAccountsQuantity(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return countTableRows(stub, []string{AccountsTableName})
+	return countTableRows(stub, passIncludeDeletedOption([]string{AccountsTableName}, args))
 }
 
 func getAccountsList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return filterTableByValue(stub, []string{AccountsTableName})
+	return filterTableByValue(stub, passIncludeDeletedOption([]string{AccountsTableName}, args))
 }
 
 func updateAccountAmount(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	if len(args) != 2 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 2")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 2")
 	}
 	accountID, newAmount := args[0], args[1]
 	return updateTableField(stub, []string{AccountsTableName, accountID, A_AmountColName, newAmount})
@@ -0,0 +1,172 @@
+package main
+
+import (
+	//"encoding/json"
+	//"errors"
+	//"fmt"
+	//"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Archive table names are made of the prefix and the live table name
+const ArchiveTableNamePrefix = "Archived"
+
+//Tables of a deal which are moved to archive tables together with the Loan Request
+var archivedTableNames = []string{LoanRequestsTableName, LoanNegotiationsTableName, LoanTermTableName,
+	LoanTermProposalTableName, LoanTermVoteTableName, LoanTermCommentTableName, SealedBidRoundsTableName, SealedBidsTableName}
+
+//Loan Request statuses which allow archiving
+var archivableLoanRequestStatuses = []string{LR_StatusClosed, LR_StatusRepaid}
+
+// ============================================================================================================================
+// Closed and repaid deals are moved from live tables to archive tables with the same columns.
+// Archive tables are read only and stay queryable for compliance.
+// ============================================================================================================================
+
+func getArchiveTableName(tableName string) string {
+	return ArchiveTableNamePrefix + tableName
+}
+
+func isArchivedTable(tableName string) bool {
+	for _, tn := range archivedTableNames {
+		if tn == tableName {
+			return true
+		}
+	}
+	return false
+}
+
+// Live tables should be created before
+func CreateArchiveTables(stub shim.ChaincodeStubInterface) error {
+	for _, tableName := range archivedTableNames {
+		tbl, err := getTable(stub, tableName)
+		if err != nil {
+			return wrapError(err, "Failed getting '" + tableName + "' table in CreateArchiveTables func: ")
+		}
+
+		var columnNames []string
+		for _, cd := range tbl.ColumnDefinitions {
+			columnNames = append(columnNames, cd.Name)
+		}
+
+		err = createTable(stub, getArchiveTableName(tableName), columnNames)
+		if err != nil {
+			return wrapError(err, "Failed creating archive table for '" + tableName + "' table: ")
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerFunction(functionDefinition{Name: "archiveLoanRequest", Mode: FM_Write, Args: []argumentDefinition{{Name: LR_LoanRequestIDColName}},
+		handler: archiveLoanRequest, Description: "Moves closed or repaid Loan Request and its rows to archive tables"})
+	registerFunction(functionDefinition{Name: "getArchivedLoanRequestsList", Mode: FM_Read, Table: getArchiveTableName(LoanRequestsTableName),
+		Args: []argumentDefinition{includeDeletedArg}, handler: getArchivedLoanRequestsList, Description: "Returns all archived Loan Requests"})
+	registerFunction(functionDefinition{Name: "getArchivedLoanRequestByKey", Mode: FM_Read, Table: getArchiveTableName(LoanRequestsTableName),
+		Args: []argumentDefinition{{Name: "key"}}, handler: getArchivedLoanRequestByKey, Description: "Returns archived Loan Request with the key"})
+}
+
+func archiveLoanRequest(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in archiveLoanRequest func. Expecting 1")
+	}
+
+	loanRequestID := args[0]
+
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanRequestRowPermissionsByBankId(stub, loanRequestID)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in archiveLoanRequest or returned false: ")
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	status, err := getTableColValueByKey(stub, LoanRequestsTableName, loanRequestID, LR_StatusColName)
+	if err != nil {
+		return nil, wrapError(err, "Error getting Loan Request status in archiveLoanRequest func: ")
+	}
+	var isArchivable bool
+	for _, s := range archivableLoanRequestStatuses {
+		if s == status {
+			isArchivable = true
+			break
+		}
+	}
+	if !isArchivable {
+		return nil, newError(ErrCodeInvalidState, "Loan Request with status '" + status + "' can not be archived in archiveLoanRequest func")
+	}
+
+	var tableNames, keyValues []string
+	err = collectReferencingRows(stub, LoanRequestsTableName, loanRequestID, &tableNames, &keyValues)
+	if err != nil {
+		return nil, wrapError(err, "Error collecting deal rows in archiveLoanRequest func: ")
+	}
+
+	// Referencing rows are moved first, so no row references a missing one at any moment
+	for i := len(keyValues) - 1; i >= 0; i-- {
+		err = archiveRow(stub, tableNames[i], keyValues[i])
+		if err != nil {
+			return nil, wrapError(err, "Error in archiveLoanRequest func: ")
+		}
+	}
+
+	return nil, nil
+}
+
+func archiveRow(stub shim.ChaincodeStubInterface, tableName, keyValue string) error {
+	if !isArchivedTable(tableName) {
+		return newError(ErrCodeInvalidArgument, "Table '" + tableName + "' has no archive table")
+	}
+
+	row, err := getRow(stub, tableName, keyValue)
+	if err != nil {
+		return wrapError(err, "Failed getting row with key '" + keyValue + "' from '" + tableName + "' table: ")
+	}
+
+	archiveTableName := getArchiveTableName(tableName)
+	ok, err := insertRow(stub, archiveTableName, row)
+	if err != nil {
+		return wrapError(err, "Failed archiving row with key '" + keyValue + "' from '" + tableName + "' table: ")
+	}
+	if !ok {
+		return newError(ErrCodeConflict, "Row with key '" + keyValue + "' is already assigned in table '" + archiveTableName + "'")
+	}
+
+	err = moveRowDeletedMark(stub, tableName, archiveTableName, keyValue)
+	if err != nil {
+		return err
+	}
+
+	err = deleteTableRow(stub, tableName, keyValue)
+	if err != nil {
+		return wrapError(err, "Failed deleting archived row with key '" + keyValue + "' from '" + tableName + "' table: ")
+	}
+
+	logInfo(stub, "Row is archived", logFields{"table": tableName, "key": keyValue, "archiveTable": archiveTableName})
+	return recordRowChange(stub, tableName, keyValue, EA_Archived, nil, nil)
+}
+
+// Same as filterTableByValue, but for the archive table of the given table
+func filterArchiveTableByValue(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in filterArchiveTableByValue func. Expecting at least 1")
+	}
+	if !isArchivedTable(args[0]) {
+		return nil, newError(ErrCodeInvalidArgument, "Table '" + args[0] + "' has no archive table")
+	}
+
+	archiveArgs := append([]string{getArchiveTableName(args[0])}, args[1:]...)
+	return filterTableByValue(stub, archiveArgs)
+}
+
+func getArchivedLoanRequestsList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	return filterTableByValue(stub, passIncludeDeletedOption([]string{getArchiveTableName(LoanRequestsTableName)}, args))
+}
+
+func getArchivedLoanRequestByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 1")
+	}
+	keyValue := args[0]
+	return filterTableByKey(stub, getArchiveTableName(LoanRequestsTableName), keyValue)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	//"encoding/json"
+	//"errors"
+	//"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Entity names
+const AuditLogTableName = "AuditLog"
+
+//Column names
+const AL_AuditLogIDColName = "AuditLogID"
+const AL_TableNameColName = "TableName"
+const AL_RowKeyColName = "RowKey"
+const AL_ActionColName = "Action"
+const AL_ColumnNameColName = "ColumnName"
+const AL_OldValueColName = "OldValue"
+const AL_NewValueColName = "NewValue"
+const AL_BankIDColName = "BankID"
+const AL_UserIDColName = "UserID"
+const AL_TxIDColName = "TxID"
+const AL_DateColName = "Date"
+
+//Column quantity
+const AuditLogTableColsQty = 11
+
+// Tables which are not audited: logs themselves and deletion marks, which are audited as actions of deleted rows
+var notAuditedTableNames = []string{AuditLogTableName, MaintenanceLogTableName, DeletedRowsTableName}
+
+// ============================================================================================================================
+// Every change of a column is an audit entry. Creating a row makes entries for all its columns,
+// deleting, restoring and archiving make one entry without column.
+// Entry keys are made of the transaction ID and entry number in the transaction,
+// so entries do not depend on other transactions.
+// ============================================================================================================================
+
+func CreateAuditLogTable(stub shim.ChaincodeStubInterface) error {
+	AL_ColumnNames := []string{AL_AuditLogIDColName, AL_TableNameColName, AL_RowKeyColName, AL_ActionColName,
+		AL_ColumnNameColName, AL_OldValueColName, AL_NewValueColName, AL_BankIDColName, AL_UserIDColName,
+		AL_TxIDColName, AL_DateColName}
+	return createTable(stub, AuditLogTableName, AL_ColumnNames)
+}
+
+func init() {
+	registerFunction(functionDefinition{Name: "getAuditLogByEntity", Mode: FM_Read, Role: FR_Assigner, isMaintenance: true, Table: AuditLogTableName,
+		Args: []argumentDefinition{{Name: "table"}, {Name: "key"}}, handler: getAuditLogByEntity, Description: "Returns history of the row"})
+	registerFunction(functionDefinition{Name: "getAuditLogByActor", Mode: FM_Read, Role: FR_Assigner, isMaintenance: true, Table: AuditLogTableName,
+		Args: []argumentDefinition{{Name: "bankid"}, {Name: "userid", IsOptional: true}}, handler: getAuditLogByActor,
+		Description: "Returns changes made by the bank or its user"})
+	registerFunction(functionDefinition{Name: "getAuditLogByTimeRange", Mode: FM_Read, Role: FR_Assigner, isMaintenance: true, Table: AuditLogTableName,
+		Args: []argumentDefinition{{Name: "from", Description: "RFC3339 date, included"}, {Name: "to", Description: "RFC3339 date, excluded"}},
+		handler: getAuditLogByTimeRange, Description: "Returns changes made in the time range, empty dates are not limited"})
+}
+
+// Records row change in audit log and events. Previous values are given for updates only.
+func recordRowChange(stub shim.ChaincodeStubInterface, tableName, keyValue, action string, changedFields, previousValues map[string]string) error {
+	err := addAuditLogEntries(stub, tableName, keyValue, action, changedFields, previousValues)
+	if err != nil {
+		return err
+	}
+	return recordRowEvent(stub, tableName, keyValue, action, changedFields, previousValues)
+}
+
+func addAuditLogEntries(stub shim.ChaincodeStubInterface, tableName, keyValue, action string, changedFields, previousValues map[string]string) error {
+	for _, tn := range notAuditedTableNames {
+		if tn == tableName {
+			return nil
+		}
+	}
+
+	bankID, err := getBankId(stub, []string{})
+	if err != nil {
+		return wrapError(err, "Error getting bankid in addAuditLogEntries func: ")
+	}
+	userID, err := getUserId(stub, []string{})
+	if err != nil {
+		return wrapError(err, "Error getting userid in addAuditLogEntries func: ")
+	}
+	date, err := getTxTimestampString(stub)
+	if err != nil {
+		return wrapError(err, "Error in addAuditLogEntries func: ")
+	}
+
+	var columnNames []string
+	for columnName := range changedFields {
+		columnNames = append(columnNames, columnName)
+	}
+	sort.Strings(columnNames)
+	if len(columnNames) == 0 {
+		columnNames = []string{""}
+	}
+	for _, columnName := range columnNames {
+		txID := stub.GetTxID()
+		auditLogID, err := getNextAuditLogID(stub, txID)
+		if err != nil {
+			return wrapError(err, "Error in addAuditLogEntries func: ")
+		}
+
+		err = addRow(stub, AuditLogTableName, []string{auditLogID, tableName, keyValue, action, columnName,
+			previousValues[columnName], changedFields[columnName], string(bankID), string(userID), txID, date}, true)
+		if err != nil {
+			return wrapError(err, "Error in addAuditLogEntries func: ")
+		}
+	}
+	return nil
+}
+
+// Keys already used by the transaction are skipped, e.g. when Init is repeated with the same transaction ID
+func getNextAuditLogID(stub shim.ChaincodeStubInterface, txID string) (string, error) {
+	ctx := getTxContext(stub)
+	for {
+		ctx.auditLogSequence++
+		auditLogID := txID + ":" + strconv.Itoa(ctx.auditLogSequence)
+
+		row, err := getRow(stub, AuditLogTableName, auditLogID)
+		if err != nil {
+			return "", err
+		}
+		if row.Columns == nil {
+			return auditLogID, nil
+		}
+	}
+}
+
+// Returns entries matching the filter, empty filter values match all entries.
+// Dates are RFC3339 timestamps, the range includes from and excludes to.
+func filterAuditLog(stub shim.ChaincodeStubInterface, filter map[string]string, from, to string) ([]byte, error) {
+	var fromTime, toTime time.Time
+	var err error
+	if from != "" {
+		fromTime, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, wrapError(err, "Wrong date '" + from + "', expecting RFC3339 format: ")
+		}
+	}
+	if to != "" {
+		toTime, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, wrapError(err, "Wrong date '" + to + "', expecting RFC3339 format: ")
+		}
+	}
+
+	tbl, err := getTable(stub, AuditLogTableName)
+	if err != nil {
+		return nil, wrapError(err, "Error getting table in filterAuditLog func: ")
+	}
+
+	allRows, err := getRows(stub, AuditLogTableName)
+	if err != nil {
+		return nil, wrapError(err, "Error getting rows in filterAuditLog func: ")
+	}
+
+	var rows []Row
+	for _, row := range allRows {
+		var isMatched = true
+		for i, cd := range tbl.ColumnDefinitions {
+			value := row.Columns[i].Value
+			if filterValue := filter[cd.Name]; filterValue != "" && filterValue != value {
+				isMatched = false
+				break
+			}
+			if cd.Name == AL_DateColName && (from != "" || to != "") {
+				date, err := time.Parse(time.RFC3339, value)
+				if err != nil || (from != "" && date.Before(fromTime)) || (to != "" && !date.Before(toTime)) {
+					isMatched = false
+					break
+				}
+			}
+		}
+		if isMatched {
+			rows = append(rows, row)
+		}
+	}
+
+	// Rows of tables are ordered by key, which is not the order of changes
+	sort.Sort(auditLogRows(rows))
+
+	return recordsetToJson(stub, tbl, rows)
+}
+
+// Sorted by date, then by transaction and entry number
+type auditLogRows []Row
+
+func (r auditLogRows) Len() int      { return len(r) }
+func (r auditLogRows) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r auditLogRows) Less(i, j int) bool {
+	dateIndex, txIDIndex := AuditLogTableColsQty-1, AuditLogTableColsQty-2
+	if di, dj := r[i].Columns[dateIndex].Value, r[j].Columns[dateIndex].Value; di != dj {
+		return di < dj
+	}
+	if ti, tj := r[i].Columns[txIDIndex].Value, r[j].Columns[txIDIndex].Value; ti != tj {
+		return ti < tj
+	}
+	ki, kj := r[i].Columns[0].Value, r[j].Columns[0].Value
+	if len(ki) != len(kj) {
+		return len(ki) < len(kj)
+	}
+	return ki < kj
+}
+
+// History of a row: table name, key
+func getAuditLogByEntity(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getAuditLogByEntity func. Expecting 2")
+	}
+	return filterAuditLog(stub, map[string]string{AL_TableNameColName: args[0], AL_RowKeyColName: args[1]}, "", "")
+}
+
+// Changes made by a bank or by a user of the bank: bankid [, userid]
+func getAuditLogByActor(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getAuditLogByActor func. Expecting 1 or 2")
+	}
+	filter := map[string]string{AL_BankIDColName: args[0]}
+	if len(args) == 2 {
+		filter[AL_UserIDColName] = args[1]
+	}
+	return filterAuditLog(stub, filter, "", "")
+}
+
+// Changes made in the time range: from, to. Either of them can be empty.
+func getAuditLogByTimeRange(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getAuditLogByTimeRange func. Expecting 2")
+	}
+	return filterAuditLog(stub, map[string]string{}, args[0], args[1])
+}
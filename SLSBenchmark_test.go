@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+// Compares benchmarks with the baseline: go test -run BenchmarkRegression -benchmark-regression
+// Rewrites the baseline on the reference machine: go test -run BenchmarkRegression -update-benchmark-baseline
+var benchmarkRegression = flag.Bool("benchmark-regression", false, "compare table benchmarks with "+benchmarkBaselinePath)
+var updateBenchmarkBaseline = flag.Bool("update-benchmark-baseline", false, "update "+benchmarkBaselinePath)
+var benchmarkThreshold = flag.Float64("benchmark-threshold", 1.5, "allowed ratio of benchmark results to the baseline")
+
+const benchmarkBaselinePath = "testdata/benchmarks.json"
+
+// Regression check keeps the best of the runs, because single runs of slow benchmarks are noisy
+const benchmarkRegressionRuns = 3
+
+// Numbers of Loan Negotiations on benchmarked ledgers. Every ledger has a Loan Request with a Loan Term per
+// 10 negotiations and 10 Loan Term Comments per negotiation, so the largest one has 100k comments.
+var benchmarkVolumes = []int{100, 1000, 10000}
+
+type benchmarkFixture struct {
+	stub         *shimtest.MockStub
+	negotiations int
+	// Keys of the first fixture rows
+	loanRequestID     string
+	loanNegotiationID string
+	loanTermID        string
+	tx                int
+}
+
+// Fixtures are built once for every volume, benchmarks of adds make them grow by the number of iterations
+var benchmarkFixtures = make(map[int]*benchmarkFixture)
+
+// Inserts rows after the greatest key of the table without audit log and foreign key checks. Rows are inserted
+// in descending key order, which MockStub inserts fastest. Returns the first key.
+func insertBenchmarkRows(b testing.TB, stub *shimtest.MockStub, tableName string, count int, values func(i int) map[string]string) string {
+	maxKey, err := getTableMaxKey(stub, tableName)
+	if err != nil {
+		fmt.Println("Failed getting max key of", tableName, err)
+		b.FailNow()
+	}
+	offset, _ := strconv.Atoi(string(maxKey))
+	tbl, err := getTable(stub, tableName)
+	if err != nil {
+		fmt.Println("Failed getting table", tableName, err)
+		b.FailNow()
+	}
+	for i := count - 1; i >= 0; i-- {
+		v := values(i)
+		v[tbl.ColumnDefinitions[0].Name] = strconv.Itoa(offset + 1 + i)
+		var cols []*Column
+		for _, cd := range tbl.ColumnDefinitions {
+			cols = append(cols, &Column{Value: v[cd.Name]})
+		}
+		ok, err := insertRow(stub, tableName, Row{Columns: cols})
+		if !ok || err != nil {
+			fmt.Println("Failed inserting row of", tableName, err)
+			b.FailNow()
+		}
+	}
+	return strconv.Itoa(offset + 1)
+}
+
+// Returns the demo ledger with the volume of rows, banks 6-15 of the demo data arrange requests and take part in them
+func getBenchmarkFixture(b testing.TB, negotiations int) *benchmarkFixture {
+	if f, ok := benchmarkFixtures[negotiations]; ok {
+		return f
+	}
+	f := &benchmarkFixture{stub: shimtest.NewMockStub("benchmark", new(SimpleChaincode)), negotiations: negotiations}
+	_, err := mockInit(f.stub, "1", []string{"mode=demo"})
+	if err != nil {
+		fmt.Println("Init failed", err)
+		b.FailNow()
+	}
+
+	requests := negotiations / 10
+	f.stub.MockTransactionStart("fixture")
+	// Tables with greater state keys are filled first, so rows of smaller tables are inserted fast as well
+	maxTerm, _ := getTableMaxKey(f.stub, LoanTermTableName)
+	maxRequest, _ := getTableMaxKey(f.stub, LoanRequestsTableName)
+	firstTerm, _ := strconv.Atoi(string(maxTerm))
+	firstRequest, _ := strconv.Atoi(string(maxRequest))
+	insertBenchmarkRows(b, f.stub, LoanTermCommentTableName, negotiations*10, func(i int) map[string]string {
+		bankID := 6 + i%10
+		return map[string]string{LTC_LoanTermIDColName: strconv.Itoa(firstTerm + 1 + i%requests), LTC_BankIDColName: strconv.Itoa(bankID),
+			LTC_UserIDColName: strconv.Itoa((bankID-6)*4 + 1), LTC_CommentTextColName: "Comment " + strconv.Itoa(i)}
+	})
+	f.loanTermID = insertBenchmarkRows(b, f.stub, LoanTermTableName, requests, func(i int) map[string]string {
+		return map[string]string{LT_LoanRequestIDColName: strconv.Itoa(firstRequest + 1 + i), LT_ParagraphNumberColName: "1",
+			LT_LoanTermTextColName: "Margin", LT_LoanTermStatusColName: "Draft"}
+	})
+	f.loanNegotiationID = insertBenchmarkRows(b, f.stub, LoanNegotiationsTableName, negotiations, func(i int) map[string]string {
+		return map[string]string{LN_LoanRequestIDColName: strconv.Itoa(firstRequest + 1 + i/10), LN_ParticipantBankIDColName: strconv.Itoa(6 + i%10),
+			LN_NegotiationStatusColName: "INVITED", LN_AmountColName: "10000000"}
+	})
+	f.loanRequestID = insertBenchmarkRows(b, f.stub, LoanRequestsTableName, requests, func(i int) map[string]string {
+		return map[string]string{LR_ArrangerBankIDColName: strconv.Itoa(6 + i%10), LR_ProjectNameColName: "Project " + strconv.Itoa(i),
+			LR_StatusColName: "Invitation Sent", LR_CurrencyColName: "NOK"}
+	})
+	endTxContext(f.stub)
+	f.stub.MockTransactionEnd("fixture")
+
+	benchmarkFixtures[negotiations] = f
+	return f
+}
+
+func (f *benchmarkFixture) invoke(b *testing.B, function string, values map[string]string) {
+	f.tx++
+	_, err := mockInvokeWithTransient(f.stub, "benchmark"+strconv.Itoa(f.tx), function, values)
+	if err != nil {
+		fmt.Println("Invoke", function, "failed", err)
+		b.FailNow()
+	}
+}
+
+func (f *benchmarkFixture) query(b *testing.B, function string, args ...string) {
+	_, err := mockQuery(f.stub, function, args)
+	if err != nil {
+		fmt.Println("Query", function, args, "failed", err)
+		b.FailNow()
+	}
+}
+
+type benchmarkCase struct {
+	Name string
+	run  func(b *testing.B, f *benchmarkFixture)
+}
+
+var benchmarkCases = []benchmarkCase{
+	{"addLoanNegotiation", func(b *testing.B, f *benchmarkFixture) {
+		for i := 0; i < b.N; i++ {
+			f.invoke(b, "addLoanNegotiation", map[string]string{LN_LoanRequestIDColName: f.loanRequestID, LN_ParticipantBankIDColName: "7",
+				LN_NegotiationStatusColName: "INVITED"})
+		}
+	}},
+	// Loan Request status is calculated again from all negotiations
+	{"updateLoanNegotiationStatus", func(b *testing.B, f *benchmarkFixture) {
+		statuses := []string{"INTERESTED", "DECLINED"}
+		for i := 0; i < b.N; i++ {
+			f.invoke(b, "updateLoanNegotiationStatus", map[string]string{LN_LoanNegotiationIDColName: f.loanNegotiationID,
+				LN_NegotiationStatusColName: statuses[i%2]})
+		}
+	}},
+	{"addLoanTermComment", func(b *testing.B, f *benchmarkFixture) {
+		for i := 0; i < b.N; i++ {
+			f.invoke(b, "addLoanTermComment", map[string]string{LTC_LoanTermIDColName: f.loanTermID, LTC_BankIDColName: "6",
+				LTC_UserIDColName: "1", LTC_CommentTextColName: "Benchmark"})
+		}
+	}},
+	{"filterLoanNegotiations", func(b *testing.B, f *benchmarkFixture) {
+		actAsMaintainer()
+		defer stopActing()
+		for i := 0; i < b.N; i++ {
+			f.query(b, "filterTableByValue", LoanNegotiationsTableName, LN_LoanRequestIDColName, f.loanRequestID)
+		}
+	}},
+	{"countLoanTermComments", func(b *testing.B, f *benchmarkFixture) {
+		for i := 0; i < b.N; i++ {
+			f.query(b, "getLoanTermCommentQuantity")
+		}
+	}},
+	// Front end of the arranger bank shows its requests with negotiations and terms, which are filtered by the front end
+	{"dealView", func(b *testing.B, f *benchmarkFixture) {
+		actAs(bank6UserIdentity)
+		defer stopActing()
+		for i := 0; i < b.N; i++ {
+			f.query(b, "getProjectsList")
+			f.query(b, "getLoanNegotiationsList")
+			f.query(b, "getLoanTermList")
+		}
+	}},
+}
+
+func getBenchmarkName(c benchmarkCase, negotiations int) string {
+	return c.Name + "/negotiations=" + strconv.Itoa(negotiations)
+}
+
+// Logs of benchmarked transactions are discarded. Garbage of previous cases is collected before the timer starts,
+// so it is not counted in the case.
+func runBenchmarkCase(b *testing.B, c benchmarkCase, negotiations int) {
+	logOutput = ioutil.Discard
+	defer func() {
+		logOutput = os.Stdout
+	}()
+	f := getBenchmarkFixture(b, negotiations)
+	runtime.GC()
+	b.ReportAllocs()
+	b.ResetTimer()
+	c.run(b, f)
+}
+
+// go test -run '^$' -bench Tables/addLoanNegotiation
+func BenchmarkSLSChaincode_Tables(b *testing.B) {
+	for _, c := range benchmarkCases {
+		for _, negotiations := range benchmarkVolumes {
+			c, negotiations := c, negotiations
+			b.Run(getBenchmarkName(c, negotiations), func(b *testing.B) {
+				runBenchmarkCase(b, c, negotiations)
+			})
+		}
+	}
+}
+
+type benchmarkResult struct {
+	NsPerOp     int64
+	AllocsPerOp int64
+}
+
+// Time depends on the machine, so the baseline is compared on the machine where it was written.
+// Allocations do not depend on it and show most changes of scans and key layouts.
+func TestSLSChaincode_BenchmarkRegression(t *testing.T) {
+	if !*benchmarkRegression && !*updateBenchmarkBaseline {
+		return
+	}
+
+	results := make(map[string]benchmarkResult)
+	for _, c := range benchmarkCases {
+		for _, negotiations := range benchmarkVolumes {
+			c, negotiations := c, negotiations
+			var best benchmarkResult
+			for i := 0; i < benchmarkRegressionRuns; i++ {
+				r := testing.Benchmark(func(b *testing.B) {
+					runBenchmarkCase(b, c, negotiations)
+				})
+				if i == 0 || r.NsPerOp() < best.NsPerOp {
+					best = benchmarkResult{r.NsPerOp(), r.AllocsPerOp()}
+				}
+			}
+			results[getBenchmarkName(c, negotiations)] = best
+		}
+	}
+
+	if *updateBenchmarkBaseline {
+		b, _ := json.MarshalIndent(results, "", "  ")
+		err := ioutil.WriteFile(benchmarkBaselinePath, append(b, '\n'), 0644)
+		if err != nil {
+			fmt.Println("Failed writing benchmark baseline", err)
+			t.FailNow()
+		}
+		return
+	}
+
+	b, err := ioutil.ReadFile(benchmarkBaselinePath)
+	if err != nil {
+		fmt.Println("Failed reading benchmark baseline", err)
+		t.FailNow()
+	}
+	var baseline map[string]benchmarkResult
+	err = json.Unmarshal(b, &baseline)
+	if err != nil {
+		fmt.Println("Wrong benchmark baseline", err)
+		t.FailNow()
+	}
+
+	var names []string
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	failed := false
+	for _, name := range names {
+		r, base := results[name], baseline[name]
+		if base.NsPerOp == 0 {
+			fmt.Println("Benchmark", name, "is missing in the baseline")
+			failed = true
+			continue
+		}
+		for _, m := range []struct {
+			unit         string
+			value, limit int64
+		}{
+			{"ns/op", r.NsPerOp, base.NsPerOp},
+			{"allocs/op", r.AllocsPerOp, base.AllocsPerOp},
+		} {
+			fmt.Printf("%-55s %12d %s (baseline %d)\n", name, m.value, m.unit, m.limit)
+			if float64(m.value) > float64(m.limit)**benchmarkThreshold {
+				fmt.Println("Benchmark", name, "regressed:", m.value, m.unit, "is more than", *benchmarkThreshold, "times", m.limit)
+				failed = true
+			}
+		}
+	}
+	if failed {
+		t.FailNow()
+	}
+}
@@ -17,17 +17,20 @@ limitations under the License.
 package main
 
 import (
-	"errors"
-	"fmt"
+	//"errors"
+	//"fmt"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
 )
 
 // SimpleChaincode example simple Chaincode implementation
 type SimpleChaincode struct {
 }
 
-const isAuthenticationEnabled = false
+// Role and bank checks read certificate attributes of the caller. They are disabled until certificates with attributes
+// are issued to users, tests enable them with test identities.
+var isAuthenticationEnabled = false
 
 // ============================================================================================================================
 // Main
@@ -35,467 +38,304 @@ const isAuthenticationEnabled = false
 func main() {
 	err := shim.Start(new(SimpleChaincode))
 	if err != nil {
-		fmt.Printf("Error starting Simple chaincode: %s", err)
+		logError(nil, "Error starting chaincode", logFields{"error": err.Error()})
 	}
 }
 
-// Init resets all the things
-func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+// Init creates missing tables and migrates existing ones, ledger data is never deleted.
+// Arguments of Init and Invoke are the function name followed by its arguments, the name is ignored by Init.
+// Errors of Init and Invoke are returned as JSON with an error code in the message of the response.
+func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	defer endTxContext(stub)
+	startLogContext(stub, function)
+	result, err := initLedger(stub, args)
+	if err != nil {
+		logError(stub, "Init failed", logFields{"error": err.Error(), "code": getErrorCode(err)})
+		return shim.Error(toResponseError(err).Error())
+	}
+	return shim.Success(result)
+}
 
-	err := CreateParticipantTable(stub)
+func initLedger(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	settings, err := parseInitArgs(args)
+	if err != nil {
+		return nil, wrapError(err, "Failed parsing Init arguments: ")
+	}
+	mode := settings[ModeSettingName]
+	if mode != "" && mode != ModeDemo && mode != ModeProduction {
+		return nil, newError(ErrCodeInvalidArgument, "Unknown mode '" + mode + "', expecting '" + ModeDemo + "' or '" + ModeProduction + "'")
+	}
+	level := settings[LogLevelSettingName]
+	if level != "" {
+		_, err = getLogLevelIndex(level)
+		if err != nil {
+			return nil, err
+		}
+	}
+	positionalArgs := settings[PositionalArgsSettingName]
+	if positionalArgs != "" {
+		err = checkPositionalArgsSetting(positionalArgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	bankMSPIDs := settings[BankMSPIDsSettingName]
+	if bankMSPIDs != "" {
+		_, err = parseBankMSPIDs(bankMSPIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Rows of ledgers created with the v0.6 table API are moved to composite keys first
+	err = migrateLegacyTables(stub)
 	if err != nil {
-		return nil, errors.New("Failed creating Participants table: " + err.Error())
+		return nil, wrapError(err, "Failed migrating tables of the v0.6 table layout: ")
+	}
+	err = migrateRowKeys(stub)
+	if err != nil {
+		return nil, wrapError(err, "Failed migrating row keys: ")
+	}
+
+	// Participants table exists since the first chaincode version
+	_, err = getTable(stub, ParticipantsTableName)
+	isNewLedger := err != nil
+
+	err = CreateParticipantTable(stub)
+	if err != nil {
+		return nil, wrapError(err, "Failed creating Participants table: ")
 	}
 	err = CreateLoanRequestTable(stub)
 	if err != nil {
-		return nil, errors.New("Failed creating LoanRequests table: " + err.Error())
+		return nil, wrapError(err, "Failed creating LoanRequests table: ")
 	}
 	err = CreateLoanNegotiationTable(stub)
 	if err != nil {
-		return nil, errors.New("Failed creating LoanNegotiations table: " + err.Error())
+		return nil, wrapError(err, "Failed creating LoanNegotiations table: ")
 	}
 	err = CreateLoanTermTable(stub)
 	if err != nil {
-		return nil, errors.New("Failed creating LoanTerms table: " + err.Error())
+		return nil, wrapError(err, "Failed creating LoanTerms table: ")
 	}
 	err = CreateLoanTermProposalTable(stub)
 	if err != nil {
-		return nil, errors.New("Failed creating LoanTermProposals table: " + err.Error())
+		return nil, wrapError(err, "Failed creating LoanTermProposals table: ")
 	}
 	err = CreateLoanTermVoteTable(stub)
 	if err != nil {
-		return nil, errors.New("Failed creating LoanTermVotes table: " + err.Error())
+		return nil, wrapError(err, "Failed creating LoanTermVotes table: ")
 	}
 	err = CreateLoanTermCommentTable(stub)
 	if err != nil {
-		return nil, errors.New("Failed creating LoanTermComments table: " + err.Error())
+		return nil, wrapError(err, "Failed creating LoanTermComments table: ")
 	}
-	err = CreateUserTable(stub)
+	err = CreateSealedBidRoundTable(stub)
 	if err != nil {
-		return nil, errors.New("Failed creating Users table: " + err.Error())
-	}
-
-	populateInitialData(stub, args)
-
-	return nil, nil
-}
-
-// Invoke is our entry point to invoke a chaincode function
-func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
-	fmt.Println("invoke is running " + function)
-
-	// Handle different functions
-	if function == "init" { //initialize the chaincode state, used as reset
-		return t.Init(stub, "init", args)
-	}
-
-	//========================================================================
-	//Participant
-	if function == "addParticipant" {
-		return addParticipant(stub, args)
-	}
-
-	//========================================================================
-	//LoanRequest
-	if function == "addLoanRequest" {
-		return addLoanRequest(stub, args)
-	}
-	if function == "updateLoanRequest" {
-		return updateLoanRequest(stub, args)
-	}
-
-	//========================================================================
-	//Loan Negotiation
-	if function == "addLoanNegotiation" {
-		return addLoanNegotiation(stub, args)
+		return nil, wrapError(err, "Failed creating SealedBidRounds table: ")
 	}
-	if function == "updateLoanNegotiation" {
-		return updateLoanNegotiation(stub, args)
+	err = CreateSealedBidTable(stub)
+	if err != nil {
+		return nil, wrapError(err, "Failed creating SealedBids table: ")
 	}
-	if function == "updateLoanNegotiationStatus" {
-		return updateLoanNegotiationStatus(stub, args)
+	err = CreateUserTable(stub)
+	if err != nil {
+		return nil, wrapError(err, "Failed creating Users table: ")
 	}
-	if function == "updateParticipantBankComment" {
-		return updateParticipantBankComment(stub, args)
+	err = CreateDeletedRowsTable(stub)
+	if err != nil {
+		return nil, wrapError(err, "Failed creating DeletedRows table: ")
 	}
-
-	//========================================================================
-	//Loan Term
-	if function == "addLoanTerm" {
-		return addLoanTerm(stub, args)
+	err = CreateArchiveTables(stub)
+	if err != nil {
+		return nil, wrapError(err, "Failed creating archive tables: ")
 	}
-	if function == "updateLoanTerm" {
-		return updateLoanTerm(stub, args)
+	err = CreateAuditLogTable(stub)
+	if err != nil {
+		return nil, wrapError(err, "Failed creating AuditLog table: ")
 	}
-
-	//========================================================================
-	//Loan Term Proposal
-	if function == "addLoanTermProposal" {
-		return addLoanTermProposal(stub, args)
+	err = CreateSettingsTable(stub)
+	if err != nil {
+		return nil, wrapError(err, "Failed creating Settings table: ")
 	}
-	if function == "updateLoanTermProposal" {
-		return updateLoanTermProposal(stub, args)
+	err = CreateMaintenanceLogTable(stub)
+	if err != nil {
+		return nil, wrapError(err, "Failed creating MaintenanceLog table: ")
 	}
 
-	//========================================================================
-	//Loan Term Proposal
-	if function == "addLoanTermProposal" {
-		return addLoanTermProposal(stub, args)
-	}
-	if function == "updateLoanTermProposal" {
-		return updateLoanTermProposal(stub, args)
+	currentMode, err := getSetting(stub, ModeSettingName, ModeDemo)
+	if err != nil {
+		return nil, wrapError(err, "Failed getting mode setting: ")
 	}
-
-	//========================================================================
-	//Loan Term Vote
-	if function == "addLoanTermVote" {
-		return addLoanTermVote(stub, args)
+	if mode == "" {
+		mode = currentMode
 	}
-	if function == "updateLoanTermVote" {
-		return updateLoanTermVote(stub, args)
+	if currentMode == ModeProduction && mode != ModeProduction {
+		return nil, newError(ErrCodeInvalidState, "Production ledger can not be switched to '" + mode + "' mode")
 	}
-
-	//========================================================================
-	//Loan Term Comment
-	if function == "addLoanTermComment" {
-		return addLoanTermComment(stub, args)
+	err = setSetting(stub, ModeSettingName, mode)
+	if err != nil {
+		return nil, wrapError(err, "Failed saving mode setting: ")
 	}
-	if function == "updateLoanTermComment" {
-		return updateLoanTermComment(stub, args)
+	if level != "" {
+		err = setLogLevel(stub, level)
+		if err != nil {
+			return nil, wrapError(err, "Failed saving log level setting: ")
+		}
 	}
-
-	//========================================================================
-	//User
-	if function == "addUser" {
-		return addUser(stub, args)
+	if positionalArgs != "" {
+		err = setSetting(stub, PositionalArgsSettingName, positionalArgs)
+		if err != nil {
+			return nil, wrapError(err, "Failed saving positional arguments setting: ")
+		}
 	}
-	if function == "updateUser" {
-		return updateUser(stub, args)
+	if bankMSPIDs != "" {
+		err = setSetting(stub, BankMSPIDsSettingName, bankMSPIDs)
+		if err != nil {
+			return nil, wrapError(err, "Failed saving bank MSP IDs setting: ")
+		}
 	}
 
-	//========================================================================
-	// Specific functions
-	if function == "updateTableField" {
-		return updateTableField(stub, args)
+	if isNewLedger {
+		// Tables are created with the latest schema
+		err = setSchemaVersion(stub, getLatestSchemaVersion())
+	} else {
+		err = runSchemaMigrations(stub)
 	}
-	if function == "deleteRow" {
-		return deleteRow(stub, args)
-	}
-	if function == "deleteRowsByColumnValue" {
-		return deleteRowsByColumnValue(stub, args)
-	}
-	if function == "populateInitialData" {
-		return populateInitialData(stub, args)
+	if err != nil {
+		return nil, wrapError(err, "Failed migrating ledger schema: ")
+	}
+
+	// Demo data is never populated in production mode. The demo fixture is populated only once,
+	// a fixture given in Init arguments is loaded every time, existing rows are skipped.
+	if mode != ModeProduction {
+		var fixtureArgs []string
+		if fixture, ok := settings[FixtureInitArgName]; ok {
+			fixtureArgs = []string{fixture}
+		}
+		if isNewLedger || fixtureArgs != nil {
+			_, err = populateInitialData(stub, fixtureArgs)
+			if err != nil {
+				return nil, wrapError(err, "Failed populating initial data: ")
+			}
+		}
+	} else if _, ok := settings[FixtureInitArgName]; ok {
+		return nil, newError(ErrCodeInvalidState, "Fixture can not be loaded in production mode")
 	}
-	//========================================================================
 
-	fmt.Println("invoke did not find func: " + function) //error
-
-	return nil, errors.New("Received unknown function invocation")
+	return nil, nil
 }
 
-// Query is our entry point for queries
-func (t *SimpleChaincode) Query(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
-	fmt.Println("query is running " + function)
-
-	//========================================================================
-	// Handle different functions
-	//Participants
-	if function == "getParticipantsQuantity" {
-		return getParticipantsQuantity(stub, args)
-	}
-	if function == "getParticipantsList" {
-		return getParticipantsList(stub, args)
-	}
-	if function == "getParticipantsByType" {
-		return getParticipantsByType(stub, args)
-	}
-	if function == "getParticipantsByKey" {
-		return getParticipantsByKey(stub, args)
-	}
-	if function == "getParticipantsMaxKey" {
-		return getParticipantsMaxKey(stub, args)
-	}
-
-	//========================================================================
-	//LoanRequest
-	if function == "getLoanRequestsQuantity" {
-		return getLoanRequestsQuantity(stub, args)
-	}
-	if function == "getLoanRequestsList" {
-		return getLoanRequestsList(stub, args)
-	}
-	if function == "getLoanRequestByKey" {
-		return getLoanRequestByKey(stub, args)
-	}
-	if function == "getLoanRequestsMaxKey" {
-		return getLoanRequestsMaxKey(stub, args)
-	}
-
-	//========================================================================
-	//Loan Negotiation
-	if function == "getLoanNegotiationsQuantity" {
-		return getLoanNegotiationsQuantity(stub, args)
-	}
-	if function == "getLoanNegotiationsList" {
-		return getLoanNegotiationsList(stub, args)
-	}
-	if function == "getLoanNegotiationByKey" {
-		return getLoanNegotiationByKey(stub, args)
-	}
-	if function == "getLoanNegotiationsMaxKey" {
-		return getLoanNegotiationsMaxKey(stub, args)
-	}
-
-	//========================================================================
-	//Loan Term
-	if function == "getLoanTermQuantity" {
-		return getLoanTermQuantity(stub, args)
-	}
-	if function == "getLoanTermList" {
-		return getLoanTermList(stub, args)
-	}
-	if function == "getLoanTermByKey" {
-		return getLoanTermByKey(stub, args)
-	}
-	if function == "getLoanTermMaxKey" {
-		return getLoanTermMaxKey(stub, args)
-	}
-
-	//========================================================================
-	//Loan Term Proposal
-	if function == "getLoanTermProposalQuantity" {
-		return getLoanTermProposalQuantity(stub, args)
-	}
-	if function == "getLoanTermProposalList" {
-		return getLoanTermProposalList(stub, args)
-	}
-	if function == "getLoanTermProposalByKey" {
-		return getLoanTermProposalByKey(stub, args)
-	}
-	if function == "getLoanTermProposalMaxKey" {
-		return getLoanTermProposalMaxKey(stub, args)
-	}
-
-	//========================================================================
-	//Loan Term Vote
-	if function == "getLoanTermVoteQuantity" {
-		return getLoanTermVoteQuantity(stub, args)
-	}
-	if function == "getLoanTermVoteList" {
-		return getLoanTermVoteList(stub, args)
-	}
-	if function == "getLoanTermVoteByKey" {
-		return getLoanTermVoteByKey(stub, args)
-	}
-	if function == "getLoanTermVoteMaxKey" {
-		return getLoanTermVoteMaxKey(stub, args)
+// Invoke is our entry point to invoke a chaincode function, events of changed rows are sent if it succeeds.
+// Read functions are run as queries, clients evaluate them without submitting transactions.
+func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	defer endTxContext(stub)
+	startLogContext(stub, function)
+	if getFunctionMode(function) == FM_Read {
+		return runQuery(stub, function, args)
 	}
 
-	//========================================================================
-	//Loan Term Comment
-	if function == "getLoanTermCommentQuantity" {
-		return getLoanTermCommentQuantity(stub, args)
-	}
-	if function == "getLoanTermCommentList" {
-		return getLoanTermCommentList(stub, args)
-	}
-	if function == "getLoanTermCommentByKey" {
-		return getLoanTermCommentByKey(stub, args)
+	startEvents(stub)
+	result, err := invoke(stub, function, args)
+	if err != nil {
+		takeEvents(stub)
+		logWarning(stub, "Invoke failed", logFields{"error": err.Error(), "code": getErrorCode(err)})
+		return shim.Error(toResponseError(err).Error())
 	}
-	if function == "getLoanTermCommentMaxKey" {
-		return getLoanTermCommentMaxKey(stub, args)
+	err = sendEvents(stub)
+	if err != nil {
+		return shim.Error(toResponseError(err).Error())
 	}
+	return shim.Success(result)
+}
 
-	//========================================================================
-	//User
-	if function == "getUserQuantity" {
-		return getUserQuantity(stub, args)
-	}
-	if function == "getUserList" {
-		return getUserList(stub, args)
-	}
-	if function == "getUserByKey" {
-		return getUserByKey(stub, args)
-	}
-	if function == "getUserMaxKey" {
-		return getUserMaxKey(stub, args)
-	}
+func invoke(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	logDebug(stub, "Invoke is running", nil)
+	return runFunction(stub, FM_Write, function, args)
+}
 
-	//========================================================================
-	// Special functions
-	if function == "countTableRows" {
-		return countTableRows(stub, args)
-	}
-	if function == "filterTableByValue" {
-		return filterTableByValue(stub, args)
-	}
-	/*if function == "printCallerCertificate" {
-		return printCallerCertificate(stub)
-	}*/
-	if function == "getCertAttribute" {
-		return getCertAttribute(stub, args)
-	}
-	if function == "getBankId" {
-		return getBankId(stub, args)
-	}
-	if function == "getUserId" {
-		return getUserId(stub, args)
-	}
-	if function == "getProjectsList" {
-		return getProjectsList(stub, args)
+// Runs read functions, which were called by Query of the v0.6 chaincode API
+func runQuery(stub shim.ChaincodeStubInterface, function string, args []string) pb.Response {
+	result, err := query(stub, function, args)
+	if err != nil {
+		logDebug(stub, "Query failed", logFields{"error": err.Error(), "code": getErrorCode(err)})
+		return shim.Error(toResponseError(err).Error())
 	}
-	//========================================================================
+	return shim.Success(result)
+}
 
-	fmt.Println("query did not find func: " + function) //error
+func query(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	logDebug(stub, "Query is running", nil)
+	return runFunction(stub, FM_Read, function, args)
+}
 
-	return nil, errors.New("Received unknown function query")
+func init() {
+	registerFunction(functionDefinition{Name: "getCertAttribute", Mode: FM_Read, Result: RT_Text, Args: []argumentDefinition{{Name: "name"}},
+		handler: getCertAttribute, Description: "Returns certificate attribute of the caller"})
+	registerFunction(functionDefinition{Name: "getBankId", Mode: FM_Read, Result: RT_Text, handler: getBankId, Description: "Returns bankid of the caller"})
+	registerFunction(functionDefinition{Name: "getUserId", Mode: FM_Read, Result: RT_Text, handler: getUserId, Description: "Returns userid of the caller"})
 }
 
 func getCertAttribute(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	if len(args) != 1 {
-		return nil, errors.New("Incorrect number of arguments in getCertAttribute func. Expecting 1")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getCertAttribute func. Expecting 1")
 	}
 
 	attrName := args[0]
-	attribute, err := stub.ReadCertAttribute(attrName)
+	attribute, err := getCallerAttribute(stub, attrName)
 	if err != nil {
-		return nil, errors.New("Failed retrieving Certificate Attribute '" + attrName + "' in getCertAttribute func: " + err.Error())
+		return nil, wrapError(err, "Failed retrieving Certificate Attribute '" + attrName + "' in getCertAttribute func: ")
 	}
 
-	return []byte("Attribute '" + attrName + "': " + string(attribute)), nil
+	return []byte("Attribute '" + attrName + "': " + attribute), nil
 }
 
 func getBankId(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	if len(args) != 0 {
-		return nil, errors.New("Incorrect number of arguments in getBankId func. Expecting 0")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getBankId func. Expecting 0")
 	}
 
-	attrName := "bankid"
-	attribute, err := stub.ReadCertAttribute(attrName)
+	attrName := CA_BankID
+	attribute, err := getCallerAttribute(stub, attrName)
 	if err != nil {
-		return nil, errors.New("Failed retrieving Certificate Attribute '" + attrName + "' in getBankId func: " + err.Error())
+		return nil, wrapError(err, "Failed retrieving Certificate Attribute '" + attrName + "' in getBankId func: ")
 	}
 
-	return []byte(string(attribute)), nil
+	return []byte(attribute), nil
 }
 
 func getUserId(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	if len(args) != 0 {
-		return nil, errors.New("Incorrect number of arguments in getUserId func. Expecting 0")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getUserId func. Expecting 0")
 	}
 
-	attrName := "userid"
-	attribute, err := stub.ReadCertAttribute(attrName)
+	attrName := CA_UserID
+	attribute, err := getCallerAttribute(stub, attrName)
 	if err != nil {
-		return nil, errors.New("Failed retrieving Certificate Attribute '" + attrName + "' in getUserId func: " + err.Error())
+		return nil, wrapError(err, "Failed retrieving Certificate Attribute '" + attrName + "' in getUserId func: ")
 	}
 
-	return []byte(string(attribute)), nil
+	return []byte(attribute), nil
 }
 
 func checkAttribute(stub shim.ChaincodeStubInterface, attrName, attrValue string) (bool, error) {
 	if !isAuthenticationEnabled {
 		return true, nil
 	}
+	return checkCallerAttribute(stub, attrName, attrValue)
+}
+
+// Checks the attribute even if authentication is disabled, e.g. roles of maintenance functions
+func checkCallerAttribute(stub shim.ChaincodeStubInterface, attrName, attrValue string) (bool, error) {
 	// Why stub.VerifyAttribute is not used here?????? Consider using it.
-	attribute, err := stub.ReadCertAttribute(attrName)
+	attribute, err := getCallerAttribute(stub, attrName)
 	if err != nil {
-		return false, errors.New("Error checking role: " + err.Error())
+		// Callers without certificate attributes are not allowed
+		return false, newError(ErrCodePermissionDenied, "Error checking role: " + err.Error())
 	}
-	if string(attribute) != attrValue {
-		return false, errors.New("Current user attribute '" + attrName + "' value is '" + string(attribute) + "' but not '" + attrValue + "'")
+	if attribute != attrValue {
+		return false, newError(ErrCodePermissionDenied, "Current user attribute '" + attrName + "' value is '" + attribute + "' but not '" + attrValue + "'")
 	}
 	return true, nil
 }
-
-func populateInitialData(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-
-	//Participants
-	_, _ = deleteRowsByColumnValue(stub, []string{ParticipantsTableName})
-	//Adding banks with keys
-	_, _ = addParticipant(stub, []string{"6", "SpareBank 1 SR-BANK", "Bank"})
-	_, _ = addParticipant(stub, []string{"7", "DNB ASA", "Bank"})
-	_, _ = addParticipant(stub, []string{"8", "Nationwide Building Society", "Bank"})
-	_, _ = addParticipant(stub, []string{"9", "JPMorgan Chase & Co", "Bank"})
-	_, _ = addParticipant(stub, []string{"10", "Barclays", "Bank"})
-	_, _ = addParticipant(stub, []string{"11", "Mizuho Bank, Ltd.", "Bank"})
-	_, _ = addParticipant(stub, []string{"12", "SpareBank 1 Nord-Norge", "Bank"})
-	_, _ = addParticipant(stub, []string{"13", "SpareBank 1 Hedmark", "Bank"})
-	_, _ = addParticipant(stub, []string{"14", "SpareBank 1 Modum", "Bank"})
-	_, _ = addParticipant(stub, []string{"15", "Skandinaviska Enskilda Banken AB", "Bank"})
-
-	//Adding users with keys
-	_, _ = addUser(stub, []string{"1", "6", "srbank"})
-	_, _ = addUser(stub, []string{"2", "6", "srbank_user1"})
-	_, _ = addUser(stub, []string{"3", "6", "srbank_user2"})
-	_, _ = addUser(stub, []string{"4", "6", "srbank_user3"})
-	_, _ = addUser(stub, []string{"5", "7", "dnb"})
-	_, _ = addUser(stub, []string{"6", "7", "dnb_user1"})
-	_, _ = addUser(stub, []string{"7", "7", "dnb_user2"})
-	_, _ = addUser(stub, []string{"8", "7", "dnb_user3"})
-	_, _ = addUser(stub, []string{"9", "8", "nationwide"})
-	_, _ = addUser(stub, []string{"10", "8", "nationwide_user1"})
-	_, _ = addUser(stub, []string{"11", "8", "nationwide_user2"})
-	_, _ = addUser(stub, []string{"12", "8", "nationwide_user3"})
-	_, _ = addUser(stub, []string{"13", "9", "jpmorgan"})
-	_, _ = addUser(stub, []string{"14", "9", "jpmorgan_user1"})
-	_, _ = addUser(stub, []string{"15", "9", "jpmorgan_user2"})
-	_, _ = addUser(stub, []string{"16", "9", "jpmorgan_user3"})
-	_, _ = addUser(stub, []string{"17", "10", "barclays"})
-	_, _ = addUser(stub, []string{"18", "10", "barclays_user1"})
-	_, _ = addUser(stub, []string{"19", "10", "barclays_user2"})
-	_, _ = addUser(stub, []string{"20", "10", "barclays_user3"})
-	_, _ = addUser(stub, []string{"21", "11", "mizuho"})
-	_, _ = addUser(stub, []string{"22", "11", "mizuho_user1"})
-	_, _ = addUser(stub, []string{"23", "11", "mizuho_user2"})
-	_, _ = addUser(stub, []string{"24", "11", "mizuho_user3"})
-	_, _ = addUser(stub, []string{"25", "12", "nordnorge"})
-	_, _ = addUser(stub, []string{"26", "12", "nordnorge_user1"})
-	_, _ = addUser(stub, []string{"27", "12", "nordnorge_user2"})
-	_, _ = addUser(stub, []string{"28", "12", "nordnorge_user3"})
-	_, _ = addUser(stub, []string{"29", "13", "hedmark"})
-	_, _ = addUser(stub, []string{"30", "13", "hedmark_user1"})
-	_, _ = addUser(stub, []string{"31", "13", "hedmark_user2"})
-	_, _ = addUser(stub, []string{"32", "13", "hedmark_user3"})
-	_, _ = addUser(stub, []string{"33", "14", "modum"})
-	_, _ = addUser(stub, []string{"34", "14", "modum_user1"})
-	_, _ = addUser(stub, []string{"35", "14", "modum_user2"})
-	_, _ = addUser(stub, []string{"36", "14", "modum_user3"})
-	_, _ = addUser(stub, []string{"37", "15", "seb"})
-	_, _ = addUser(stub, []string{"38", "15", "seb_user1"})
-	_, _ = addUser(stub, []string{"39", "15", "seb_user2"})
-	_, _ = addUser(stub, []string{"40", "15", "seb_user3"})
-
-	//Accounts
-	_, _ = deleteRowsByColumnValue(stub, []string{AccountsTableName})
-	_, _ = addAccount(stub, []string{"1", "10000"})
-	_, _ = addAccount(stub, []string{"2", "50000"})
-	_, _ = addAccount(stub, []string{"3", "30000"})
-
-	//Loan Request
-	// "BorrowerID", "ArrangerBankID", "LoanSharesAmount", "ProjectRevenue", "ProjectName", "ProjectInformation",
-	//"Company", "Website", "ContactPersonName", "ContactPersonSurname", "RequestDate",
-	//"Status", "MarketAndIndustry"
-	_, _ = deleteRowsByColumnValue(stub, []string{LoanRequestsTableName})
-	_, _ = addLoanRequest(stub, []string{"Statoil ASA", "6", "1M", "1M", "Statoil ASA project",
-		"Statoil ASA project info", "Statoil ASA", "www.statoil.com",
-		"John", "Smith", "10-01-2016", "Draft", "Oil industry",
-		"some LoanTerm", "some Assets", "some Convenants", "some InterestRate"})
-	_, _ = addLoanRequest(stub, []string{"BP Global", "7", "1M", "1M", "BP Global project",
-		"BP Global project info", "BP Global", "www.bp.com", "Peter",
-		"Froystad", "10-01-2016", "Draft", "Oil industry",
-		"some LoanTerm", "some Assets", "some Convenants", "some InterestRate"})
-
-	//Loan Share Negotiation
-	//"InvitationID","ParticipantBankID","Amount","NegotiationStatus", "ParticipantBankComment", "Date"
-	_, _ = deleteRowsByColumnValue(stub, []string{LoanNegotiationsTableName})
-	_, _ = addLoanNegotiation(stub, []string{"1", "6", "200 M USD", "INVITED", "Comment of SpareBank 1 SR-BANK", "11-01-2016"})
-	_, _ = addLoanNegotiation(stub, []string{"1", "9", "100 M USD", "INVITED", "Comment of JPMorgan", "12-01-2016"})
-	_, _ = addLoanNegotiation(stub, []string{"1", "10", "100 M USD", "INVITED", "Comment of Barclays", "12-01-2016"})
-	_, _ = addLoanNegotiation(stub, []string{"2", "7", "250 M USD", "INVITED", "Comment of Nationwide Building Society", "21-01-2016"})
-	_, _ = addLoanNegotiation(stub, []string{"2", "9", "200 M USD", "INVITED", "Comment of JPMorgan", "22-01-2016"})
-	_, _ = addLoanNegotiation(stub, []string{"2", "11", "300 M USD", "INVITED", "Comment of Mizuho Bank, Ltd.", "22-01-2016"})
-
-	return nil, nil
-}
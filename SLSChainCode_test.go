@@ -2,21 +2,141 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
 )
 
-func checkInit(t *testing.T, stub *shim.MockStub, args []string) {
-	_, err := stub.MockInit("1", "init", args)
+// Rewrites API catalogue used to generate clients: go test -run APICatalogue -update-catalogue
+var updateCatalogue = flag.Bool("update-catalogue", false, "update "+apiCataloguePath)
+
+const apiCataloguePath = "api/catalogue.json"
+const eventCataloguePath = "api/events.md"
+
+// Arguments of Init and Invoke are the function name followed by its arguments
+func getMockArgs(function string, args []string) [][]byte {
+	mockArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		mockArgs = append(mockArgs, []byte(arg))
+	}
+	return mockArgs
+}
+
+// Returns the payload of the response or its message as error. Sent events are taken from the stub,
+// because MockStub blocks when 100 events are not taken.
+func getMockResult(stub *shimtest.MockStub, response pb.Response) ([]byte, error) {
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+	if response.Status != shim.OK {
+		return nil, errors.New(response.Message)
+	}
+	return response.Payload, nil
+}
+
+// Clients pass the salt of private values with every invoke, tests derive it from the transaction ID.
+// Salt given by the test is kept, an empty one as well.
+func withTestSalt(transient map[string][]byte, uuid string) map[string][]byte {
+	if _, ok := transient[PrivateValueSaltName]; ok {
+		return transient
+	}
+	salted := map[string][]byte{}
+	for name, value := range transient {
+		salted[name] = value
+	}
+	salt := sha256.Sum256([]byte(uuid))
+	salted[PrivateValueSaltName] = salt[:]
+	return salted
+}
+
+// Adds the salt to the transient map of the stub, the returned func restores the map
+func addTestSalt(stub *shimtest.MockStub, uuid string) func() {
+	transient := stub.TransientMap
+	stub.TransientMap = withTestSalt(transient, uuid)
+	return func() {
+		stub.TransientMap = transient
+	}
+}
+
+func mockInit(stub *shimtest.MockStub, uuid string, args []string) ([]byte, error) {
+	defer addTestSalt(stub, uuid)()
+	return getMockResult(stub, stub.MockInit(uuid, getMockArgs("init", args)))
+}
+
+func mockInvoke(stub *shimtest.MockStub, uuid string, function string, args []string) ([]byte, error) {
+	defer addTestSalt(stub, uuid)()
+	return getMockResult(stub, stub.MockInvoke(uuid, getMockArgs(function, args)))
+}
+
+// Stub of a transaction with the given timestamp, MockStub gives transactions the current time when they start.
+// The mock stub keeps arguments unexported, so they are kept by the wrapper.
+type timedStub struct {
+	*shimtest.MockStub
+	args [][]byte
+	now  time.Time
+}
+
+func (s *timedStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return &timestamp.Timestamp{Seconds: s.now.Unix(), Nanos: int32(s.now.Nanosecond())}, nil
+}
+
+func (s *timedStub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s *timedStub) GetStringArgs() []string {
+	var args []string
+	for _, a := range s.args {
+		args = append(args, string(a))
+	}
+	return args
+}
+
+func (s *timedStub) GetFunctionAndParameters() (string, []string) {
+	args := s.GetStringArgs()
+	return args[0], args[1:]
+}
+
+// Invokes the function as a transaction made at the time
+func mockInvokeAt(stub *shimtest.MockStub, uuid string, now time.Time, function string, args []string) ([]byte, error) {
+	defer addTestSalt(stub, uuid)()
+	stub.MockTransactionStart(uuid)
+	response := new(SimpleChaincode).Invoke(&timedStub{MockStub: stub, args: getMockArgs(function, args), now: now})
+	stub.MockTransactionEnd(uuid)
+	return getMockResult(stub, response)
+}
+
+// Queries are invokes which are not submitted by clients
+func mockQuery(stub *shimtest.MockStub, function string, args []string) ([]byte, error) {
+	return mockInvoke(stub, "query", function, args)
+}
+
+func checkInit(t *testing.T, stub *shimtest.MockStub, args []string) {
+	_, err := mockInit(stub, "1", args)
 	if err != nil {
 		fmt.Println("Init failed", err)
 		t.FailNow()
 	}
 }
 
-func checkState(t *testing.T, stub *shim.MockStub, name string, value string) {
+func checkState(t *testing.T, stub *shimtest.MockStub, name string, value string) {
 	bytes := stub.State[name]
 	if bytes == nil {
 		fmt.Println("State", name, "failed to get value")
@@ -28,8 +148,8 @@ func checkState(t *testing.T, stub *shim.MockStub, name string, value string) {
 	}
 }
 
-func checkQuery(t *testing.T, stub *shim.MockStub, name string, args []string, value string) {
-	bytes, err := stub.MockQuery(name, args)
+func checkQuery(t *testing.T, stub *shimtest.MockStub, name string, args []string, value string) {
+	bytes, err := mockQuery(stub, name, args)
 	if err != nil {
 		fmt.Println("Query", name, "failed", err)
 		t.FailNow()
@@ -44,8 +164,8 @@ func checkQuery(t *testing.T, stub *shim.MockStub, name string, args []string, v
 	}
 }
 
-func checkInvoke(t *testing.T, stub *shim.MockStub, function string, args []string) {
-	_, err := stub.MockInvoke("1", "getBankId", args)
+func checkInvoke(t *testing.T, stub *shimtest.MockStub, function string, args []string) {
+	_, err := mockInvoke(stub, "1", function, args)
 	if err != nil {
 		fmt.Println("Invoke function", function, "with agrs", args, "failed", err)
 		t.FailNow()
@@ -54,7 +174,7 @@ func checkInvoke(t *testing.T, stub *shim.MockStub, function string, args []stri
 
 func TestSLSChaincode_Init(t *testing.T) {
 	scc := new(SimpleChaincode)
-	stub := shim.NewMockStub("ex02", scc)
+	stub := shimtest.NewMockStub("ex02", scc)
 
 	// Init A=123 B=234
 	checkInit(t, stub, []string{""})
@@ -71,6 +191,926 @@ func TestSLSChaincode_Init(t *testing.T) {
 
 /*func TestSLSChaincode_Query(t *testing.T) {
 	scc := new(SimpleChaincode)
-	stub := shim.NewMockStub("ex02", scc)
+	stub := shimtest.NewMockStub("ex02", scc)
 	checkQuery(t, stub, "countTableRowsg", []string{"Participants"}, "0")
 }*/
+
+func TestSLSChaincode_ForeignKeys(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+	actAsMaintainer()
+	defer stopActing()
+
+	checkInit(t, stub, []string{""})
+
+	// Loan term proposal should reference existing loan term
+	_, err := mockInvoke(stub, "2", "addLoanTermProposal", []string{"100", "1", "", "2016-02-01T00:00:00Z", ""})
+	if err == nil {
+		fmt.Println("Proposal referencing missing loan term was added")
+		t.FailNow()
+	}
+
+	_, err = mockInvoke(stub, "3", "addLoanTerm", []string{"1", "1", "Loan term text", "Draft"})
+	if err != nil {
+		fmt.Println("Failed adding loan term", err)
+		t.FailNow()
+	}
+	_, err = mockInvoke(stub, "4", "addLoanTermProposal", []string{"1", "1", "", "2016-02-01T00:00:00Z", ""})
+	if err != nil {
+		fmt.Println("Failed adding loan term proposal", err)
+		t.FailNow()
+	}
+
+	// Participant referenced by loan requests can not be deleted
+	_, err = mockInvoke(stub, "5", "deleteRow", []string{ParticipantsTableName, "6"})
+	if err == nil {
+		fmt.Println("Referenced participant was deleted")
+		t.FailNow()
+	}
+
+	// Loan request deletion cascades to negotiations, loan terms and proposals
+	_, err = mockInvoke(stub, "6", "deleteRow", []string{LoanRequestsTableName, "1"})
+	if err != nil {
+		fmt.Println("Failed deleting loan request", err)
+		t.FailNow()
+	}
+	checkQuery(t, stub, "getLoanNegotiationsQuantity", []string{}, "3")
+	checkQuery(t, stub, "getLoanTermQuantity", []string{}, "0")
+	checkQuery(t, stub, "getLoanTermProposalQuantity", []string{}, "0")
+}
+
+func TestSLSChaincode_SoftDeleteAndArchive(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+	actAsMaintainer()
+	defer stopActing()
+
+	checkInit(t, stub, []string{""})
+
+	_, err := mockInvoke(stub, "2", "deleteRow", []string{LoanRequestsTableName, "1"})
+	if err != nil {
+		fmt.Println("Failed deleting loan request", err)
+		t.FailNow()
+	}
+	checkQuery(t, stub, "getLoanNegotiationsQuantity", []string{}, "3")
+	checkQuery(t, stub, "getLoanNegotiationsQuantity", []string{IncludeDeletedOption}, "6")
+
+	// Restore brings back rows deleted together with the loan request
+	_, err = mockInvoke(stub, "3", "restoreRow", []string{LoanRequestsTableName, "1"})
+	if err != nil {
+		fmt.Println("Failed restoring loan request", err)
+		t.FailNow()
+	}
+	checkQuery(t, stub, "getLoanNegotiationsQuantity", []string{}, "6")
+
+	// Only closed or repaid deals can be archived
+	_, err = mockInvoke(stub, "4", "archiveLoanRequest", []string{"1"})
+	if err == nil {
+		fmt.Println("Draft loan request was archived")
+		t.FailNow()
+	}
+	_, err = mockInvoke(stub, "5", "updateTableField", []string{LoanRequestsTableName, "1", LR_StatusColName, LR_StatusClosed})
+	if err != nil {
+		fmt.Println("Failed closing loan request", err)
+		t.FailNow()
+	}
+	_, err = mockInvoke(stub, "6", "archiveLoanRequest", []string{"1"})
+	if err != nil {
+		fmt.Println("Failed archiving loan request", err)
+		t.FailNow()
+	}
+	checkQuery(t, stub, "getLoanRequestsQuantity", []string{}, "1")
+	checkQuery(t, stub, "getLoanNegotiationsQuantity", []string{IncludeDeletedOption}, "3")
+	checkQuery(t, stub, "countTableRows", []string{getArchiveTableName(LoanNegotiationsTableName)}, "3")
+
+	// Archived keys are not reused
+	checkQuery(t, stub, "getLoanRequestsMaxKey", []string{}, "2")
+}
+
+func TestSLSChaincode_Maintenance(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+	actAsMaintainer()
+	defer stopActing()
+
+	checkInit(t, stub, []string{"mode=production"})
+
+	// No demo data in production mode
+	checkQuery(t, stub, "getParticipantsQuantity", []string{}, "0")
+	_, err := mockInvoke(stub, "2", "populateInitialData", []string{})
+	if err == nil {
+		fmt.Println("Demo data was populated in production mode")
+		t.FailNow()
+	}
+
+	_, err = mockInvoke(stub, "3", "addParticipant", []string{"6", "SpareBank 1 SR-BANK", "Bank"})
+	if err != nil {
+		fmt.Println("Failed adding participant", err)
+		t.FailNow()
+	}
+
+	// Key columns are not in the allowlist
+	_, err = mockInvoke(stub, "4", "updateTableField", []string{ParticipantsTableName, "6", P_ParticipantKeyColName, "7"})
+	if err == nil {
+		fmt.Println("Key column was updated through maintenance API")
+		t.FailNow()
+	}
+	_, err = mockInvoke(stub, "5", "updateTableField", []string{ParticipantsTableName, "6", P_ParticipantNameColName, "SR-BANK"})
+	if err != nil {
+		fmt.Println("Failed updating participant name", err)
+		t.FailNow()
+	}
+	_, err = mockInvoke(stub, "6", "deleteRow", []string{SettingsTableName, ModeSettingName})
+	if err == nil {
+		fmt.Println("Settings row was deleted through maintenance API")
+		t.FailNow()
+	}
+
+	// Only successful maintenance invokes are recorded
+	checkQuery(t, stub, "countTableRows", []string{MaintenanceLogTableName}, "1")
+}
+
+func TestSLSChaincode_Upgrade(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+
+	checkInit(t, stub, []string{""})
+	checkQuery(t, stub, "getSchemaVersion", []string{}, strconv.Itoa(getLatestSchemaVersion()))
+
+	_, err := mockInvoke(stub, "2", "init", []string{})
+	if err == nil {
+		fmt.Println("Init was invoked as a reset")
+		t.FailNow()
+	}
+
+	// Simulate a ledger created before the Currency column was added
+	stub.MockTransactionStart("3")
+	err = deleteTable(stub, LoanRequestsTableName)
+	if err == nil {
+		err = createTable(stub, LoanRequestsTableName, []string{LR_LoanRequestIDColName, LR_BorrowerIDColName, LR_ArrangerBankIDColName})
+	}
+	if err == nil {
+		err = addRow(stub, LoanRequestsTableName, []string{"1", "Statoil ASA", "6"}, true)
+	}
+	if err == nil {
+		err = setSchemaVersion(stub, 0)
+	}
+	endTxContext(stub)
+	stub.MockTransactionEnd("3")
+	if err != nil {
+		fmt.Println("Failed preparing old schema", err)
+		t.FailNow()
+	}
+
+	// Repeated Init keeps data and migrates the schema
+	checkInit(t, stub, []string{""})
+	checkQuery(t, stub, "getParticipantsQuantity", []string{}, "10")
+	checkQuery(t, stub, "getSchemaVersion", []string{}, strconv.Itoa(getLatestSchemaVersion()))
+	checkQuery(t, stub, "getLoanRequestByKey", []string{"1"},
+		`[{"LoanRequestID":"1","BorrowerID":"Statoil ASA","ArrangerBankID":"6","Currency":"USD"}]`)
+}
+
+// Deadlines written before time columns were checked are converted by Init, deadlines in unknown layouts fail the upgrade
+func TestSLSChaincode_DeadlineMigration(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+
+	checkInit(t, stub, []string{""})
+	checkInvoke(t, stub, "addLoanTerm", []string{`{"LoanRequestID":"1","LoanTermText":"Tenor is 5 years"}`})
+	checkInvoke(t, stub, "addLoanTermProposal", []string{`{"LoanTermID":"1","ParagraphNumber":"1"}`})
+
+	writeLegacyDeadline := func(value string) {
+		stub.MockTransactionStart("legacy")
+		tbl, err := getTable(stub, LoanTermProposalTableName)
+		var row Row
+		if err == nil {
+			row, err = getRowByKeyValue(stub, LoanTermProposalTableName, "1")
+		}
+		if err == nil {
+			row.Columns[getColumnIndex(tbl, LTP_LoanTermProposalExpTimeColName)] = &Column{Value: value}
+			_, err = replaceRow(stub, LoanTermProposalTableName, row)
+		}
+		if err == nil {
+			err = setSchemaVersion(stub, 3)
+		}
+		endTxContext(stub)
+		stub.MockTransactionEnd("legacy")
+		if err != nil {
+			fmt.Println("Failed writing legacy deadline", err)
+			t.FailNow()
+		}
+	}
+
+	writeLegacyDeadline("2030-03-03")
+	checkInit(t, stub, []string{""})
+	checkQuery(t, stub, "getSchemaVersion", []string{}, strconv.Itoa(getLatestSchemaVersion()))
+	checkQuery(t, stub, "getLoanTermProposalByKey", []string{"1"},
+		`[{"LoanTermProposalID":"1","LoanTermID":"1","ParagraphNumber":"1","LoanTermProposalText":"",`+
+			`"LoanTermProposalExpTime":"2030-03-03T00:00:00Z","LoanTermProposalStatus":""}]`)
+
+	writeLegacyDeadline("03/03/2030")
+	_, err := mockInit(stub, "2", []string{""})
+	checkErrorCode(t, err, ErrCodeInvalidState, LTP_LoanTermProposalExpTimeColName)
+}
+
+// Encodes the length delimited protobuf field
+func appendLegacyField(b []byte, number int, value []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(number<<3|PW_Bytes))
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// Writes the table with string columns and its rows in the layout of the v0.6 table API, the first column is the key
+func putLegacyTable(stub *shimtest.MockStub, tableName string, columns []string, rows [][]string) {
+	var tbl []byte
+	tbl = appendLegacyField(tbl, PF_TableName, []byte(tableName))
+	for i, name := range columns {
+		cd := appendLegacyField(nil, PF_ColumnDefinitionName, []byte(name))
+		if i == 0 {
+			cd = append(cd, byte(PF_ColumnDefinitionKey<<3|PW_Varint), 1)
+		}
+		tbl = appendLegacyField(tbl, PF_TableColumnDefinitions, cd)
+	}
+	tableKey := strconv.Itoa(len(tableName)) + tableName
+	stub.PutState(tableKey, tbl)
+
+	for _, values := range rows {
+		var row []byte
+		for _, value := range values {
+			row = appendLegacyField(row, PF_RowColumns, appendLegacyField(nil, PF_ColumnString, []byte(value)))
+		}
+		stub.PutState(tableKey+strconv.Itoa(len(values[0]))+values[0], row)
+	}
+}
+
+func TestSLSChaincode_LegacyTables(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+
+	stub.MockTransactionStart("legacy")
+	putLegacyTable(stub, ParticipantsTableName, []string{P_ParticipantKeyColName, P_ParticipantNameColName, P_ParticipantTypeColName},
+		[][]string{{"6", "SpareBank 1 SR-BANK", "Bank"}, {"16", "Statoil ASA", "Borrower"}})
+	stub.PutState("12Participantsx", []byte("not a row"))
+	stub.MockTransactionEnd("legacy")
+	checkInit(t, stub, []string{"mode=production"})
+
+	for key := range stub.State {
+		if strings.HasPrefix(key, "12Participants") && key != "12Participantsx" {
+			fmt.Println("Legacy key", key, "is not migrated")
+			t.FailNow()
+		}
+	}
+	if stub.State["12Participantsx"] == nil {
+		fmt.Println("Key which is not a row of a table is deleted")
+		t.FailNow()
+	}
+
+	// Integer keys keep their numeric order
+	bytes, err := mockQuery(stub, "getParticipantsList", []string{})
+	var participants []map[string]string
+	if err == nil {
+		err = json.Unmarshal(bytes, &participants)
+	}
+	if err != nil || len(participants) != 2 || participants[0][P_ParticipantKeyColName] != "6" ||
+		participants[1][P_ParticipantNameColName] != "Statoil ASA" {
+		fmt.Println("Migrated participants are wrong", string(bytes), err)
+		t.FailNow()
+	}
+	checkQuery(t, stub, "getSchemaVersion", []string{}, strconv.Itoa(getLatestSchemaVersion()))
+}
+
+// Rows of ledgers written before lengths of key values were zero-padded are moved by Init before settings are read
+func TestSLSChaincode_RowKeys(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+
+	fixture := `{"Participants": [{"ParticipantKey": "1234567890", "ParticipantName": "Equinor ASA", "ParticipantType": "Borrower"},
+		{"ParticipantKey": "9", "ParticipantName": "Test Bank", "ParticipantType": "Bank"}]}`
+	checkInit(t, stub, []string{FixtureInitArgName + "=" + fixture})
+	checkInit(t, stub, []string{"mode=production"})
+
+	stub.MockTransactionStart("unpadded")
+	var keys []string
+	for key := range stub.State {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, compositeKeyNamespace) {
+			continue
+		}
+		objectType, attributes, err := stub.SplitCompositeKey(key)
+		if err != nil || objectType == TableDefinitionObjectType || len(attributes) != 2 {
+			continue
+		}
+		unpadded, _ := stub.CreateCompositeKey(objectType, []string{strconv.Itoa(len(attributes[1])), attributes[1]})
+		value := stub.State[key]
+		stub.DelState(key)
+		stub.PutState(unpadded, value)
+	}
+	stub.MockTransactionEnd("unpadded")
+
+	// Production mode and schema version are read from migrated settings
+	_, err := mockInit(stub, "2", []string{"mode=demo"})
+	checkErrorCode(t, err, ErrCodeInvalidState, "")
+	checkInit(t, stub, []string{})
+	checkQuery(t, stub, "getSchemaVersion", []string{}, strconv.Itoa(getLatestSchemaVersion()))
+
+	for key := range stub.State {
+		_, attributes, err := stub.SplitCompositeKey(key)
+		if err == nil && len(attributes) == 2 && attributes[0] != getKeyValueLength(attributes[1]) {
+			fmt.Println("Row key", attributes, "is not migrated")
+			t.FailNow()
+		}
+	}
+	bytes, err := mockQuery(stub, "getParticipantsList", []string{})
+	var participants []map[string]string
+	if err == nil {
+		err = json.Unmarshal(bytes, &participants)
+	}
+	if err != nil || len(participants) == 0 || participants[len(participants)-1][P_ParticipantKeyColName] != "1234567890" {
+		fmt.Println("10-digit key should be the last one", string(bytes), err)
+		t.FailNow()
+	}
+}
+
+func TestSLSChaincode_Fixture(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+	actAsMaintainer()
+	defer stopActing()
+
+	fixture := `{"Participants": [{"ParticipantKey": "1", "ParticipantName": "Test Bank", "ParticipantType": "Bank"}],
+		"Users": [{"UserID": "1", "UserName": "test_user", "ParticipantID": "1"}]}`
+	checkInit(t, stub, []string{FixtureInitArgName + "=" + fixture})
+	checkQuery(t, stub, "getParticipantsQuantity", []string{}, "1")
+
+	// Loading the same fixture again skips existing rows
+	bytes, err := mockInvoke(stub, "2", "populateInitialData", []string{fixture})
+	if err != nil {
+		fmt.Println("Failed reloading fixture", err)
+		t.FailNow()
+	}
+	if string(bytes) != "Fixture loaded: 0 rows inserted, 2 rows already present" {
+		fmt.Println("Unexpected fixture reload result", string(bytes))
+		t.FailNow()
+	}
+
+	// Invalid fixtures are rejected as a whole
+	invalidFixtures := []string{
+		`{"Participants": [{"ParticipantKey": "2", "ParticipantName": "Bank 2", "ParticipantType": "Bank"}], "Accounts": []}`,
+		`{"Participants": [{"ParticipantKey": "2", "ParticipantName": "Bank 2", "ParticipantType": "Bank", "Country": "NO"}]}`,
+		`{"Participants": [{"ParticipantKey": "2", "ParticipantName": "Bank 2"}]}`,
+		`{"Participants": [{"ParticipantKey": "2", "ParticipantName": "Bank 2", "ParticipantType": "Bank"},
+			{"ParticipantKey": "2", "ParticipantName": "Bank 2", "ParticipantType": "Bank"}]}`,
+		`{"Participants": [{"ParticipantKey": "1", "ParticipantName": "Other Bank", "ParticipantType": "Bank"}]}`,
+		`{"Participants": [{"ParticipantKey": "2", "ParticipantName": "Bank 2", "ParticipantType": "Bank"}],
+			"Users": [{"UserID": "2", "UserName": "user_2", "ParticipantID": "100"}]}`,
+	}
+	for i, f := range invalidFixtures {
+		_, err = mockInvoke(stub, strconv.Itoa(i+3), "populateInitialData", []string{f})
+		if err == nil {
+			fmt.Println("Invalid fixture was loaded", f)
+			t.FailNow()
+		}
+	}
+	checkQuery(t, stub, "getParticipantsQuantity", []string{}, "1")
+}
+
+// Rows of the demo fixture have hashes of private values of Loan Negotiations, reloading compares values with them
+func TestSLSChaincode_FixtureReload(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+	actAsMaintainer()
+	defer stopActing()
+
+	checkInit(t, stub, []string{"mode=demo"})
+	var tables map[string][]map[string]string
+	json.Unmarshal([]byte(demoFixture), &tables)
+	var quantity int
+	for _, rows := range tables {
+		quantity += len(rows)
+	}
+
+	bytes, err := mockInvoke(stub, "2", "populateInitialData", []string{})
+	if err != nil {
+		fmt.Println("Failed reloading demo fixture", err)
+		t.FailNow()
+	}
+	if string(bytes) != "Fixture loaded: 0 rows inserted, "+strconv.Itoa(quantity)+" rows already present" {
+		fmt.Println("Unexpected demo fixture reload result", string(bytes))
+		t.FailNow()
+	}
+
+	negotiations, _ := json.Marshal(map[string][]map[string]string{LoanNegotiationsTableName: tables[LoanNegotiationsTableName][:1]})
+	checkInvoke(t, stub, "populateInitialData", []string{string(negotiations)})
+	tables[LoanNegotiationsTableName][0][LN_AmountColName] = "201 M USD"
+	negotiations, _ = json.Marshal(map[string][]map[string]string{LoanNegotiationsTableName: tables[LoanNegotiationsTableName][:1]})
+	_, err = mockInvoke(stub, "3", "populateInitialData", []string{string(negotiations)})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, "")
+}
+
+func TestSLSChaincode_Events(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+
+	checkInit(t, stub, []string{"mode=production"})
+
+	stub.MockTransactionStart("2")
+	startEvents(stub)
+	_, err := addParticipant(stub, []string{"6", "SpareBank 1 SR-BANK", "Bank"})
+	if err == nil {
+		_, err = addLoanRequest(stub, []string{"Statoil ASA", "6", "1M", "1M", "Statoil ASA project", "Statoil ASA project info",
+			"Statoil ASA", "www.statoil.com", "John", "Smith", "10-01-2016", "Draft", "Oil industry", "5 years", "Assets", "Convenants", "5%"})
+	}
+	events := takeEvents(stub)
+	endTxContext(stub)
+	stub.MockTransactionEnd("2")
+	if err != nil {
+		fmt.Println("Failed adding rows", err)
+		t.FailNow()
+	}
+	if len(events) != 2 || events[0].Type != "ParticipantCreated" || events[1].Type != "LoanRequestCreated" ||
+		events[1].ChangedFields[LR_CurrencyColName] != "USD" || events[1].TxID != "2" {
+		fmt.Println("Unexpected events of added rows", events)
+		t.FailNow()
+	}
+
+	// Updates of a row are merged into one event, deal events take precedence over entity events
+	stub.MockTransactionStart("3")
+	startEvents(stub)
+	_, err = updateTableField(stub, []string{LoanRequestsTableName, "1", LR_ProjectNameColName, "New project"})
+	if err == nil {
+		_, err = updateTableField(stub, []string{LoanRequestsTableName, "1", LR_StatusColName, LR_StatusClosed})
+	}
+	if err == nil {
+		_, err = updateTableField(stub, []string{LoanRequestsTableName, "1", LR_StatusColName, LR_StatusRepaid})
+	}
+	events = takeEvents(stub)
+	endTxContext(stub)
+	stub.MockTransactionEnd("3")
+	if err != nil {
+		fmt.Println("Failed updating rows", err)
+		t.FailNow()
+	}
+	if len(events) != 1 || events[0].Type != "RepaymentRecorded" || events[0].Action != EA_Updated ||
+		events[0].ChangedFields[LR_StatusColName] != LR_StatusRepaid || events[0].PreviousValues[LR_StatusColName] != "Draft" ||
+		events[0].ChangedFields[LR_ProjectNameColName] != "New project" {
+		fmt.Println("Unexpected events of updated row", events)
+		t.FailNow()
+	}
+
+	// Deal events with values are used only for changes to their values
+	stub.MockTransactionStart("4")
+	startEvents(stub)
+	_, err = updateTableField(stub, []string{LoanRequestsTableName, "1", LR_StatusColName, LR_StatusClosed})
+	if err == nil {
+		err = addRow(stub, LoanTermTableName, []string{"1", "1", "1", "", "DRAFT"}, true)
+	}
+	if err == nil {
+		err = addRow(stub, LoanTermProposalTableName, []string{"1", "1", "1", "", "", LTP_StatusProposed}, true)
+	}
+	events = takeEvents(stub)
+	endTxContext(stub)
+	stub.MockTransactionEnd("4")
+	if err != nil {
+		fmt.Println("Failed updating statuses", err)
+		t.FailNow()
+	}
+	if len(events) != 3 || events[0].Type != "LoanRequestStatusChanged" || events[1].Type != "LoanTermCreated" ||
+		events[2].Type != "LoanTermProposalCreated" {
+		fmt.Println("Unexpected events of status changes", events)
+		t.FailNow()
+	}
+
+	stub.MockTransactionStart("5")
+	startEvents(stub)
+	_, err = updateTableField(stub, []string{LoanTermProposalTableName, "1", LTP_LoanTermProposalStatusColName, LTP_StatusAdopted})
+	events = takeEvents(stub)
+	endTxContext(stub)
+	stub.MockTransactionEnd("5")
+	if err != nil || len(events) != 1 || events[0].Type != "ProposalAdopted" || events[0].PreviousValues[LTP_LoanTermProposalStatusColName] != LTP_StatusProposed {
+		fmt.Println("Unexpected events of adopted proposal", events, err)
+		t.FailNow()
+	}
+
+	// Events are not kept after failed invokes
+	_, err = mockInvoke(stub, "6", "addLoanRequest", []string{"Statoil ASA", "100"})
+	_, isKept := txContexts["6"]
+	if err == nil || isKept {
+		fmt.Println("Events of failed invoke were kept")
+		t.FailNow()
+	}
+}
+
+// api/events.md is written by hand, every event type and payload field should be described there
+func TestSLSChaincode_EventCatalogueDocument(t *testing.T) {
+	document, err := ioutil.ReadFile(eventCataloguePath)
+	if err != nil {
+		fmt.Println("Failed reading event catalogue", err)
+		t.FailNow()
+	}
+	for _, d := range getEventCatalogue() {
+		if !strings.Contains(string(document), "`"+d.Type+"`") {
+			fmt.Println("Event type", d.Type, "is not described in", eventCataloguePath)
+			t.FailNow()
+		}
+	}
+	eventType := reflect.TypeOf(chaincodeEvent{})
+	for i := 0; i < eventType.NumField(); i++ {
+		if f := eventType.Field(i); f.PkgPath == "" && !strings.Contains(string(document), "| `"+f.Name+"` |") {
+			fmt.Println("Event field", f.Name, "is not described in", eventCataloguePath)
+			t.FailNow()
+		}
+	}
+}
+
+func TestSLSChaincode_AuditLog(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+	actAsMaintainer()
+	defer stopActing()
+
+	checkInit(t, stub, []string{"mode=production"})
+
+	_, err := mockInvoke(stub, "2", "addParticipant", []string{"6", "SpareBank 1 SR-BANK", "Bank"})
+	if err != nil {
+		fmt.Println("Failed adding participant", err)
+		t.FailNow()
+	}
+	_, err = mockInvoke(stub, "3", "updateTableField", []string{ParticipantsTableName, "6", P_ParticipantNameColName, "SR-BANK"})
+	if err != nil {
+		fmt.Println("Failed updating participant name", err)
+		t.FailNow()
+	}
+	_, err = mockInvoke(stub, "4", "deleteRow", []string{ParticipantsTableName, "6"})
+	if err != nil {
+		fmt.Println("Failed deleting participant", err)
+		t.FailNow()
+	}
+
+	// Mock transactions are timestamped with the current time
+	result, err := mockQuery(stub, "getAuditLogByEntity", []string{ParticipantsTableName, "6"})
+	if err != nil {
+		fmt.Println("Query getAuditLogByEntity failed", err)
+		t.FailNow()
+	}
+	result = regexp.MustCompile(`"Date":"[^"]+"`).ReplaceAll(result, []byte(`"Date":""`))
+	if string(result) != `[`+
+		`{"AuditLogID":"2:1","TableName":"Participants","RowKey":"6","Action":"Created","ColumnName":"ParticipantKey","OldValue":"","NewValue":"6","BankID":"","UserID":"admin","TxID":"2","Date":""},`+
+		`{"AuditLogID":"2:2","TableName":"Participants","RowKey":"6","Action":"Created","ColumnName":"ParticipantName","OldValue":"","NewValue":"SpareBank 1 SR-BANK","BankID":"","UserID":"admin","TxID":"2","Date":""},`+
+		`{"AuditLogID":"2:3","TableName":"Participants","RowKey":"6","Action":"Created","ColumnName":"ParticipantType","OldValue":"","NewValue":"Bank","BankID":"","UserID":"admin","TxID":"2","Date":""},`+
+		`{"AuditLogID":"3:1","TableName":"Participants","RowKey":"6","Action":"Updated","ColumnName":"ParticipantName","OldValue":"SpareBank 1 SR-BANK","NewValue":"SR-BANK","BankID":"","UserID":"admin","TxID":"3","Date":""},`+
+		`{"AuditLogID":"4:1","TableName":"Participants","RowKey":"6","Action":"Deleted","ColumnName":"","OldValue":"","NewValue":"","BankID":"","UserID":"admin","TxID":"4","Date":""}]` {
+		fmt.Println("Audit log of the participant is wrong", string(result))
+		t.FailNow()
+	}
+
+	// Settings changed by Init are audited as well
+	bytes, err := mockQuery(stub, "getAuditLogByTimeRange", []string{"", ""})
+	if err != nil || !strings.Contains(string(bytes), `"TableName":"Settings","RowKey":"mode","Action":"Created"`) {
+		fmt.Println("Settings changes are missing in audit log", err)
+		t.FailNow()
+	}
+	_, err = mockQuery(stub, "getAuditLogByTimeRange", []string{"2016-01-01", ""})
+	if err == nil {
+		fmt.Println("Wrong date format was accepted")
+		t.FailNow()
+	}
+}
+
+func TestSLSChaincode_Logging(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+
+	var output bytes.Buffer
+	logOutput = &output
+	defer func() {
+		logOutput = os.Stdout
+	}()
+
+	_, err := mockInit(stub, "1", []string{"mode=production", "logLevel=verbose"})
+	if err == nil {
+		fmt.Println("Unknown log level was accepted")
+		t.FailNow()
+	}
+	checkInit(t, stub, []string{"mode=production", "logLevel=debug"})
+
+	output.Reset()
+	_, err = mockInvoke(stub, "2", "addParticipant", []string{"6", "SpareBank 1 SR-BANK", "Bank"})
+	if err != nil {
+		fmt.Println("Failed adding participant", err)
+		t.FailNow()
+	}
+
+	var isAddedRowLogged bool
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var entry map[string]string
+		err = json.Unmarshal([]byte(line), &entry)
+		if err != nil {
+			fmt.Println("Log entry is not JSON", line)
+			t.FailNow()
+		}
+		if entry["txid"] != "2" || entry["function"] != "addParticipant" || entry["level"] == "" {
+			fmt.Println("Log entry misses transaction fields", line)
+			t.FailNow()
+		}
+		if entry["message"] == "Values of added row" && entry["table"] == ParticipantsTableName {
+			isAddedRowLogged = true
+			if entry[P_ParticipantKeyColName] != "6" || entry[P_ParticipantNameColName] != logRedactedValue {
+				fmt.Println("Values of added row are not redacted", line)
+				t.FailNow()
+			}
+		}
+	}
+	if !isAddedRowLogged || strings.Contains(output.String(), "SpareBank") {
+		fmt.Println("Unexpected log output", output.String())
+		t.FailNow()
+	}
+
+	// Entries below the level are skipped
+	checkInit(t, stub, []string{"logLevel=warning"})
+	output.Reset()
+	_, err = mockInvoke(stub, "3", "addParticipant", []string{"7", "DNB ASA", "Bank"})
+	if err != nil || output.Len() != 0 {
+		fmt.Println("Entries below warning level were written", err, output.String())
+		t.FailNow()
+	}
+}
+
+func checkErrorCode(t *testing.T, err error, code string, field string) {
+	if err == nil {
+		fmt.Println("Expected", code, "error but got none")
+		t.FailNow()
+	}
+	var e chaincodeError
+	if jsonErr := json.Unmarshal([]byte(err.Error()), &e); jsonErr != nil {
+		fmt.Println("Error is not JSON", err)
+		t.FailNow()
+	}
+	if e.Code != code || e.Message == "" {
+		fmt.Println("Expected", code, "error but got", err)
+		t.FailNow()
+	}
+	if field != "" && (len(e.Details) == 0 || e.Details[0].Field != field) {
+		fmt.Println("Expected error of field", field, "but got", err)
+		t.FailNow()
+	}
+}
+
+func TestSLSChaincode_ErrorCodes(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+	actAsMaintainer()
+	defer stopActing()
+
+	checkInit(t, stub, []string{""})
+
+	_, err := mockQuery(stub, "getLoanRequestByKey", []string{"99"})
+	checkErrorCode(t, err, ErrCodeNotFound, "")
+	_, err = mockQuery(stub, "getLoanRequestByKey", []string{})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, "")
+	_, err = mockQuery(stub, "unknownFunction", []string{})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, "")
+
+	_, err = mockInvoke(stub, "2", "addParticipant", []string{"6", "Duplicate", "Bank"})
+	checkErrorCode(t, err, ErrCodeConflict, "")
+	_, err = mockInvoke(stub, "3", "addUser", []string{"99", "Unknown Bank User"})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, U_ParticipantIDColName)
+	_, err = mockInvoke(stub, "4", "archiveLoanRequest", []string{"1"})
+	checkErrorCode(t, err, ErrCodeInvalidState, "")
+	_, err = mockInvoke(stub, "5", "updateTableField", []string{ParticipantsTableName, "6", P_ParticipantKeyColName, "9"})
+	checkErrorCode(t, err, ErrCodePermissionDenied, P_ParticipantKeyColName)
+	_, err = mockInvoke(stub, "6", "deleteRow", []string{SettingsTableName, ModeSettingName})
+	checkErrorCode(t, err, ErrCodePermissionDenied, "")
+
+	// Missing columns were dereferencing a nil error
+	_, _, err = getRowsByColumnValue(stub, []string{ParticipantsTableName, "UnknownColumn", "Bank"})
+	if getErrorCode(err) != ErrCodeInvalidArgument {
+		fmt.Println("Unknown filter column was not reported", err)
+		t.FailNow()
+	}
+
+	// Fixture errors are listed in details
+	_, err = mockInit(stub, "7", []string{`fixture={"Participants":[{"ParticipantKey":"20"}],"Unknown":[]}`})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, "")
+	var e chaincodeError
+	json.Unmarshal([]byte(err.Error()), &e)
+	if len(e.Details) != 3 {
+		fmt.Println("Expected 3 fixture errors but got", err)
+		t.FailNow()
+	}
+}
+
+func TestSLSChaincode_NamedArgs(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+
+	checkInit(t, stub, []string{"mode=production"})
+
+	_, err := mockInvoke(stub, "2", "addParticipant", []string{`{"ParticipantKey":"6","ParticipantName":"SpareBank 1 SR-BANK","ParticipantType":"Bank"}`})
+	if err != nil {
+		fmt.Println("Failed invoking addParticipant with named arguments", err)
+		t.FailNow()
+	}
+	// Omitted columns get defaults, numbers are accepted
+	_, err = mockInvoke(stub, "3", "addLoanRequest", []string{`{"BorrowerID":"Statoil ASA","ArrangerBankID":"6","LoanSharesAmount":1000000,"Status":"Draft"}`})
+	if err != nil {
+		fmt.Println("Failed invoking addLoanRequest with named arguments", err)
+		t.FailNow()
+	}
+	checkQuery(t, stub, "getLoanRequestByKey", []string{"1"}, `[{"LoanRequestID":"1","BorrowerID":"Statoil ASA","ArrangerBankID":"6",`+
+		`"LoanSharesAmount":"1000000","ProjectRevenue":"","ProjectName":"","ProjectInformation":"","Company":"","Website":"",`+
+		`"ContactPersonName":"","ContactPersonSurname":"","RequestDate":"","Status":"Draft","MarketAndIndustry":"","LoanTerm":"",`+
+		`"Assets":"","Convenants":"","InterestRate":"","Currency":"USD"}]`)
+
+	// Omitted columns keep current values on update
+	_, err = mockInvoke(stub, "4", "updateLoanRequest", []string{`{"LoanRequestID":"1","ProjectName":"Johan Sverdrup"}`})
+	if err != nil {
+		fmt.Println("Failed invoking updateLoanRequest with named arguments", err)
+		t.FailNow()
+	}
+	checkQuery(t, stub, "getLoanRequestByKey", []string{"1"}, `[{"LoanRequestID":"1","BorrowerID":"Statoil ASA","ArrangerBankID":"6",`+
+		`"LoanSharesAmount":"1000000","ProjectRevenue":"","ProjectName":"Johan Sverdrup","ProjectInformation":"","Company":"","Website":"",`+
+		`"ContactPersonName":"","ContactPersonSurname":"","RequestDate":"","Status":"Draft","MarketAndIndustry":"","LoanTerm":"",`+
+		`"Assets":"","Convenants":"","InterestRate":"","Currency":"USD"}]`)
+
+	_, err = mockInvoke(stub, "5", "updateLoanRequest", []string{`{"ProjectName":"Johan Sverdrup"}`})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, LR_LoanRequestIDColName)
+	_, err = mockInvoke(stub, "6", "updateLoanRequest", []string{`{"LoanRequestID":"1","ProjectTitle":"Johan Sverdrup"}`})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, "ProjectTitle")
+	_, err = mockInvoke(stub, "7", "updateLoanRequest", []string{`{"LoanRequestID":"2"}`})
+	checkErrorCode(t, err, ErrCodeNotFound, "")
+
+	// Positional arguments are rejected when they are disabled
+	checkInit(t, stub, []string{"positionalArgs=disabled"})
+	_, err = mockInvoke(stub, "8", "addParticipant", []string{"7", "DNB ASA", "Bank"})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, "")
+	_, err = mockInvoke(stub, "9", "addParticipant", []string{`{"ParticipantName":"DNB ASA","ParticipantType":"Bank"}`})
+	if err != nil {
+		fmt.Println("Failed invoking addParticipant with named arguments", err)
+		t.FailNow()
+	}
+	checkQuery(t, stub, "getParticipantsQuantity", []string{}, "2")
+}
+
+func TestSLSChaincode_Patch(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+	actAsMaintainer()
+	defer stopActing()
+
+	checkInit(t, stub, []string{""})
+
+	eTag, err := mockQuery(stub, "getRowETag", []string{LoanRequestsTableName, "1"})
+	if err != nil || len(eTag) == 0 {
+		fmt.Println("Failed getting ETag", err)
+		t.FailNow()
+	}
+
+	// Bank A patches the row it has read
+	newETag, err := mockInvoke(stub, "2", "patchLoanRequest", []string{`{"LoanRequestID":"1","ProjectName":"Johan Sverdrup","Currency":"NOK","ETag":"` + string(eTag) + `"}`})
+	if err != nil || string(newETag) == string(eTag) {
+		fmt.Println("Failed patching loan request", err)
+		t.FailNow()
+	}
+	checkQuery(t, stub, "getRowETag", []string{LoanRequestsTableName, "1"}, string(newETag))
+
+	// Bank B patches the row it has read before, its change is not written
+	_, err = mockInvoke(stub, "3", "patchLoanRequest", []string{`{"LoanRequestID":"1","ProjectName":"Castberg","ETag":"` + string(eTag) + `"}`})
+	checkErrorCode(t, err, ErrCodeConflict, "")
+
+	// Both columns are changed by one update
+	bytes, err := mockQuery(stub, "getAuditLogByEntity", []string{LoanRequestsTableName, "1"})
+	if err != nil {
+		fmt.Println("Failed getting audit log", err)
+		t.FailNow()
+	}
+	var entries []map[string]string
+	json.Unmarshal(bytes, &entries)
+	var changed []string
+	for _, e := range entries {
+		if e[AL_TxIDColName] == "2" {
+			changed = append(changed, e[AL_ColumnNameColName]+"="+e[AL_NewValueColName])
+		}
+	}
+	if strings.Join(changed, ",") != "Currency=NOK,ProjectName=Johan Sverdrup" {
+		fmt.Println("Wrong audit entries of patch", changed)
+		t.FailNow()
+	}
+
+	// Patch without ETag is applied unconditionally, changed references are checked
+	_, err = mockInvoke(stub, "4", "patchUser", []string{`{"UserID":"1","UserName":"John Smith"}`})
+	if err != nil {
+		fmt.Println("Failed patching user", err)
+		t.FailNow()
+	}
+	_, err = mockInvoke(stub, "5", "patchUser", []string{`{"UserID":"1","ParticipantID":"99"}`})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, U_ParticipantIDColName)
+	_, err = mockInvoke(stub, "6", "patchUser", []string{"1", "srbank"})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, "")
+}
+
+func TestSLSChaincode_Registry(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+	actAsMaintainer()
+	defer stopActing()
+
+	checkInit(t, stub, []string{""})
+
+	for name, d := range functionRegistry {
+		if d.Name != name || d.handler == nil || (d.Mode != FM_Read && d.Mode != FM_Write) {
+			fmt.Println("Function", name, "is not registered correctly")
+			t.FailNow()
+		}
+	}
+
+	bytes, err := mockQuery(stub, "describeAPI", []string{})
+	if err != nil {
+		fmt.Println("Failed describing API", err)
+		t.FailNow()
+	}
+	var catalogue apiCatalogue
+	err = json.Unmarshal(bytes, &catalogue)
+	if err != nil || len(catalogue.Functions) != len(functionRegistry) || len(catalogue.Tables[LoanRequestsTableName]) != LoanRequestsTableColsQty {
+		fmt.Println("Wrong API catalogue", err)
+		t.FailNow()
+	}
+	functions := make(map[string]functionDefinition)
+	for _, d := range catalogue.Functions {
+		functions[d.Name] = d
+	}
+
+	// Object arguments are table columns, generated keys are not arguments
+	add := functions["addLoanRequest"]
+	if add.Mode != FM_Write || add.ArgsFormat != AF_Object || len(add.Args) != LoanRequestsTableColsQty-1 ||
+		add.Args[0].Name != LR_BorrowerIDColName || add.Args[len(add.Args)-1].Description != "Default is '"+LR_CurrencyDefault+"'" {
+		fmt.Println("Wrong definition of addLoanRequest", add)
+		t.FailNow()
+	}
+	patch := functions["patchLoanRequest"]
+	if len(patch.Args) != LoanRequestsTableColsQty+1 || patch.Args[0].IsOptional || patch.Args[len(patch.Args)-1].Name != PatchETagArgName {
+		fmt.Println("Wrong definition of patchLoanRequest", patch)
+		t.FailNow()
+	}
+	if functions["countTableRows"].Role != FR_Assigner || functions["getLoanRequestsList"].Role != "" {
+		fmt.Println("Wrong roles of functions")
+		t.FailNow()
+	}
+	if patch.Result != RT_Text || functions["getLoanRequestsList"].Result != RT_Rows || functions["addLoanRequest"].Result != RT_None {
+		fmt.Println("Wrong results of functions")
+		t.FailNow()
+	}
+
+	// Invoke runs functions in their mode, unknown functions are written so they fail as writes
+	if getFunctionMode("getParticipantsList") != FM_Read || getFunctionMode("addParticipant") != FM_Write ||
+		getFunctionMode("unknownFunction") != FM_Write {
+		fmt.Println("Wrong modes of functions")
+		t.FailNow()
+	}
+	_, err = mockInvoke(stub, "2", "unknownFunction", []string{})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, "")
+
+	// Functions run only with their number of arguments
+	_, err = mockQuery(stub, "getSchemaVersion", []string{"1"})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, "")
+	_, err = mockInvoke(stub, "3", "deleteRowsByColumnValue", []string{ParticipantsTableName, P_ParticipantTypeColName})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, "")
+	_, err = mockQuery(stub, "countTableRows", []string{ParticipantsTableName, P_ParticipantTypeColName, "Bank", IncludeDeletedOption})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, "")
+	checkQuery(t, stub, "countTableRows", []string{ParticipantsTableName, IncludeDeletedOption}, "10")
+}
+
+func TestSLSChaincode_APICatalogue(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+
+	checkInit(t, stub, []string{"mode=production"})
+
+	bytes, err := mockQuery(stub, "describeAPI", []string{})
+	if err != nil {
+		fmt.Println("Failed describing API", err)
+		t.FailNow()
+	}
+	var catalogue apiCatalogue
+	json.Unmarshal(bytes, &catalogue)
+	bytes, err = json.MarshalIndent(catalogue, "", "  ")
+	if err != nil {
+		fmt.Println("Failed formatting API catalogue", err)
+		t.FailNow()
+	}
+	bytes = append(bytes, '\n')
+
+	if *updateCatalogue {
+		err = ioutil.WriteFile(apiCataloguePath, bytes, 0644)
+		if err != nil {
+			fmt.Println("Failed writing API catalogue", err)
+			t.FailNow()
+		}
+	}
+	committed, err := ioutil.ReadFile(apiCataloguePath)
+	if err != nil || string(committed) != string(bytes) {
+		fmt.Println(apiCataloguePath, "is out of date, run 'go generate' to update it and generated clients", err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	//"errors"
+	//"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Proposal statuses
+const LTP_StatusProposed = "PROPOSED"
+const LTP_StatusAccepted = "ACCEPTED"
+const LTP_StatusAdopted = "ADOPTED"
+const LTP_StatusRejected = "REJECTED"
+const LTP_StatusLapsed = "LAPSED"
+
+// Checks of changes which are too late: previousValues is nil when the row is added and has previous values of changed
+// columns when it is changed. now is the transaction timestamp, so every endorsing peer gets the same result.
+type lateChangeCheck func(stub shim.ChaincodeStubInterface, now time.Time, tbl *Table, row Row, previousValues map[string]string) error
+
+// Checks of tables are run by addRow and patchRow. updateTableField does not run them, so internal changes of statuses
+// like expireOverdue are not rejected, functions which change business columns with it call checkLateChange.
+var lateChangeChecks = make(map[string]lateChangeCheck)
+
+// ============================================================================================================================
+//
+// ============================================================================================================================
+
+func init() {
+	registerFunction(functionDefinition{Name: "expireOverdue", Mode: FM_Write, Result: RT_JSON, handler: expireOverdue,
+		Description: "Moves invited Loan Negotiations after their response deadlines to EXPIRED and open Loan Term Proposals " +
+			"after their expiration times to LAPSED, returns numbers of changed rows"})
+
+	// Votes are not accepted after the deadline of the vote, after the expiration time of the proposal or for lapsed proposals
+	lateChangeChecks[LoanTermVoteTableName] = func(stub shim.ChaincodeStubInterface, now time.Time, tbl *Table, row Row,
+		previousValues map[string]string) error {
+		deadline := getRowColumnValue(tbl, row, LTV_LoanTermVoteDeadlineColName)
+		if value, ok := previousValues[LTV_LoanTermVoteDeadlineColName]; ok {
+			deadline = value
+			if isDeadlinePassed(stub, now, deadline) {
+				return newFieldError(ErrCodeInvalidState, LTV_LoanTermVoteDeadlineColName, "Vote deadline '"+deadline+"' has passed")
+			}
+		}
+		if _, ok := previousValues[LTV_LoanTermVoteStatusColName]; previousValues != nil && !ok {
+			return nil
+		}
+		if isDeadlinePassed(stub, now, deadline) {
+			return newFieldError(ErrCodeInvalidState, LTV_LoanTermVoteStatusColName, "Vote deadline '"+deadline+"' has passed")
+		}
+
+		proposalID := getRowColumnValue(tbl, row, LTV_LoanTermProposalIDColName)
+		proposalStatus, err := getTableColValueByKey(stub, LoanTermProposalTableName, proposalID, LTP_LoanTermProposalStatusColName)
+		if err != nil {
+			return wrapError(err, "Error getting Loan Term Proposal of the vote: ")
+		}
+		expTime, err := getTableColValueByKey(stub, LoanTermProposalTableName, proposalID, LTP_LoanTermProposalExpTimeColName)
+		if err != nil {
+			return wrapError(err, "Error getting Loan Term Proposal of the vote: ")
+		}
+		if proposalStatus == LTP_StatusLapsed || isDeadlinePassed(stub, now, expTime) {
+			return newFieldError(ErrCodeInvalidState, LTV_LoanTermProposalIDColName, "Loan Term Proposal '"+proposalID+"' has lapsed")
+		}
+		return nil
+	}
+
+	// Statuses and expiration times of proposals are not changed after their expiration time
+	lateChangeChecks[LoanTermProposalTableName] = func(stub shim.ChaincodeStubInterface, now time.Time, tbl *Table, row Row,
+		previousValues map[string]string) error {
+		if previousValues == nil {
+			return nil
+		}
+		expTime := getRowColumnValue(tbl, row, LTP_LoanTermProposalExpTimeColName)
+		if value, ok := previousValues[LTP_LoanTermProposalExpTimeColName]; ok {
+			expTime = value
+		}
+		if !isDeadlinePassed(stub, now, expTime) {
+			return nil
+		}
+		if _, ok := previousValues[LTP_LoanTermProposalExpTimeColName]; ok {
+			return newFieldError(ErrCodeInvalidState, LTP_LoanTermProposalExpTimeColName, "Expiration time '"+expTime+"' has passed")
+		}
+		if _, ok := previousValues[LTP_LoanTermProposalStatusColName]; ok {
+			return newFieldError(ErrCodeInvalidState, LTP_LoanTermProposalStatusColName, "Loan Term Proposal has lapsed at '"+expTime+"'")
+		}
+		return nil
+	}
+}
+
+// Proposals without a decision lapse after their expiration time
+func isLoanTermProposalOpen(status string) bool {
+	return status == "" || status == LTP_StatusProposed
+}
+
+// Empty deadlines are not set. Time columns accept only RFC 3339 dates and older values are converted by a migration,
+// so a deadline which can not be parsed is treated as passed.
+func isDeadlinePassed(stub shim.ChaincodeStubInterface, now time.Time, value string) bool {
+	if value == "" {
+		return false
+	}
+	deadline, err := parseDeadline("", value)
+	if err != nil {
+		logWarning(stub, "Deadline is not an RFC 3339 date and is treated as passed", logFields{"value": value})
+		return true
+	}
+	return !now.Before(deadline)
+}
+
+// Runs the check of the table for the added or changed row
+func checkLateChanges(stub shim.ChaincodeStubInterface, tbl *Table, row Row, previousValues map[string]string) error {
+	check, ok := lateChangeChecks[tbl.Name]
+	if !ok {
+		return nil
+	}
+	now, err := getTxTime(stub)
+	if err != nil {
+		return err
+	}
+	return check(stub, now, tbl, row, previousValues)
+}
+
+// Runs the check of the table for a change of one column, which is written with updateTableField
+func checkLateChange(stub shim.ChaincodeStubInterface, tableName, keyValue, columnName, newValue string) error {
+	if _, ok := lateChangeChecks[tableName]; !ok {
+		return nil
+	}
+	tbl, err := getTable(stub, tableName)
+	if err != nil {
+		return wrapError(err, "Error in checkLateChange func: ")
+	}
+	row, err := getRowByKeyValue(stub, tableName, keyValue)
+	if err != nil {
+		return wrapError(err, "Error in checkLateChange func: ")
+	}
+	i := getColumnIndex(tbl, columnName)
+	if i < 0 {
+		return newFieldError(ErrCodeInvalidArgument, columnName, "Column '"+columnName+"' is missing")
+	}
+	previousValues := map[string]string{columnName: row.Columns[i].Value}
+	if previousValues[columnName] == newValue {
+		return nil
+	}
+	columns := append([]*Column(nil), row.Columns...)
+	columns[i] = &Column{Value: newValue}
+	return checkLateChanges(stub, tbl, Row{Columns: columns}, previousValues)
+}
+
+// Can be invoked by anyone, e.g. by a scheduler, it changes only rows which are overdue at the transaction timestamp
+func expireOverdue(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 0 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 0")
+	}
+	now, err := getTxTime(stub)
+	if err != nil {
+		return nil, wrapError(err, "Error in expireOverdue func: ")
+	}
+
+	tbl, negotiations, err := getRowsByColumnValue(stub, []string{LoanNegotiationsTableName, LN_NegotiationStatusColName, LN_StatusInvited})
+	if err != nil {
+		return nil, wrapError(err, "Error in expireOverdue func: ")
+	}
+	var expired int
+	var loanRequestIDs []string
+	isChanged := make(map[string]bool)
+	for _, row := range negotiations {
+		if !isDeadlinePassed(stub, now, getRowColumnValue(tbl, row, LN_ResponseDeadlineColName)) {
+			continue
+		}
+		_, err = updateTableField(stub, []string{LoanNegotiationsTableName, row.Columns[0].Value, LN_NegotiationStatusColName, LN_StatusExpired})
+		if err != nil {
+			return nil, wrapError(err, "Error in expireOverdue func: ")
+		}
+		expired++
+		if loanRequestID := getRowColumnValue(tbl, row, LN_LoanRequestIDColName); !isChanged[loanRequestID] {
+			isChanged[loanRequestID] = true
+			loanRequestIDs = append(loanRequestIDs, loanRequestID)
+		}
+	}
+	for _, loanRequestID := range loanRequestIDs {
+		err = updateLoanRequestStatus(stub, loanRequestID)
+		if err != nil {
+			return nil, wrapError(err, "Error in expireOverdue func: ")
+		}
+	}
+
+	tbl, proposals, err := getRowsByColumnValue(stub, []string{LoanTermProposalTableName})
+	if err != nil {
+		return nil, wrapError(err, "Error in expireOverdue func: ")
+	}
+	var lapsed int
+	for _, row := range proposals {
+		if !isLoanTermProposalOpen(getRowColumnValue(tbl, row, LTP_LoanTermProposalStatusColName)) ||
+			!isDeadlinePassed(stub, now, getRowColumnValue(tbl, row, LTP_LoanTermProposalExpTimeColName)) {
+			continue
+		}
+		_, err = updateTableField(stub, []string{LoanTermProposalTableName, row.Columns[0].Value, LTP_LoanTermProposalStatusColName, LTP_StatusLapsed})
+		if err != nil {
+			return nil, wrapError(err, "Error in expireOverdue func: ")
+		}
+		lapsed++
+	}
+
+	logInfo(stub, "Overdue rows are expired", logFields{"count": strconv.Itoa(expired + lapsed)})
+	return json.Marshal(map[string]int{"expired": expired, "lapsed": lapsed})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Banks are invited with a response deadline and a proposal is voted before its expiration time: Nationwide does not
+// answer in time and its invitation expires, a vote after its own deadline and a vote on the expired proposal are rejected.
+func TestSLSChaincode_DeadlinesScenario(t *testing.T) {
+	s := newScenario(t, []string{"mode=demo"})
+	defer s.close()
+
+	opened := time.Date(2030, 3, 1, 9, 0, 0, 0, time.UTC)
+	deadline := opened.Add(24 * time.Hour)
+	expireOverdue := func(expected string) {
+		result, err := s.tryInvoke("expireOverdue", nil)
+		if err != nil || string(result) != expected {
+			s.fail("Wrong result of expireOverdue", string(result), err)
+		}
+	}
+
+	s.as(arrangerIdentity).at(opened).in("invite banks")
+	s.invoke("addLoanRequest", map[string]string{LR_BorrowerIDColName: "Equinor", LR_ArrangerBankIDColName: "6",
+		LR_ProjectNameColName: "Hywind Tampen", LR_StatusColName: "Draft"})
+	s.invokeFails(ErrCodeInvalidArgument, "addLoanNegotiation", map[string]string{LN_LoanRequestIDColName: "3",
+		LN_ParticipantBankIDColName: "7", LN_NegotiationStatusColName: LN_StatusInvited, LN_ResponseDeadlineColName: "02-03-2030"})
+	s.invokeFails(ErrCodeInvalidState, "addLoanNegotiation", map[string]string{LN_LoanRequestIDColName: "3",
+		LN_ParticipantBankIDColName: "7", LN_NegotiationStatusColName: LN_StatusInvited, LN_ResponseDeadlineColName: opened.Add(-time.Hour).Format(time.RFC3339)})
+	for _, bankID := range []string{"7", "8"} {
+		s.invoke("addLoanNegotiation", map[string]string{LN_LoanRequestIDColName: "3", LN_ParticipantBankIDColName: bankID,
+			LN_NegotiationStatusColName: LN_StatusInvited, LN_DateColName: "2030-03-01", LN_ResponseDeadlineColName: deadline.Format(time.RFC3339)})
+	}
+	s.checkRow("getLoanRequestByKey", "3", map[string]string{LR_StatusColName: "Invitation Sent"})
+
+	s.as(assignerIdentity).in("propose terms")
+	s.invoke("addLoanTerm", map[string]string{LT_LoanRequestIDColName: "3", LT_ParagraphNumberColName: "1",
+		LT_LoanTermTextColName: "Tenor is 5 years", LT_LoanTermStatusColName: "DRAFT"})
+	s.invokeFails(ErrCodeInvalidArgument, "addLoanTermProposal", map[string]string{LTP_LoanTermIDColName: "1",
+		LTP_LoanTermProposalTextColName: "Tenor is 7 years", LTP_LoanTermProposalExpTimeColName: "03-03-2030"})
+	s.invoke("addLoanTermProposal", map[string]string{LTP_LoanTermIDColName: "1", LTP_LoanTermProposalTextColName: "Tenor is 7 years",
+		LTP_LoanTermProposalExpTimeColName: opened.Add(48 * time.Hour).Format(time.RFC3339)})
+	s.invoke("addLoanTermProposal", map[string]string{LTP_LoanTermIDColName: "1", LTP_LoanTermProposalTextColName: "Tenor is 6 years",
+		LTP_LoanTermProposalExpTimeColName: opened.Add(48 * time.Hour).Format(time.RFC3339), LTP_LoanTermProposalStatusColName: LTP_StatusProposed})
+	s.invoke("patchLoanTermProposal", map[string]string{LTP_LoanTermProposalIDColName: "2", LTP_LoanTermProposalStatusColName: LTP_StatusAdopted})
+	s.invoke("addLoanTermVote", map[string]string{LTV_LoanTermProposalIDColName: "1", LTV_BankIDColName: "6",
+		LTV_LoanTermVoteStatusColName: "ACCEPTED", LTV_LoanTermVoteDeadlineColName: opened.Add(12 * time.Hour).Format(time.RFC3339)})
+
+	s.as(dnbIdentity).at(opened.Add(time.Hour)).in("DNB responds in time")
+	s.invoke("updateLoanNegotiationStatus", map[string]string{LN_LoanNegotiationIDColName: "7", LN_NegotiationStatusColName: "INTERESTED"})
+	expireOverdue(`{"expired":0,"lapsed":0}`)
+
+	s.as(nationwideIdentity).at(deadline).in("Nationwide responds late")
+	s.invokeFails(ErrCodeInvalidState, "updateLoanNegotiationStatus", map[string]string{LN_LoanNegotiationIDColName: "8",
+		LN_NegotiationStatusColName: "INTERESTED"})
+	s.invokeFails(ErrCodeInvalidState, "patchLoanNegotiation", map[string]string{LN_LoanNegotiationIDColName: "8",
+		LN_NegotiationStatusColName: "INTERESTED"})
+	s.invokeFails(ErrCodeInvalidState, "patchLoanNegotiation", map[string]string{LN_LoanNegotiationIDColName: "8",
+		LN_ResponseDeadlineColName: deadline.Add(time.Hour).Format(time.RFC3339)})
+	s.invoke("updateParticipantBankComment", map[string]string{LN_LoanNegotiationIDColName: "8", LN_ParticipantBankCommentColName: "Sorry"})
+
+	s.in("expire invitations")
+	expireOverdue(`{"expired":1,"lapsed":0}`)
+	s.checkRow("getLoanNegotiationByKey", "7", map[string]string{LN_NegotiationStatusColName: "INTERESTED"})
+	s.checkRow("getLoanNegotiationByKey", "8", map[string]string{LN_NegotiationStatusColName: LN_StatusExpired})
+	s.checkRow("getLoanNegotiationByKey", "1", map[string]string{LN_NegotiationStatusColName: LN_StatusInvited})
+	s.checkRow("getLoanRequestByKey", "3", map[string]string{LR_StatusColName: "Negotiation Completed"})
+
+	s.as(assignerIdentity).in("vote after the vote deadline")
+	s.invokeFails(ErrCodeInvalidState, "patchLoanTermVote", map[string]string{LTV_LoanTermVoteIDColName: "1",
+		LTV_LoanTermVoteStatusColName: "REJECTED"})
+	s.invoke("addLoanTermVote", map[string]string{LTV_LoanTermProposalIDColName: "1", LTV_BankIDColName: "7",
+		LTV_LoanTermVoteStatusColName: "ACCEPTED"})
+
+	s.at(opened.Add(48 * time.Hour)).in("vote after the proposal expired")
+	s.invokeFails(ErrCodeInvalidState, "addLoanTermVote", map[string]string{LTV_LoanTermProposalIDColName: "1", LTV_BankIDColName: "8",
+		LTV_LoanTermVoteStatusColName: "ACCEPTED"})
+	s.invokeFails(ErrCodeInvalidState, "patchLoanTermProposal", map[string]string{LTP_LoanTermProposalIDColName: "1",
+		LTP_LoanTermProposalStatusColName: LTP_StatusAccepted})
+	s.invokeFails(ErrCodeInvalidState, "patchLoanTermProposal", map[string]string{LTP_LoanTermProposalIDColName: "1",
+		LTP_LoanTermProposalExpTimeColName: opened.Add(72 * time.Hour).Format(time.RFC3339)})
+	s.invoke("patchLoanTermProposal", map[string]string{LTP_LoanTermProposalIDColName: "1", LTP_LoanTermProposalTextColName: "Tenor is 7 years."})
+
+	s.in("expire proposals")
+	expireOverdue(`{"expired":0,"lapsed":1}`)
+	s.checkRow("getLoanTermProposalByKey", "1", map[string]string{LTP_LoanTermProposalStatusColName: LTP_StatusLapsed})
+	s.checkRow("getLoanTermProposalByKey", "2", map[string]string{LTP_LoanTermProposalStatusColName: LTP_StatusAdopted})
+	expireOverdue(`{"expired":0,"lapsed":0}`)
+}
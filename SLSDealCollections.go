@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	//"errors"
+	//"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Setting names
+const BankMSPIDsSettingName = "bankMSPIDs"
+
+//Collection configuration of deals
+const DCC_RequiredPeerCount = 0
+const DCC_MaxPeerCount = 3
+const DCC_BlockToLive = 0
+
+// ============================================================================================================================
+// Private values of a deal are kept in its own collection, see SLSPrivateData.go. Collections are defined with the
+// chaincode, so getDealCollectionsConfig generates the collection configuration from the ledger: members of the
+// collection of a deal are organizations of its arranger bank and of the banks invited by its Loan Negotiations.
+// Organizations of banks are given by the "bankMSPIDs" setting passed to Init, e.g.
+//   bankMSPIDs=6:SR1MSP,7:DNBMSP
+// The configuration is regenerated and the chaincode definition is updated before values are written to a new
+// collection, i.e. after a Loan Request is added or a bank is invited. Fabric does not remove collections, so
+// collections of archived deals stay in the configuration.
+// ============================================================================================================================
+
+// Collection definition of the collection configuration file of Fabric
+type dealCollectionConfig struct {
+	Name              string `json:"name"`
+	Policy            string `json:"policy"`
+	RequiredPeerCount int    `json:"requiredPeerCount"`
+	MaxPeerCount      int    `json:"maxPeerCount"`
+	BlockToLive       int    `json:"blockToLive"`
+	MemberOnlyRead    bool   `json:"memberOnlyRead"`
+	MemberOnlyWrite   bool   `json:"memberOnlyWrite"`
+}
+
+func init() {
+	registerFunction(functionDefinition{Name: "getDealCollectionsConfig", Mode: FM_Read, Role: FR_Assigner, isMaintenance: true,
+		handler: getDealCollectionsConfig,
+		Description: "Returns the collection configuration of the chaincode with the private data collection of every deal"})
+}
+
+// Parses "bankID:MSPID" pairs separated by commas
+func parseBankMSPIDs(value string) (map[string]string, error) {
+	mspIDs := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		i := strings.Index(pair, ":")
+		if i <= 0 || i == len(pair)-1 {
+			return nil, newError(ErrCodeInvalidArgument, "Bank MSP ID '"+pair+"' should have 'bankID:MSPID' format")
+		}
+		mspIDs[pair[:i]] = pair[i+1:]
+	}
+	return mspIDs, nil
+}
+
+// Returns members of the collection of the deal: arranger bank and participant banks of Loan Negotiations,
+// archived deals included
+func getDealBankIDs(stub shim.ChaincodeStubInterface) (map[string][]string, error) {
+	bankIDs := make(map[string][]string)
+	for _, archived := range []bool{false, true} {
+		requestsTableName, negotiationsTableName := LoanRequestsTableName, LoanNegotiationsTableName
+		if archived {
+			requestsTableName, negotiationsTableName = getArchiveTableName(requestsTableName), getArchiveTableName(negotiationsTableName)
+		}
+
+		tbl, err := getTable(stub, requestsTableName)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := getRows(stub, requestsTableName)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			loanRequestID := row.Columns[0].Value
+			bankIDs[loanRequestID] = append(bankIDs[loanRequestID], getRowColumnValue(tbl, row, LR_ArrangerBankIDColName))
+		}
+
+		tbl, err = getTable(stub, negotiationsTableName)
+		if err != nil {
+			return nil, err
+		}
+		rows, err = getRows(stub, negotiationsTableName)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			loanRequestID := getRowColumnValue(tbl, row, LN_LoanRequestIDColName)
+			bankIDs[loanRequestID] = append(bankIDs[loanRequestID], getRowColumnValue(tbl, row, LN_ParticipantBankIDColName))
+		}
+	}
+	return bankIDs, nil
+}
+
+// Query: no arguments
+func getDealCollectionsConfig(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	setting, err := getSetting(stub, BankMSPIDsSettingName, "")
+	if err != nil {
+		return nil, wrapError(err, "Error in getDealCollectionsConfig func: ")
+	}
+	mspIDs, err := parseBankMSPIDs(setting)
+	if err != nil {
+		return nil, wrapError(err, "Error in getDealCollectionsConfig func: ")
+	}
+	bankIDs, err := getDealBankIDs(stub)
+	if err != nil {
+		return nil, wrapError(err, "Error getting banks of deals in getDealCollectionsConfig func: ")
+	}
+
+	var loanRequestIDs []string
+	for loanRequestID := range bankIDs {
+		loanRequestIDs = append(loanRequestIDs, loanRequestID)
+	}
+	sort.Strings(loanRequestIDs)
+
+	configs := []dealCollectionConfig{}
+	for _, loanRequestID := range loanRequestIDs {
+		var members []string
+		isMember := make(map[string]bool)
+		for _, bankID := range bankIDs[loanRequestID] {
+			mspID, ok := mspIDs[bankID]
+			if !ok {
+				return nil, newError(ErrCodeInvalidState, "Bank '"+bankID+"' of Loan Request '"+loanRequestID+"' has no MSP ID in '"+
+					BankMSPIDsSettingName+"' setting")
+			}
+			if !isMember[mspID] {
+				isMember[mspID] = true
+				members = append(members, "'"+mspID+".member'")
+			}
+		}
+		sort.Strings(members)
+		configs = append(configs, dealCollectionConfig{
+			Name:              getDealCollectionName(loanRequestID),
+			Policy:            "OR(" + strings.Join(members, ",") + ")",
+			RequiredPeerCount: DCC_RequiredPeerCount,
+			MaxPeerCount:      DCC_MaxPeerCount,
+			BlockToLive:       DCC_BlockToLive,
+			MemberOnlyRead:    true,
+			MemberOnlyWrite:   true,
+		})
+	}
+	return json.Marshal(configs)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	//"encoding/json"
+	//"errors"
+	//"fmt"
+	//"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Entity names
+const DeletedRowsTableName = "DeletedRows"
+
+//Column names
+const DR_DeletedRowIDColName = "DeletedRowID"
+const DR_TableNameColName = "TableName"
+const DR_RowKeyColName = "RowKey"
+const DR_DeletedByBankIDColName = "DeletedByBankID"
+const DR_DeletedByUserIDColName = "DeletedByUserID"
+const DR_DeletedDateColName = "DeletedDate"
+const DR_DeleteTxIDColName = "DeleteTxID"
+
+//Column quantity
+const DeletedRowsTableColsQty = 7
+
+//Query option
+const IncludeDeletedOption = "includeDeleted"
+
+// ============================================================================================================================
+// Deleted rows are kept in their own tables and marked in this one.
+// Rows marked here are excluded from queries and foreign key checks unless includeDeleted option is given.
+// ============================================================================================================================
+
+func CreateDeletedRowsTable(stub shim.ChaincodeStubInterface) error {
+	DR_ColumnNames := []string{DR_DeletedRowIDColName, DR_TableNameColName, DR_RowKeyColName,
+		DR_DeletedByBankIDColName, DR_DeletedByUserIDColName, DR_DeletedDateColName, DR_DeleteTxIDColName}
+	return createTable(stub, DeletedRowsTableName, DR_ColumnNames)
+}
+
+func init() {
+	registerFunction(functionDefinition{Name: "getDeletedRowsList", Mode: FM_Read, Role: FR_Assigner, isMaintenance: true, Table: DeletedRowsTableName,
+		Args: []argumentDefinition{{Name: "table", IsOptional: true}}, handler: getDeletedRowsList,
+		Description: "Returns deletion marks of all rows or rows of the table"})
+}
+
+func getDeletedRowID(tableName, keyValue string) string {
+	return tableName + ":" + keyValue
+}
+
+func markRowDeleted(stub shim.ChaincodeStubInterface, tableName, keyValue string) error {
+	isDeleted, err := isRowDeleted(stub, tableName, keyValue)
+	if err != nil {
+		return wrapError(err, "Error in markRowDeleted func: ")
+	}
+	if isDeleted {
+		return nil
+	}
+
+	bankID, err := getBankId(stub, []string{})
+	if err != nil {
+		return wrapError(err, "Error getting bankid in markRowDeleted func: ")
+	}
+	userID, err := getUserId(stub, []string{})
+	if err != nil {
+		return wrapError(err, "Error getting userid in markRowDeleted func: ")
+	}
+	deletedDate, err := getTxTimestampString(stub)
+	if err != nil {
+		return wrapError(err, "Error in markRowDeleted func: ")
+	}
+
+	err = addRow(stub, DeletedRowsTableName, []string{getDeletedRowID(tableName, keyValue), tableName, keyValue,
+		string(bankID), string(userID), deletedDate, stub.GetTxID()}, true)
+	if err != nil {
+		return wrapError(err, "Error in markRowDeleted func: ")
+	}
+	return recordRowChange(stub, tableName, keyValue, EA_Deleted, nil, nil)
+}
+
+// Moves deleted mark of the row to another table keeping who and when deleted it. Used by archiving.
+func moveRowDeletedMark(stub shim.ChaincodeStubInterface, fromTableName, toTableName, keyValue string) error {
+	row, err := getDeletedRowMark(stub, fromTableName, keyValue)
+	if err != nil {
+		return wrapError(err, "Error in moveRowDeletedMark func: ")
+	}
+	if row.Columns == nil {
+		return nil
+	}
+
+	var values []string
+	for _, c := range row.Columns {
+		values = append(values, c.Value)
+	}
+	values[0], values[1] = getDeletedRowID(toTableName, keyValue), toTableName
+
+	err = addRow(stub, DeletedRowsTableName, values, true)
+	if err != nil {
+		return wrapError(err, "Error in moveRowDeletedMark func: ")
+	}
+	return unmarkRowDeleted(stub, fromTableName, keyValue)
+}
+
+func unmarkRowDeleted(stub shim.ChaincodeStubInterface, tableName, keyValue string) error {
+	err := deleteTableRow(stub, DeletedRowsTableName, getDeletedRowID(tableName, keyValue))
+	if err != nil {
+		return wrapError(err, "Error in unmarkRowDeleted func: ")
+	}
+	return nil
+}
+
+// Returns row of DeletedRows table, its columns are nil if the row is not deleted
+func getDeletedRowMark(stub shim.ChaincodeStubInterface, tableName, keyValue string) (Row, error) {
+	row, err := getRow(stub, DeletedRowsTableName, getDeletedRowID(tableName, keyValue))
+	if err != nil {
+		return row, wrapError(err, "Error in getDeletedRowMark func: ")
+	}
+	return row, nil
+}
+
+func isRowDeleted(stub shim.ChaincodeStubInterface, tableName, keyValue string) (bool, error) {
+	if tableName == DeletedRowsTableName {
+		return false, nil
+	}
+
+	row, err := getDeletedRowMark(stub, tableName, keyValue)
+	if err != nil {
+		return false, wrapError(err, "Error in isRowDeleted func: ")
+	}
+
+	return row.Columns != nil, nil
+}
+
+// Strips includeDeleted option from the end of args
+func parseIncludeDeletedOption(args []string) ([]string, bool) {
+	if len(args) > 0 && args[len(args)-1] == IncludeDeletedOption {
+		return args[:len(args)-1], true
+	}
+	return args, false
+}
+
+// Appends includeDeleted option to queryArgs if it is given in args
+func passIncludeDeletedOption(queryArgs []string, args []string) []string {
+	_, includeDeleted := parseIncludeDeletedOption(args)
+	if includeDeleted {
+		return append(queryArgs, IncludeDeletedOption)
+	}
+	return queryArgs
+}
+
+func restoreRow(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in restoreRow func. Expecting 2")
+	}
+
+	tableName, keyValue := args[0], args[1]
+
+	err := restoreRowWithReferences(stub, tableName, keyValue)
+	if err != nil {
+		return nil, wrapError(err, "Error in restoreRow func: ")
+	}
+	return nil, nil
+}
+
+func getDeletedRowsList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	switch len(args) {
+	case 0:
+		return filterTableByValue(stub, []string{DeletedRowsTableName})
+	case 1:
+		return filterTableByValue(stub, []string{DeletedRowsTableName, DR_TableNameColName, args[0]})
+	}
+	return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getDeletedRowsList func. Expecting 0 or 1")
+}
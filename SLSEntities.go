@@ -0,0 +1,57 @@
+package main
+
+import (
+	//"encoding/json"
+	//"errors"
+	//"fmt"
+	"strconv"
+	"time"
+)
+
+//Column types
+const CT_String = "string"
+const CT_Int = "int"
+const CT_Time = "time"
+
+// Types of columns which are not strings, generated entity files add their columns in init()
+var columnTypes = make(map[string]map[string]string)
+
+// ============================================================================================================================
+// Entities without own business rules (LoanTerm, LoanTermProposal, LoanTermVote, LoanTermComment, User) are generated
+// by entitygen from api/entities.json: table, create, add, update, patch, get and count functions, their registration,
+// foreign keys, column types and SLSEntities_test.go. Change the spec and run go generate instead of editing them.
+// ============================================================================================================================
+
+//go:generate go run ./entitygen
+
+// Empty values are allowed for columns of every type, they mean that the value is not set
+func checkColumnType(tableName, columnName, value string) error {
+	if value == "" {
+		return nil
+	}
+	switch columnTypes[tableName][columnName] {
+	case CT_Int:
+		_, err := strconv.Atoi(value)
+		if err != nil {
+			return newFieldError(ErrCodeInvalidArgument, columnName, "Column '"+tableName+"."+columnName+
+				"' value '"+value+"' is not an integer")
+		}
+	case CT_Time:
+		_, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return newFieldError(ErrCodeInvalidArgument, columnName, "Column '"+tableName+"."+columnName+
+				"' value '"+value+"' is not an RFC 3339 time")
+		}
+	}
+	return nil
+}
+
+func checkColumnTypes(tableName string, colDefs []*ColumnDefinition, cols []*Column) error {
+	for i, cd := range colDefs {
+		err := checkColumnType(tableName, cd.Name, cols[i].Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+// Code generated by entitygen from api/entities.json. DO NOT EDIT.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+type entityTest struct {
+	Name string
+	Key  string
+	// Row added with the key, its references are rows of earlier entities or demo rows
+	Values       map[string]string
+	UpdateColumn string
+	IntColumn    string
+	RefColumn    string
+	// Update and patch are allowed to the owner bank of the row referenced by the column,
+	// OtherKey row references a row of another bank
+	OwnerColumn string
+	OwnerBank   string
+	OtherKey    string
+	OtherOwner  string
+}
+
+var entityTests = []entityTest{
+	{Name: "User", Key: U_UserIDColName,
+		Values: map[string]string{
+			U_UserIDColName:        "90",
+			U_ParticipantIDColName: "6",
+			U_UserNameColName:      "Test UserName",
+		},
+		UpdateColumn: U_UserNameColName,
+		RefColumn:    U_ParticipantIDColName,
+	},
+	{Name: "LoanTerm", Key: LT_LoanTermIDColName,
+		Values: map[string]string{
+			LT_LoanTermIDColName:      "90",
+			LT_LoanRequestIDColName:   "1",
+			LT_ParagraphNumberColName: "1",
+			LT_LoanTermTextColName:    "Test LoanTermText",
+			LT_LoanTermStatusColName:  "Test LoanTermStatus",
+		},
+		UpdateColumn: LT_LoanTermTextColName,
+		IntColumn:    LT_ParagraphNumberColName,
+		RefColumn:    LT_LoanRequestIDColName,
+		OwnerColumn:  LT_LoanRequestIDColName, OwnerBank: "6", OtherKey: "80", OtherOwner: "2",
+	},
+	{Name: "LoanTermProposal", Key: LTP_LoanTermProposalIDColName,
+		Values: map[string]string{
+			LTP_LoanTermProposalIDColName:      "90",
+			LTP_LoanTermIDColName:              "90",
+			LTP_ParagraphNumberColName:         "1",
+			LTP_LoanTermProposalTextColName:    "Test LoanTermProposalText",
+			LTP_LoanTermProposalExpTimeColName: "2099-12-31T00:00:00Z",
+			LTP_LoanTermProposalStatusColName:  "Test LoanTermProposalStatus",
+		},
+		UpdateColumn: LTP_LoanTermProposalTextColName,
+		IntColumn:    LTP_ParagraphNumberColName,
+		RefColumn:    LTP_LoanTermIDColName,
+		OwnerColumn:  LTP_LoanTermIDColName, OwnerBank: "6", OtherKey: "80", OtherOwner: "80",
+	},
+	{Name: "LoanTermVote", Key: LTV_LoanTermVoteIDColName,
+		Values: map[string]string{
+			LTV_LoanTermVoteIDColName:       "90",
+			LTV_LoanTermProposalIDColName:   "90",
+			LTV_BankIDColName:               "6",
+			LTV_LoanTermVoteStatusColName:   "Test LoanTermVoteStatus",
+			LTV_LoanTermVoteDeadlineColName: "2099-12-31T00:00:00Z",
+		},
+		UpdateColumn: LTV_LoanTermVoteStatusColName,
+		RefColumn:    LTV_LoanTermProposalIDColName,
+		OwnerColumn:  LTV_BankIDColName, OwnerBank: "6", OtherKey: "80", OtherOwner: "7",
+	},
+	{Name: "LoanTermComment", Key: LTC_LoanTermCommentIDColName,
+		Values: map[string]string{
+			LTC_LoanTermCommentIDColName:       "90",
+			LTC_ParentLoanTermCommentIDColName: "",
+			LTC_LoanTermIDColName:              "90",
+			LTC_UserIDColName:                  "90",
+			LTC_BankIDColName:                  "6",
+			LTC_CommentTextColName:             "Test CommentText",
+			LTC_LoanTermCommentDateColName:     "Test LoanTermCommentDate",
+		},
+		UpdateColumn: LTC_CommentTextColName,
+		RefColumn:    LTC_ParentLoanTermCommentIDColName,
+		OwnerColumn:  LTC_BankIDColName, OwnerBank: "6", OtherKey: "80", OtherOwner: "7",
+	},
+}
+
+func getEntityTestRow(t *testing.T, stub *shimtest.MockStub, e entityTest, keyValue string) map[string]string {
+	bytes, err := mockQuery(stub, "get"+e.Name+"ByKey", []string{keyValue})
+	if err != nil {
+		fmt.Println("Failed getting", e.Name, keyValue, err)
+		t.FailNow()
+	}
+	var rows []map[string]string
+	err = json.Unmarshal(bytes, &rows)
+	if err != nil || len(rows) != 1 {
+		fmt.Println("Wrong rows of", e.Name, keyValue, string(bytes))
+		t.FailNow()
+	}
+	return rows[0]
+}
+
+// Invokes the function with named arguments, private values are passed in the transient map
+func invokeEntityTest(stub *shimtest.MockStub, function string, txID string, values map[string]string) error {
+	_, err := mockInvokeWithTransient(stub, txID, function, values)
+	return err
+}
+
+// Entities are tested in spec order, so rows referenced by an entity are added before it
+func TestSLSChaincode_Entities(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+
+	checkInit(t, stub, []string{""})
+
+	for _, e := range entityTests {
+		bytes, err := mockQuery(stub, "get"+e.Name+"Quantity", nil)
+		quantity, _ := strconv.Atoi(string(bytes))
+		if err != nil {
+			fmt.Println("Failed counting", e.Name, err)
+			t.FailNow()
+		}
+
+		err = invokeEntityTest(stub, "add"+e.Name, e.Name+"-add", e.Values)
+		if err != nil {
+			fmt.Println("Failed adding", e.Name, err)
+			t.FailNow()
+		}
+		keyValue := e.Values[e.Key]
+		row := getEntityTestRow(t, stub, e, keyValue)
+		for column, value := range e.Values {
+			if row[column] != value {
+				fmt.Println("Wrong", e.Name, column, row[column], "expected", value)
+				t.FailNow()
+			}
+		}
+		checkQuery(t, stub, "get"+e.Name+"Quantity", nil, strconv.Itoa(quantity+1))
+		checkQuery(t, stub, "get"+e.Name+"MaxKey", nil, keyValue)
+
+		// Omitted key is generated
+		values := make(map[string]string)
+		for column, value := range e.Values {
+			if column != e.Key {
+				values[column] = value
+			}
+		}
+		err = invokeEntityTest(stub, "add"+e.Name, e.Name+"-generated", values)
+		if err != nil {
+			fmt.Println("Failed adding", e.Name, "without key", err)
+			t.FailNow()
+		}
+		generatedKey, _ := strconv.Atoi(keyValue)
+		checkQuery(t, stub, "get"+e.Name+"MaxKey", nil, strconv.Itoa(generatedKey+1))
+
+		if e.UpdateColumn != "" {
+			err = invokeEntityTest(stub, "update"+e.Name, e.Name+"-update", map[string]string{e.Key: keyValue, e.UpdateColumn: "Updated"})
+			if err != nil {
+				fmt.Println("Failed updating", e.Name, err)
+				t.FailNow()
+			}
+			row = getEntityTestRow(t, stub, e, keyValue)
+			if row[e.UpdateColumn] != "Updated" || len(row) != len(e.Values) {
+				fmt.Println("Wrong updated", e.Name, row)
+				t.FailNow()
+			}
+
+			err = invokeEntityTest(stub, "patch"+e.Name, e.Name+"-patch", map[string]string{e.Key: keyValue, e.UpdateColumn: "Patched", PatchETagArgName: "stale"})
+			checkErrorCode(t, err, ErrCodeConflict, "")
+		}
+
+		// Banks change only their own rows and can not move them to other banks
+		if e.OwnerColumn != "" {
+			values = make(map[string]string)
+			for column, value := range e.Values {
+				values[column] = value
+			}
+			values[e.Key], values[e.OwnerColumn] = e.OtherKey, e.OtherOwner
+			err = invokeEntityTest(stub, "add"+e.Name, e.Name+"-other-add", values)
+			if err != nil {
+				fmt.Println("Failed adding", e.Name, "of another bank", err)
+				t.FailNow()
+			}
+
+			actAs(testIdentity{CA_Role: FR_Bank, CA_BankID: "0"})
+			err = invokeEntityTest(stub, "update"+e.Name, e.Name+"-other-update", map[string]string{e.Key: keyValue, e.UpdateColumn: "Other"})
+			checkErrorCode(t, err, ErrCodePermissionDenied, "")
+			err = invokeEntityTest(stub, "patch"+e.Name, e.Name+"-other-patch", map[string]string{e.Key: keyValue, e.UpdateColumn: "Other"})
+			checkErrorCode(t, err, ErrCodePermissionDenied, "")
+
+			actAs(testIdentity{CA_Role: FR_Bank, CA_BankID: e.OwnerBank})
+			err = invokeEntityTest(stub, "patch"+e.Name, e.Name+"-move", map[string]string{e.Key: keyValue, e.OwnerColumn: e.OtherOwner})
+			checkErrorCode(t, err, ErrCodePermissionDenied, "")
+			err = invokeEntityTest(stub, "patch"+e.Name, e.Name+"-other-patch-by-owner", map[string]string{e.Key: e.OtherKey, e.UpdateColumn: "Other"})
+			checkErrorCode(t, err, ErrCodePermissionDenied, "")
+			err = invokeEntityTest(stub, "patch"+e.Name, e.Name+"-owner-patch", map[string]string{e.Key: keyValue, e.UpdateColumn: "Patched"})
+			stopActing()
+			if err != nil {
+				fmt.Println("Owner bank failed patching", e.Name, err)
+				t.FailNow()
+			}
+		}
+
+		if e.IntColumn != "" {
+			err = invokeEntityTest(stub, "add"+e.Name, e.Name+"-int", map[string]string{e.IntColumn: "one"})
+			checkErrorCode(t, err, ErrCodeInvalidArgument, e.IntColumn)
+		}
+		if e.RefColumn != "" {
+			err = invokeEntityTest(stub, "add"+e.Name, e.Name+"-ref", map[string]string{e.RefColumn: "999"})
+			checkErrorCode(t, err, ErrCodeInvalidArgument, e.RefColumn)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	//"fmt"
+	"strings"
+)
+
+//Error codes
+const ErrCodePermissionDenied = "PERMISSION_DENIED"
+const ErrCodeNotFound = "NOT_FOUND"
+const ErrCodeInvalidArgument = "INVALID_ARGUMENT"
+const ErrCodeConflict = "CONFLICT"
+const ErrCodeInvalidState = "INVALID_STATE"
+
+// Code of errors which are not caused by the request, e.g. failures of the ledger
+const ErrCodeInternal = "INTERNAL"
+
+// Detail of an error related to an argument, column or fixture row
+type errorDetail struct {
+	Field   string
+	Message string
+}
+
+// Error returned to clients as JSON. Message includes messages of wrapped errors.
+type chaincodeError struct {
+	Code    string
+	Message string
+	Details []errorDetail `json:",omitempty"`
+}
+
+// ============================================================================================================================
+// Errors are created with a code where they happen and wrapped with wrapError, which keeps the code and details.
+// Init and Invoke return errors as JSON, errors without code are returned as INTERNAL.
+// ============================================================================================================================
+
+func (e *chaincodeError) Error() string {
+	return e.Message
+}
+
+func newError(code, message string) error {
+	return &chaincodeError{Code: code, Message: message}
+}
+
+func newFieldError(code, field, message string) error {
+	return &chaincodeError{Code: code, Message: message, Details: []errorDetail{{field, message}}}
+}
+
+// Prefixes the message of the error keeping its code and details
+func wrapError(err error, prefix string) error {
+	if err == nil {
+		return newError(ErrCodeInternal, strings.TrimSuffix(strings.TrimSpace(prefix), ":"))
+	}
+	if e, ok := err.(*chaincodeError); ok {
+		return &chaincodeError{Code: e.Code, Message: prefix + e.Message, Details: e.Details}
+	}
+	return errors.New(prefix + err.Error())
+}
+
+func getErrorCode(err error) string {
+	if e, ok := err.(*chaincodeError); ok {
+		return e.Code
+	}
+	return ErrCodeInternal
+}
+
+// Converts error to the JSON error message returned by Init and Invoke
+func toResponseError(err error) error {
+	e, ok := err.(*chaincodeError)
+	if !ok {
+		e = &chaincodeError{Code: ErrCodeInternal, Message: err.Error()}
+	}
+	b, jsonErr := json.Marshal(e)
+	if jsonErr != nil {
+		return err
+	}
+	return errors.New(string(b))
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	//"errors"
+	//"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Event actions
+const EA_Created = "Created"
+const EA_Updated = "Updated"
+const EA_Deleted = "Deleted"
+const EA_Restored = "Restored"
+const EA_Archived = "Archived"
+
+// Entity names used in events, rows of other tables do not emit events
+var eventEntityNames = map[string]string{
+	ParticipantsTableName:     "Participant",
+	UserTableName:             "User",
+	LoanRequestsTableName:     "LoanRequest",
+	LoanNegotiationsTableName: "LoanNegotiation",
+	LoanTermTableName:         "LoanTerm",
+	LoanTermProposalTableName: "LoanTermProposal",
+	LoanTermVoteTableName:     "LoanTermVote",
+	LoanTermCommentTableName:  "LoanTermComment",
+}
+
+type eventDefinition struct {
+	Type        string
+	Entity      string
+	Action      string
+	ColumnName  string `json:",omitempty"`
+	ColumnValue string `json:",omitempty"`
+	Description string
+}
+
+// Deal events replace the generic entity event type when the action matches and the column is among changed fields
+// (any column if it is empty) with the new value (any value if it is empty). The first matching definition is used,
+// so definitions with values go before definitions of the same column without them.
+// api/events.md describes all event types and the payload, keep it in line with changes here.
+var dealEventDefinitions = []eventDefinition{
+	{"RepaymentRecorded", "LoanRequest", EA_Updated, LR_StatusColName, LR_StatusRepaid,
+		"Arranger recorded that the loan is repaid, Loan Request status is changed to " + LR_StatusRepaid},
+	{"LoanRequestStatusChanged", "LoanRequest", EA_Updated, LR_StatusColName, "",
+		"Loan Request status is changed, usually as a result of negotiation responses"},
+	{"NegotiationResponded", "LoanNegotiation", EA_Updated, LN_NegotiationStatusColName, "",
+		"Participant bank changed status of its negotiation, e.g. to INTERESTED or DECLINED"},
+	{"LoanTermStatusChanged", "LoanTerm", EA_Updated, LT_LoanTermStatusColName, "",
+		"Loan Term status is changed"},
+	{"ProposalAdopted", "LoanTermProposal", EA_Updated, LTP_LoanTermProposalStatusColName, LTP_StatusAdopted,
+		"Arranger adopted a Loan Term Proposal, its status is changed to " + LTP_StatusAdopted},
+	{"VoteCast", "LoanTermVote", EA_Created, "", "",
+		"Bank voted for a Loan Term Proposal"},
+	{"VoteCast", "LoanTermVote", EA_Updated, LTV_LoanTermVoteStatusColName, "",
+		"Bank changed its vote for a Loan Term Proposal"},
+}
+
+// Event of a single row change. Updates of the same row in a transaction are merged into one event.
+type chaincodeEvent struct {
+	Type           string
+	Entity         string
+	ID             string
+	Action         string
+	BankID         string
+	UserID         string
+	TxID           string
+	Date           string
+	ChangedFields  map[string]string `json:",omitempty"`
+	PreviousValues map[string]string `json:",omitempty"`
+
+	tableName string
+}
+
+// ============================================================================================================================
+// A transaction can set only one chaincode event, so row events are collected in the context of the transaction
+// while invoke is running and sent together as a JSON array when it succeeds. Event name is the list of distinct
+// event types separated by commas, so listeners can filter events by name.
+// Init does not emit events.
+// ============================================================================================================================
+
+func init() {
+	registerFunction(functionDefinition{Name: "getEventCatalogueList", Mode: FM_Read, handler: getEventCatalogueList,
+		Description: "Returns all event types with descriptions"})
+}
+
+// Returns all event types with descriptions
+func getEventCatalogue() []eventDefinition {
+	var catalogue []eventDefinition
+	for _, tableName := range fixtureTableNames {
+		entity := eventEntityNames[tableName]
+		actions := []string{EA_Created, EA_Updated, EA_Deleted, EA_Restored}
+		if isArchivedTable(tableName) {
+			actions = append(actions, EA_Archived)
+		}
+		for _, action := range actions {
+			catalogue = append(catalogue, eventDefinition{entity + action, entity, action, "", "",
+				entity + " is " + strings.ToLower(action) + ", unless a deal event below describes the change"})
+		}
+	}
+	return append(catalogue, dealEventDefinitions...)
+}
+
+func getEventCatalogueList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	catalogue, err := json.Marshal(getEventCatalogue())
+	if err != nil {
+		return nil, wrapError(err, "Error in getEventCatalogueList func: ")
+	}
+	return catalogue, nil
+}
+
+func startEvents(stub shim.ChaincodeStubInterface) {
+	getTxContext(stub).events = []*chaincodeEvent{}
+}
+
+// Returns collected events of the transaction with resolved types and stops collecting them
+func takeEvents(stub shim.ChaincodeStubInterface) []*chaincodeEvent {
+	ctx := getTxContext(stub)
+	events := ctx.events
+	ctx.events = nil
+
+	for _, e := range events {
+		e.Type = e.Entity + e.Action
+		for _, d := range dealEventDefinitions {
+			if d.Entity != e.Entity || d.Action != e.Action {
+				continue
+			}
+			if value, ok := e.ChangedFields[d.ColumnName]; (ok && (d.ColumnValue == "" || value == d.ColumnValue)) || d.ColumnName == "" {
+				e.Type = d.Type
+				break
+			}
+		}
+	}
+	return events
+}
+
+func sendEvents(stub shim.ChaincodeStubInterface) error {
+	events := takeEvents(stub)
+	if len(events) == 0 {
+		return nil
+	}
+
+	var types []string
+	for _, e := range events {
+		var isTypeFound bool
+		for _, t := range types {
+			if t == e.Type {
+				isTypeFound = true
+				break
+			}
+		}
+		if !isTypeFound {
+			types = append(types, e.Type)
+		}
+	}
+
+	payload, err := json.Marshal(events)
+	if err != nil {
+		return wrapError(err, "Error in sendEvents func: ")
+	}
+	err = stub.SetEvent(strings.Join(types, ","), payload)
+	if err != nil {
+		return wrapError(err, "Error sending events: ")
+	}
+
+	logDebug(stub, "Events are sent", logFields{"events": strings.Join(types, ",")})
+	return nil
+}
+
+// Adds row change to events of running invoke. Previous values are given for updates only.
+func recordRowEvent(stub shim.ChaincodeStubInterface, tableName, keyValue, action string, changedFields, previousValues map[string]string) error {
+	ctx := getTxContext(stub)
+	if ctx.events == nil {
+		return nil
+	}
+	entity, ok := eventEntityNames[tableName]
+	if !ok {
+		return nil
+	}
+
+	// Updates are merged into the earlier event of the row, created row keeps Created action
+	if action == EA_Updated {
+		for _, e := range ctx.events {
+			if e.tableName != tableName || e.ID != keyValue || (e.Action != EA_Created && e.Action != EA_Updated) {
+				continue
+			}
+			for k, v := range changedFields {
+				if _, ok := e.PreviousValues[k]; !ok && e.Action == EA_Updated {
+					e.PreviousValues[k] = previousValues[k]
+				}
+				e.ChangedFields[k] = v
+			}
+			return nil
+		}
+	}
+
+	bankID, err := getBankId(stub, []string{})
+	if err != nil {
+		return wrapError(err, "Error getting bankid in recordRowEvent func: ")
+	}
+	userID, err := getUserId(stub, []string{})
+	if err != nil {
+		return wrapError(err, "Error getting userid in recordRowEvent func: ")
+	}
+	date, err := getTxTimestampString(stub)
+	if err != nil {
+		return wrapError(err, "Error in recordRowEvent func: ")
+	}
+
+	e := &chaincodeEvent{Entity: entity, ID: keyValue, Action: action, BankID: string(bankID), UserID: string(userID),
+		TxID: stub.GetTxID(), Date: date, ChangedFields: changedFields, PreviousValues: previousValues, tableName: tableName}
+	if e.ChangedFields == nil && action == EA_Updated {
+		e.ChangedFields = make(map[string]string)
+	}
+	if e.PreviousValues == nil && action == EA_Updated {
+		e.PreviousValues = make(map[string]string)
+	}
+	ctx.events = append(ctx.events, e)
+	return nil
+}
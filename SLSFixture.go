@@ -0,0 +1,353 @@
+package main
+
+import (
+	"encoding/json"
+	//"errors"
+	//"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Init argument with fixture JSON
+const FixtureInitArgName = "fixture"
+
+// Tables which can be loaded from a fixture, in the order they are loaded,
+// so rows are always loaded after the rows they reference
+var fixtureTableNames = []string{ParticipantsTableName, UserTableName, LoanRequestsTableName, LoanNegotiationsTableName,
+	LoanTermTableName, LoanTermProposalTableName, LoanTermVoteTableName, LoanTermCommentTableName}
+
+// ============================================================================================================================
+// Fixture is a JSON object with table names as keys and arrays of rows as values.
+// Every row is an object with column names as keys and string values, keys should always be provided.
+// Loading is all or nothing and can be repeated: rows equal to existing ones are skipped,
+// rows which differ from existing ones with the same key are reported as errors.
+// ============================================================================================================================
+
+// Loads fixture from the first argument or the demo fixture if there are no arguments
+func populateInitialData(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var fixture string
+	switch len(args) {
+	case 0:
+		fixture = demoFixture
+	case 1:
+		fixture = args[0]
+	default:
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in populateInitialData func. Expecting 0 or 1")
+	}
+
+	inserted, skipped, err := loadFixture(stub, fixture)
+	if err != nil {
+		return nil, wrapError(err, "Error in populateInitialData func: ")
+	}
+
+	return []byte("Fixture loaded: " + strconv.Itoa(inserted) + " rows inserted, " + strconv.Itoa(skipped) + " rows already present"), nil
+}
+
+func loadFixture(stub shim.ChaincodeStubInterface, fixture string) (int, int, error) {
+	var tables map[string][]map[string]string
+	err := json.Unmarshal([]byte(fixture), &tables)
+	if err != nil {
+		return 0, 0, newError(ErrCodeInvalidArgument, "Fixture is not valid JSON: "+err.Error())
+	}
+
+	// All rows are validated before anything is written
+	rowsByTable, details := validateFixture(stub, tables)
+	if len(details) > 0 {
+		var messages []string
+		for _, d := range details {
+			messages = append(messages, d.Field+": "+d.Message)
+		}
+		return 0, 0, &chaincodeError{Code: ErrCodeInvalidArgument, Message: "Fixture validation failed: " + strings.Join(messages, "; "),
+			Details: details}
+	}
+
+	var inserted, skipped int
+	for _, tableName := range fixtureTableNames {
+		for _, row := range rowsByTable[tableName] {
+			if row.IsExisting {
+				skipped++
+				continue
+			}
+
+			err = sealFixtureValues(stub, tableName, row.Values)
+			if err == nil {
+				err = addRow(stub, tableName, row.Values, true)
+			}
+			if err != nil {
+				return 0, 0, wrapError(err, "Failed loading fixture row with key '" + row.Values[0] + "' to '" + tableName + "' table: ")
+			}
+			inserted++
+		}
+	}
+
+	logInfo(stub, "Fixture is loaded", logFields{"inserted": strconv.Itoa(inserted), "skipped": strconv.Itoa(skipped)})
+	return inserted, skipped, nil
+}
+
+// Values of private columns are replaced with their hashes, addRow takes private values from the transient map only
+func sealFixtureValues(stub shim.ChaincodeStubInterface, tableName string, values []string) error {
+	if _, ok := privateColumns[tableName]; !ok {
+		return nil
+	}
+	tbl, err := getTable(stub, tableName)
+	if err != nil {
+		return err
+	}
+	cols := make([]*Column, len(values))
+	for i, value := range values {
+		cols[i] = &Column{Value: value}
+	}
+	err = sealPrivateColumns(stub, tbl, Row{Columns: cols})
+	if err != nil {
+		return err
+	}
+	for i, c := range cols {
+		values[i] = c.Value
+	}
+	return nil
+}
+
+type fixtureRow struct {
+	Values     []string
+	IsExisting bool
+}
+
+// Checks fixture rows against table schemas, foreign keys and existing rows.
+// Returns row values in column order and all found errors, fields of errors are tables or rows.
+func validateFixture(stub shim.ChaincodeStubInterface, tables map[string][]map[string]string) (map[string][]fixtureRow, []errorDetail) {
+	var errs []errorDetail
+	rowsByTable := make(map[string][]fixtureRow)
+
+	for tableName := range tables {
+		var isFixtureTable bool
+		for _, tn := range fixtureTableNames {
+			if tn == tableName {
+				isFixtureTable = true
+				break
+			}
+		}
+		if !isFixtureTable {
+			errs = append(errs, errorDetail{tableName, "table can not be loaded from fixture"})
+		}
+	}
+
+	// Keys of fixture rows by table, rows may reference rows loaded before them
+	keysByTable := make(map[string]map[string]bool)
+
+	for _, tableName := range fixtureTableNames {
+		rows, ok := tables[tableName]
+		if !ok {
+			continue
+		}
+
+		tbl, err := getTable(stub, tableName)
+		if err != nil {
+			errs = append(errs, errorDetail{tableName, "table is not found: " + err.Error()})
+			continue
+		}
+
+		keys := make(map[string]bool)
+		keysByTable[tableName] = keys
+		for i, row := range rows {
+			rowName := tableName + "[" + strconv.Itoa(i) + "]"
+
+			for columnName := range row {
+				var isColumnFound bool
+				for _, cd := range tbl.ColumnDefinitions {
+					if cd.Name == columnName {
+						isColumnFound = true
+						break
+					}
+				}
+				if !isColumnFound {
+					errs = append(errs, errorDetail{rowName, "unknown column '" + columnName + "'"})
+				}
+			}
+
+			var values []string
+			for j, cd := range tbl.ColumnDefinitions {
+				value, ok := row[cd.Name]
+				if !ok {
+					value, ok = columnDefaults[tableName][cd.Name]
+				}
+				if !ok || (j == 0 && value == "") {
+					errs = append(errs, errorDetail{rowName, "column '" + cd.Name + "' is missing"})
+				}
+				values = append(values, value)
+
+				err = checkColumnType(tableName, cd.Name, value)
+				if err != nil {
+					errs = append(errs, errorDetail{rowName, err.Error()})
+				}
+				for _, fk := range foreignKeys {
+					if fk.TableName != tableName || fk.ColumnName != cd.Name || value == "" || keysByTable[fk.RefTableName][value] {
+						continue
+					}
+					err = checkForeignKeyValue(stub, tableName, cd.Name, value)
+					if err != nil {
+						errs = append(errs, errorDetail{rowName, err.Error()})
+					}
+				}
+			}
+
+			if keys[values[0]] {
+				errs = append(errs, errorDetail{rowName, "duplicate key '" + values[0] + "'"})
+			}
+			keys[values[0]] = true
+
+			isExisting, err := checkFixtureRowExisting(stub, tableName, values)
+			if err != nil {
+				errs = append(errs, errorDetail{rowName, err.Error()})
+			}
+
+			rowsByTable[tableName] = append(rowsByTable[tableName], fixtureRow{values, isExisting})
+		}
+	}
+
+	return rowsByTable, errs
+}
+
+// Existing rows are accepted only if they are not deleted and equal to the fixture row
+func checkFixtureRowExisting(stub shim.ChaincodeStubInterface, tableName string, values []string) (bool, error) {
+	existingValues, err := getRowValuesByKey(stub, tableName, values[0])
+	if err != nil {
+		return false, err
+	}
+	if existingValues == nil {
+		return false, nil
+	}
+
+	isDeleted, err := isRowDeleted(stub, tableName, values[0])
+	if err != nil {
+		return false, err
+	}
+	if isDeleted {
+		return false, newError(ErrCodeConflict, "row with key '" + values[0] + "' is deleted in '" + tableName + "' table")
+	}
+	isEqual := len(existingValues) == len(values)
+	for i := 0; isEqual && i < len(values); i++ {
+		if values[i] != existingValues[i] {
+			isEqual, err = isFixturePrivateValueEqual(stub, tableName, existingValues, i, values[i])
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	if !isEqual {
+		return false, newError(ErrCodeConflict, "row with key '" + values[0] + "' differs from the existing row in '" + tableName + "' table")
+	}
+	return true, nil
+}
+
+// Rows have hashes of private values, so the fixture value is hashed with the salt of the value in the collection
+func isFixturePrivateValueEqual(stub shim.ChaincodeStubInterface, tableName string, existingValues []string, i int, value string) (bool, error) {
+	tbl, err := getTable(stub, tableName)
+	if err != nil {
+		return false, err
+	}
+	columnName := tbl.ColumnDefinitions[i].Name
+	hash := existingValues[i]
+	if !isPrivateColumn(tableName, columnName) || !isPrivateValueHash(hash) || isPrivateValueHash(value) {
+		return false, nil
+	}
+
+	row := Row{}
+	for _, v := range existingValues {
+		row.Columns = append(row.Columns, &Column{Value: v})
+	}
+	loanRequestID, err := getRowDealID(stub, tbl, row)
+	if err != nil {
+		return false, err
+	}
+	v, err := getMatchingPrivateValue(stub, getDealCollectionName(loanRequestID), tableName, existingValues[0], columnName, hash)
+	if err != nil || v == nil {
+		return false, err
+	}
+	return getPrivateValueHash(v.Salt, value) == hash, nil
+}
+
+// Returns nil if the row does not exist, deleted rows are returned as well
+func getRowValuesByKey(stub shim.ChaincodeStubInterface, tableName, keyValue string) ([]string, error) {
+	row, err := getRow(stub, tableName, keyValue)
+	if err != nil {
+		return nil, wrapError(err, "Error getting row in getRowValuesByKey func: ")
+	}
+
+	var values []string
+	for _, c := range row.Columns {
+		values = append(values, c.Value)
+	}
+	return values, nil
+}
+
+// Demo data set loaded by Init into new ledgers when no other fixture is given
+const demoFixture = `{
+	"Participants": [
+		{"ParticipantKey": "6", "ParticipantName": "SpareBank 1 SR-BANK", "ParticipantType": "Bank"},
+		{"ParticipantKey": "7", "ParticipantName": "DNB ASA", "ParticipantType": "Bank"},
+		{"ParticipantKey": "8", "ParticipantName": "Nationwide Building Society", "ParticipantType": "Bank"},
+		{"ParticipantKey": "9", "ParticipantName": "JPMorgan Chase & Co", "ParticipantType": "Bank"},
+		{"ParticipantKey": "10", "ParticipantName": "Barclays", "ParticipantType": "Bank"},
+		{"ParticipantKey": "11", "ParticipantName": "Mizuho Bank, Ltd.", "ParticipantType": "Bank"},
+		{"ParticipantKey": "12", "ParticipantName": "SpareBank 1 Nord-Norge", "ParticipantType": "Bank"},
+		{"ParticipantKey": "13", "ParticipantName": "SpareBank 1 Hedmark", "ParticipantType": "Bank"},
+		{"ParticipantKey": "14", "ParticipantName": "SpareBank 1 Modum", "ParticipantType": "Bank"},
+		{"ParticipantKey": "15", "ParticipantName": "Skandinaviska Enskilda Banken AB", "ParticipantType": "Bank"}
+	],
+	"Users": [
+		{"UserID": "1", "ParticipantID": "6", "UserName": "srbank"},
+		{"UserID": "2", "ParticipantID": "6", "UserName": "srbank_user1"},
+		{"UserID": "3", "ParticipantID": "6", "UserName": "srbank_user2"},
+		{"UserID": "4", "ParticipantID": "6", "UserName": "srbank_user3"},
+		{"UserID": "5", "ParticipantID": "7", "UserName": "dnb"},
+		{"UserID": "6", "ParticipantID": "7", "UserName": "dnb_user1"},
+		{"UserID": "7", "ParticipantID": "7", "UserName": "dnb_user2"},
+		{"UserID": "8", "ParticipantID": "7", "UserName": "dnb_user3"},
+		{"UserID": "9", "ParticipantID": "8", "UserName": "nationwide"},
+		{"UserID": "10", "ParticipantID": "8", "UserName": "nationwide_user1"},
+		{"UserID": "11", "ParticipantID": "8", "UserName": "nationwide_user2"},
+		{"UserID": "12", "ParticipantID": "8", "UserName": "nationwide_user3"},
+		{"UserID": "13", "ParticipantID": "9", "UserName": "jpmorgan"},
+		{"UserID": "14", "ParticipantID": "9", "UserName": "jpmorgan_user1"},
+		{"UserID": "15", "ParticipantID": "9", "UserName": "jpmorgan_user2"},
+		{"UserID": "16", "ParticipantID": "9", "UserName": "jpmorgan_user3"},
+		{"UserID": "17", "ParticipantID": "10", "UserName": "barclays"},
+		{"UserID": "18", "ParticipantID": "10", "UserName": "barclays_user1"},
+		{"UserID": "19", "ParticipantID": "10", "UserName": "barclays_user2"},
+		{"UserID": "20", "ParticipantID": "10", "UserName": "barclays_user3"},
+		{"UserID": "21", "ParticipantID": "11", "UserName": "mizuho"},
+		{"UserID": "22", "ParticipantID": "11", "UserName": "mizuho_user1"},
+		{"UserID": "23", "ParticipantID": "11", "UserName": "mizuho_user2"},
+		{"UserID": "24", "ParticipantID": "11", "UserName": "mizuho_user3"},
+		{"UserID": "25", "ParticipantID": "12", "UserName": "nordnorge"},
+		{"UserID": "26", "ParticipantID": "12", "UserName": "nordnorge_user1"},
+		{"UserID": "27", "ParticipantID": "12", "UserName": "nordnorge_user2"},
+		{"UserID": "28", "ParticipantID": "12", "UserName": "nordnorge_user3"},
+		{"UserID": "29", "ParticipantID": "13", "UserName": "hedmark"},
+		{"UserID": "30", "ParticipantID": "13", "UserName": "hedmark_user1"},
+		{"UserID": "31", "ParticipantID": "13", "UserName": "hedmark_user2"},
+		{"UserID": "32", "ParticipantID": "13", "UserName": "hedmark_user3"},
+		{"UserID": "33", "ParticipantID": "14", "UserName": "modum"},
+		{"UserID": "34", "ParticipantID": "14", "UserName": "modum_user1"},
+		{"UserID": "35", "ParticipantID": "14", "UserName": "modum_user2"},
+		{"UserID": "36", "ParticipantID": "14", "UserName": "modum_user3"},
+		{"UserID": "37", "ParticipantID": "15", "UserName": "seb"},
+		{"UserID": "38", "ParticipantID": "15", "UserName": "seb_user1"},
+		{"UserID": "39", "ParticipantID": "15", "UserName": "seb_user2"},
+		{"UserID": "40", "ParticipantID": "15", "UserName": "seb_user3"}
+	],
+	"LoanRequests": [
+		{"LoanRequestID": "1", "BorrowerID": "Statoil ASA", "ArrangerBankID": "6", "LoanSharesAmount": "1M", "ProjectRevenue": "1M", "ProjectName": "Statoil ASA project", "ProjectInformation": "Statoil ASA project info", "Company": "Statoil ASA", "Website": "www.statoil.com", "ContactPersonName": "John", "ContactPersonSurname": "Smith", "RequestDate": "10-01-2016", "Status": "Invitation Sent", "MarketAndIndustry": "Oil industry", "LoanTerm": "some LoanTerm", "Assets": "some Assets", "Convenants": "some Convenants", "InterestRate": "some InterestRate", "Currency": "USD"},
+		{"LoanRequestID": "2", "BorrowerID": "BP Global", "ArrangerBankID": "7", "LoanSharesAmount": "1M", "ProjectRevenue": "1M", "ProjectName": "BP Global project", "ProjectInformation": "BP Global project info", "Company": "BP Global", "Website": "www.bp.com", "ContactPersonName": "Peter", "ContactPersonSurname": "Froystad", "RequestDate": "10-01-2016", "Status": "Invitation Sent", "MarketAndIndustry": "Oil industry", "LoanTerm": "some LoanTerm", "Assets": "some Assets", "Convenants": "some Convenants", "InterestRate": "some InterestRate", "Currency": "USD"}
+	],
+	"LoanNegotiations": [
+		{"LoanNegotiationID": "1", "LoanRequestID": "1", "ParticipantBankID": "6", "Amount": "200 M USD", "NegotiationStatus": "INVITED", "ParticipantBankComment": "Comment of SpareBank 1 SR-BANK", "Date": "11-01-2016"},
+		{"LoanNegotiationID": "2", "LoanRequestID": "1", "ParticipantBankID": "9", "Amount": "100 M USD", "NegotiationStatus": "INVITED", "ParticipantBankComment": "Comment of JPMorgan", "Date": "12-01-2016"},
+		{"LoanNegotiationID": "3", "LoanRequestID": "1", "ParticipantBankID": "10", "Amount": "100 M USD", "NegotiationStatus": "INVITED", "ParticipantBankComment": "Comment of Barclays", "Date": "12-01-2016"},
+		{"LoanNegotiationID": "4", "LoanRequestID": "2", "ParticipantBankID": "7", "Amount": "250 M USD", "NegotiationStatus": "INVITED", "ParticipantBankComment": "Comment of Nationwide Building Society", "Date": "21-01-2016"},
+		{"LoanNegotiationID": "5", "LoanRequestID": "2", "ParticipantBankID": "9", "Amount": "200 M USD", "NegotiationStatus": "INVITED", "ParticipantBankComment": "Comment of JPMorgan", "Date": "22-01-2016"},
+		{"LoanNegotiationID": "6", "LoanRequestID": "2", "ParticipantBankID": "11", "Amount": "300 M USD", "NegotiationStatus": "INVITED", "ParticipantBankComment": "Comment of Mizuho Bank, Ltd.", "Date": "22-01-2016"}
+	]
+}`
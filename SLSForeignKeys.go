@@ -0,0 +1,281 @@
+package main
+
+import (
+	//"encoding/json"
+	"errors"
+	//"fmt"
+	//"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Delete rules
+const FK_OnDeleteRestrict = "RESTRICT"     // deleting a referenced row fails
+const FK_OnDeleteCascade = "CASCADE"       // referencing rows are deleted as well
+const FK_OnDeleteSoftDelete = "SOFTDELETE" // referencing rows are kept but marked as deleted
+
+type foreignKey struct {
+	TableName    string
+	ColumnName   string
+	RefTableName string
+	OnDelete     string
+}
+
+// Referenced column is always the single column key of RefTableName.
+// Empty column value means that the row does not reference anything.
+// Foreign keys of generated entities are added by their files, see api/entities.json.
+var foreignKeys = []foreignKey{
+	{LoanRequestsTableName, LR_ArrangerBankIDColName, ParticipantsTableName, FK_OnDeleteRestrict},
+	{LoanNegotiationsTableName, LN_LoanRequestIDColName, LoanRequestsTableName, FK_OnDeleteCascade},
+	{LoanNegotiationsTableName, LN_ParticipantBankIDColName, ParticipantsTableName, FK_OnDeleteRestrict},
+}
+
+// ============================================================================================================================
+//
+// ============================================================================================================================
+
+// Checks that every foreign key value of the row references an existing not deleted row
+func checkForeignKeys(stub shim.ChaincodeStubInterface, tableName string, colDefs []*ColumnDefinition, cols []*Column) error {
+	for i, cd := range colDefs {
+		err := checkForeignKeyValue(stub, tableName, cd.Name, cols[i].Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkForeignKeyValue(stub shim.ChaincodeStubInterface, tableName, columnName, columnValue string) error {
+	if columnValue == "" {
+		return nil
+	}
+
+	for _, fk := range foreignKeys {
+		if fk.TableName != tableName || fk.ColumnName != columnName {
+			continue
+		}
+
+		exists, err := isRowExisting(stub, fk.RefTableName, columnValue)
+		if err != nil {
+			return wrapError(err, "Error checking foreign key '" + tableName + "." + columnName + "': ")
+		}
+		if !exists {
+			return newFieldError(ErrCodeInvalidArgument, columnName, "Foreign key violation: '"+tableName+"."+columnName+
+				"' value '"+columnValue+"' does not exist in '"+fk.RefTableName+"' table")
+		}
+	}
+	return nil
+}
+
+func isRowExisting(stub shim.ChaincodeStubInterface, tableName, keyValue string) (bool, error) {
+	row, err := getRow(stub, tableName, keyValue)
+	if err != nil {
+		return false, wrapError(err, "Error getting row in isRowExisting func: ")
+	}
+	if row.Columns == nil {
+		return false, nil
+	}
+
+	isDeleted, err := isRowDeleted(stub, tableName, keyValue)
+	if err != nil {
+		return false, wrapError(err, "Error in isRowExisting func: ")
+	}
+	return !isDeleted, nil
+}
+
+// Returns keys of all rows, including soft deleted ones, which reference keyValue through fk
+func getReferencingKeys(stub shim.ChaincodeStubInterface, fk foreignKey, keyValue string) ([]string, error) {
+	tbl, err := getTable(stub, fk.TableName)
+	if err != nil {
+		return nil, wrapError(err, "Error getting table in getReferencingKeys func: ")
+	}
+
+	columnNumber := -1
+	for i, cd := range tbl.ColumnDefinitions {
+		if cd.Name == fk.ColumnName {
+			columnNumber = i
+			break
+		}
+	}
+	if columnNumber < 0 {
+		return nil, errors.New("Column '" + fk.ColumnName + "' is not found in '" + fk.TableName + "' table in getReferencingKeys func")
+	}
+
+	rows, err := getRows(stub, fk.TableName)
+	if err != nil {
+		return nil, wrapError(err, "Error getting rows in getReferencingKeys func: ")
+	}
+
+	var keys []string
+	for _, row := range rows {
+		if row.Columns[columnNumber].Value == keyValue {
+			keys = append(keys, row.Columns[0].Value)
+		}
+	}
+	return keys, nil
+}
+
+// Applies delete rules of all foreign keys referencing the row and then marks the row as deleted.
+// Deletion is logical, rows stay in the ledger, so referencing rows never point to missing rows.
+func deleteRowWithReferences(stub shim.ChaincodeStubInterface, tableName, keyValue string) error {
+	exists, err := isRowExisting(stub, tableName, keyValue)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return newError(ErrCodeNotFound, "Row with key '" + keyValue + "' is not found in '" + tableName + "' table")
+	}
+
+	// Restrict rules are checked first, so nothing is deleted if any of them fails
+	for _, fk := range foreignKeys {
+		if fk.RefTableName != tableName || fk.OnDelete != FK_OnDeleteRestrict {
+			continue
+		}
+		keys, err := getReferencingKeys(stub, fk, keyValue)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			isDeleted, err := isRowDeleted(stub, fk.TableName, key)
+			if err != nil {
+				return err
+			}
+			if !isDeleted {
+				return newError(ErrCodeInvalidState, "Row with key '" + keyValue + "' in '" + tableName + "' table is referenced by row with key '" +
+					key + "' in '" + fk.TableName + "' table")
+			}
+		}
+	}
+
+	err = markRowDeleted(stub, tableName, keyValue)
+	if err != nil {
+		return wrapError(err, "Failed to delete row with key '" + keyValue + "' from '" + tableName + "' table: ")
+	}
+
+	for _, fk := range foreignKeys {
+		if fk.RefTableName != tableName || fk.OnDelete == FK_OnDeleteRestrict {
+			continue
+		}
+		keys, err := getReferencingKeys(stub, fk, keyValue)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			isDeleted, err := isRowDeleted(stub, fk.TableName, key)
+			if err != nil {
+				return err
+			}
+			if isDeleted {
+				continue
+			}
+			switch fk.OnDelete {
+			case FK_OnDeleteCascade:
+				err = deleteRowWithReferences(stub, fk.TableName, key)
+			case FK_OnDeleteSoftDelete:
+				err = markRowDeleted(stub, fk.TableName, key)
+			}
+			if err != nil {
+				return wrapError(err, "Failed deleting row with key '" + key + "' from '" + fk.TableName + "' table: ")
+			}
+		}
+	}
+
+	logInfo(stub, "Row is deleted", logFields{"table": tableName, "key": keyValue})
+	return nil
+}
+
+// Restores the row and all referencing rows deleted in the same transaction with it.
+// Rows referenced by the restored row should not be deleted.
+func restoreRowWithReferences(stub shim.ChaincodeStubInterface, tableName, keyValue string) error {
+	mark, err := getDeletedRowMark(stub, tableName, keyValue)
+	if err != nil {
+		return err
+	}
+	if mark.Columns == nil {
+		return newError(ErrCodeInvalidState, "Row with key '" + keyValue + "' in '" + tableName + "' table is not deleted")
+	}
+	deleteTxID := mark.Columns[DeletedRowsTableColsQty-1].Value
+
+	tbl, err := getTable(stub, tableName)
+	if err != nil {
+		return wrapError(err, "Error getting table in restoreRowWithReferences func: ")
+	}
+	row, err := getRow(stub, tableName, keyValue)
+	if err != nil {
+		return wrapError(err, "Error getting row in restoreRowWithReferences func: ")
+	}
+
+	err = checkForeignKeys(stub, tableName, tbl.ColumnDefinitions, row.Columns)
+	if err != nil {
+		return wrapError(err, "Row with key '" + keyValue + "' in '" + tableName + "' table can not be restored: ")
+	}
+
+	err = unmarkRowDeleted(stub, tableName, keyValue)
+	if err != nil {
+		return err
+	}
+	err = recordRowChange(stub, tableName, keyValue, EA_Restored, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, fk := range foreignKeys {
+		if fk.RefTableName != tableName {
+			continue
+		}
+		keys, err := getReferencingKeys(stub, fk, keyValue)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			childMark, err := getDeletedRowMark(stub, fk.TableName, key)
+			if err != nil {
+				return err
+			}
+			if childMark.Columns == nil || childMark.Columns[DeletedRowsTableColsQty-1].Value != deleteTxID {
+				continue
+			}
+			err = restoreRowWithReferences(stub, fk.TableName, key)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	logInfo(stub, "Row is restored", logFields{"table": tableName, "key": keyValue})
+	return nil
+}
+
+// Appends keys of all rows, including deleted ones, which reference the row directly or through other rows.
+// Referencing rows follow the rows they reference in the result.
+func collectReferencingRows(stub shim.ChaincodeStubInterface, tableName, keyValue string, tableNames, keyValues *[]string) error {
+	*tableNames = append(*tableNames, tableName)
+	*keyValues = append(*keyValues, keyValue)
+
+	for _, fk := range foreignKeys {
+		if fk.RefTableName != tableName {
+			continue
+		}
+		keys, err := getReferencingKeys(stub, fk, keyValue)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			var isCollected bool
+			for i := range *keyValues {
+				if (*tableNames)[i] == fk.TableName && (*keyValues)[i] == key {
+					isCollected = true
+					break
+				}
+			}
+			if isCollected {
+				continue
+			}
+			err = collectReferencingRows(stub, fk.TableName, key, tableNames, keyValues)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
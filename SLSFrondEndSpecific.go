@@ -2,34 +2,33 @@ package main
 
 import (
 	//"encoding/json"
-	"errors"
+	//"errors"
 	//"fmt"
 	//"strconv"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
 // ============================================================================================================================
 //
 // ============================================================================================================================
+func init() {
+	registerFunction(functionDefinition{Name: "getProjectsList", Mode: FM_Read, Table: LoanRequestsTableName, handler: getProjectsList,
+		Description: "Returns Loan Requests arranged by the bank of the caller"})
+}
+
 func getProjectsList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	bankid, err := getBankId(stub, []string{})
 	if err != nil {
-		return nil, errors.New("Error getting bankid in getProjectsList func: " + err.Error())
+		return nil, wrapError(err, "Error getting bankid in getProjectsList func: ")
 	}
 
 	/*
 		maxKey, err := getTableMaxKey(stub, LoanNegotiationsTableName)
 		if err != nil {
-			return nil, errors.New("Error getting maxKey in getProjectsList func: " + err.Error())
+			return nil, wrapError(err, "Error getting maxKey in getProjectsList func: ")
 		}
 	*/
 
-	err = stub.SetEvent("test string", bankid)
-
-	if err != nil {
-		return nil, errors.New("Error sending event in getProjectsList func: " + err.Error())
-	}
-
 	return filterTableByValue(stub, []string{LoanRequestsTableName, LR_ArrangerBankIDColName, string(bankid)})
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	//"errors"
+	//"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Certificate attributes of callers
+const CA_Role = "role"
+const CA_BankID = "bankid"
+const CA_UserID = "userid"
+
+// Identity of the caller of a transaction, made of certificate attributes
+type identity interface {
+	getAttribute(name string) (string, error)
+}
+
+// Identity read from attributes of the certificate of the transaction creator, missing attributes are empty
+type certIdentity struct {
+	stub shim.ChaincodeStubInterface
+}
+
+func (i certIdentity) getAttribute(name string) (string, error) {
+	attribute, _, err := cid.GetAttributeValue(i.stub, name)
+	return attribute, err
+}
+
+func getCertIdentity(stub shim.ChaincodeStubInterface) identity {
+	return certIdentity{stub}
+}
+
+// MockStub has no certificates, so tests replace it with identities of fixed attributes
+var getCallerIdentity = getCertIdentity
+
+func getCallerAttribute(stub shim.ChaincodeStubInterface, name string) (string, error) {
+	return getCallerIdentity(stub).getAttribute(name)
+}
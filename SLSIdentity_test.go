@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+// Identity of fixed certificate attributes, missing attributes are empty like in certificates without them
+type testIdentity map[string]string
+
+func (i testIdentity) getAttribute(name string) (string, error) {
+	return i[name], nil
+}
+
+// Callers of tests. Users 1, 5 and 9 of the demo data belong to banks 6, 7 and 8.
+var (
+	assignerIdentity  = testIdentity{CA_Role: FR_Assigner, CA_BankID: "", CA_UserID: "admin"}
+	bank6UserIdentity = testIdentity{CA_Role: FR_Bank, CA_BankID: "6", CA_UserID: "1"}
+	bank7UserIdentity = testIdentity{CA_Role: FR_Bank, CA_BankID: "7", CA_UserID: "5"}
+	bank8UserIdentity = testIdentity{CA_Role: FR_Bank, CA_BankID: "8", CA_UserID: "9"}
+	borrowerIdentity  = testIdentity{CA_Role: "borrower", CA_BankID: "6", CA_UserID: "borrower"}
+	anonymousIdentity = testIdentity{}
+)
+
+// Enables authentication, following transactions are called by the identity until stopActing
+func actAs(caller testIdentity) {
+	isAuthenticationEnabled = true
+	getCallerIdentity = func(stub shim.ChaincodeStubInterface) identity {
+		return caller
+	}
+}
+
+func stopActing() {
+	isAuthenticationEnabled = false
+	getCallerIdentity = getCertIdentity
+}
+
+// Maintenance functions check the role even if authentication is disabled, so tests of them are called by the assigner
+// while other checks stay disabled
+func actAsMaintainer() {
+	getCallerIdentity = func(stub shim.ChaincodeStubInterface) identity {
+		return assignerIdentity
+	}
+}
+
+type permissionTest struct {
+	caller  testIdentity
+	id      string
+	allowed bool
+}
+
+func checkPermissions(t *testing.T, stub *shimtest.MockStub, name string, check func(shim.ChaincodeStubInterface, string) (bool, error), tests []permissionTest) {
+	for _, test := range tests {
+		actAs(test.caller)
+		allowed, err := check(stub, test.id)
+		stopActing()
+		if allowed != test.allowed {
+			fmt.Println(name, test.id, "of", test.caller, "returned", allowed, "expected", test.allowed, err)
+			t.FailNow()
+		}
+		if !allowed && getErrorCode(err) != ErrCodePermissionDenied {
+			fmt.Println(name, test.id, "of", test.caller, "expected", ErrCodePermissionDenied, "error but got", err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestSLSChaincode_Permissions(t *testing.T) {
+	stub := shimtest.NewMockStub("ex02", new(SimpleChaincode))
+	checkInit(t, stub, []string{"mode=demo"})
+
+	checkPermissions(t, stub, "checkRowPermissionsByBankId", checkRowPermissionsByBankId, []permissionTest{
+		{bank6UserIdentity, "6", true},
+		{bank7UserIdentity, "6", false},
+		{assignerIdentity, "6", true},
+		{assignerIdentity, "7", true},
+		{borrowerIdentity, "6", false},
+		{anonymousIdentity, "6", false},
+		{anonymousIdentity, "", false},
+	})
+
+	// Loan Request 1 is arranged by bank 6, Loan Request 2 by bank 7
+	checkPermissions(t, stub, "checkLoanRequestRowPermissionsByBankId", checkLoanRequestRowPermissionsByBankId, []permissionTest{
+		{bank6UserIdentity, "1", true},
+		{bank6UserIdentity, "2", false},
+		{bank7UserIdentity, "2", true},
+		{bank8UserIdentity, "1", false},
+		{assignerIdentity, "2", true},
+		{borrowerIdentity, "1", false},
+	})
+
+	// Loan Negotiation 1 is of bank 6, Loan Negotiation 4 of bank 7
+	checkPermissions(t, stub, "checkLoanNegotiationRowPermissionsByBankId", checkLoanNegotiationRowPermissionsByBankId, []permissionTest{
+		{bank6UserIdentity, "1", true},
+		{bank6UserIdentity, "4", false},
+		{bank7UserIdentity, "4", true},
+		{assignerIdentity, "1", true},
+		{borrowerIdentity, "1", false},
+		{anonymousIdentity, "4", false},
+	})
+
+	// Checks pass without authentication
+	allowed, err := checkRowPermissionsByBankId(stub, "6")
+	if !allowed {
+		fmt.Println("checkRowPermissionsByBankId without authentication failed", err)
+		t.FailNow()
+	}
+}
+
+func TestSLSChaincode_CallerAttributes(t *testing.T) {
+	stub := shimtest.NewMockStub("ex02", new(SimpleChaincode))
+	checkInit(t, stub, []string{"mode=demo"})
+
+	actAs(bank6UserIdentity)
+	defer stopActing()
+	checkQuery(t, stub, "getBankId", []string{}, "6")
+	checkQuery(t, stub, "getUserId", []string{}, "1")
+	checkQuery(t, stub, "getCertAttribute", []string{CA_Role}, "Attribute 'role': bank")
+
+	// Functions of the assigner are denied to banks and borrowers
+	for _, caller := range []testIdentity{bank6UserIdentity, borrowerIdentity} {
+		actAs(caller)
+		_, err := runFunction(stub, FM_Write, "addParticipant", []string{"90", "Test Bank", "Bank"})
+		if getErrorCode(err) != ErrCodePermissionDenied {
+			fmt.Println("addParticipant of", caller, "expected", ErrCodePermissionDenied, "error but got", err)
+			t.FailNow()
+		}
+	}
+}
+
+// Maintenance functions are denied to other roles even if authentication is disabled, entity functions are not
+func TestSLSChaincode_MaintenanceRoles(t *testing.T) {
+	stub := shimtest.NewMockStub("ex02", new(SimpleChaincode))
+	checkInit(t, stub, []string{"mode=demo"})
+	defer stopActing()
+
+	for _, caller := range []testIdentity{bank6UserIdentity, borrowerIdentity, anonymousIdentity} {
+		getCallerIdentity = func(stub shim.ChaincodeStubInterface) identity {
+			return caller
+		}
+		for function, args := range map[string][]string{
+			"updateTableField":    {LoanRequestsTableName, "1", LR_StatusColName, LR_StatusClosed},
+			"deleteRow":           {LoanRequestsTableName, "1"},
+			"populateInitialData": {},
+		} {
+			_, err := mockInvoke(stub, "2", function, args)
+			checkErrorCode(t, err, ErrCodePermissionDenied, "")
+		}
+		_, err := mockQuery(stub, "countTableRows", []string{LoanRequestsTableName})
+		checkErrorCode(t, err, ErrCodePermissionDenied, "")
+		_, err = mockQuery(stub, "getLoanRequestsList", []string{})
+		if err != nil {
+			fmt.Println("getLoanRequestsList of", caller, "failed", err)
+			t.FailNow()
+		}
+	}
+
+	actAsMaintainer()
+	checkQuery(t, stub, "countTableRows", []string{LoanRequestsTableName}, "2")
+	_, err := mockInvoke(stub, "3", "deleteRow", []string{LoanRequestsTableName, "1"})
+	if err != nil {
+		fmt.Println("deleteRow of the assigner failed", err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	//"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Field numbers of protobuf messages of the v0.6 table API
+const PF_TableName = 1
+const PF_TableColumnDefinitions = 2
+const PF_ColumnDefinitionName = 1
+const PF_ColumnDefinitionType = 2
+const PF_ColumnDefinitionKey = 3
+const PF_RowColumns = 1
+const PF_ColumnString = 1
+
+//Protobuf wire types
+const PW_Varint = 0
+const PW_Fixed64 = 1
+const PW_Bytes = 2
+const PW_Fixed32 = 5
+
+// ============================================================================================================================
+// The v0.6 table API kept tables in simple keys: definitions of tables under the length of the table name followed
+// by the name, rows under the key of their table followed by the length of the key value and the value,
+// e.g. "12Participants16" for participant 6. Values are protobuf messages Table and Row of the v0.6 shim.
+// Init moves such tables to composite keys, so world state copied from a v0.6 ledger can be used by this chaincode.
+// Keys which are not rows of found tables are kept.
+// ============================================================================================================================
+
+type protoField struct {
+	Number   int
+	WireType int
+	Varint   uint64
+	Bytes    []byte
+}
+
+// Decodes fields of the protobuf message, values of length delimited fields are not decoded
+func decodeProtoFields(b []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errors.New("Malformed field tag")
+		}
+		b = b[n:]
+		f := protoField{Number: int(tag >> 3), WireType: int(tag & 7)}
+
+		switch f.WireType {
+		case PW_Varint:
+			f.Varint, n = binary.Uvarint(b)
+			if n <= 0 {
+				return nil, errors.New("Malformed value of field " + strconv.Itoa(f.Number))
+			}
+			b = b[n:]
+		case PW_Bytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || length > uint64(len(b)-n) {
+				return nil, errors.New("Malformed value of field " + strconv.Itoa(f.Number))
+			}
+			f.Bytes = b[n : n+int(length)]
+			b = b[n+int(length):]
+		case PW_Fixed64, PW_Fixed32:
+			size := 8
+			if f.WireType == PW_Fixed32 {
+				size = 4
+			}
+			if len(b) < size {
+				return nil, errors.New("Malformed value of field " + strconv.Itoa(f.Number))
+			}
+			b = b[size:]
+		default:
+			return nil, errors.New("Unknown wire type " + strconv.Itoa(f.WireType) + " of field " + strconv.Itoa(f.Number))
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func appendProtoVarint(b []byte, number int, value uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(number<<3|PW_Varint))
+	return binary.AppendUvarint(b, value)
+}
+
+func appendProtoBytes(b []byte, number int, value []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(number<<3|PW_Bytes))
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// Only string columns were created by the chaincode
+func decodeLegacyTable(b []byte) (*Table, error) {
+	fields, err := decodeProtoFields(b)
+	if err != nil {
+		return nil, err
+	}
+
+	tbl := &Table{}
+	for _, f := range fields {
+		switch f.Number {
+		case PF_TableName:
+			tbl.Name = string(f.Bytes)
+		case PF_TableColumnDefinitions:
+			cdFields, err := decodeProtoFields(f.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			cd := &ColumnDefinition{}
+			for _, cdf := range cdFields {
+				switch cdf.Number {
+				case PF_ColumnDefinitionName:
+					cd.Name = string(cdf.Bytes)
+				case PF_ColumnDefinitionType:
+					if cdf.Varint != 0 {
+						return nil, errors.New("Column of type " + strconv.FormatUint(cdf.Varint, 10) + " is not supported")
+					}
+				case PF_ColumnDefinitionKey:
+					cd.Key = cdf.Varint != 0
+				}
+			}
+			tbl.ColumnDefinitions = append(tbl.ColumnDefinitions, cd)
+		}
+	}
+	return tbl, nil
+}
+
+// Columns are of the string type, which is the default value of the type field
+func encodeLegacyTable(tbl *Table) []byte {
+	b := appendProtoBytes(nil, PF_TableName, []byte(tbl.Name))
+	for _, cd := range tbl.ColumnDefinitions {
+		cdBytes := appendProtoBytes(nil, PF_ColumnDefinitionName, []byte(cd.Name))
+		if cd.Key {
+			cdBytes = appendProtoVarint(cdBytes, PF_ColumnDefinitionKey, 1)
+		}
+		b = appendProtoBytes(b, PF_TableColumnDefinitions, cdBytes)
+	}
+	return b
+}
+
+func decodeLegacyRow(b []byte) (Row, error) {
+	var row Row
+	fields, err := decodeProtoFields(b)
+	if err != nil {
+		return row, err
+	}
+
+	for _, f := range fields {
+		if f.Number != PF_RowColumns {
+			continue
+		}
+		columnFields, err := decodeProtoFields(f.Bytes)
+		if err != nil {
+			return row, err
+		}
+		// Empty strings may be omitted
+		column := &Column{}
+		for _, cf := range columnFields {
+			if cf.Number != PF_ColumnString || cf.WireType != PW_Bytes {
+				return row, errors.New("Column value of field " + strconv.Itoa(cf.Number) + " is not supported")
+			}
+			column.Value = string(cf.Bytes)
+		}
+		row.Columns = append(row.Columns, column)
+	}
+	return row, nil
+}
+
+func encodeLegacyRow(row Row) []byte {
+	var b []byte
+	for _, c := range row.Columns {
+		b = appendProtoBytes(b, PF_RowColumns, appendProtoBytes(nil, PF_ColumnString, []byte(c.Value)))
+	}
+	return b
+}
+
+func getLegacyTableKey(tableName string) string {
+	return strconv.Itoa(len(tableName)) + tableName
+}
+
+// Lengths are not padded in the v0.6 layout
+func getLegacyRowKey(tableName, keyValue string) string {
+	return getLegacyTableKey(tableName) + strconv.Itoa(len(keyValue)) + keyValue
+}
+
+// Returns table name of the key and the rest of the key, which is empty for keys of tables
+func parseLegacyTableKey(key string) (string, string, bool) {
+	i := 0
+	for i < len(key) && key[i] >= '0' && key[i] <= '9' {
+		i++
+	}
+	length, err := strconv.Atoi(key[:i])
+	if i == 0 || err != nil || length == 0 || length > len(key)-i {
+		return "", "", false
+	}
+	return key[i : i+length], key[i+length:], true
+}
+
+// Returns key value of the rest of the row key. Key values may start with digits, so the length of the value is
+// found by the length of the rest. Lengths have no leading zeros, only the empty value has length 0.
+func parseLegacyKeyValue(rest string) (string, bool) {
+	if rest == "0" {
+		return "", true
+	}
+	for i := 1; i <= len(rest) && rest[i-1] >= '0' && rest[i-1] <= '9' && rest[0] != '0'; i++ {
+		length, err := strconv.Atoi(rest[:i])
+		if err == nil && length == len(rest)-i {
+			return rest[i:], true
+		}
+	}
+	return "", false
+}
+
+// Repository of the v0.6 table layout, rows are ordered by their keys as the v0.6 table API ordered them
+type legacyTableRepository struct {
+	stub shim.ChaincodeStubInterface
+}
+
+func getLegacyTableRepository(stub shim.ChaincodeStubInterface) Repository {
+	return legacyTableRepository{stub}
+}
+
+func (r legacyTableRepository) GetTable(tableName string) (*Table, error) {
+	b, err := getState(r.stub, getLegacyTableKey(tableName))
+	if err != nil {
+		return nil, wrapError(err, "Failed getting table '"+tableName+"': ")
+	}
+	if b == nil {
+		return nil, newError(ErrCodeNotFound, "Table '"+tableName+"' does not exist")
+	}
+	tbl, err := decodeLegacyTable(b)
+	if err != nil {
+		return nil, wrapError(err, "Failed decoding table '"+tableName+"': ")
+	}
+	return tbl, nil
+}
+
+func (r legacyTableRepository) PutTable(tbl *Table) error {
+	return putState(r.stub, getLegacyTableKey(tbl.Name), encodeLegacyTable(tbl))
+}
+
+func (r legacyTableRepository) DeleteTable(tableName string) error {
+	return delState(r.stub, getLegacyTableKey(tableName))
+}
+
+func (r legacyTableRepository) Get(tableName, keyValue string) (Row, error) {
+	var row Row
+	b, err := getState(r.stub, getLegacyRowKey(tableName, keyValue))
+	if err != nil {
+		return row, wrapError(err, "Failed getting row of '"+tableName+"' table: ")
+	}
+	if b == nil {
+		return row, nil
+	}
+	row, err = decodeLegacyRow(b)
+	if err != nil {
+		return row, wrapError(err, "Failed decoding row '"+keyValue+"' of '"+tableName+"' table: ")
+	}
+	return row, nil
+}
+
+func (r legacyTableRepository) Put(tableName string, row Row) error {
+	err := putState(r.stub, getLegacyRowKey(tableName, row.Columns[0].Value), encodeLegacyRow(row))
+	if err != nil {
+		return wrapError(err, "Failed saving row '"+row.Columns[0].Value+"' of '"+tableName+"' table: ")
+	}
+	return nil
+}
+
+func (r legacyTableRepository) Delete(tableName, keyValue string) error {
+	return delState(r.stub, getLegacyRowKey(tableName, keyValue))
+}
+
+func (r legacyTableRepository) Query(tableName string, match func(Row) bool) ([]Row, error) {
+	return queryByIterate(r, tableName, match)
+}
+
+// Keys of rows start with the key of their table, other keys with the same start are skipped
+func (r legacyTableRepository) Iterate(tableName string, visit func(Row) bool) error {
+	tableKey := getLegacyTableKey(tableName)
+	keys, values, err := getStateByRange(r.stub, tableKey, tableKey+string(utf8.MaxRune))
+	if err != nil {
+		return wrapError(err, "Failed getting rows of '"+tableName+"' table: ")
+	}
+	for _, key := range keys {
+		rowTableName, rest, ok := parseLegacyTableKey(key)
+		if !ok || rowTableName != tableName || rest == "" || values[key] == nil {
+			continue
+		}
+		keyValue, ok := parseLegacyKeyValue(rest)
+		if !ok {
+			continue
+		}
+		row, err := decodeLegacyRow(values[key])
+		if err == nil && (len(row.Columns) == 0 || row.Columns[0].Value != keyValue) {
+			err = errors.New("Key column differs from the key")
+		}
+		if err != nil {
+			return wrapError(err, "Failed decoding row '"+keyValue+"' of '"+tableName+"' table: ")
+		}
+		if !visit(row) {
+			break
+		}
+	}
+	return nil
+}
+
+// Moves tables of the v0.6 table layout to composite keys
+func migrateLegacyTables(stub shim.ChaincodeStubInterface) error {
+	keys, _, err := getStateByRange(stub, "", "")
+	if err != nil {
+		return wrapError(err, "Error getting state in migrateLegacyTables func: ")
+	}
+
+	// Values of other keys which look like keys of tables are not tables
+	legacy := getLegacyTableRepository(stub)
+	var tables []*Table
+	for _, key := range keys {
+		tableName, rest, ok := parseLegacyTableKey(key)
+		if !ok || rest != "" {
+			continue
+		}
+		tbl, err := legacy.GetTable(tableName)
+		if err != nil || tbl.Name != tableName || len(tbl.ColumnDefinitions) == 0 {
+			continue
+		}
+		tables = append(tables, tbl)
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	r := getCompositeKeyRepository(stub)
+	var rowQty int
+	for _, tbl := range tables {
+		rows, err := legacy.Query(tbl.Name, nil)
+		if err != nil {
+			return wrapError(err, "Error reading '"+tbl.Name+"' table in migrateLegacyTables func: ")
+		}
+		err = r.PutTable(tbl)
+		if err != nil {
+			return wrapError(err, "Error creating table '"+tbl.Name+"' in migrateLegacyTables func: ")
+		}
+
+		for _, row := range rows {
+			keyValue := row.Columns[0].Value
+			if len(row.Columns) != len(tbl.ColumnDefinitions) {
+				return newError(ErrCodeInvalidArgument, "Row '"+keyValue+"' of '"+tbl.Name+"' table has "+
+					strconv.Itoa(len(row.Columns))+" columns, expected "+strconv.Itoa(len(tbl.ColumnDefinitions)))
+			}
+			existing, err := r.Get(tbl.Name, keyValue)
+			if err != nil {
+				return wrapError(err, "Error moving row '"+keyValue+"' of '"+tbl.Name+"' table in migrateLegacyTables func: ")
+			}
+			if existing.Columns != nil {
+				return newError(ErrCodeConflict, "Row with key '"+keyValue+"' is already assigned in table '"+tbl.Name+"'")
+			}
+			err = r.Put(tbl.Name, row)
+			if err == nil {
+				err = legacy.Delete(tbl.Name, keyValue)
+			}
+			if err != nil {
+				return wrapError(err, "Error moving row '"+keyValue+"' of '"+tbl.Name+"' table in migrateLegacyTables func: ")
+			}
+			rowQty++
+		}
+
+		err = legacy.DeleteTable(tbl.Name)
+		if err != nil {
+			return wrapError(err, "Error deleting table '"+tbl.Name+"' in migrateLegacyTables func: ")
+		}
+	}
+
+	logInfo(stub, "Tables of the v0.6 table layout are migrated", logFields{"tables": strconv.Itoa(len(tables)),
+		"rows": strconv.Itoa(rowQty)})
+	return nil
+}
@@ -2,11 +2,12 @@ package main
 
 import (
 	//"encoding/json"
-	"errors"
+	//"errors"
 	//"fmt"
 	"strconv"
+	"time"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
 //Entity names
@@ -20,9 +21,14 @@ const LN_AmountColName = "Amount"
 const LN_NegotiationStatusColName = "NegotiationStatus"
 const LN_ParticipantBankCommentColName = "ParticipantBankComment"
 const LN_DateColName = "Date"
+const LN_ResponseDeadlineColName = "ResponseDeadline"
+
+//Negotiation statuses
+const LN_StatusInvited = "INVITED"
+const LN_StatusExpired = "EXPIRED"
 
 //Column quantity
-const LoanNegotiationsTableColsQty = 7
+const LoanNegotiationsTableColsQty = 8
 
 // ============================================================================================================================
 //
@@ -30,13 +36,80 @@ const LoanNegotiationsTableColsQty = 7
 
 func CreateLoanNegotiationTable(stub shim.ChaincodeStubInterface) error {
 	LN_ColumnNames := []string{LN_LoanNegotiationIDColName, LN_LoanRequestIDColName, LN_ParticipantBankIDColName,
-		LN_AmountColName, LN_NegotiationStatusColName, LN_ParticipantBankCommentColName, LN_DateColName}
+		LN_AmountColName, LN_NegotiationStatusColName, LN_ParticipantBankCommentColName, LN_DateColName, LN_ResponseDeadlineColName}
 	return createTable(stub, LoanNegotiationsTableName, LN_ColumnNames)
 }
 
+func init() {
+	registerFunction(functionDefinition{Name: "addLoanNegotiation", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanNegotiationsTableName, Key: AK_Generated,
+		handler: addLoanNegotiation, Description: "Adds Loan Negotiation"})
+	registerFunction(functionDefinition{Name: "updateLoanNegotiation", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanNegotiationsTableName, Key: AK_Required,
+		handler: updateLoanNegotiation, Description: "Updates Loan Negotiation, omitted columns keep current values"})
+	registerFunction(functionDefinition{Name: "patchLoanNegotiation", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanNegotiationsTableName, Key: AK_Required,
+		Result: RT_Text, Args: []argumentDefinition{patchETagArg}, handler: patchLoanNegotiation, Description: "Changes given columns of Loan Negotiation and returns its new ETag"})
+	registerFunction(functionDefinition{Name: "updateLoanNegotiationStatus", Mode: FM_Write, ArgsFormat: AF_Object,
+		Args: []argumentDefinition{{Name: LN_LoanNegotiationIDColName}, {Name: LN_NegotiationStatusColName}},
+		handler: updateLoanNegotiationStatus, Description: "Sets status of Loan Negotiation"})
+	registerFunction(functionDefinition{Name: "updateParticipantBankComment", Mode: FM_Write, ArgsFormat: AF_Object,
+		Args: []argumentDefinition{{Name: LN_LoanNegotiationIDColName}, {Name: LN_ParticipantBankCommentColName}},
+		handler: updateParticipantBankComment, Description: "Sets comment of the participant bank on Loan Negotiation"})
+	registerFunction(functionDefinition{Name: "getLoanNegotiationsQuantity", Mode: FM_Read, Result: RT_Text, Table: LoanNegotiationsTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getLoanNegotiationsQuantity, Description: "Returns number of Loan Negotiations"})
+	registerFunction(functionDefinition{Name: "getLoanNegotiationsList", Mode: FM_Read, Table: LoanNegotiationsTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getLoanNegotiationsList, Description: "Returns all Loan Negotiations"})
+	registerFunction(functionDefinition{Name: "getLoanNegotiationByKey", Mode: FM_Read, Table: LoanNegotiationsTableName,
+		Args: []argumentDefinition{{Name: "key"}}, handler: getLoanNegotiationByKey, Description: "Returns Loan Negotiation with the key"})
+	registerFunction(functionDefinition{Name: "getLoanNegotiationsMaxKey", Mode: FM_Read, Result: RT_Text, Table: LoanNegotiationsTableName,
+		handler: getLoanNegotiationsMaxKey, Description: "Returns the greatest key of Loan Negotiations"})
+
+	// Amounts and comments are seen by the arranger bank and the participant bank only
+	privateColumns[LoanNegotiationsTableName] = privateColumnsDefinition{
+		Columns: []string{LN_AmountColName, LN_ParticipantBankCommentColName},
+		getLoanRequestID: func(stub shim.ChaincodeStubInterface, tbl *Table, row Row) (string, error) {
+			return getRowColumnValue(tbl, row, LN_LoanRequestIDColName), nil
+		},
+		getParticipantBankIDs: func(stub shim.ChaincodeStubInterface, tbl *Table, row Row, loanRequestID string) ([]string, error) {
+			return []string{getRowColumnValue(tbl, row, LN_ParticipantBankIDColName)}, nil
+		},
+	}
+
+	columnTypes[LoanNegotiationsTableName] = map[string]string{LN_ResponseDeadlineColName: CT_Time}
+
+	// Invited banks can not answer after the response deadline, the deadline can not be moved once it passed and
+	// negotiations can not be added with a passed deadline
+	lateChangeChecks[LoanNegotiationsTableName] = func(stub shim.ChaincodeStubInterface, now time.Time, tbl *Table, row Row,
+		previousValues map[string]string) error {
+		deadline := getRowColumnValue(tbl, row, LN_ResponseDeadlineColName)
+		if previousValues == nil && isDeadlinePassed(stub, now, deadline) {
+			return newFieldError(ErrCodeInvalidState, LN_ResponseDeadlineColName, "Response deadline '"+deadline+"' has passed")
+		}
+		if value, ok := previousValues[LN_ResponseDeadlineColName]; ok {
+			deadline = value
+			if isDeadlinePassed(stub, now, deadline) {
+				return newFieldError(ErrCodeInvalidState, LN_ResponseDeadlineColName, "Response deadline '"+deadline+"' has passed")
+			}
+		}
+		status, ok := previousValues[LN_NegotiationStatusColName]
+		if ok && status == LN_StatusInvited && isDeadlinePassed(stub, now, deadline) {
+			return newFieldError(ErrCodeInvalidState, LN_NegotiationStatusColName, "Response deadline '"+deadline+"' has passed")
+		}
+		return nil
+	}
+}
+
 func addLoanNegotiation(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getAddArgs(stub, LoanNegotiationsTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Response deadline is the last column and can be omitted by clients which do not know it yet
+	if len(args) == LoanNegotiationsTableColsQty-2 {
+		args = append(args, "")
+	}
 	if len(args) != LoanNegotiationsTableColsQty-1 {
-		return nil, errors.New("Incorrect number of arguments. Expecting " + strconv.Itoa(LoanNegotiationsTableColsQty-1))
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting " + strconv.Itoa(LoanNegotiationsTableColsQty-2) +
+			" or " + strconv.Itoa(LoanNegotiationsTableColsQty-1))
 	}
 
 	loanRequestID := args[0] // 0 is a hardcode position of LN_LoanRequestIDColName argument. Consider avoid hardcoding in the future.
@@ -45,31 +118,43 @@ func addLoanNegotiation(stub shim.ChaincodeStubInterface, args []string) ([]byte
 	//Check if related Loan Invitation exists
 	arrangerBankId, err := getTableColValueByKey(stub, LoanRequestsTableName, loanRequestID, LR_ArrangerBankIDColName)
 	if err != nil {
-		return nil, errors.New("Error getting related Loan Invitation in addLoanNegotiation func: " + err.Error())
+		return nil, wrapError(err, "Error getting related Loan Invitation in addLoanNegotiation func: ")
 	}
 
 	///////////////////////////Security check////////////////////////////
 	check, err := checkRowPermissionsByBankId(stub, arrangerBankId)
 	if !check {
-		return nil, errors.New("Failed checking security in addLoanNegotiation func or returned false: " + err.Error())
+		return nil, wrapError(err, "Failed checking security in addLoanNegotiation func or returned false: ")
 	}
 	////////////////////////////////////////////////////////////////////
+
+	err = checkSealedBidAmount(stub, loanRequestID, "", args[2]) // 2 is a hardcode position of LN_AmountColName argument
+	if err != nil {
+		return nil, wrapError(err, "Error in addLoanNegotiation func: ")
+	}
 	err = addRow(stub, LoanNegotiationsTableName, args, false)
 	if err != nil {
-		return nil, errors.New("Error in addLoanNegotiation func: " + err.Error())
+		return nil, wrapError(err, "Error in addLoanNegotiation func: ")
 	}
 
 	err = updateLoanRequestStatus(stub, loanRequestID)
 	if err != nil {
-		return nil, errors.New("Error in addLoanNegotiation func: " + err.Error())
+		return nil, wrapError(err, "Error in addLoanNegotiation func: ")
 	}
 
 	return nil, err
 }
 
 func updateLoanNegotiation(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	if len(args) != LoanNegotiationsTableColsQty {
-		return nil, errors.New("Incorrect number of arguments in updateLoanRequest func. Expecting " + strconv.Itoa(LoanNegotiationsTableColsQty))
+	args, err := getUpdateArgs(stub, LoanNegotiationsTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Response deadline is the last column and is kept unchanged if it is omitted
+	if len(args) != LoanNegotiationsTableColsQty && len(args) != LoanNegotiationsTableColsQty-1 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in updateLoanNegotiation func. Expecting " + strconv.Itoa(LoanNegotiationsTableColsQty-1) +
+			" or " + strconv.Itoa(LoanNegotiationsTableColsQty))
 	}
 
 	loanNegotiationID := args[0]
@@ -77,45 +162,138 @@ func updateLoanNegotiation(stub shim.ChaincodeStubInterface, args []string) ([]b
 	///////////////////////////Security check////////////////////////////
 	check, err := checkLoanNegotiationRowPermissionsByBankId(stub, loanNegotiationID)
 	if !check {
-		return nil, errors.New("Failed checking security in updateLoanNegotiation or returned false: " + err.Error())
+		return nil, wrapError(err, "Failed checking security in updateLoanNegotiation or returned false: ")
 	}
 	////////////////////////////////////////////////////////////////////
 
-	tbl, err := stub.GetTable(LoanNegotiationsTableName)
+	changes, err := getUpdateChanges(stub, LoanNegotiationsTableName, args)
+	if err != nil {
+		return nil, wrapError(err, "An error occured while running updateLoanNegotiation: ")
+	}
+	err = checkLoanNegotiationAmountChange(stub, loanNegotiationID, changes)
 	if err != nil {
-		return nil, errors.New("An error occured while running updateLoanNegotiation: " + err.Error())
+		return nil, wrapError(err, "Error in updateLoanNegotiation func: ")
 	}
 
-	var loanRequestID string
-	for i, cd := range tbl.ColumnDefinitions {
-		_, err := updateTableField(stub, []string{LoanNegotiationsTableName, loanNegotiationID, cd.Name, args[i]})
-		if err != nil {
-			return nil, errors.New("Failed updating field '" + cd.Name + "' in updateLoanNegotiation func: " + err.Error())
-		}
-		if cd.Name == LN_LoanRequestIDColName {
-			loanRequestID = args[i]
-		}
+	oldLoanRequestID, err := getTableColValueByKey(stub, LoanNegotiationsTableName, loanNegotiationID, LN_LoanRequestIDColName)
+	if err != nil {
+		return nil, wrapError(err, "Error in updateLoanNegotiation func: ")
 	}
 
-	err = updateLoanRequestStatus(stub, loanRequestID)
+	///////////////////////////Security check////////////////////////////
+	check, err = checkLoanNegotiationMove(stub, oldLoanRequestID, changes)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in updateLoanNegotiation or returned false: ")
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	_, err = patchRow(stub, LoanNegotiationsTableName, loanNegotiationID, changes, "")
+	if err != nil {
+		return nil, wrapError(err, "Failed updating row in updateLoanNegotiation func: ")
+	}
+
+	err = updateMovedLoanRequestStatuses(stub, oldLoanRequestID, changes)
 	if err != nil {
-		return nil, errors.New("Error in updateLoanNegotiation func: " + err.Error())
+		return nil, wrapError(err, "Error in updateLoanNegotiation func: ")
 	}
 
 	return nil, nil
 }
 
+// Changes only given columns: JSON object with the key, changed columns and optional ETag. Returns the new ETag.
+func patchLoanNegotiation(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	loanNegotiationID, changes, eTag, err := parsePatchArgs(stub, LoanNegotiationsTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanNegotiationRowPermissionsByBankId(stub, loanNegotiationID)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in patchLoanNegotiation or returned false: ")
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	err = checkLoanNegotiationAmountChange(stub, loanNegotiationID, changes)
+	if err != nil {
+		return nil, wrapError(err, "Error in patchLoanNegotiation func: ")
+	}
+
+	oldLoanRequestID, err := getTableColValueByKey(stub, LoanNegotiationsTableName, loanNegotiationID, LN_LoanRequestIDColName)
+	if err != nil {
+		return nil, wrapError(err, "Error in patchLoanNegotiation func: ")
+	}
+
+	///////////////////////////Security check////////////////////////////
+	check, err = checkLoanNegotiationMove(stub, oldLoanRequestID, changes)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in patchLoanNegotiation or returned false: ")
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	newETag, err := patchRow(stub, LoanNegotiationsTableName, loanNegotiationID, changes, eTag)
+	if err != nil {
+		return nil, err
+	}
+
+	err = updateMovedLoanRequestStatuses(stub, oldLoanRequestID, changes)
+	if err != nil {
+		return nil, wrapError(err, "Error in patchLoanNegotiation func: ")
+	}
+
+	return newETag, nil
+}
+
+// Statuses of both Loan Requests change when the negotiation is moved to another one
+func updateMovedLoanRequestStatuses(stub shim.ChaincodeStubInterface, oldLoanRequestID string, changes map[string]string) error {
+	loanRequestIDs := []string{oldLoanRequestID}
+	if newLoanRequestID, ok := changes[LN_LoanRequestIDColName]; ok && newLoanRequestID != oldLoanRequestID {
+		loanRequestIDs = append(loanRequestIDs, newLoanRequestID)
+	}
+	for _, loanRequestID := range loanRequestIDs {
+		err := updateLoanRequestStatus(stub, loanRequestID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rows can not be moved to other banks or to Loan Requests of other arrangers
+func checkLoanNegotiationMove(stub shim.ChaincodeStubInterface, oldLoanRequestID string, changes map[string]string) (bool, error) {
+	if bankId, ok := changes[LN_ParticipantBankIDColName]; ok {
+		check, err := checkRowPermissionsByBankId(stub, bankId)
+		if !check {
+			return false, wrapError(err, "Failed checking Participant Bank ID in checkLoanNegotiationMove func or returned false: ")
+		}
+	}
+	if newLoanRequestID, ok := changes[LN_LoanRequestIDColName]; ok && newLoanRequestID != oldLoanRequestID {
+		for _, loanRequestID := range []string{oldLoanRequestID, newLoanRequestID} {
+			check, err := checkLoanRequestRowPermissionsByBankId(stub, loanRequestID)
+			if !check {
+				return false, wrapError(err, "Failed checking Loan Request ID in checkLoanNegotiationMove func or returned false: ")
+			}
+		}
+	}
+	return true, nil
+}
+
 func getLoanNegotiationsQuantity(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return countTableRows(stub, []string{LoanNegotiationsTableName})
+	return countTableRows(stub, passIncludeDeletedOption([]string{LoanNegotiationsTableName}, args))
 }
 
 func getLoanNegotiationsList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return filterTableByValue(stub, []string{LoanNegotiationsTableName})
+	return filterTableByValue(stub, passIncludeDeletedOption([]string{LoanNegotiationsTableName}, args))
 }
 
 func updateLoanNegotiationStatus(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getNamedArgValues(stub, args, []string{LN_LoanNegotiationIDColName, LN_NegotiationStatusColName})
+	if err != nil {
+		return nil, err
+	}
+
 	if len(args) != 2 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 2")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 2")
 	}
 
 	loanNegotiationID, newStatus := args[0], args[1]
@@ -123,16 +301,40 @@ func updateLoanNegotiationStatus(stub shim.ChaincodeStubInterface, args []string
 	///////////////////////////Security check////////////////////////////
 	check, err := checkLoanNegotiationRowPermissionsByBankId(stub, loanNegotiationID)
 	if !check {
-		return nil, errors.New("Failed checking security in updateLoanNegotiationStatus or returned false: " + err.Error())
+		return nil, wrapError(err, "Failed checking security in updateLoanNegotiationStatus or returned false: ")
 	}
 	/////////////////////////////////////////////////////////////////////
 
-	return updateTableField(stub, []string{LoanNegotiationsTableName, loanNegotiationID, LN_NegotiationStatusColName, newStatus})
+	err = checkLateChange(stub, LoanNegotiationsTableName, loanNegotiationID, LN_NegotiationStatusColName, newStatus)
+	if err != nil {
+		return nil, wrapError(err, "Error in updateLoanNegotiationStatus func: ")
+	}
+	_, err = updateTableField(stub, []string{LoanNegotiationsTableName, loanNegotiationID, LN_NegotiationStatusColName, newStatus})
+	if err != nil {
+		return nil, wrapError(err, "Error in updateLoanNegotiationStatus func: ")
+	}
+
+	// Status of the Loan Request is calculated from statuses of its negotiations
+	loanRequestID, err := getTableColValueByKey(stub, LoanNegotiationsTableName, loanNegotiationID, LN_LoanRequestIDColName)
+	if err != nil {
+		return nil, wrapError(err, "Error in updateLoanNegotiationStatus func: ")
+	}
+	err = updateLoanRequestStatus(stub, loanRequestID)
+	if err != nil {
+		return nil, wrapError(err, "Error in updateLoanNegotiationStatus func: ")
+	}
+
+	return nil, nil
 }
 
 func updateParticipantBankComment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getNamedArgValues(stub, args, []string{LN_LoanNegotiationIDColName, LN_ParticipantBankCommentColName})
+	if err != nil {
+		return nil, err
+	}
+
 	if len(args) != 2 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 2")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 2")
 	}
 
 	loanNegotiationID, newComment := args[0], args[1]
@@ -140,7 +342,7 @@ func updateParticipantBankComment(stub shim.ChaincodeStubInterface, args []strin
 	///////////////////////////Security check////////////////////////////
 	check, err := checkLoanNegotiationRowPermissionsByBankId(stub, loanNegotiationID)
 	if !check {
-		return nil, errors.New("Failed checking security in updateParticipantBankComment or returned false: " + err.Error())
+		return nil, wrapError(err, "Failed checking security in updateParticipantBankComment or returned false: ")
 	}
 	/////////////////////////////////////////////////////////////////////
 
@@ -149,7 +351,7 @@ func updateParticipantBankComment(stub shim.ChaincodeStubInterface, args []strin
 
 func getLoanNegotiationByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	if len(args) != 1 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 1")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 1")
 	}
 	keyValue := args[0]
 	return filterTableByKey(stub, LoanNegotiationsTableName, keyValue)
@@ -158,7 +360,7 @@ func getLoanNegotiationByKey(stub shim.ChaincodeStubInterface, args []string) ([
 func getLoanNegotiationsMaxKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	maxKey, err := getTableMaxKey(stub, LoanNegotiationsTableName)
 	if err != nil {
-		return nil, errors.New("Error in getLoanNegotiationsMaxKey func: " + err.Error())
+		return nil, wrapError(err, "Error in getLoanNegotiationsMaxKey func: ")
 	}
 	return maxKey, nil
 }
@@ -166,12 +368,12 @@ func getLoanNegotiationsMaxKey(stub shim.ChaincodeStubInterface, args []string)
 func checkLoanNegotiationRowPermissionsByBankId(stub shim.ChaincodeStubInterface, loanNegotiationID string) (bool, error) {
 	participantBankId, err := getTableColValueByKey(stub, LoanNegotiationsTableName, loanNegotiationID, LN_ParticipantBankIDColName)
 	if err != nil {
-		return false, errors.New("Error getting Participant Bank ID in checkLoanNegotiationRowPermissionsByBankId func: " + err.Error())
+		return false, wrapError(err, "Error getting Participant Bank ID in checkLoanNegotiationRowPermissionsByBankId func: ")
 	}
 
 	check, err := checkRowPermissionsByBankId(stub, participantBankId)
 	if !check {
-		return false, errors.New("Failed checking security in checkLoanNegotiationRowPermissionsByBankId func or returned false: " + err.Error())
+		return false, wrapError(err, "Failed checking security in checkLoanNegotiationRowPermissionsByBankId func or returned false: ")
 	}
 
 	return true, nil
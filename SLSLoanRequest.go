@@ -2,11 +2,11 @@ package main
 
 import (
 	//"encoding/json"
-	"errors"
-	"fmt"
+	//"errors"
+	//"fmt"
 	"strconv"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
 //Entity names
@@ -31,8 +31,16 @@ const LR_LoanTermColName = "LoanTerm"
 const LR_AssetsColName = "Assets"
 const LR_ConvenantsColName = "Convenants"
 const LR_InterestRateColName = "InterestRate"
+const LR_CurrencyColName = "Currency"
 
-const LoanRequestsTableColsQty = 18
+const LoanRequestsTableColsQty = 19
+
+//Currency of Loan Requests added before the column existed or without currency argument
+const LR_CurrencyDefault = "USD"
+
+//Statuses set by updateLoanRequest which finish the deal
+const LR_StatusClosed = "Closed"
+const LR_StatusRepaid = "Repaid"
 
 // ============================================================================================================================
 //
@@ -43,19 +51,46 @@ func CreateLoanRequestTable(stub shim.ChaincodeStubInterface) error {
 		LR_LoanSharesAmountColName, LR_ProjectRevenueColName, LR_ProjectNameColName, LR_ProjectInformationColName,
 		LR_CompanyColName, LR_WebsiteColName, LR_ContactPersonNameColName,
 		LR_ContactPersonSurnameColName, LR_RequestDateColName, LR_StatusColName, LR_MarketAndIndustryColName,
-		LR_LoanTermColName, LR_AssetsColName, LR_ConvenantsColName, LR_InterestRateColName}
+		LR_LoanTermColName, LR_AssetsColName, LR_ConvenantsColName, LR_InterestRateColName, LR_CurrencyColName}
 	return createTable(stub, LoanRequestsTableName, LR_ColumnNames)
 }
 
+func init() {
+	registerFunction(functionDefinition{Name: "addLoanRequest", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanRequestsTableName, Key: AK_Generated,
+		handler: addLoanRequest, Description: "Adds Loan Request"})
+	registerFunction(functionDefinition{Name: "updateLoanRequest", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanRequestsTableName, Key: AK_Required,
+		handler: updateLoanRequest, Description: "Updates Loan Request, omitted columns keep current values"})
+	registerFunction(functionDefinition{Name: "patchLoanRequest", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanRequestsTableName, Key: AK_Required,
+		Result: RT_Text, Args: []argumentDefinition{patchETagArg}, handler: patchLoanRequest, Description: "Changes given columns of Loan Request and returns its new ETag"})
+	registerFunction(functionDefinition{Name: "getLoanRequestsQuantity", Mode: FM_Read, Result: RT_Text, Table: LoanRequestsTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getLoanRequestsQuantity, Description: "Returns number of Loan Requests"})
+	registerFunction(functionDefinition{Name: "getLoanRequestsList", Mode: FM_Read, Table: LoanRequestsTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getLoanRequestsList, Description: "Returns all Loan Requests"})
+	registerFunction(functionDefinition{Name: "getLoanRequestByKey", Mode: FM_Read, Table: LoanRequestsTableName,
+		Args: []argumentDefinition{{Name: "key"}}, handler: getLoanRequestByKey, Description: "Returns Loan Request with the key"})
+	registerFunction(functionDefinition{Name: "getLoanRequestsMaxKey", Mode: FM_Read, Result: RT_Text, Table: LoanRequestsTableName,
+		handler: getLoanRequestsMaxKey, Description: "Returns the greatest key of Loan Requests"})
+}
+
 func addLoanRequest(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getAddArgs(stub, LoanRequestsTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Currency is the last column and can be omitted by clients which do not know it yet
+	if len(args) == LoanRequestsTableColsQty-2 {
+		args = append(args, LR_CurrencyDefault)
+	}
 	if len(args) != LoanRequestsTableColsQty-1 {
-		return nil, errors.New("Incorrect number of arguments in addLoanRequest func. Expecting " + strconv.Itoa(LoanRequestsTableColsQty-1))
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in addLoanRequest func. Expecting " + strconv.Itoa(LoanRequestsTableColsQty-2) +
+			" or " + strconv.Itoa(LoanRequestsTableColsQty-1))
 	}
 
 	///////////////////////////Security check////////////////////////////
 	check, err := checkRowPermissionsByBankId(stub, args[1])
 	if !check {
-		return nil, errors.New("Failed checking security in addLoanRequest func or returned false: " + err.Error())
+		return nil, wrapError(err, "Failed checking security in addLoanRequest func or returned false: ")
 	}
 	/////////////////////////////////////////////////////////////////
 
@@ -63,8 +98,15 @@ func addLoanRequest(stub shim.ChaincodeStubInterface, args []string) ([]byte, er
 }
 
 func updateLoanRequest(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	if len(args) != LoanRequestsTableColsQty {
-		return nil, errors.New("Incorrect number of arguments in updateLoanRequest func. Expecting " + strconv.Itoa(LoanRequestsTableColsQty))
+	args, err := getUpdateArgs(stub, LoanRequestsTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Currency is the last column and is kept unchanged if it is omitted
+	if len(args) != LoanRequestsTableColsQty && len(args) != LoanRequestsTableColsQty-1 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in updateLoanRequest func. Expecting " + strconv.Itoa(LoanRequestsTableColsQty-1) +
+			" or " + strconv.Itoa(LoanRequestsTableColsQty))
 	}
 
 	loanRequestID := args[0]
@@ -72,36 +114,66 @@ func updateLoanRequest(stub shim.ChaincodeStubInterface, args []string) ([]byte,
 	///////////////////////////Security check////////////////////////////
 	check, err := checkLoanRequestRowPermissionsByBankId(stub, loanRequestID)
 	if !check {
-		return nil, errors.New("Failed checking security in updateLoanRequest or returned false: " + err.Error())
+		return nil, wrapError(err, "Failed checking security in updateLoanRequest or returned false: ")
 	}
 	/////////////////////////////////////////////////////////////////////
 
-	tbl, err := stub.GetTable(LoanRequestsTableName)
+	changes, err := getUpdateChanges(stub, LoanRequestsTableName, args)
 	if err != nil {
-		return nil, errors.New("An error occured while running updateLoanRequest: " + err.Error())
+		return nil, wrapError(err, "An error occured while running updateLoanRequest: ")
 	}
 
-	for i, cd := range tbl.ColumnDefinitions {
-		_, err := updateTableField(stub, []string{LoanRequestsTableName, loanRequestID, cd.Name, args[i]})
-		if err != nil {
-			return nil, errors.New("Failed updating field '" + cd.Name + "' in updateLoanRequest func: " + err.Error())
-		}
+	///////////////////////////Security check////////////////////////////
+	// Rows can not be moved to other banks
+	check, err = checkRowPermissionsByBankId(stub, changes[LR_ArrangerBankIDColName])
+	if !check {
+		return nil, wrapError(err, "Failed checking security in updateLoanRequest or returned false: ")
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	_, err = patchRow(stub, LoanRequestsTableName, loanRequestID, changes, "")
+	if err != nil {
+		return nil, wrapError(err, "Failed updating row in updateLoanRequest func: ")
 	}
 
 	return nil, nil
 }
 
+// Changes only given columns: JSON object with the key, changed columns and optional ETag. Returns the new ETag.
+func patchLoanRequest(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	loanRequestID, changes, eTag, err := parsePatchArgs(stub, LoanRequestsTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanRequestRowPermissionsByBankId(stub, loanRequestID)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in patchLoanRequest or returned false: ")
+	}
+	// Rows can not be moved to other banks
+	if bankId, ok := changes[LR_ArrangerBankIDColName]; ok {
+		check, err = checkRowPermissionsByBankId(stub, bankId)
+		if !check {
+			return nil, wrapError(err, "Failed checking security in patchLoanRequest or returned false: ")
+		}
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	return patchRow(stub, LoanRequestsTableName, loanRequestID, changes, eTag)
+}
+
 func getLoanRequestsQuantity(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return countTableRows(stub, []string{LoanRequestsTableName})
+	return countTableRows(stub, passIncludeDeletedOption([]string{LoanRequestsTableName}, args))
 }
 
 func getLoanRequestsList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return filterTableByValue(stub, []string{LoanRequestsTableName})
+	return filterTableByValue(stub, passIncludeDeletedOption([]string{LoanRequestsTableName}, args))
 }
 
 func getLoanRequestByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	if len(args) != 1 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 1")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 1")
 	}
 	keyValue := args[0]
 	return filterTableByKey(stub, LoanRequestsTableName, keyValue)
@@ -110,7 +182,7 @@ func getLoanRequestByKey(stub shim.ChaincodeStubInterface, args []string) ([]byt
 func getLoanRequestsMaxKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	maxKey, err := getTableMaxKey(stub, LoanRequestsTableName)
 	if err != nil {
-		return nil, errors.New("Error in getLoanRequestsMaxKey func: " + err.Error())
+		return nil, wrapError(err, "Error in getLoanRequestsMaxKey func: ")
 	}
 	return maxKey, nil
 }
@@ -118,22 +190,59 @@ func getLoanRequestsMaxKey(stub shim.ChaincodeStubInterface, args []string) ([]b
 func checkLoanRequestRowPermissionsByBankId(stub shim.ChaincodeStubInterface, loanRequestID string) (bool, error) {
 	arrangerBankId, err := getTableColValueByKey(stub, LoanRequestsTableName, loanRequestID, LR_ArrangerBankIDColName)
 	if err != nil {
-		return false, errors.New("Error getting Arranger Bank ID in checkLoanRequestRowPermissionsByBankId func: " + err.Error())
+		return false, wrapError(err, "Error getting Arranger Bank ID in checkLoanRequestRowPermissionsByBankId func: ")
 	}
 
 	check, err := checkRowPermissionsByBankId(stub, arrangerBankId)
 	if !check {
-		return false, errors.New("Failed checking security in checkLoanRequestRowPermissionsByBankId func or returned false: " + err.Error())
+		return false, wrapError(err, "Failed checking security in checkLoanRequestRowPermissionsByBankId func or returned false: ")
 	}
 
 	return true, nil
 }
 
 func updateLoanRequestStatus(stub shim.ChaincodeStubInterface, loanRequestID string) error {
+	newLoanRequesStatus, count, err := getLoanRequestStatusByNegotiations(getRepository(stub), loanRequestID)
+	if err != nil {
+		return wrapError(err, "Error in updateLoanRequestStatus func: ")
+	}
+
+	logDebug(stub, "Loan Request status is calculated from negotiation statuses",
+		logFields{"key": loanRequestID, LR_StatusColName: newLoanRequesStatus, "count": strconv.Itoa(count)})
 
-	loanNegStatuses, err := getTableColValuesInSlice(stub, []string{LoanNegotiationsTableName, LN_NegotiationStatusColName, LN_LoanRequestIDColName, loanRequestID})
+	_, err = updateTableField(stub, []string{LoanRequestsTableName, loanRequestID, LR_StatusColName, newLoanRequesStatus})
 	if err != nil {
-		return errors.New("Error in updateLoanRequestStatus func: " + err.Error())
+		return wrapError(err, "Error in updateLoanRequestStatus func: ")
+	}
+
+	return nil
+}
+
+// Returns the Loan Request status derived from statuses of its Loan Negotiations which are not deleted and their number.
+// The rule reads the repository only, so it runs without the shim.
+func getLoanRequestStatusByNegotiations(r Repository, loanRequestID string) (string, int, error) {
+	tbl, err := r.GetTable(LoanNegotiationsTableName)
+	if err != nil {
+		return "", 0, err
+	}
+	loanRequestIDColumn := getColumnIndex(tbl, LN_LoanRequestIDColName)
+	statusColumn := getColumnIndex(tbl, LN_NegotiationStatusColName)
+	rows, err := r.Query(LoanNegotiationsTableName, func(row Row) bool {
+		return row.Columns[loanRequestIDColumn].Value == loanRequestID
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	var loanNegStatuses []string
+	for _, row := range rows {
+		mark, err := r.Get(DeletedRowsTableName, getDeletedRowID(LoanNegotiationsTableName, row.Columns[0].Value))
+		if err != nil {
+			return "", 0, err
+		}
+		if mark.Columns == nil {
+			loanNegStatuses = append(loanNegStatuses, row.Columns[statusColumn].Value)
+		}
 	}
 
 	var invited, interested, notInterested int
@@ -141,14 +250,11 @@ func updateLoanRequestStatus(stub shim.ChaincodeStubInterface, loanRequestID str
 	interested = 0
 	notInterested = 0
 
-	for i, lnStatus := range loanNegStatuses {
-		fmt.Println()
-		fmt.Println("lnStatus[" + strconv.Itoa(i) + "]: " + lnStatus)
-		fmt.Println()
+	for _, lnStatus := range loanNegStatuses {
 		switch lnStatus {
 		case "INTERESTED":
 			interested = 1
-		case "DECLINED":
+		case "DECLINED", LN_StatusExpired:
 			notInterested = 1
 		default:
 			invited = 1
@@ -178,16 +284,5 @@ func updateLoanRequestStatus(stub shim.ChaincodeStubInterface, loanRequestID str
 		newLoanRequesStatus = "Negotiation Started"
 	}
 
-	fmt.Println()
-	fmt.Println()
-	fmt.Println(" newLoanRequesStatus: " + newLoanRequesStatus)
-	fmt.Println()
-	fmt.Println()
-
-	_, err = updateTableField(stub, []string{LoanRequestsTableName, loanRequestID, LR_StatusColName, newLoanRequesStatus})
-	if err != nil {
-		return errors.New("Error in updateLoanRequestStatus func: " + err.Error())
-	}
-
-	return nil
+	return newLoanRequesStatus, len(loanNegStatuses), nil
 }
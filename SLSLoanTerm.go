@@ -1,25 +1,24 @@
+// Code generated by entitygen from api/entities.json. DO NOT EDIT.
+
 package main
 
 import (
-	//"encoding/json"
-	"errors"
-	//"fmt"
 	"strconv"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
-//Entity names
+// Entity names
 const LoanTermTableName = "LoanTerms"
 
-//Column names
+// Column names
 const LT_LoanTermIDColName = "LoanTermID"
 const LT_LoanRequestIDColName = "LoanRequestID"
 const LT_ParagraphNumberColName = "ParagraphNumber"
 const LT_LoanTermTextColName = "LoanTermText"
 const LT_LoanTermStatusColName = "LoanTermStatus"
 
-//Column quantity
+// Column quantity
 const LoanTermTableColsQty = 5
 
 // ============================================================================================================================
@@ -27,18 +26,36 @@ const LoanTermTableColsQty = 5
 // ============================================================================================================================
 
 func CreateLoanTermTable(stub shim.ChaincodeStubInterface) error {
-	LT_ColumnNames := []string{LT_LoanTermIDColName, LT_LoanRequestIDColName, LT_ParagraphNumberColName,
-		LT_LoanTermTextColName, LT_LoanTermStatusColName}
-	return createTable(stub, LoanTermTableName, LT_ColumnNames)
+	columnNames := []string{LT_LoanTermIDColName, LT_LoanRequestIDColName, LT_ParagraphNumberColName, LT_LoanTermTextColName, LT_LoanTermStatusColName}
+	return createTable(stub, LoanTermTableName, columnNames)
 }
 
-func addLoanTerm(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+func init() {
+	registerFunction(functionDefinition{Name: "addLoanTerm", Mode: FM_Write, Role: FR_Assigner, ArgsFormat: AF_Object, Table: LoanTermTableName, Key: AK_Optional,
+		handler: addLoanTerm, Description: "Adds Loan Term"})
+	registerFunction(functionDefinition{Name: "updateLoanTerm", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanTermTableName, Key: AK_Required,
+		handler: updateLoanTerm, Description: "Updates Loan Term, omitted columns keep current values"})
+	registerFunction(functionDefinition{Name: "patchLoanTerm", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanTermTableName, Key: AK_Required,
+		Result: RT_Text, Args: []argumentDefinition{patchETagArg}, handler: patchLoanTerm, Description: "Changes given columns of Loan Term and returns its new ETag"})
+	registerFunction(functionDefinition{Name: "getLoanTermQuantity", Mode: FM_Read, Result: RT_Text, Table: LoanTermTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getLoanTermQuantity, Description: "Returns number of Loan Terms"})
+	registerFunction(functionDefinition{Name: "getLoanTermList", Mode: FM_Read, Table: LoanTermTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getLoanTermList, Description: "Returns all Loan Terms"})
+	registerFunction(functionDefinition{Name: "getLoanTermByKey", Mode: FM_Read, Table: LoanTermTableName,
+		Args: []argumentDefinition{{Name: "key"}}, handler: getLoanTermByKey, Description: "Returns Loan Term with the key"})
+	registerFunction(functionDefinition{Name: "getLoanTermMaxKey", Mode: FM_Read, Result: RT_Text, Table: LoanTermTableName,
+		handler: getLoanTermMaxKey, Description: "Returns the greatest key of Loan Terms"})
+
+	foreignKeys = append(foreignKeys,
+		foreignKey{LoanTermTableName, LT_LoanRequestIDColName, LoanRequestsTableName, FK_OnDeleteCascade},
+	)
+	columnTypes[LoanTermTableName] = map[string]string{LT_ParagraphNumberColName: CT_Int}
+}
 
-	attrName := "role"
-	attrValue := "assigner"
-	checkPermissionsAssigner, errA := checkAttribute(stub, attrName, attrValue)
-	if !checkPermissionsAssigner {
-		return nil, errors.New("Error checking permission in addLoanTerm: " + errA.Error())
+func addLoanTerm(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getAddArgs(stub, LoanTermTableName, args)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(args) == LoanTermTableColsQty {
@@ -48,22 +65,22 @@ func addLoanTerm(stub shim.ChaincodeStubInterface, args []string) ([]byte, error
 		return nil, addRow(stub, LoanTermTableName, args, false)
 	}
 
-	return nil, errors.New("Incorrect number of arguments. " +
-		"Provided " + strconv.Itoa(len(args)) + "Expecting " + strconv.Itoa(LoanTermTableColsQty-1) +
-		" or " + strconv.Itoa(LoanTermTableColsQty))
+	return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in addLoanTerm func. "+
+		"Provided "+strconv.Itoa(len(args))+", expecting "+strconv.Itoa(LoanTermTableColsQty-1)+
+		" or "+strconv.Itoa(LoanTermTableColsQty))
 }
 
 func getLoanTermQuantity(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return countTableRows(stub, []string{LoanTermTableName})
+	return countTableRows(stub, passIncludeDeletedOption([]string{LoanTermTableName}, args))
 }
 
 func getLoanTermList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return filterTableByValue(stub, []string{LoanTermTableName})
+	return filterTableByValue(stub, passIncludeDeletedOption([]string{LoanTermTableName}, args))
 }
 
 func getLoanTermByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	if len(args) != 1 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 1")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getLoanTermByKey func. Expecting 1")
 	}
 	keyValue := args[0]
 	return filterTableByKey(stub, LoanTermTableName, keyValue)
@@ -72,27 +89,83 @@ func getLoanTermByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte,
 func getLoanTermMaxKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	maxKey, err := getTableMaxKey(stub, LoanTermTableName)
 	if err != nil {
-		return nil, errors.New("Error in getLoanTermMaxKey func: " + err.Error())
+		return nil, wrapError(err, "Error in getLoanTermMaxKey func: ")
 	}
 	return maxKey, nil
 }
 
 func updateLoanTerm(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getUpdateArgs(stub, LoanTermTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(args) != LoanTermTableColsQty {
-		return nil, errors.New("Incorrect number of arguments in updateLoanTerm func. Expecting " + strconv.Itoa(LoanTermTableColsQty))
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in updateLoanTerm func. Expecting "+strconv.Itoa(LoanTermTableColsQty))
 	}
 
-	tbl, err := stub.GetTable(LoanTermTableName)
+	changes, err := getUpdateChanges(stub, LoanTermTableName, args)
 	if err != nil {
-		return nil, errors.New("An error occured while running updateLoanTerm: " + err.Error())
+		return nil, wrapError(err, "An error occured while running updateLoanTerm: ")
 	}
 
-	for i, cd := range tbl.ColumnDefinitions {
-		_, err := updateTableField(stub, []string{LoanTermTableName, args[0], cd.Name, args[i]}) //args[0] is hardcoded as row id
-		if err != nil {
-			return nil, errors.New("Failed updating field '" + cd.Name + "' in updateLoanTerm func: " + err.Error())
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanTermRowPermissionsByBankId(stub, args[0])
+	if !check {
+		return nil, wrapError(err, "Failed checking security in updateLoanTerm or returned false: ")
+	}
+	// Rows can not be moved to other banks
+	if ownerKey, ok := changes[LT_LoanRequestIDColName]; ok {
+		check, err = checkLoanRequestRowPermissionsByBankId(stub, ownerKey)
+		if !check {
+			return nil, wrapError(err, "Failed checking security in updateLoanTerm or returned false: ")
 		}
 	}
+	/////////////////////////////////////////////////////////////////////
+
+	_, err = patchRow(stub, LoanTermTableName, args[0], changes, "")
+	if err != nil {
+		return nil, wrapError(err, "Failed updating row in updateLoanTerm func: ")
+	}
 
 	return nil, nil
 }
+
+// Changes only given columns: JSON object with the key, changed columns and optional ETag. Returns the new ETag.
+func patchLoanTerm(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	keyValue, changes, eTag, err := parsePatchArgs(stub, LoanTermTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanTermRowPermissionsByBankId(stub, keyValue)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in patchLoanTerm or returned false: ")
+	}
+	// Rows can not be moved to other banks
+	if ownerKey, ok := changes[LT_LoanRequestIDColName]; ok {
+		check, err = checkLoanRequestRowPermissionsByBankId(stub, ownerKey)
+		if !check {
+			return nil, wrapError(err, "Failed checking security in patchLoanTerm or returned false: ")
+		}
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	return patchRow(stub, LoanTermTableName, keyValue, changes, eTag)
+}
+
+// Banks change only their own Loan Terms, the assigner changes Loan Terms of every bank
+func checkLoanTermRowPermissionsByBankId(stub shim.ChaincodeStubInterface, keyValue string) (bool, error) {
+	ownerKey, err := getTableColValueByKey(stub, LoanTermTableName, keyValue, LT_LoanRequestIDColName)
+	if err != nil {
+		return false, wrapError(err, "Error getting owner in checkLoanTermRowPermissionsByBankId func: ")
+	}
+
+	check, err := checkLoanRequestRowPermissionsByBankId(stub, ownerKey)
+	if !check {
+		return false, wrapError(err, "Failed checking security in checkLoanTermRowPermissionsByBankId func or returned false: ")
+	}
+
+	return true, nil
+}
@@ -1,18 +1,17 @@
+// Code generated by entitygen from api/entities.json. DO NOT EDIT.
+
 package main
 
 import (
-	//"encoding/json"
-	"errors"
-	//"fmt"
 	"strconv"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
-//Entity names
+// Entity names
 const LoanTermCommentTableName = "LoanTermComments"
 
-//Column names
+// Column names
 const LTC_LoanTermCommentIDColName = "LoanTermCommentID"
 const LTC_ParentLoanTermCommentIDColName = "ParentLoanTermCommentID"
 const LTC_LoanTermIDColName = "LoanTermID"
@@ -21,7 +20,7 @@ const LTC_BankIDColName = "BankID"
 const LTC_CommentTextColName = "CommentText"
 const LTC_LoanTermCommentDateColName = "LoanTermCommentDate"
 
-//Column quantity
+// Column quantity
 const LoanTermCommentTableColsQty = 7
 
 // ============================================================================================================================
@@ -29,18 +28,38 @@ const LoanTermCommentTableColsQty = 7
 // ============================================================================================================================
 
 func CreateLoanTermCommentTable(stub shim.ChaincodeStubInterface) error {
-	LTC_ColumnNames := []string{LTC_LoanTermCommentIDColName, LTC_ParentLoanTermCommentIDColName, LTC_LoanTermIDColName,
-		LTC_UserIDColName, LTC_BankIDColName, LTC_CommentTextColName, LTC_LoanTermCommentDateColName}
-	return createTable(stub, LoanTermCommentTableName, LTC_ColumnNames)
+	columnNames := []string{LTC_LoanTermCommentIDColName, LTC_ParentLoanTermCommentIDColName, LTC_LoanTermIDColName, LTC_UserIDColName, LTC_BankIDColName, LTC_CommentTextColName, LTC_LoanTermCommentDateColName}
+	return createTable(stub, LoanTermCommentTableName, columnNames)
 }
 
-func addLoanTermComment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+func init() {
+	registerFunction(functionDefinition{Name: "addLoanTermComment", Mode: FM_Write, Role: FR_Assigner, ArgsFormat: AF_Object, Table: LoanTermCommentTableName, Key: AK_Optional,
+		handler: addLoanTermComment, Description: "Adds Loan Term Comment"})
+	registerFunction(functionDefinition{Name: "updateLoanTermComment", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanTermCommentTableName, Key: AK_Required,
+		handler: updateLoanTermComment, Description: "Updates Loan Term Comment, omitted columns keep current values"})
+	registerFunction(functionDefinition{Name: "patchLoanTermComment", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanTermCommentTableName, Key: AK_Required,
+		Result: RT_Text, Args: []argumentDefinition{patchETagArg}, handler: patchLoanTermComment, Description: "Changes given columns of Loan Term Comment and returns its new ETag"})
+	registerFunction(functionDefinition{Name: "getLoanTermCommentQuantity", Mode: FM_Read, Result: RT_Text, Table: LoanTermCommentTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getLoanTermCommentQuantity, Description: "Returns number of Loan Term Comments"})
+	registerFunction(functionDefinition{Name: "getLoanTermCommentList", Mode: FM_Read, Table: LoanTermCommentTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getLoanTermCommentList, Description: "Returns all Loan Term Comments"})
+	registerFunction(functionDefinition{Name: "getLoanTermCommentByKey", Mode: FM_Read, Table: LoanTermCommentTableName,
+		Args: []argumentDefinition{{Name: "key"}}, handler: getLoanTermCommentByKey, Description: "Returns Loan Term Comment with the key"})
+	registerFunction(functionDefinition{Name: "getLoanTermCommentMaxKey", Mode: FM_Read, Result: RT_Text, Table: LoanTermCommentTableName,
+		handler: getLoanTermCommentMaxKey, Description: "Returns the greatest key of Loan Term Comments"})
+
+	foreignKeys = append(foreignKeys,
+		foreignKey{LoanTermCommentTableName, LTC_ParentLoanTermCommentIDColName, LoanTermCommentTableName, FK_OnDeleteSoftDelete},
+		foreignKey{LoanTermCommentTableName, LTC_LoanTermIDColName, LoanTermTableName, FK_OnDeleteSoftDelete},
+		foreignKey{LoanTermCommentTableName, LTC_UserIDColName, UserTableName, FK_OnDeleteRestrict},
+		foreignKey{LoanTermCommentTableName, LTC_BankIDColName, ParticipantsTableName, FK_OnDeleteRestrict},
+	)
+}
 
-	attrName := "role"
-	attrValue := "assigner"
-	checkPermissionsAssigner, errA := checkAttribute(stub, attrName, attrValue)
-	if !checkPermissionsAssigner {
-		return nil, errors.New("Error checking permission in addLoanTermComment: " + errA.Error())
+func addLoanTermComment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getAddArgs(stub, LoanTermCommentTableName, args)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(args) == LoanTermCommentTableColsQty {
@@ -50,22 +69,22 @@ func addLoanTermComment(stub shim.ChaincodeStubInterface, args []string) ([]byte
 		return nil, addRow(stub, LoanTermCommentTableName, args, false)
 	}
 
-	return nil, errors.New("Incorrect number of arguments. " +
-		"Provided " + strconv.Itoa(len(args)) + " ,expected " + strconv.Itoa(LoanTermCommentTableColsQty-1) +
-		" or " + strconv.Itoa(LoanTermCommentTableColsQty))
+	return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in addLoanTermComment func. "+
+		"Provided "+strconv.Itoa(len(args))+", expecting "+strconv.Itoa(LoanTermCommentTableColsQty-1)+
+		" or "+strconv.Itoa(LoanTermCommentTableColsQty))
 }
 
 func getLoanTermCommentQuantity(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return countTableRows(stub, []string{LoanTermCommentTableName})
+	return countTableRows(stub, passIncludeDeletedOption([]string{LoanTermCommentTableName}, args))
 }
 
 func getLoanTermCommentList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return filterTableByValue(stub, []string{LoanTermCommentTableName})
+	return filterTableByValue(stub, passIncludeDeletedOption([]string{LoanTermCommentTableName}, args))
 }
 
 func getLoanTermCommentByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	if len(args) != 1 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 1")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getLoanTermCommentByKey func. Expecting 1")
 	}
 	keyValue := args[0]
 	return filterTableByKey(stub, LoanTermCommentTableName, keyValue)
@@ -74,27 +93,83 @@ func getLoanTermCommentByKey(stub shim.ChaincodeStubInterface, args []string) ([
 func getLoanTermCommentMaxKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	maxKey, err := getTableMaxKey(stub, LoanTermCommentTableName)
 	if err != nil {
-		return nil, errors.New("Error in getLoanTermCommentMaxKey func: " + err.Error())
+		return nil, wrapError(err, "Error in getLoanTermCommentMaxKey func: ")
 	}
 	return maxKey, nil
 }
 
 func updateLoanTermComment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getUpdateArgs(stub, LoanTermCommentTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(args) != LoanTermCommentTableColsQty {
-		return nil, errors.New("Incorrect number of arguments in updateLoanTermComment func. Expecting " + strconv.Itoa(LoanTermCommentTableColsQty))
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in updateLoanTermComment func. Expecting "+strconv.Itoa(LoanTermCommentTableColsQty))
 	}
 
-	tbl, err := stub.GetTable(LoanTermCommentTableName)
+	changes, err := getUpdateChanges(stub, LoanTermCommentTableName, args)
 	if err != nil {
-		return nil, errors.New("An error occured while running updateLoanTermComment: " + err.Error())
+		return nil, wrapError(err, "An error occured while running updateLoanTermComment: ")
 	}
 
-	for i, cd := range tbl.ColumnDefinitions {
-		_, err := updateTableField(stub, []string{LoanTermCommentTableName, args[0], cd.Name, args[i]}) //args[0] is hardcoded as row id
-		if err != nil {
-			return nil, errors.New("Failed updating field '" + cd.Name + "' in updateLoanTermComment func: " + err.Error())
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanTermCommentRowPermissionsByBankId(stub, args[0])
+	if !check {
+		return nil, wrapError(err, "Failed checking security in updateLoanTermComment or returned false: ")
+	}
+	// Rows can not be moved to other banks
+	if ownerKey, ok := changes[LTC_BankIDColName]; ok {
+		check, err = checkRowPermissionsByBankId(stub, ownerKey)
+		if !check {
+			return nil, wrapError(err, "Failed checking security in updateLoanTermComment or returned false: ")
 		}
 	}
+	/////////////////////////////////////////////////////////////////////
+
+	_, err = patchRow(stub, LoanTermCommentTableName, args[0], changes, "")
+	if err != nil {
+		return nil, wrapError(err, "Failed updating row in updateLoanTermComment func: ")
+	}
 
 	return nil, nil
 }
+
+// Changes only given columns: JSON object with the key, changed columns and optional ETag. Returns the new ETag.
+func patchLoanTermComment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	keyValue, changes, eTag, err := parsePatchArgs(stub, LoanTermCommentTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanTermCommentRowPermissionsByBankId(stub, keyValue)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in patchLoanTermComment or returned false: ")
+	}
+	// Rows can not be moved to other banks
+	if ownerKey, ok := changes[LTC_BankIDColName]; ok {
+		check, err = checkRowPermissionsByBankId(stub, ownerKey)
+		if !check {
+			return nil, wrapError(err, "Failed checking security in patchLoanTermComment or returned false: ")
+		}
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	return patchRow(stub, LoanTermCommentTableName, keyValue, changes, eTag)
+}
+
+// Banks change only their own Loan Term Comments, the assigner changes Loan Term Comments of every bank
+func checkLoanTermCommentRowPermissionsByBankId(stub shim.ChaincodeStubInterface, keyValue string) (bool, error) {
+	ownerKey, err := getTableColValueByKey(stub, LoanTermCommentTableName, keyValue, LTC_BankIDColName)
+	if err != nil {
+		return false, wrapError(err, "Error getting owner in checkLoanTermCommentRowPermissionsByBankId func: ")
+	}
+
+	check, err := checkRowPermissionsByBankId(stub, ownerKey)
+	if !check {
+		return false, wrapError(err, "Failed checking security in checkLoanTermCommentRowPermissionsByBankId func or returned false: ")
+	}
+
+	return true, nil
+}
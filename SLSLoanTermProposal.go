@@ -1,44 +1,62 @@
+// Code generated by entitygen from api/entities.json. DO NOT EDIT.
+
 package main
 
 import (
-	//"encoding/json"
-	"errors"
-	//"fmt"
 	"strconv"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
-//Entity names
+// Entity names
 const LoanTermProposalTableName = "LoanTermProposals"
 
-//Column names
+// Column names
 const LTP_LoanTermProposalIDColName = "LoanTermProposalID"
 const LTP_LoanTermIDColName = "LoanTermID"
 const LTP_ParagraphNumberColName = "ParagraphNumber"
 const LTP_LoanTermProposalTextColName = "LoanTermProposalText"
 const LTP_LoanTermProposalExpTimeColName = "LoanTermProposalExpTime"
+const LTP_LoanTermProposalStatusColName = "LoanTermProposalStatus"
 
-//Column quantity
-const LoanTermProposalTableColsQty = 5
+// Column quantity
+const LoanTermProposalTableColsQty = 6
 
 // ============================================================================================================================
 //
 // ============================================================================================================================
 
 func CreateLoanTermProposalTable(stub shim.ChaincodeStubInterface) error {
-	LTP_ColumnNames := []string{LTP_LoanTermProposalIDColName, LTP_LoanTermIDColName, LTP_ParagraphNumberColName,
-		LTP_LoanTermProposalTextColName, LTP_LoanTermProposalExpTimeColName}
-	return createTable(stub, LoanTermProposalTableName, LTP_ColumnNames)
+	columnNames := []string{LTP_LoanTermProposalIDColName, LTP_LoanTermIDColName, LTP_ParagraphNumberColName, LTP_LoanTermProposalTextColName, LTP_LoanTermProposalExpTimeColName, LTP_LoanTermProposalStatusColName}
+	return createTable(stub, LoanTermProposalTableName, columnNames)
 }
 
-func addLoanTermProposal(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+func init() {
+	registerFunction(functionDefinition{Name: "addLoanTermProposal", Mode: FM_Write, Role: FR_Assigner, ArgsFormat: AF_Object, Table: LoanTermProposalTableName, Key: AK_Optional,
+		handler: addLoanTermProposal, Description: "Adds Loan Term Proposal"})
+	registerFunction(functionDefinition{Name: "updateLoanTermProposal", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanTermProposalTableName, Key: AK_Required,
+		handler: updateLoanTermProposal, Description: "Updates Loan Term Proposal, omitted columns keep current values"})
+	registerFunction(functionDefinition{Name: "patchLoanTermProposal", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanTermProposalTableName, Key: AK_Required,
+		Result: RT_Text, Args: []argumentDefinition{patchETagArg}, handler: patchLoanTermProposal, Description: "Changes given columns of Loan Term Proposal and returns its new ETag"})
+	registerFunction(functionDefinition{Name: "getLoanTermProposalQuantity", Mode: FM_Read, Result: RT_Text, Table: LoanTermProposalTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getLoanTermProposalQuantity, Description: "Returns number of Loan Term Proposals"})
+	registerFunction(functionDefinition{Name: "getLoanTermProposalList", Mode: FM_Read, Table: LoanTermProposalTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getLoanTermProposalList, Description: "Returns all Loan Term Proposals"})
+	registerFunction(functionDefinition{Name: "getLoanTermProposalByKey", Mode: FM_Read, Table: LoanTermProposalTableName,
+		Args: []argumentDefinition{{Name: "key"}}, handler: getLoanTermProposalByKey, Description: "Returns Loan Term Proposal with the key"})
+	registerFunction(functionDefinition{Name: "getLoanTermProposalMaxKey", Mode: FM_Read, Result: RT_Text, Table: LoanTermProposalTableName,
+		handler: getLoanTermProposalMaxKey, Description: "Returns the greatest key of Loan Term Proposals"})
+
+	foreignKeys = append(foreignKeys,
+		foreignKey{LoanTermProposalTableName, LTP_LoanTermIDColName, LoanTermTableName, FK_OnDeleteCascade},
+	)
+	columnTypes[LoanTermProposalTableName] = map[string]string{LTP_ParagraphNumberColName: CT_Int, LTP_LoanTermProposalExpTimeColName: CT_Time}
+}
 
-	attrName := "role"
-	attrValue := "assigner"
-	checkPermissionsAssigner, errA := checkAttribute(stub, attrName, attrValue)
-	if !checkPermissionsAssigner {
-		return nil, errors.New("Error checking permission in addLoanTermProposal: " + errA.Error())
+func addLoanTermProposal(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getAddArgs(stub, LoanTermProposalTableName, args)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(args) == LoanTermProposalTableColsQty {
@@ -48,22 +66,22 @@ func addLoanTermProposal(stub shim.ChaincodeStubInterface, args []string) ([]byt
 		return nil, addRow(stub, LoanTermProposalTableName, args, false)
 	}
 
-	return nil, errors.New("Incorrect number of arguments. " +
-		"Provided " + strconv.Itoa(len(args)) + "Expecting " + strconv.Itoa(LoanTermProposalTableColsQty-1) +
-		" or " + strconv.Itoa(LoanTermProposalTableColsQty))
+	return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in addLoanTermProposal func. "+
+		"Provided "+strconv.Itoa(len(args))+", expecting "+strconv.Itoa(LoanTermProposalTableColsQty-1)+
+		" or "+strconv.Itoa(LoanTermProposalTableColsQty))
 }
 
 func getLoanTermProposalQuantity(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return countTableRows(stub, []string{LoanTermProposalTableName})
+	return countTableRows(stub, passIncludeDeletedOption([]string{LoanTermProposalTableName}, args))
 }
 
 func getLoanTermProposalList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return filterTableByValue(stub, []string{LoanTermProposalTableName})
+	return filterTableByValue(stub, passIncludeDeletedOption([]string{LoanTermProposalTableName}, args))
 }
 
 func getLoanTermProposalByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	if len(args) != 1 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 1")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getLoanTermProposalByKey func. Expecting 1")
 	}
 	keyValue := args[0]
 	return filterTableByKey(stub, LoanTermProposalTableName, keyValue)
@@ -72,27 +90,83 @@ func getLoanTermProposalByKey(stub shim.ChaincodeStubInterface, args []string) (
 func getLoanTermProposalMaxKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	maxKey, err := getTableMaxKey(stub, LoanTermProposalTableName)
 	if err != nil {
-		return nil, errors.New("Error in getLoanTermProposalMaxKey func: " + err.Error())
+		return nil, wrapError(err, "Error in getLoanTermProposalMaxKey func: ")
 	}
 	return maxKey, nil
 }
 
 func updateLoanTermProposal(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getUpdateArgs(stub, LoanTermProposalTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(args) != LoanTermProposalTableColsQty {
-		return nil, errors.New("Incorrect number of arguments in updateLoanTermProposal func. Expecting " + strconv.Itoa(LoanTermProposalTableColsQty))
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in updateLoanTermProposal func. Expecting "+strconv.Itoa(LoanTermProposalTableColsQty))
 	}
 
-	tbl, err := stub.GetTable(LoanTermProposalTableName)
+	changes, err := getUpdateChanges(stub, LoanTermProposalTableName, args)
 	if err != nil {
-		return nil, errors.New("An error occured while running updateLoanTermProposal: " + err.Error())
+		return nil, wrapError(err, "An error occured while running updateLoanTermProposal: ")
 	}
 
-	for i, cd := range tbl.ColumnDefinitions {
-		_, err := updateTableField(stub, []string{LoanTermProposalTableName, args[0], cd.Name, args[i]}) //args[0] is hardcoded as row id
-		if err != nil {
-			return nil, errors.New("Failed updating field '" + cd.Name + "' in updateLoanTermProposal func: " + err.Error())
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanTermProposalRowPermissionsByBankId(stub, args[0])
+	if !check {
+		return nil, wrapError(err, "Failed checking security in updateLoanTermProposal or returned false: ")
+	}
+	// Rows can not be moved to other banks
+	if ownerKey, ok := changes[LTP_LoanTermIDColName]; ok {
+		check, err = checkLoanTermRowPermissionsByBankId(stub, ownerKey)
+		if !check {
+			return nil, wrapError(err, "Failed checking security in updateLoanTermProposal or returned false: ")
 		}
 	}
+	/////////////////////////////////////////////////////////////////////
+
+	_, err = patchRow(stub, LoanTermProposalTableName, args[0], changes, "")
+	if err != nil {
+		return nil, wrapError(err, "Failed updating row in updateLoanTermProposal func: ")
+	}
 
 	return nil, nil
 }
+
+// Changes only given columns: JSON object with the key, changed columns and optional ETag. Returns the new ETag.
+func patchLoanTermProposal(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	keyValue, changes, eTag, err := parsePatchArgs(stub, LoanTermProposalTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanTermProposalRowPermissionsByBankId(stub, keyValue)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in patchLoanTermProposal or returned false: ")
+	}
+	// Rows can not be moved to other banks
+	if ownerKey, ok := changes[LTP_LoanTermIDColName]; ok {
+		check, err = checkLoanTermRowPermissionsByBankId(stub, ownerKey)
+		if !check {
+			return nil, wrapError(err, "Failed checking security in patchLoanTermProposal or returned false: ")
+		}
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	return patchRow(stub, LoanTermProposalTableName, keyValue, changes, eTag)
+}
+
+// Banks change only their own Loan Term Proposals, the assigner changes Loan Term Proposals of every bank
+func checkLoanTermProposalRowPermissionsByBankId(stub shim.ChaincodeStubInterface, keyValue string) (bool, error) {
+	ownerKey, err := getTableColValueByKey(stub, LoanTermProposalTableName, keyValue, LTP_LoanTermIDColName)
+	if err != nil {
+		return false, wrapError(err, "Error getting owner in checkLoanTermProposalRowPermissionsByBankId func: ")
+	}
+
+	check, err := checkLoanTermRowPermissionsByBankId(stub, ownerKey)
+	if !check {
+		return false, wrapError(err, "Failed checking security in checkLoanTermProposalRowPermissionsByBankId func or returned false: ")
+	}
+
+	return true, nil
+}
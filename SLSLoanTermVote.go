@@ -1,43 +1,62 @@
+// Code generated by entitygen from api/entities.json. DO NOT EDIT.
+
 package main
 
 import (
-	//"encoding/json"
-	"errors"
-	//"fmt"
 	"strconv"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
-//Entity names
+// Entity names
 const LoanTermVoteTableName = "LoanTermVotes"
 
-//Column names
+// Column names
 const LTV_LoanTermVoteIDColName = "LoanTermVoteID"
 const LTV_LoanTermProposalIDColName = "LoanTermProposalID"
 const LTV_BankIDColName = "BankID"
 const LTV_LoanTermVoteStatusColName = "LoanTermVoteStatus"
+const LTV_LoanTermVoteDeadlineColName = "LoanTermVoteDeadline"
 
-//Column quantity
-const LoanTermVoteTableColsQty = 4
+// Column quantity
+const LoanTermVoteTableColsQty = 5
 
 // ============================================================================================================================
 //
 // ============================================================================================================================
 
 func CreateLoanTermVoteTable(stub shim.ChaincodeStubInterface) error {
-	LTV_ColumnNames := []string{LTV_LoanTermVoteIDColName, LTV_LoanTermProposalIDColName, LTV_BankIDColName,
-		LTV_LoanTermVoteStatusColName}
-	return createTable(stub, LoanTermVoteTableName, LTV_ColumnNames)
+	columnNames := []string{LTV_LoanTermVoteIDColName, LTV_LoanTermProposalIDColName, LTV_BankIDColName, LTV_LoanTermVoteStatusColName, LTV_LoanTermVoteDeadlineColName}
+	return createTable(stub, LoanTermVoteTableName, columnNames)
 }
 
-func addLoanTermVote(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+func init() {
+	registerFunction(functionDefinition{Name: "addLoanTermVote", Mode: FM_Write, Role: FR_Assigner, ArgsFormat: AF_Object, Table: LoanTermVoteTableName, Key: AK_Optional,
+		handler: addLoanTermVote, Description: "Adds Loan Term Vote"})
+	registerFunction(functionDefinition{Name: "updateLoanTermVote", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanTermVoteTableName, Key: AK_Required,
+		handler: updateLoanTermVote, Description: "Updates Loan Term Vote, omitted columns keep current values"})
+	registerFunction(functionDefinition{Name: "patchLoanTermVote", Mode: FM_Write, ArgsFormat: AF_Object, Table: LoanTermVoteTableName, Key: AK_Required,
+		Result: RT_Text, Args: []argumentDefinition{patchETagArg}, handler: patchLoanTermVote, Description: "Changes given columns of Loan Term Vote and returns its new ETag"})
+	registerFunction(functionDefinition{Name: "getLoanTermVoteQuantity", Mode: FM_Read, Result: RT_Text, Table: LoanTermVoteTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getLoanTermVoteQuantity, Description: "Returns number of Loan Term Votes"})
+	registerFunction(functionDefinition{Name: "getLoanTermVoteList", Mode: FM_Read, Table: LoanTermVoteTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getLoanTermVoteList, Description: "Returns all Loan Term Votes"})
+	registerFunction(functionDefinition{Name: "getLoanTermVoteByKey", Mode: FM_Read, Table: LoanTermVoteTableName,
+		Args: []argumentDefinition{{Name: "key"}}, handler: getLoanTermVoteByKey, Description: "Returns Loan Term Vote with the key"})
+	registerFunction(functionDefinition{Name: "getLoanTermVoteMaxKey", Mode: FM_Read, Result: RT_Text, Table: LoanTermVoteTableName,
+		handler: getLoanTermVoteMaxKey, Description: "Returns the greatest key of Loan Term Votes"})
+
+	foreignKeys = append(foreignKeys,
+		foreignKey{LoanTermVoteTableName, LTV_LoanTermProposalIDColName, LoanTermProposalTableName, FK_OnDeleteCascade},
+		foreignKey{LoanTermVoteTableName, LTV_BankIDColName, ParticipantsTableName, FK_OnDeleteRestrict},
+	)
+	columnTypes[LoanTermVoteTableName] = map[string]string{LTV_LoanTermVoteDeadlineColName: CT_Time}
+}
 
-	attrName := "role"
-	attrValue := "assigner"
-	checkPermissionsAssigner, errA := checkAttribute(stub, attrName, attrValue)
-	if !checkPermissionsAssigner {
-		return nil, errors.New("Error checking permission in addLoanTermVote: " + errA.Error())
+func addLoanTermVote(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getAddArgs(stub, LoanTermVoteTableName, args)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(args) == LoanTermVoteTableColsQty {
@@ -47,22 +66,22 @@ func addLoanTermVote(stub shim.ChaincodeStubInterface, args []string) ([]byte, e
 		return nil, addRow(stub, LoanTermVoteTableName, args, false)
 	}
 
-	return nil, errors.New("Incorrect number of arguments. " +
-		"Provided " + strconv.Itoa(len(args)) + "Expecting " + strconv.Itoa(LoanTermVoteTableColsQty-1) +
-		" or " + strconv.Itoa(LoanTermVoteTableColsQty))
+	return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in addLoanTermVote func. "+
+		"Provided "+strconv.Itoa(len(args))+", expecting "+strconv.Itoa(LoanTermVoteTableColsQty-1)+
+		" or "+strconv.Itoa(LoanTermVoteTableColsQty))
 }
 
 func getLoanTermVoteQuantity(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return countTableRows(stub, []string{LoanTermVoteTableName})
+	return countTableRows(stub, passIncludeDeletedOption([]string{LoanTermVoteTableName}, args))
 }
 
 func getLoanTermVoteList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return filterTableByValue(stub, []string{LoanTermVoteTableName})
+	return filterTableByValue(stub, passIncludeDeletedOption([]string{LoanTermVoteTableName}, args))
 }
 
 func getLoanTermVoteByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	if len(args) != 1 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 1")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getLoanTermVoteByKey func. Expecting 1")
 	}
 	keyValue := args[0]
 	return filterTableByKey(stub, LoanTermVoteTableName, keyValue)
@@ -71,27 +90,83 @@ func getLoanTermVoteByKey(stub shim.ChaincodeStubInterface, args []string) ([]by
 func getLoanTermVoteMaxKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	maxKey, err := getTableMaxKey(stub, LoanTermVoteTableName)
 	if err != nil {
-		return nil, errors.New("Error in getLoanTermVoteMaxKey func: " + err.Error())
+		return nil, wrapError(err, "Error in getLoanTermVoteMaxKey func: ")
 	}
 	return maxKey, nil
 }
 
 func updateLoanTermVote(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getUpdateArgs(stub, LoanTermVoteTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(args) != LoanTermVoteTableColsQty {
-		return nil, errors.New("Incorrect number of arguments in updateLoanTermVote func. Expecting " + strconv.Itoa(LoanTermVoteTableColsQty))
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in updateLoanTermVote func. Expecting "+strconv.Itoa(LoanTermVoteTableColsQty))
 	}
 
-	tbl, err := stub.GetTable(LoanTermVoteTableName)
+	changes, err := getUpdateChanges(stub, LoanTermVoteTableName, args)
 	if err != nil {
-		return nil, errors.New("An error occured while running updateLoanTermVote: " + err.Error())
+		return nil, wrapError(err, "An error occured while running updateLoanTermVote: ")
 	}
 
-	for i, cd := range tbl.ColumnDefinitions {
-		_, err := updateTableField(stub, []string{LoanTermVoteTableName, args[0], cd.Name, args[i]}) //args[0] is hardcoded as row id
-		if err != nil {
-			return nil, errors.New("Failed updating field '" + cd.Name + "' in updateLoanTermVote func: " + err.Error())
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanTermVoteRowPermissionsByBankId(stub, args[0])
+	if !check {
+		return nil, wrapError(err, "Failed checking security in updateLoanTermVote or returned false: ")
+	}
+	// Rows can not be moved to other banks
+	if ownerKey, ok := changes[LTV_BankIDColName]; ok {
+		check, err = checkRowPermissionsByBankId(stub, ownerKey)
+		if !check {
+			return nil, wrapError(err, "Failed checking security in updateLoanTermVote or returned false: ")
 		}
 	}
+	/////////////////////////////////////////////////////////////////////
+
+	_, err = patchRow(stub, LoanTermVoteTableName, args[0], changes, "")
+	if err != nil {
+		return nil, wrapError(err, "Failed updating row in updateLoanTermVote func: ")
+	}
 
 	return nil, nil
 }
+
+// Changes only given columns: JSON object with the key, changed columns and optional ETag. Returns the new ETag.
+func patchLoanTermVote(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	keyValue, changes, eTag, err := parsePatchArgs(stub, LoanTermVoteTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanTermVoteRowPermissionsByBankId(stub, keyValue)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in patchLoanTermVote or returned false: ")
+	}
+	// Rows can not be moved to other banks
+	if ownerKey, ok := changes[LTV_BankIDColName]; ok {
+		check, err = checkRowPermissionsByBankId(stub, ownerKey)
+		if !check {
+			return nil, wrapError(err, "Failed checking security in patchLoanTermVote or returned false: ")
+		}
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	return patchRow(stub, LoanTermVoteTableName, keyValue, changes, eTag)
+}
+
+// Banks change only their own Loan Term Votes, the assigner changes Loan Term Votes of every bank
+func checkLoanTermVoteRowPermissionsByBankId(stub shim.ChaincodeStubInterface, keyValue string) (bool, error) {
+	ownerKey, err := getTableColValueByKey(stub, LoanTermVoteTableName, keyValue, LTV_BankIDColName)
+	if err != nil {
+		return false, wrapError(err, "Error getting owner in checkLoanTermVoteRowPermissionsByBankId func: ")
+	}
+
+	check, err := checkRowPermissionsByBankId(stub, ownerKey)
+	if !check {
+		return false, wrapError(err, "Failed checking security in checkLoanTermVoteRowPermissionsByBankId func or returned false: ")
+	}
+
+	return true, nil
+}
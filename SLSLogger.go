@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	//"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Setting names
+const LogLevelSettingName = "logLevel"
+
+//Log level setting values
+const LogLevelDebug = "debug"
+const LogLevelInfo = "info"
+const LogLevelWarning = "warning"
+const LogLevelError = "error"
+
+var logLevelNames = []string{LogLevelDebug, LogLevelInfo, LogLevelWarning, LogLevelError}
+
+// Value written instead of fields which are not visible
+const logRedactedValue = "[REDACTED]"
+
+// Fields which are written as is, values of other fields are redacted,
+// so deal data, certificates and payloads never reach peer logs
+var logVisibleFields = []string{"level", "message", "txid", "function", "caller", "table", "key", "column", "error", "code",
+	"count", "inserted", "skipped", "version", "setting", "events", "archiveTable", "migration",
+	// Keys, references and statuses of entities
+	P_ParticipantKeyColName, P_ParticipantTypeColName, U_UserIDColName, U_ParticipantIDColName,
+	LR_LoanRequestIDColName, LR_ArrangerBankIDColName, LR_StatusColName, LR_CurrencyColName,
+	LN_LoanNegotiationIDColName, LN_LoanRequestIDColName, LN_ParticipantBankIDColName, LN_NegotiationStatusColName,
+	LT_LoanTermIDColName, LT_LoanRequestIDColName, LT_LoanTermStatusColName,
+	LTP_LoanTermProposalIDColName, LTP_LoanTermIDColName, LTP_LoanTermProposalStatusColName,
+	LTV_LoanTermVoteIDColName, LTV_LoanTermProposalIDColName, LTV_BankIDColName, LTV_LoanTermVoteStatusColName,
+	LTC_LoanTermCommentIDColName, LTC_LoanTermIDColName, LTC_ParentLoanTermCommentIDColName, LTC_UserIDColName, LTC_BankIDColName,
+	SB_LoanNegotiationIDColName, SBR_RoundStatusColName, SB_BidStatusColName}
+
+type logFields map[string]string
+
+type logContext struct {
+	Function string
+	Caller   string
+	Level    int
+}
+
+var logOutput io.Writer = os.Stdout
+
+// ============================================================================================================================
+// Log entries are JSON objects written to stdout, which is collected by the peer.
+// Level is set by "logLevel=<level>" Init argument and kept in Settings. Every transaction reads it into its context,
+// so a level set by a transaction which is not committed is not used by others.
+// ============================================================================================================================
+
+func getLogLevelIndex(level string) (int, error) {
+	for i, l := range logLevelNames {
+		if l == level {
+			return i, nil
+		}
+	}
+	return 0, newError(ErrCodeInvalidArgument, "Unknown log level '" + level + "'")
+}
+
+func setLogLevel(stub shim.ChaincodeStubInterface, level string) error {
+	i, err := getLogLevelIndex(level)
+	if err != nil {
+		return err
+	}
+	err = setSetting(stub, LogLevelSettingName, level)
+	if err != nil {
+		return err
+	}
+	if ctx := getTxContext(stub); ctx.log != nil {
+		ctx.log.Level = i
+	}
+	return nil
+}
+
+// Starts log context of Init or Invoke, it is dropped with the context of the transaction
+func startLogContext(stub shim.ChaincodeStubInterface, function string) {
+	// Settings table is missing before the first Init
+	level, _ := getLogLevelIndex(LogLevelInfo)
+	if name, err := getSetting(stub, LogLevelSettingName, LogLevelInfo); err == nil {
+		if i, err := getLogLevelIndex(name); err == nil {
+			level = i
+		}
+	}
+
+	bankID, _ := getCallerAttribute(stub, CA_BankID)
+	userID, _ := getCallerAttribute(stub, CA_UserID)
+	getTxContext(stub).log = &logContext{Function: function, Caller: bankID + "/" + userID, Level: level}
+}
+
+func isLogFieldVisible(name string) bool {
+	for _, f := range logVisibleFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Stub can be nil outside of transactions, entries without log context are written from info level
+func writeLog(stub shim.ChaincodeStubInterface, level int, message string, fields logFields) {
+	var ctx *logContext
+	if stub != nil {
+		ctx = getTxContext(stub).log
+	}
+	minLevel, _ := getLogLevelIndex(LogLevelInfo)
+	if ctx != nil {
+		minLevel = ctx.Level
+	}
+	if level < minLevel {
+		return
+	}
+
+	entry := make(map[string]string)
+	for name, value := range fields {
+		if !isLogFieldVisible(name) {
+			value = logRedactedValue
+		}
+		entry[name] = value
+	}
+	entry["level"] = logLevelNames[level]
+	entry["message"] = message
+	if stub != nil {
+		entry["txid"] = stub.GetTxID()
+		if ctx != nil {
+			entry["function"] = ctx.Function
+			entry["caller"] = ctx.Caller
+		}
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		b = []byte(`{"level":"error","message":"Failed writing log entry"}`)
+	}
+	fmt.Fprintln(logOutput, string(b))
+}
+
+func logDebug(stub shim.ChaincodeStubInterface, message string, fields logFields) {
+	writeLog(stub, 0, message, fields)
+}
+
+func logInfo(stub shim.ChaincodeStubInterface, message string, fields logFields) {
+	writeLog(stub, 1, message, fields)
+}
+
+func logWarning(stub shim.ChaincodeStubInterface, message string, fields logFields) {
+	writeLog(stub, 2, message, fields)
+}
+
+func logError(stub shim.ChaincodeStubInterface, message string, fields logFields) {
+	writeLog(stub, 3, message, fields)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	//"errors"
+	//"fmt"
+	//"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Entity names
+const MaintenanceLogTableName = "MaintenanceLog"
+
+//Column names
+const ML_MaintenanceLogIDColName = "MaintenanceLogID"
+const ML_FunctionColName = "Function"
+const ML_ArgumentsColName = "Arguments"
+const ML_BankIDColName = "BankID"
+const ML_UserIDColName = "UserID"
+const ML_TxIDColName = "TxID"
+const ML_DateColName = "Date"
+
+//Column quantity
+const MaintenanceLogTableColsQty = 7
+
+// Tables which can be read, deleted and restored through the maintenance API
+// and their columns which can be updated. Keys and references are never updatable.
+var maintenanceAllowlist = map[string][]string{
+	ParticipantsTableName: {P_ParticipantNameColName, P_ParticipantTypeColName},
+	UserTableName:         {U_UserNameColName},
+	LoanRequestsTableName: {LR_StatusColName, LR_ProjectNameColName, LR_ProjectInformationColName, LR_CompanyColName,
+		LR_WebsiteColName, LR_ContactPersonNameColName, LR_ContactPersonSurnameColName, LR_MarketAndIndustryColName,
+		LR_CurrencyColName},
+	LoanNegotiationsTableName: {LN_NegotiationStatusColName, LN_ParticipantBankCommentColName, LN_ResponseDeadlineColName},
+	LoanTermTableName:         {LT_ParagraphNumberColName, LT_LoanTermTextColName, LT_LoanTermStatusColName},
+	LoanTermProposalTableName: {LTP_ParagraphNumberColName, LTP_LoanTermProposalTextColName, LTP_LoanTermProposalExpTimeColName,
+		LTP_LoanTermProposalStatusColName},
+	LoanTermVoteTableName:     {LTV_LoanTermVoteStatusColName, LTV_LoanTermVoteDeadlineColName},
+	LoanTermCommentTableName:  {LTC_CommentTextColName},
+}
+
+// Tables which can only be read through the maintenance API, archive tables are read only as well
+var maintenanceReadOnlyTables = []string{DeletedRowsTableName, MaintenanceLogTableName, SettingsTableName, AuditLogTableName}
+
+// ============================================================================================================================
+// Generic table functions skip entity level permission checks, so they are available to administrators only.
+// Every maintenance invoke is recorded in MaintenanceLog table.
+// ============================================================================================================================
+
+func CreateMaintenanceLogTable(stub shim.ChaincodeStubInterface) error {
+	ML_ColumnNames := []string{ML_MaintenanceLogIDColName, ML_FunctionColName, ML_ArgumentsColName,
+		ML_BankIDColName, ML_UserIDColName, ML_TxIDColName, ML_DateColName}
+	return createTable(stub, MaintenanceLogTableName, ML_ColumnNames)
+}
+
+func init() {
+	tableArgs := []argumentDefinition{{Name: "table"}, {Name: "column", IsOptional: true}, {Name: "value", IsOptional: true}, includeDeletedArg}
+	registerFunction(functionDefinition{Name: "updateTableField", Mode: FM_Write, Role: FR_Assigner, isMaintenance: true,
+		Args: []argumentDefinition{{Name: "table"}, {Name: "key"}, {Name: "column"}, {Name: "value"}},
+		isLogged: true, check: checkMaintenanceFieldArgs, handler: updateTableField, Description: "Sets value of an allowed column"})
+	registerFunction(functionDefinition{Name: "deleteRow", Mode: FM_Write, Role: FR_Assigner, isMaintenance: true, Args: []argumentDefinition{{Name: "table"}, {Name: "key"}},
+		isLogged: true, check: checkMaintenanceWriteArgs, handler: deleteRow, Description: "Marks the row and rows referencing it deleted"})
+	registerFunction(functionDefinition{Name: "deleteRowsByColumnValue", Mode: FM_Write, Role: FR_Assigner, isMaintenance: true,
+		Args: []argumentDefinition{{Name: "table"}, {Name: "column", IsOptional: true}, {Name: "value", IsOptional: true}},
+		isLogged: true, check: checkMaintenanceDeleteArgs, handler: deleteRowsByColumnValue,
+		Description: "Marks rows with the column value deleted, all rows of the table if column is not given"})
+	registerFunction(functionDefinition{Name: "restoreRow", Mode: FM_Write, Role: FR_Assigner, isMaintenance: true, Args: []argumentDefinition{{Name: "table"}, {Name: "key"}},
+		isLogged: true, check: checkMaintenanceWriteArgs, handler: restoreRow, Description: "Restores the deleted row and rows deleted with it"})
+	registerFunction(functionDefinition{Name: "populateInitialData", Mode: FM_Write, Role: FR_Assigner, isMaintenance: true,
+		Args: []argumentDefinition{{Name: "fixture", IsOptional: true, Description: "Fixture JSON, demo fixture is loaded if it is not given"}},
+		isLogged: true, check: checkDemoMode, handler: populateInitialData, Description: "Loads fixture in demo mode"})
+	registerFunction(functionDefinition{Name: "countTableRows", Mode: FM_Read, Result: RT_Text, Role: FR_Assigner, isMaintenance: true,
+		Args: []argumentDefinition{{Name: "table"}, includeDeletedArg},
+		check: checkMaintenanceReadArgs, handler: countTableRows, Description: "Returns number of rows of the table"})
+	registerFunction(functionDefinition{Name: "filterTableByValue", Mode: FM_Read, Role: FR_Assigner, isMaintenance: true, Args: tableArgs,
+		check: checkMaintenanceReadArgs, handler: filterTableByValue, Description: "Returns rows with the column value"})
+	registerFunction(functionDefinition{Name: "filterArchiveTableByValue", Mode: FM_Read, Role: FR_Assigner, isMaintenance: true, Args: tableArgs,
+		check: checkMaintenanceArchiveArgs, handler: filterArchiveTableByValue, Description: "Returns archived rows of the table with the column value"})
+	registerFunction(functionDefinition{Name: "getMaintenanceLogList", Mode: FM_Read, Role: FR_Assigner, isMaintenance: true, Table: MaintenanceLogTableName,
+		handler: getMaintenanceLogList, Description: "Returns all maintenance invokes"})
+}
+
+func checkMaintenanceTable(tableName string, isWrite bool) error {
+	if _, ok := maintenanceAllowlist[tableName]; ok {
+		return nil
+	}
+	if !isWrite {
+		for _, tn := range maintenanceReadOnlyTables {
+			if tn == tableName {
+				return nil
+			}
+		}
+		for _, tn := range archivedTableNames {
+			if getArchiveTableName(tn) == tableName {
+				return nil
+			}
+		}
+	}
+	return newError(ErrCodePermissionDenied, "Table '" + tableName + "' is not allowed in maintenance functions")
+}
+
+func checkMaintenanceColumn(tableName, columnName string) error {
+	for _, cn := range maintenanceAllowlist[tableName] {
+		if cn == columnName {
+			return nil
+		}
+	}
+	return newFieldError(ErrCodePermissionDenied, columnName, "Column '"+columnName+"' of '"+tableName+"' table is not allowed in maintenance functions")
+}
+
+func addMaintenanceLog(stub shim.ChaincodeStubInterface, function string, args []string) error {
+	arguments, err := json.Marshal(args)
+	if err != nil {
+		return wrapError(err, "Error in addMaintenanceLog func: ")
+	}
+	bankID, err := getBankId(stub, []string{})
+	if err != nil {
+		return wrapError(err, "Error getting bankid in addMaintenanceLog func: ")
+	}
+	userID, err := getUserId(stub, []string{})
+	if err != nil {
+		return wrapError(err, "Error getting userid in addMaintenanceLog func: ")
+	}
+	date, err := getTxTimestampString(stub)
+	if err != nil {
+		return wrapError(err, "Error in addMaintenanceLog func: ")
+	}
+
+	return addRow(stub, MaintenanceLogTableName, []string{function, string(arguments),
+		string(bankID), string(userID), stub.GetTxID(), date}, false)
+}
+
+func checkMaintenanceFieldArgs(stub shim.ChaincodeStubInterface, args []string) error {
+	return checkMaintenanceColumn(args[0], args[2])
+}
+
+func checkMaintenanceWriteArgs(stub shim.ChaincodeStubInterface, args []string) error {
+	return checkMaintenanceTable(args[0], true)
+}
+
+// Column and value are given together
+func checkMaintenanceDeleteArgs(stub shim.ChaincodeStubInterface, args []string) error {
+	if len(args) == 2 {
+		return newError(ErrCodeInvalidArgument, "Incorrect number of arguments in deleteRowsByColumnValue func. Expecting 1 or 3")
+	}
+	return checkMaintenanceTable(args[0], true)
+}
+
+func checkMaintenanceReadArgs(stub shim.ChaincodeStubInterface, args []string) error {
+	return checkMaintenanceTable(args[0], false)
+}
+
+func checkMaintenanceArchiveArgs(stub shim.ChaincodeStubInterface, args []string) error {
+	return checkMaintenanceTable(getArchiveTableName(args[0]), false)
+}
+
+func checkDemoMode(stub shim.ChaincodeStubInterface, args []string) error {
+	isProduction, err := isProductionMode(stub)
+	if err != nil {
+		return err
+	}
+	if isProduction {
+		return newError(ErrCodeInvalidState, "Demo data can not be populated in production mode")
+	}
+	return nil
+}
+
+func getMaintenanceLogList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	return filterTableByValue(stub, []string{MaintenanceLogTableName})
+}
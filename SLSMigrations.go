@@ -0,0 +1,251 @@
+package main
+
+import (
+	//"encoding/json"
+	//"errors"
+	//"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Setting names
+const SchemaVersionSettingName = "schemaVersion"
+
+type schemaMigration struct {
+	Version     int
+	Description string
+	Apply       func(stub shim.ChaincodeStubInterface) error
+}
+
+// Migrations are applied in order to ledgers created with an older schema version.
+// Version 0 is the schema before versioning was introduced.
+// New tables are created by Init, so migrations only change existing tables.
+var schemaMigrations = []schemaMigration{
+	{1, "Add Currency column to LoanRequests", func(stub shim.ChaincodeStubInterface) error {
+		return addTableColumn(stub, LoanRequestsTableName, LR_CurrencyColName, LR_CurrencyDefault)
+	}},
+	{2, "Add deadline columns to LoanNegotiations and LoanTermVotes and status column to LoanTermProposals", func(stub shim.ChaincodeStubInterface) error {
+		err := addTableColumn(stub, LoanNegotiationsTableName, LN_ResponseDeadlineColName, "")
+		if err != nil {
+			return err
+		}
+		err = addTableColumn(stub, LoanTermProposalTableName, LTP_LoanTermProposalStatusColName, "")
+		if err != nil {
+			return err
+		}
+		return addTableColumn(stub, LoanTermVoteTableName, LTV_LoanTermVoteDeadlineColName, "")
+	}},
+	{3, "Add RevealDeadline column to SealedBidRounds", func(stub shim.ChaincodeStubInterface) error {
+		return addTableColumn(stub, SealedBidRoundsTableName, SBR_RevealDeadlineColName, "")
+	}},
+	{4, "Convert deadlines to RFC 3339 dates", convertLegacyDeadlines},
+}
+
+// Layouts of deadlines written before deadline columns had the time type, they are UTC times
+var legacyDeadlineLayouts = []string{"2006-01-02", "2006-01-02T15:04:05", "2006-01-02 15:04:05"}
+
+// ============================================================================================================================
+//
+// ============================================================================================================================
+
+func init() {
+	registerFunction(functionDefinition{Name: "getSchemaVersion", Mode: FM_Read, Result: RT_Text, handler: getSchemaVersion,
+		Description: "Returns schema version of the ledger"})
+}
+
+func getLatestSchemaVersion() int {
+	if len(schemaMigrations) == 0 {
+		return 0
+	}
+	return schemaMigrations[len(schemaMigrations)-1].Version
+}
+
+func getLedgerSchemaVersion(stub shim.ChaincodeStubInterface) (int, error) {
+	version, err := getSetting(stub, SchemaVersionSettingName, "0")
+	if err != nil {
+		return 0, err
+	}
+	versionInt, err := strconv.Atoi(version)
+	if err != nil {
+		return 0, wrapError(err, "Schema version '" + version + "' is not a number: ")
+	}
+	return versionInt, nil
+}
+
+func setSchemaVersion(stub shim.ChaincodeStubInterface, version int) error {
+	return setSetting(stub, SchemaVersionSettingName, strconv.Itoa(version))
+}
+
+// Applies migrations newer than the ledger schema version
+func runSchemaMigrations(stub shim.ChaincodeStubInterface) error {
+	version, err := getLedgerSchemaVersion(stub)
+	if err != nil {
+		return wrapError(err, "Error in runSchemaMigrations func: ")
+	}
+	if version > getLatestSchemaVersion() {
+		return newError(ErrCodeInvalidState, "Ledger schema version " + strconv.Itoa(version) + " is newer than chaincode schema version " +
+			strconv.Itoa(getLatestSchemaVersion()))
+	}
+
+	for _, m := range schemaMigrations {
+		if m.Version <= version {
+			continue
+		}
+		err = m.Apply(stub)
+		if err != nil {
+			return wrapError(err, "Failed applying migration " + strconv.Itoa(m.Version) + " '" + m.Description + "': ")
+		}
+		err = setSchemaVersion(stub, m.Version)
+		if err != nil {
+			return wrapError(err, "Failed saving schema version " + strconv.Itoa(m.Version) + ": ")
+		}
+		logInfo(stub, "Migration is applied", logFields{"version": strconv.Itoa(m.Version), "migration": m.Description})
+	}
+	return nil
+}
+
+// Adds the column to the end of the table and its archive table, existing rows get the default value.
+// Rows are saved again with the value of the new column.
+func addTableColumn(stub shim.ChaincodeStubInterface, tableName, columnName, defaultValue string) error {
+	tableNames := []string{tableName}
+	if isArchivedTable(tableName) {
+		tableNames = append(tableNames, getArchiveTableName(tableName))
+	}
+
+	for _, tn := range tableNames {
+		tbl, err := getTable(stub, tn)
+		if err != nil {
+			return wrapError(err, "Error getting table '" + tn + "' in addTableColumn func: ")
+		}
+
+		var isColumnFound bool
+		for _, cd := range tbl.ColumnDefinitions {
+			if cd.Name == columnName {
+				isColumnFound = true
+				break
+			}
+		}
+		if isColumnFound {
+			continue
+		}
+
+		rows, err := getRows(stub, tn)
+		if err != nil {
+			return wrapError(err, "Error getting rows of table '" + tn + "' in addTableColumn func: ")
+		}
+
+		tbl.ColumnDefinitions = append(tbl.ColumnDefinitions, &ColumnDefinition{Name: columnName, Key: false})
+		err = putTable(stub, tbl)
+		if err != nil {
+			return wrapError(err, "Error saving table '" + tn + "' in addTableColumn func: ")
+		}
+
+		for _, row := range rows {
+			row.Columns = append(row.Columns, &Column{Value: defaultValue})
+			_, err = replaceRow(stub, tn, row)
+			if err != nil {
+				return wrapError(err, "Error copying row to table '" + tn + "' in addTableColumn func: ")
+			}
+		}
+
+		logInfo(stub, "Column is added", logFields{"table": tn, "column": columnName})
+	}
+	return nil
+}
+
+// Rewrites values of time columns which are not RFC 3339 dates. Values in other layouts fail the migration,
+// they should be corrected with the previous chaincode version before the upgrade.
+func convertLegacyDeadlines(stub shim.ChaincodeStubInterface) error {
+	// Tables and columns are sorted, so every endorsing peer writes rows in the same order
+	var tableNames []string
+	for tableName := range columnTypes {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+
+	for _, tableName := range tableNames {
+		var columnNames []string
+		for columnName, columnType := range columnTypes[tableName] {
+			if columnType == CT_Time {
+				columnNames = append(columnNames, columnName)
+			}
+		}
+		if len(columnNames) == 0 {
+			continue
+		}
+		sort.Strings(columnNames)
+
+		tableNames := []string{tableName}
+		if isArchivedTable(tableName) {
+			tableNames = append(tableNames, getArchiveTableName(tableName))
+		}
+		for _, tn := range tableNames {
+			err := convertTableDeadlines(stub, tableName, tn, columnNames)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Column types are registered by names of live tables, tn is the live table or its archive table
+func convertTableDeadlines(stub shim.ChaincodeStubInterface, tableName, tn string, columnNames []string) error {
+	tbl, err := getTable(stub, tn)
+	if err != nil {
+		return wrapError(err, "Error getting table '" + tn + "' in convertTableDeadlines func: ")
+	}
+	rows, err := getRows(stub, tn)
+	if err != nil {
+		return wrapError(err, "Error getting rows of table '" + tn + "' in convertTableDeadlines func: ")
+	}
+
+	for _, row := range rows {
+		var isChanged bool
+		for _, columnName := range columnNames {
+			i := getColumnIndex(tbl, columnName)
+			if i < 0 || row.Columns[i].Value == "" || checkColumnType(tableName, columnName, row.Columns[i].Value) == nil {
+				continue
+			}
+			value, err := convertLegacyDeadline(row.Columns[i].Value)
+			if err != nil {
+				return newFieldError(ErrCodeInvalidState, columnName, "Deadline '" + row.Columns[i].Value + "' of row '" +
+					row.Columns[0].Value + "' in table '" + tn + "' is not an RFC 3339 date and can not be converted")
+			}
+			row.Columns[i] = &Column{Value: value}
+			isChanged = true
+		}
+		if !isChanged {
+			continue
+		}
+		_, err = replaceRow(stub, tn, row)
+		if err != nil {
+			return wrapError(err, "Error saving row of table '" + tn + "' in convertTableDeadlines func: ")
+		}
+		logInfo(stub, "Deadlines are converted", logFields{"table": tn, "key": row.Columns[0].Value})
+	}
+	return nil
+}
+
+func convertLegacyDeadline(value string) (string, error) {
+	var err error
+	for _, layout := range legacyDeadlineLayouts {
+		var deadline time.Time
+		deadline, err = time.Parse(layout, value)
+		if err == nil {
+			return deadline.Format(time.RFC3339), nil
+		}
+	}
+	return "", err
+}
+
+func getSchemaVersion(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	version, err := getLedgerSchemaVersion(stub)
+	if err != nil {
+		return nil, wrapError(err, "Error in getSchemaVersion func: ")
+	}
+	return []byte(strconv.Itoa(version)), nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	//"errors"
+	//"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Setting names
+const PositionalArgsSettingName = "positionalArgs"
+
+//Positional args setting values
+const PositionalArgsEnabled = "enabled"
+const PositionalArgsDisabled = "disabled"
+
+// Values of columns which can be omitted in named arguments and fixture rows
+var columnDefaults = map[string]map[string]string{
+	LoanRequestsTableName:     {LR_CurrencyColName: LR_CurrencyDefault},
+	LoanNegotiationsTableName: {LN_ResponseDeadlineColName: ""},
+}
+
+// ============================================================================================================================
+// Add and update functions take a single JSON object argument with column names as keys, e.g.
+//   addLoanRequest {"BorrowerID":"1","ArrangerBankID":"6","ProjectName":"Solar park", ...}
+// Values can be strings, numbers or booleans. Omitted columns get default or empty values when a row is added
+// and keep current values when a row is updated.
+// The old positional arguments in column order are accepted while "positionalArgs" setting is not "disabled",
+// so clients can be migrated one by one.
+// ============================================================================================================================
+
+func isNamedArgs(args []string) bool {
+	return len(args) == 1 && strings.HasPrefix(strings.TrimSpace(args[0]), "{")
+}
+
+func checkPositionalArgsEnabled(stub shim.ChaincodeStubInterface) error {
+	value, err := getSetting(stub, PositionalArgsSettingName, PositionalArgsEnabled)
+	if err != nil {
+		return wrapError(err, "Error getting positional arguments setting: ")
+	}
+	if value == PositionalArgsDisabled {
+		return newError(ErrCodeInvalidArgument, "Positional arguments are disabled, expecting a JSON object with named arguments")
+	}
+	return nil
+}
+
+func checkPositionalArgsSetting(value string) error {
+	if value != PositionalArgsEnabled && value != PositionalArgsDisabled {
+		return newError(ErrCodeInvalidArgument, "Unknown positional arguments setting '"+value+"', expecting '"+
+			PositionalArgsEnabled+"' or '"+PositionalArgsDisabled+"'")
+	}
+	return nil
+}
+
+// Parses JSON object argument. Names which are not in the list are reported as errors, null values are omitted.
+func parseNamedArgs(arg string, names []string) (map[string]string, error) {
+	decoder := json.NewDecoder(strings.NewReader(arg))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	err := decoder.Decode(&object)
+	if err != nil {
+		return nil, newError(ErrCodeInvalidArgument, "Arguments are not a valid JSON object: "+err.Error())
+	}
+
+	values := make(map[string]string)
+	var details []errorDetail
+	for name, value := range object {
+		var isKnown bool
+		for _, n := range names {
+			if n == name {
+				isKnown = true
+				break
+			}
+		}
+		if !isKnown {
+			details = append(details, errorDetail{name, "unknown argument"})
+			continue
+		}
+
+		switch v := value.(type) {
+		case nil:
+		case string:
+			values[name] = v
+		case json.Number:
+			values[name] = v.String()
+		case bool:
+			if v {
+				values[name] = "true"
+			} else {
+				values[name] = "false"
+			}
+		default:
+			details = append(details, errorDetail{name, "value should be a string, number or boolean"})
+		}
+	}
+
+	if len(details) > 0 {
+		var messages []string
+		for _, d := range details {
+			messages = append(messages, d.Field+": "+d.Message)
+		}
+		return nil, &chaincodeError{Code: ErrCodeInvalidArgument, Message: "Wrong named arguments: " + strings.Join(messages, "; "),
+			Details: details}
+	}
+	return values, nil
+}
+
+func getTableColumnNames(stub shim.ChaincodeStubInterface, tableName string) ([]string, error) {
+	tbl, err := getTable(stub, tableName)
+	if err != nil {
+		return nil, wrapError(err, "Error getting table '"+tableName+"' in getTableColumnNames func: ")
+	}
+	var names []string
+	for _, cd := range tbl.ColumnDefinitions {
+		names = append(names, cd.Name)
+	}
+	return names, nil
+}
+
+// Returns arguments of add functions in column order. Key is the first value if it is given,
+// otherwise it is omitted and generated when the row is added.
+func getAddArgs(stub shim.ChaincodeStubInterface, tableName string, args []string) ([]string, error) {
+	if !isNamedArgs(args) {
+		return args, checkPositionalArgsEnabled(stub)
+	}
+
+	names, err := getTableColumnNames(stub, tableName)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseNamedArgs(args[0], names)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := values[names[0]]; !ok {
+		names = names[1:]
+	}
+	var result []string
+	for _, name := range names {
+		value, ok := values[name]
+		if !ok {
+			value = columnDefaults[tableName][name]
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// Returns arguments of update functions in column order. Key is required, omitted columns keep current values.
+func getUpdateArgs(stub shim.ChaincodeStubInterface, tableName string, args []string) ([]string, error) {
+	if !isNamedArgs(args) {
+		return args, checkPositionalArgsEnabled(stub)
+	}
+
+	names, err := getTableColumnNames(stub, tableName)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseNamedArgs(args[0], names)
+	if err != nil {
+		return nil, err
+	}
+
+	keyValue, ok := values[names[0]]
+	if !ok {
+		return nil, newFieldError(ErrCodeInvalidArgument, names[0], "Key argument '"+names[0]+"' is missing")
+	}
+	current, err := getRowValuesByKey(stub, tableName, keyValue)
+	if err != nil {
+		return nil, err
+	}
+	if len(current) == 0 {
+		return nil, newError(ErrCodeNotFound, "Row with key '"+keyValue+"' is not found in '"+tableName+"' table")
+	}
+
+	var result []string
+	for i, name := range names {
+		value, ok := values[name]
+		if !ok {
+			value = current[i]
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// Returns arguments of functions which do not match table columns in the order of names, all of them are required
+func getNamedArgValues(stub shim.ChaincodeStubInterface, args []string, names []string) ([]string, error) {
+	if !isNamedArgs(args) {
+		return args, checkPositionalArgsEnabled(stub)
+	}
+
+	values, err := parseNamedArgs(args[0], names)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, name := range names {
+		value, ok := values[name]
+		if !ok {
+			return nil, newFieldError(ErrCodeInvalidArgument, name, "Argument '"+name+"' is missing")
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
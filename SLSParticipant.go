@@ -2,11 +2,11 @@ package main
 
 import (
 	//"encoding/json"
-	"errors"
+	//"errors"
 	//"fmt"
 	"strconv"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
 //Entity names
@@ -29,17 +29,30 @@ func CreateParticipantTable(stub shim.ChaincodeStubInterface) error {
 	return createTable(stub, ParticipantsTableName, P_ColumnNames)
 }
 
+func init() {
+	registerFunction(functionDefinition{Name: "addParticipant", Mode: FM_Write, Role: FR_Assigner, ArgsFormat: AF_Object, Table: ParticipantsTableName, Key: AK_Optional,
+		handler: addParticipant, Description: "Adds Participant"})
+	registerFunction(functionDefinition{Name: "getParticipantsQuantity", Mode: FM_Read, Result: RT_Text, Table: ParticipantsTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getParticipantsQuantity, Description: "Returns number of Participants"})
+	registerFunction(functionDefinition{Name: "getParticipantsList", Mode: FM_Read, Table: ParticipantsTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getParticipantsList, Description: "Returns all Participants"})
+	registerFunction(functionDefinition{Name: "getParticipantsByKey", Mode: FM_Read, Table: ParticipantsTableName,
+		Args: []argumentDefinition{{Name: "key"}}, handler: getParticipantsByKey, Description: "Returns Participant with the key"})
+	registerFunction(functionDefinition{Name: "getParticipantsByType", Mode: FM_Read, Table: ParticipantsTableName,
+		Args: []argumentDefinition{{Name: P_ParticipantTypeColName}, includeDeletedArg}, handler: getParticipantsByType,
+		Description: "Returns Participants of the type"})
+	registerFunction(functionDefinition{Name: "getParticipantsMaxKey", Mode: FM_Read, Result: RT_Text, Table: ParticipantsTableName,
+		handler: getParticipantsMaxKey, Description: "Returns the greatest key of Participants"})
+}
+
 //1. Administrator: add Participant (Bank or Borrower)
 //Two arguments expected:
 //Participant Name (string)
 //Participant Type (string) BANK, BORROWER, LAYER
 func addParticipant(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-
-	attrName := "role"
-	attrValue := "assigner"
-	checkPermissionsAssigner, errA := checkAttribute(stub, attrName, attrValue)
-	if !checkPermissionsAssigner {
-		return nil, errors.New("Error checking permission to add Participant: " + errA.Error())
+	args, err := getAddArgs(stub, ParticipantsTableName, args)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(args) == ParticipantsTableColsQty {
@@ -49,30 +62,31 @@ func addParticipant(stub shim.ChaincodeStubInterface, args []string) ([]byte, er
 		return nil, addRow(stub, ParticipantsTableName, args, false)
 	}
 
-	return nil, errors.New("Incorrect number of arguments. " +
+	return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. " +
 		"Provided " + strconv.Itoa(len(args)) + "Expecting " + strconv.Itoa(ParticipantsTableColsQty-1) +
 		" or " + strconv.Itoa(ParticipantsTableColsQty))
 }
 
 func getParticipantsQuantity(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return countTableRows(stub, []string{ParticipantsTableName})
+	return countTableRows(stub, passIncludeDeletedOption([]string{ParticipantsTableName}, args))
 }
 
 func getParticipantsList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return filterTableByValue(stub, []string{ParticipantsTableName})
+	return filterTableByValue(stub, passIncludeDeletedOption([]string{ParticipantsTableName}, args))
 }
 
 func getParticipantsByType(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	if len(args) != 1 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 1")
+	queryArgs, _ := parseIncludeDeletedOption(args)
+	if len(queryArgs) != 1 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 1")
 	}
-	filterValue := args[0]
-	return filterTableByValue(stub, []string{ParticipantsTableName, P_ParticipantTypeColName, filterValue})
+	filterValue := queryArgs[0]
+	return filterTableByValue(stub, passIncludeDeletedOption([]string{ParticipantsTableName, P_ParticipantTypeColName, filterValue}, args))
 }
 
 func getParticipantsByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	if len(args) != 1 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 1")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 1")
 	}
 	keyValue := args[0]
 	return filterTableByKey(stub, ParticipantsTableName, keyValue)
@@ -81,7 +95,7 @@ func getParticipantsByKey(stub shim.ChaincodeStubInterface, args []string) ([]by
 func getParticipantsMaxKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	maxKey, err := getTableMaxKey(stub, ParticipantsTableName)
 	if err != nil {
-		return nil, errors.New("Error in getParticipantsMaxKey func: " + err.Error())
+		return nil, wrapError(err, "Error in getParticipantsMaxKey func: ")
 	}
 	return maxKey, nil
 }
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	//"errors"
+	//"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Name of the optional patch argument with the ETag of the row the client has read
+const PatchETagArgName = "ETag"
+
+var patchETagArg = argumentDefinition{Name: PatchETagArgName, IsOptional: true,
+	Description: "ETag of the row the changes are based on, the patch fails with CONFLICT if the row was changed since then"}
+
+// ============================================================================================================================
+// Patch functions take a JSON object with the key and the columns to change, e.g.
+//   patchLoanRequest {"LoanRequestID":"1","ProjectName":"Solar park","ETag":"<etag>"}
+// All changes are written with one ReplaceRow. ETag is a hash of the row values returned by patch functions
+// and getRowETag query. If the given ETag does not match the current row, the row was changed by someone else
+// and the patch fails with CONFLICT.
+// ============================================================================================================================
+
+func init() {
+	registerFunction(functionDefinition{Name: "getRowETag", Mode: FM_Read, Result: RT_Text, Args: []argumentDefinition{{Name: "table"}, {Name: "key"}},
+		handler: getRowETagByKey, Description: "Returns ETag of the row"})
+}
+
+func getRowETag(row Row) string {
+	var values []string
+	for _, c := range row.Columns {
+		values = append(values, c.Value)
+	}
+	// Marshalling of a string slice does not fail
+	b, _ := json.Marshal(values)
+	hash := sha256.Sum256(b)
+	return hex.EncodeToString(hash[:])
+}
+
+// Query: table name, key
+func getRowETagByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getRowETag func. Expecting 2")
+	}
+	row, err := getRowByKeyValue(stub, args[0], args[1])
+	if err != nil {
+		return nil, wrapError(err, "Error in getRowETag func: ")
+	}
+	return []byte(getRowETag(row)), nil
+}
+
+// Parses patch arguments: a JSON object with the key, changed columns and optional ETag
+func parsePatchArgs(stub shim.ChaincodeStubInterface, tableName string, args []string) (string, map[string]string, string, error) {
+	if !isNamedArgs(args) {
+		return "", nil, "", newError(ErrCodeInvalidArgument, "Patch functions expect a JSON object with named arguments")
+	}
+
+	names, err := getTableColumnNames(stub, tableName)
+	if err != nil {
+		return "", nil, "", err
+	}
+	values, err := parseNamedArgs(args[0], append(names, PatchETagArgName))
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	keyValue, ok := values[names[0]]
+	if !ok {
+		return "", nil, "", newFieldError(ErrCodeInvalidArgument, names[0], "Key argument '"+names[0]+"' is missing")
+	}
+	eTag := values[PatchETagArgName]
+	delete(values, names[0])
+	delete(values, PatchETagArgName)
+	return keyValue, values, eTag, nil
+}
+
+// Applies changes to the row with one ReplaceRow and returns the new ETag.
+// Changes are checked against the ETag if it is not empty.
+func patchRow(stub shim.ChaincodeStubInterface, tableName, keyValue string, changes map[string]string, eTag string) ([]byte, error) {
+	row, err := getRowByKeyValue(stub, tableName, keyValue)
+	if err != nil {
+		return nil, wrapError(err, "Error in patchRow func: ")
+	}
+	if eTag != "" && eTag != getRowETag(row) {
+		return nil, newError(ErrCodeConflict, "Row with key '"+keyValue+"' in '"+tableName+"' table was changed since it was read")
+	}
+
+	tbl, err := getTable(stub, tableName)
+	if err != nil {
+		return nil, wrapError(err, "Error getting table in patchRow func: ")
+	}
+	oldRow := copyRow(row)
+	oldLoanRequestID, err := getRowDealID(stub, tbl, row)
+	if err != nil {
+		return nil, wrapError(err, "Error in patchRow func: ")
+	}
+
+	var columnNames []string
+	for columnName := range changes {
+		columnNames = append(columnNames, columnName)
+		changes[columnName], err = getPrivateColumnArg(stub, tbl, row, columnName, changes[columnName])
+		if err != nil {
+			return nil, wrapError(err, "Error in patchRow func: ")
+		}
+	}
+	sort.Strings(columnNames)
+
+	changedFields := make(map[string]string)
+	previousValues := make(map[string]string)
+	for _, columnName := range columnNames {
+		var f bool
+		for i, cd := range tbl.ColumnDefinitions {
+			if cd.Name != columnName {
+				continue
+			}
+			f = true
+			if i == 0 {
+				return nil, newFieldError(ErrCodeInvalidArgument, columnName, "Key column '"+columnName+"' can not be changed")
+			}
+			if oldValue := row.Columns[i].Value; oldValue != changes[columnName] {
+				err = checkColumnType(tableName, columnName, changes[columnName])
+				if err != nil {
+					return nil, wrapError(err, "Error in patchRow func: ")
+				}
+				err = checkForeignKeyValue(stub, tableName, columnName, changes[columnName])
+				if err != nil {
+					return nil, wrapError(err, "Error in patchRow func: ")
+				}
+				row.Columns[i] = &Column{Value: changes[columnName]}
+				changedFields[columnName] = changes[columnName]
+				previousValues[columnName] = oldValue
+			}
+		}
+		if !f {
+			return nil, newFieldError(ErrCodeInvalidArgument, columnName, "Column '"+columnName+"' is missing")
+		}
+	}
+
+	if len(changedFields) > 0 {
+		err = checkLateChanges(stub, tbl, row, previousValues)
+		if err != nil {
+			return nil, wrapError(err, "Error in patchRow func: ")
+		}
+	}
+
+	// Private values are replaced with hashes, values which were written again keep their hashes
+	if len(changedFields) > 0 {
+		loanRequestID, err := getRowDealID(stub, tbl, row)
+		if err != nil {
+			return nil, wrapError(err, "Error in patchRow func: ")
+		}
+		err = moveDealPrivateValues(stub, tbl, oldRow, oldLoanRequestID, loanRequestID)
+		if err != nil {
+			return nil, wrapError(err, "Error in patchRow func: ")
+		}
+		err = sealPrivateColumns(stub, tbl, row)
+		if err != nil {
+			return nil, wrapError(err, "Error in patchRow func: ")
+		}
+		for columnName := range changedFields {
+			value := getRowColumnValue(tbl, row, columnName)
+			if value == previousValues[columnName] {
+				delete(changedFields, columnName)
+				delete(previousValues, columnName)
+			} else {
+				changedFields[columnName] = value
+			}
+		}
+	}
+
+	if len(changedFields) > 0 {
+		ok, err := replaceRow(stub, tableName, row)
+		if err != nil {
+			return nil, wrapError(err, "Error replacing row in patchRow func: ")
+		}
+		if !ok {
+			return nil, newError(ErrCodeNotFound, "A row does not exist the given key")
+		}
+
+		fields := logFields{"table": tableName, "key": keyValue}
+		for columnName, value := range changedFields {
+			fields[columnName] = value
+		}
+		logInfo(stub, "Row is patched", logFields{"table": tableName, "key": keyValue, "count": strconv.Itoa(len(changedFields))})
+		logDebug(stub, "Values of patched row", fields)
+
+		err = recordRowChange(stub, tableName, keyValue, EA_Updated, changedFields, previousValues)
+		if err != nil {
+			return nil, wrapError(err, "Error in patchRow func: ")
+		}
+	}
+
+	return []byte(getRowETag(row)), nil
+}
+
+// Converts positional arguments of update functions to changes of all given columns except the key
+func getUpdateChanges(stub shim.ChaincodeStubInterface, tableName string, args []string) (map[string]string, error) {
+	names, err := getTableColumnNames(stub, tableName)
+	if err != nil {
+		return nil, err
+	}
+	changes := make(map[string]string)
+	for i := 1; i < len(args) && i < len(names); i++ {
+		changes[names[i]] = args[i]
+	}
+	return changes, nil
+}
@@ -0,0 +1,580 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	//"errors"
+	//"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Prefix of names of private data collections of deals, the name ends with the Loan Request ID
+const DealCollectionNamePrefix = "deal-"
+
+//Prefix of hashes kept in rows instead of private values
+const PrivateValueHashPrefix = "sha256:"
+
+//Name of the salt of private values in the transient map
+const PrivateValueSaltName = "salt"
+
+//Minimal length of the salt in the transient map, in bytes
+const MinPrivateValueSaltLength = 16
+
+//Status of Loan Terms which are adopted, texts of their proposals are published
+const LT_StatusAdopted = "ADOPTED"
+
+// ============================================================================================================================
+// Commercially sensitive columns are kept in the private data collection of their deal, rows on the public ledger have
+// hashes of their values. Every Loan Request has its own collection "deal-<Loan Request ID>" with organizations of
+// the arranger bank and the invited banks as members, see getDealCollectionsConfig. The collection configuration of
+// the chaincode is regenerated and the chaincode definition is updated when deals are added or banks are invited.
+// Values are sealed when rows are written: the value goes to the collection with a salt and the row gets the SHA-256
+// hash of the salt followed by the value, so values like amounts can not be found by hashing guesses.
+// Audit log and events have hashes only.
+// Arguments are visible in blocks, so clients leave private arguments empty and pass values in the transient map
+// under column names, values in arguments are rejected. Hashes may be written back as they were read.
+// Query results have values for the arranger bank and participant banks of the deal, other callers get hashes. Readers get salts with getPrivateValueSalt, and anyone can verify a disclosed value and its salt against
+// the hash with verifyPrivateValue. Filters by private columns compare hashes.
+// Endorsements by several peers must be equal, so the chaincode draws no random values. Clients pass random bytes
+// in the transient map under the salt name with every invoke which writes private values, salts of columns are
+// derived from them. Rows moved to another deal take their values to the collection of the new deal.
+// ============================================================================================================================
+
+type privateColumnsDefinition struct {
+	Columns []string
+	// Returns the Loan Request of the row, banks of its deal read the values
+	getLoanRequestID func(stub shim.ChaincodeStubInterface, tbl *Table, row Row) (string, error)
+	// Returns false if values of the row are public, nil if they are always private
+	isPrivate func(stub shim.ChaincodeStubInterface, tbl *Table, row Row) (bool, error)
+	// Returns banks which read values of the row besides the arranger bank of the deal
+	getParticipantBankIDs func(stub shim.ChaincodeStubInterface, tbl *Table, row Row, loanRequestID string) ([]string, error)
+}
+
+// Private columns by table names
+var privateColumns = make(map[string]privateColumnsDefinition)
+
+// Columns with Loan Request IDs of tables without private columns whose referencing rows have private columns
+var dealColumns = map[string]string{LoanTermTableName: LT_LoanRequestIDColName}
+
+func init() {
+	registerFunction(functionDefinition{Name: "verifyPrivateValue", Mode: FM_Read, Result: RT_Text,
+		Args: []argumentDefinition{{Name: "table"}, {Name: "key"}, {Name: "column"}, {Name: "value"}, {Name: "salt"}}, handler: verifyPrivateValue,
+		Description: "Returns true if the disclosed value and salt of the private column match the hash in the row, otherwise false"})
+	registerFunction(functionDefinition{Name: "getPrivateValueSalt", Mode: FM_Read, Result: RT_Text,
+		Args: []argumentDefinition{{Name: "table"}, {Name: "key"}, {Name: "column"}}, handler: getPrivateValueSalt,
+		Description: "Returns the salt of the private column for readers of its value, so they can disclose the value"})
+	registerFunction(functionDefinition{Name: "publishLoanTermProposals", Mode: FM_Write, ArgsFormat: AF_Object,
+		Args: []argumentDefinition{{Name: LTP_LoanTermIDColName}}, handler: publishLoanTermProposals,
+		Description: "Writes texts of proposals of the adopted Loan Term to their rows and removes them from the collection of the deal"})
+
+	// Loan Term Proposals are generated by entitygen, so their texts are declared private here
+	privateColumns[LoanTermProposalTableName] = privateColumnsDefinition{
+		Columns:               []string{LTP_LoanTermProposalTextColName},
+		getLoanRequestID:      getLoanTermProposalLoanRequestID,
+		isPrivate:             isLoanTermProposalPrivate,
+		getParticipantBankIDs: getDealParticipantBankIDs,
+	}
+}
+
+// Value and its salt as they are kept in the collection
+type privateValue struct {
+	Salt  string
+	Value string
+}
+
+// Values sealed before salts were added are kept without them, their hashes are hashes of values
+func getPrivateValueHash(salt, value string) string {
+	hash := sha256.Sum256([]byte(salt + value))
+	return PrivateValueHashPrefix + hex.EncodeToString(hash[:])
+}
+
+// Returns the salt of the value with the key, it is derived from the salt of the transient map which the client must send
+func newPrivateValueSalt(stub shim.ChaincodeStubInterface, key string) (string, error) {
+	transient, err := stub.GetTransient()
+	if err != nil {
+		return "", wrapError(err, "Error getting transient data: ")
+	}
+	seed := transient[PrivateValueSaltName]
+	if len(seed) < MinPrivateValueSaltLength {
+		return "", newFieldError(ErrCodeInvalidArgument, PrivateValueSaltName, "Private values are written, random '"+
+			PrivateValueSaltName+"' of at least "+strconv.Itoa(MinPrivateValueSaltLength)+" bytes should be passed in the transient map")
+	}
+	salt := sha256.Sum256(append(seed, key...))
+	return hex.EncodeToString(salt[:]), nil
+}
+
+func getDealCollectionName(loanRequestID string) string {
+	return DealCollectionNamePrefix + loanRequestID
+}
+
+// Returns the value with the key of the collection, nil if it is missing
+func readPrivateValue(stub shim.ChaincodeStubInterface, collection, key string) (*privateValue, error) {
+	b, err := stub.GetPrivateData(collection, key)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	var v privateValue
+	if json.Unmarshal(b, &v) != nil {
+		return &privateValue{Value: string(b)}, nil
+	}
+	return &v, nil
+}
+
+func isPrivateValueHash(value string) bool {
+	return strings.HasPrefix(value, PrivateValueHashPrefix)
+}
+
+// Private values are read only by their keys, so lengths of key values are not padded as in row keys. Peers which are
+// not members of the collection could not move values written with other keys.
+func getPrivateValueKey(stub shim.ChaincodeStubInterface, tableName, keyValue, columnName string) (string, error) {
+	return stub.CreateCompositeKey(tableName, []string{strconv.Itoa(len(keyValue)), keyValue, columnName})
+}
+
+// Returns the value with the name from the transient map, the argument of the value should be empty
+func getTransientValue(stub shim.ChaincodeStubInterface, name, value string) (string, error) {
+	if value != "" {
+		return "", newFieldError(ErrCodeInvalidArgument, name, "Value of '"+name+"' is private, it should be passed in the transient map "+
+			"and its argument should be empty")
+	}
+	transient, err := stub.GetTransient()
+	if err != nil {
+		return "", wrapError(err, "Error getting transient data: ")
+	}
+	return string(transient[name]), nil
+}
+
+func isPrivateColumn(tableName, columnName string) bool {
+	for _, c := range privateColumns[tableName].Columns {
+		if c == columnName {
+			return true
+		}
+	}
+	return false
+}
+
+// Arguments of private columns are taken from the transient map, arguments of rows which are public are kept
+func getPrivateColumnArg(stub shim.ChaincodeStubInterface, tbl *Table, row Row, columnName, value string) (string, error) {
+	if !isPrivateColumn(tbl.Name, columnName) || isPrivateValueHash(value) {
+		return value, nil
+	}
+	if def := privateColumns[tbl.Name]; value != "" && def.isPrivate != nil {
+		isPrivate, err := def.isPrivate(stub, tbl, row)
+		if err != nil || !isPrivate {
+			return value, err
+		}
+	}
+	return getTransientValue(stub, columnName, value)
+}
+
+func getColumnIndex(tbl *Table, columnName string) int {
+	for i, cd := range tbl.ColumnDefinitions {
+		if cd.Name == columnName {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the column value of the row of the table or empty string if the table has no such column
+func getRowColumnValue(tbl *Table, row Row, columnName string) string {
+	i := getColumnIndex(tbl, columnName)
+	if i < 0 || i >= len(row.Columns) {
+		return ""
+	}
+	return row.Columns[i].Value
+}
+
+// Moves values of private columns of the row to the collection and replaces them with hashes.
+// Hashes are kept, so rows which were read can be written back.
+func sealPrivateColumns(stub shim.ChaincodeStubInterface, tbl *Table, row Row) error {
+	def, ok := privateColumns[tbl.Name]
+	if !ok {
+		return nil
+	}
+	if def.isPrivate != nil {
+		isPrivate, err := def.isPrivate(stub, tbl, row)
+		if err != nil || !isPrivate {
+			return err
+		}
+	}
+	loanRequestID, err := def.getLoanRequestID(stub, tbl, row)
+	if err != nil {
+		return wrapError(err, "Error getting deal of the row in sealPrivateColumns func: ")
+	}
+
+	for _, columnName := range def.Columns {
+		i := getColumnIndex(tbl, columnName)
+		if i < 0 {
+			continue
+		}
+		value := row.Columns[i].Value
+		if value == "" || isPrivateValueHash(value) {
+			continue
+		}
+		hash, err := sealPrivateValue(stub, getDealCollectionName(loanRequestID), tbl.Name, row.Columns[0].Value, columnName, value)
+		if err != nil {
+			return wrapError(err, "Error in sealPrivateColumns func: ")
+		}
+		row.Columns[i] = &Column{Value: hash}
+	}
+	return nil
+}
+
+// Saves the value of the private column of the row in the collection of the deal and returns its hash.
+// The value which is written again keeps its salt, so the row keeps its hash.
+func sealPrivateValue(stub shim.ChaincodeStubInterface, collection, tableName, keyValue, columnName, value string) (string, error) {
+	key, err := getPrivateValueKey(stub, tableName, keyValue, columnName)
+	if err != nil {
+		return "", newError(ErrCodeInvalidArgument, "Wrong key '"+keyValue+"' of '"+tableName+"' table: "+err.Error())
+	}
+	v, err := readPrivateValue(stub, collection, key)
+	if err != nil {
+		return "", wrapError(err, "Error reading private value of '"+columnName+"': ")
+	}
+	if v == nil || v.Value != value {
+		salt, err := newPrivateValueSalt(stub, key)
+		if err != nil {
+			return "", err
+		}
+		v = &privateValue{Salt: salt, Value: value}
+		b, _ := json.Marshal(v)
+		err = stub.PutPrivateData(collection, key, b)
+		if err != nil {
+			return "", wrapError(err, "Error saving private value of '"+columnName+"': ")
+		}
+	}
+	return getPrivateValueHash(v.Salt, value), nil
+}
+
+// Returns the private value of the column or nil if it is not in the collection of the deal or does not match the hash
+func getMatchingPrivateValue(stub shim.ChaincodeStubInterface, collection, tableName, keyValue, columnName, hash string) (*privateValue, error) {
+	key, err := getPrivateValueKey(stub, tableName, keyValue, columnName)
+	if err != nil {
+		return nil, err
+	}
+	v, err := readPrivateValue(stub, collection, key)
+	if err != nil || v == nil || getPrivateValueHash(v.Salt, v.Value) != hash {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Returns the private value of the column or empty string if it is not in the collection of the deal or does not match the hash
+func getPrivateValue(stub shim.ChaincodeStubInterface, collection, tableName, keyValue, columnName, hash string) (string, error) {
+	v, err := getMatchingPrivateValue(stub, collection, tableName, keyValue, columnName, hash)
+	if err != nil || v == nil {
+		return "", err
+	}
+	return v.Value, nil
+}
+
+// Returns the Loan Request of the row or empty string if the row has no private values of a deal
+func getRowDealID(stub shim.ChaincodeStubInterface, tbl *Table, row Row) (string, error) {
+	if def, ok := privateColumns[tbl.Name]; ok {
+		return def.getLoanRequestID(stub, tbl, row)
+	}
+	if columnName, ok := dealColumns[tbl.Name]; ok {
+		return getRowColumnValue(tbl, row, columnName), nil
+	}
+	return "", nil
+}
+
+// Moves private values of the row and of rows referencing it to the collection of the new deal.
+// The row should have values it had before the move, its changed values are sealed again afterwards.
+func moveDealPrivateValues(stub shim.ChaincodeStubInterface, tbl *Table, row Row, oldLoanRequestID, loanRequestID string) error {
+	if oldLoanRequestID == "" || oldLoanRequestID == loanRequestID {
+		return nil
+	}
+	from, to := getDealCollectionName(oldLoanRequestID), getDealCollectionName(loanRequestID)
+	keyValue := row.Columns[0].Value
+
+	for _, columnName := range privateColumns[tbl.Name].Columns {
+		if !isPrivateValueHash(getRowColumnValue(tbl, row, columnName)) {
+			continue
+		}
+		key, err := getPrivateValueKey(stub, tbl.Name, keyValue, columnName)
+		if err != nil {
+			return newError(ErrCodeInvalidArgument, "Wrong key '"+keyValue+"' of '"+tbl.Name+"' table: "+err.Error())
+		}
+		v, err := readPrivateValue(stub, from, key)
+		if err != nil {
+			return wrapError(err, "Error reading private value of '"+columnName+"': ")
+		}
+		if v == nil {
+			return newError(ErrCodeNotFound, "Private value of '"+columnName+"' of row '"+keyValue+"' in '"+tbl.Name+
+				"' table is not found in the collection of Loan Request '"+oldLoanRequestID+"'")
+		}
+		b, _ := json.Marshal(v)
+		err = stub.PutPrivateData(to, key, b)
+		if err == nil {
+			err = stub.DelPrivateData(from, key)
+		}
+		if err != nil {
+			return wrapError(err, "Error moving private value of '"+columnName+"': ")
+		}
+	}
+
+	for _, fk := range foreignKeys {
+		if fk.RefTableName != tbl.Name {
+			continue
+		}
+		if _, ok := privateColumns[fk.TableName]; !ok {
+			continue
+		}
+		refTbl, rows, err := getRowsByColumnValue(stub, []string{fk.TableName, fk.ColumnName, keyValue})
+		if err != nil {
+			return wrapError(err, "Error getting rows of '"+fk.TableName+"' table: ")
+		}
+		for _, r := range rows {
+			err = moveDealPrivateValues(stub, refTbl, r, oldLoanRequestID, loanRequestID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	logInfo(stub, "Private values are moved", logFields{"table": tbl.Name, "key": keyValue, "from": from, "to": to})
+	return nil
+}
+
+// Returns true if the caller is the arranger bank of the deal or one of the participant banks
+func canReadPrivateValues(stub shim.ChaincodeStubInterface, def privateColumnsDefinition, tbl *Table, row Row, loanRequestID string) (bool, error) {
+	if !isAuthenticationEnabled {
+		return true, nil
+	}
+	role, err := getCallerAttribute(stub, CA_Role)
+	if err != nil || role != FR_Bank {
+		return false, err
+	}
+	bankID, err := getCallerAttribute(stub, CA_BankID)
+	if err != nil {
+		return false, err
+	}
+
+	arrangerBankID, err := getTableColValueByKey(stub, LoanRequestsTableName, loanRequestID, LR_ArrangerBankIDColName)
+	if err != nil || bankID == arrangerBankID {
+		return err == nil, err
+	}
+	participantBankIDs, err := def.getParticipantBankIDs(stub, tbl, row, loanRequestID)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range participantBankIDs {
+		if id == bankID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Returns copies of rows with values of private columns the caller can read, other private columns keep hashes.
+// Values missing in the collection of the endorsing peer are kept as hashes too.
+func unsealPrivateColumns(stub shim.ChaincodeStubInterface, tbl *Table, rows []Row) ([]Row, error) {
+	def, ok := privateColumns[tbl.Name]
+	if !ok {
+		return rows, nil
+	}
+
+	unsealed := make([]Row, len(rows))
+	for n, row := range rows {
+		unsealed[n] = row
+		var sealed []int
+		for _, columnName := range def.Columns {
+			i := getColumnIndex(tbl, columnName)
+			if i >= 0 && i < len(row.Columns) && isPrivateValueHash(row.Columns[i].Value) {
+				sealed = append(sealed, i)
+			}
+		}
+		if len(sealed) == 0 {
+			continue
+		}
+
+		loanRequestID, err := def.getLoanRequestID(stub, tbl, row)
+		if err != nil {
+			return nil, wrapError(err, "Error getting deal of the row in unsealPrivateColumns func: ")
+		}
+		canRead, err := canReadPrivateValues(stub, def, tbl, row, loanRequestID)
+		if err != nil {
+			return nil, wrapError(err, "Error checking readers of the row in unsealPrivateColumns func: ")
+		}
+		if !canRead {
+			continue
+		}
+
+		unsealed[n] = copyRow(row)
+		for _, i := range sealed {
+			value, err := getPrivateValue(stub, getDealCollectionName(loanRequestID), tbl.Name, row.Columns[0].Value,
+				tbl.ColumnDefinitions[i].Name, row.Columns[i].Value)
+			if err != nil {
+				logDebug(stub, "Private value is not read", logFields{"table": tbl.Name, "key": row.Columns[0].Value,
+					"column": tbl.ColumnDefinitions[i].Name, "error": err.Error()})
+				continue
+			}
+			if value != "" {
+				unsealed[n].Columns[i] = &Column{Value: value}
+			}
+		}
+	}
+	return unsealed, nil
+}
+
+// Query: table, key, column, disclosed value and salt
+func verifyPrivateValue(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 5 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in verifyPrivateValue func. Expecting 5")
+	}
+	tableName, keyValue, columnName, value, salt := args[0], args[1], args[2], args[3], args[4]
+
+	if !isPrivateColumn(tableName, columnName) {
+		return nil, newFieldError(ErrCodeInvalidArgument, columnName, "Column '"+columnName+"' of '"+tableName+"' table is not private")
+	}
+
+	hash, err := getTableColValueByKey(stub, tableName, keyValue, columnName)
+	if err != nil {
+		return nil, wrapError(err, "Error in verifyPrivateValue func: ")
+	}
+	if !isPrivateValueHash(hash) {
+		return nil, newError(ErrCodeInvalidState, "Value of '"+columnName+"' of row '"+keyValue+"' in '"+tableName+"' table is public")
+	}
+	return []byte(strconv.FormatBool(getPrivateValueHash(salt, value) == hash)), nil
+}
+
+// Query: table, key, column
+func getPrivateValueSalt(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getPrivateValueSalt func. Expecting 3")
+	}
+	tableName, keyValue, columnName := args[0], args[1], args[2]
+
+	if !isPrivateColumn(tableName, columnName) {
+		return nil, newFieldError(ErrCodeInvalidArgument, columnName, "Column '"+columnName+"' of '"+tableName+"' table is not private")
+	}
+	tbl, err := getTable(stub, tableName)
+	if err != nil {
+		return nil, wrapError(err, "Error in getPrivateValueSalt func: ")
+	}
+	row, err := getRowByKeyValue(stub, tableName, keyValue)
+	if err != nil {
+		return nil, wrapError(err, "Error in getPrivateValueSalt func: ")
+	}
+	hash := getRowColumnValue(tbl, row, columnName)
+	if !isPrivateValueHash(hash) {
+		return nil, newError(ErrCodeInvalidState, "Value of '"+columnName+"' of row '"+keyValue+"' in '"+tableName+"' table is public")
+	}
+
+	def := privateColumns[tableName]
+	loanRequestID, err := def.getLoanRequestID(stub, tbl, row)
+	if err != nil {
+		return nil, wrapError(err, "Error getting deal of the row in getPrivateValueSalt func: ")
+	}
+	canRead, err := canReadPrivateValues(stub, def, tbl, row, loanRequestID)
+	if err != nil {
+		return nil, wrapError(err, "Error checking readers of the row in getPrivateValueSalt func: ")
+	}
+	if !canRead {
+		return nil, newError(ErrCodePermissionDenied, "Private values of row '"+keyValue+"' in '"+tableName+"' table are not readable by the caller")
+	}
+
+	v, err := getMatchingPrivateValue(stub, getDealCollectionName(loanRequestID), tableName, keyValue, columnName, hash)
+	if err != nil {
+		return nil, wrapError(err, "Error in getPrivateValueSalt func: ")
+	}
+	if v == nil {
+		return nil, newError(ErrCodeNotFound, "Private value of '"+columnName+"' of row '"+keyValue+"' in '"+tableName+"' table is not found")
+	}
+	return []byte(v.Salt), nil
+}
+
+func getLoanTermProposalLoanRequestID(stub shim.ChaincodeStubInterface, tbl *Table, row Row) (string, error) {
+	return getTableColValueByKey(stub, LoanTermTableName, getRowColumnValue(tbl, row, LTP_LoanTermIDColName), LT_LoanRequestIDColName)
+}
+
+// Proposals are private until their Loan Term is adopted
+func isLoanTermProposalPrivate(stub shim.ChaincodeStubInterface, tbl *Table, row Row) (bool, error) {
+	status, err := getTableColValueByKey(stub, LoanTermTableName, getRowColumnValue(tbl, row, LTP_LoanTermIDColName), LT_LoanTermStatusColName)
+	if err != nil {
+		return false, err
+	}
+	return status != LT_StatusAdopted, nil
+}
+
+// Banks with Loan Negotiations of the Loan Request take part in the deal
+func getDealParticipantBankIDs(stub shim.ChaincodeStubInterface, tbl *Table, row Row, loanRequestID string) ([]string, error) {
+	return getTableColValuesInSlice(stub, []string{LoanNegotiationsTableName, LN_ParticipantBankIDColName, LN_LoanRequestIDColName, loanRequestID})
+}
+
+// Invoke: JSON object with the Loan Term ID
+func publishLoanTermProposals(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getNamedArgValues(stub, args, []string{LTP_LoanTermIDColName})
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 1 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 1")
+	}
+	loanTermID := args[0]
+
+	status, err := getTableColValueByKey(stub, LoanTermTableName, loanTermID, LT_LoanTermStatusColName)
+	if err != nil {
+		return nil, wrapError(err, "Error in publishLoanTermProposals func: ")
+	}
+	if status != LT_StatusAdopted {
+		return nil, newError(ErrCodeInvalidState, "Loan Term '"+loanTermID+"' is not "+LT_StatusAdopted)
+	}
+	loanRequestID, err := getTableColValueByKey(stub, LoanTermTableName, loanTermID, LT_LoanRequestIDColName)
+	if err != nil {
+		return nil, wrapError(err, "Error in publishLoanTermProposals func: ")
+	}
+	arrangerBankID, err := getTableColValueByKey(stub, LoanRequestsTableName, loanRequestID, LR_ArrangerBankIDColName)
+	if err != nil {
+		return nil, wrapError(err, "Error in publishLoanTermProposals func: ")
+	}
+
+	///////////////////////////Security check////////////////////////////
+	check, err := checkRowPermissionsByBankId(stub, arrangerBankID)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in publishLoanTermProposals func or returned false: ")
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	tbl, rows, err := getRowsByColumnValue(stub, []string{LoanTermProposalTableName, LTP_LoanTermIDColName, loanTermID})
+	if err != nil {
+		return nil, wrapError(err, "Error in publishLoanTermProposals func: ")
+	}
+	for _, row := range rows {
+		keyValue := row.Columns[0].Value
+		for _, columnName := range privateColumns[LoanTermProposalTableName].Columns {
+			hash := getRowColumnValue(tbl, row, columnName)
+			if !isPrivateValueHash(hash) {
+				continue
+			}
+			value, err := getPrivateValue(stub, getDealCollectionName(loanRequestID), LoanTermProposalTableName, keyValue, columnName, hash)
+			if err == nil && value == "" {
+				err = newError(ErrCodeNotFound, "Private value of '"+columnName+"' of Loan Term Proposal '"+keyValue+"' is not found")
+			}
+			if err != nil {
+				return nil, wrapError(err, "Error in publishLoanTermProposals func: ")
+			}
+
+			// The Loan Term is adopted, so the value is written to the row
+			_, err = updateTableField(stub, []string{LoanTermProposalTableName, keyValue, columnName, value})
+			if err != nil {
+				return nil, wrapError(err, "Error in publishLoanTermProposals func: ")
+			}
+			key, err := getPrivateValueKey(stub, LoanTermProposalTableName, keyValue, columnName)
+			if err == nil {
+				err = stub.DelPrivateData(getDealCollectionName(loanRequestID), key)
+			}
+			if err != nil {
+				return nil, wrapError(err, "Error deleting private value in publishLoanTermProposals func: ")
+			}
+		}
+	}
+
+	logInfo(stub, "Loan Term Proposals are published", logFields{"key": loanTermID, "count": strconv.Itoa(len(rows))})
+	return nil, nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+// Clients pass values of private columns and salts of reveals in the transient map, so named arguments of tests
+// are split the same way: values are moved to the transient map and their arguments are left empty.
+// Hashes are kept in arguments, as they are written back.
+func getTransientArgs(values map[string]string) (map[string]string, map[string][]byte) {
+	isPrivate := map[string]bool{SB_SaltArgName: true}
+	for _, def := range privateColumns {
+		for _, columnName := range def.Columns {
+			isPrivate[columnName] = true
+		}
+	}
+	args := make(map[string]string)
+	transient := make(map[string][]byte)
+	for name, value := range values {
+		args[name] = value
+		if isPrivate[name] && value != "" && !isPrivateValueHash(value) {
+			args[name] = ""
+			transient[name] = []byte(value)
+		}
+	}
+	return args, transient
+}
+
+// Invokes the function with named arguments, private values are passed in the transient map
+func mockInvokeWithTransient(stub *shimtest.MockStub, txID string, function string, values map[string]string) ([]byte, error) {
+	args, transient := getTransientArgs(values)
+	arg, _ := json.Marshal(args)
+	stub.TransientMap = transient
+	defer func() {
+		stub.TransientMap = nil
+	}()
+	return mockInvoke(stub, txID, function, []string{string(arg)})
+}
+
+// Returns the value kept in the collection of the deal, nil if it is missing
+func getCollectionValue(stub *shimtest.MockStub, loanRequestID, tableName, keyValue, columnName string) *privateValue {
+	key, _ := getPrivateValueKey(stub, tableName, keyValue, columnName)
+	b, ok := stub.PvtState[getDealCollectionName(loanRequestID)][key]
+	if !ok {
+		return nil
+	}
+	var v privateValue
+	json.Unmarshal(b, &v)
+	return &v
+}
+
+// Private values are taken from the transient map, kept in the collection of the deal and not written to the public state
+func TestSLSPrivateData_Sealing(t *testing.T) {
+	stub := shimtest.NewMockStub("private", new(SimpleChaincode))
+	checkInit(t, stub, []string{"mode=demo"})
+	actAsMaintainer()
+	defer stopActing()
+
+	stub.TransientMap = map[string][]byte{LN_AmountColName: []byte("250 M USD")}
+	arg, _ := json.Marshal(map[string]string{LN_LoanRequestIDColName: "1", LN_ParticipantBankIDColName: "11",
+		LN_AmountColName: "", LN_NegotiationStatusColName: "INVITED", LN_ParticipantBankCommentColName: "", LN_DateColName: "01-02-2016"})
+	_, err := mockInvoke(stub, "add", "addLoanNegotiation", []string{string(arg)})
+	stub.TransientMap = nil
+	if err != nil {
+		fmt.Println("Failed adding Loan Negotiation", err)
+		t.FailNow()
+	}
+	for k, v := range stub.State {
+		if strings.Contains(string(v), "250 M USD") {
+			fmt.Println("Private value is in public state", k, string(v))
+			t.FailNow()
+		}
+	}
+	amount := getCollectionValue(stub, "1", LoanNegotiationsTableName, "7", LN_AmountColName)
+	if amount == nil || amount.Value != "250 M USD" || len(amount.Salt) != 64 {
+		fmt.Println("Private value is not in the collection of the deal with its salt", stub.PvtState)
+		t.FailNow()
+	}
+	if getCollectionValue(stub, "1", LoanNegotiationsTableName, "7", LN_ParticipantBankCommentColName) != nil {
+		fmt.Println("Empty private value is saved", stub.PvtState)
+		t.FailNow()
+	}
+	checkQuery(t, stub, "getPrivateValueSalt", []string{LoanNegotiationsTableName, "7", LN_AmountColName}, amount.Salt)
+	checkQuery(t, stub, "verifyPrivateValue", []string{LoanNegotiationsTableName, "7", LN_AmountColName, "250 M USD", amount.Salt}, "true")
+	checkQuery(t, stub, "verifyPrivateValue", []string{LoanNegotiationsTableName, "7", LN_AmountColName, "250 M USD", ""}, "false")
+
+	// Writing the same value again keeps the hash and changes nothing
+	patch := func(amount string) string {
+		eTag, err := mockInvokeWithTransient(stub, "patch", "patchLoanNegotiation", map[string]string{LN_LoanNegotiationIDColName: "7",
+			LN_AmountColName: amount})
+		if err != nil {
+			fmt.Println("Failed patching Loan Negotiation", err)
+			t.FailNow()
+		}
+		return string(eTag)
+	}
+	eTag := patch("250 M USD")
+	history, _ := mockQuery(stub, "getAuditLogByEntity", []string{LoanNegotiationsTableName, "7"})
+	if patch("250 M USD") != eTag || patch("300 M USD") == eTag {
+		fmt.Println("ETag does not follow private values")
+		t.FailNow()
+	}
+	changed, _ := mockQuery(stub, "getAuditLogByEntity", []string{LoanNegotiationsTableName, "7"})
+	if strings.Count(string(changed), "},{") != strings.Count(string(history), "},{")+1 || strings.Contains(string(changed), "300 M USD") {
+		fmt.Println("Wrong audit log of private values", string(changed))
+		t.FailNow()
+	}
+	salt := getCollectionValue(stub, "1", LoanNegotiationsTableName, "7", LN_AmountColName).Salt
+	if salt == amount.Salt {
+		fmt.Println("Salt of the changed value is kept")
+		t.FailNow()
+	}
+	checkQuery(t, stub, "verifyPrivateValue", []string{LoanNegotiationsTableName, "7", LN_AmountColName, "300 M USD", salt}, "true")
+
+	// Only changed columns are taken from the transient map, salts are derived from the salt of the client
+	stub.TransientMap = map[string][]byte{LN_AmountColName: []byte("400 M USD"), LN_ParticipantBankCommentColName: []byte("Not changed"),
+		PrivateValueSaltName: []byte("client salt of 16+ bytes")}
+	arg, _ = json.Marshal(map[string]string{LN_LoanNegotiationIDColName: "7", LN_AmountColName: ""})
+	_, err = mockInvoke(stub, "transient", "patchLoanNegotiation", []string{string(arg)})
+	stub.TransientMap = nil
+	if err != nil {
+		fmt.Println("Failed patching Loan Negotiation from the transient map", err)
+		t.FailNow()
+	}
+	key, _ := getPrivateValueKey(stub, LoanNegotiationsTableName, "7", LN_AmountColName)
+	seeded := sha256.Sum256([]byte("client salt of 16+ bytes" + key))
+	salt = hex.EncodeToString(seeded[:])
+	checkQuery(t, stub, "verifyPrivateValue", []string{LoanNegotiationsTableName, "7", LN_AmountColName, "400 M USD", salt}, "true")
+	checkQuery(t, stub, "getRowETag", []string{LoanNegotiationsTableName, "7"}, patch("400 M USD"))
+	value, _ := mockQuery(stub, "getLoanNegotiationByKey", []string{"7"})
+	if strings.Contains(string(value), "Not changed") {
+		fmt.Println("Transient value of not changed column is saved", string(value))
+		t.FailNow()
+	}
+
+	// Values in arguments would be visible in blocks, hashes which were read are written back
+	arg, _ = json.Marshal(map[string]string{LN_LoanNegotiationIDColName: "7", LN_AmountColName: "500 M USD"})
+	_, err = mockInvoke(stub, "disclosed", "patchLoanNegotiation", []string{string(arg)})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, LN_AmountColName)
+	_, err = mockInvoke(stub, "disclosed", "updateTableField", []string{LoanNegotiationsTableName, "7", LN_ParticipantBankCommentColName, "Disclosed"})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, LN_ParticipantBankCommentColName)
+	arg, _ = json.Marshal(map[string]string{LN_LoanRequestIDColName: "1", LN_ParticipantBankIDColName: "12", LN_AmountColName: "500 M USD"})
+	_, err = mockInvoke(stub, "disclosed", "addLoanNegotiation", []string{string(arg)})
+	checkErrorCode(t, err, ErrCodeInvalidArgument, LN_AmountColName)
+	eTag = patch("400 M USD")
+	hash400, _ := getTableColValueByKey(stub, LoanNegotiationsTableName, "7", LN_AmountColName)
+	if patch(hash400) != eTag {
+		fmt.Println("Hash written back changed the row")
+		t.FailNow()
+	}
+}
+
+// Banks of other deals and the assigner get hashes, values which do not match hashes are not returned
+func TestSLSPrivateData_Readers(t *testing.T) {
+	stub := shimtest.NewMockStub("private", new(SimpleChaincode))
+	checkInit(t, stub, []string{"mode=demo"})
+	defer stopActing()
+
+	getAmount := func(caller testIdentity) string {
+		actAs(caller)
+		result, err := mockQuery(stub, "getLoanNegotiationByKey", []string{"2"})
+		var rows []map[string]string
+		if err == nil {
+			err = json.Unmarshal(result, &rows)
+		}
+		if err != nil || len(rows) != 1 {
+			fmt.Println("Bank", caller[CA_BankID], "failed getting Loan Negotiation", string(result), err)
+			t.FailNow()
+		}
+		return rows[0][LN_AmountColName]
+	}
+
+	// Loan Negotiation 2 of Loan Request 1 arranged by bank 6 is of bank 9
+	amount := getCollectionValue(stub, "1", LoanNegotiationsTableName, "2", LN_AmountColName)
+	hash := getPrivateValueHash(amount.Salt, "100 M USD")
+	for _, test := range []struct {
+		caller testIdentity
+		value  string
+	}{
+		{bank6UserIdentity, "100 M USD"},
+		{testIdentity{CA_Role: FR_Bank, CA_BankID: "9", CA_UserID: "13"}, "100 M USD"},
+		{testIdentity{CA_Role: FR_Bank, CA_BankID: "10", CA_UserID: "17"}, hash},
+		{bank7UserIdentity, hash},
+		{assignerIdentity, hash},
+	} {
+		if amount := getAmount(test.caller); amount != test.value {
+			fmt.Println("Bank", test.caller[CA_BankID], "got wrong amount", amount)
+			t.FailNow()
+		}
+	}
+
+	// The collection of the endorsing peer may have another value
+	key, _ := getPrivateValueKey(stub, LoanNegotiationsTableName, "2", LN_AmountColName)
+	stub.PvtState[getDealCollectionName("1")][key], _ = json.Marshal(privateValue{Salt: amount.Salt, Value: "999 M USD"})
+	if amount := getAmount(bank6UserIdentity); amount != hash {
+		fmt.Println("Value which does not match the hash is returned", amount)
+		t.FailNow()
+	}
+}
+
+// Values are kept in the collection of their deal and move with rows to other deals, the collection configuration
+// has the banks of every deal as members
+func TestSLSPrivateData_DealCollections(t *testing.T) {
+	s := newScenario(t, []string{"mode=demo", BankMSPIDsSettingName + "=6:SR1MSP, 7:DNBMSP, 9:JPMMSP, 10:BarclaysMSP, 11:MizuhoMSP"})
+	defer s.close()
+
+	s.in("generate collections config")
+	s.checkText("getDealCollectionsConfig", nil, `[`+
+		`{"name":"deal-1","policy":"OR('BarclaysMSP.member','JPMMSP.member','SR1MSP.member')","requiredPeerCount":0,"maxPeerCount":3,"blockToLive":0,"memberOnlyRead":true,"memberOnlyWrite":true},`+
+		`{"name":"deal-2","policy":"OR('DNBMSP.member','JPMMSP.member','MizuhoMSP.member')","requiredPeerCount":0,"maxPeerCount":3,"blockToLive":0,"memberOnlyRead":true,"memberOnlyWrite":true}]`)
+	if getCollectionValue(s.stub, "1", LoanNegotiationsTableName, "2", LN_AmountColName) == nil ||
+		getCollectionValue(s.stub, "2", LoanNegotiationsTableName, "2", LN_AmountColName) != nil {
+		s.fail("Amount of Loan Negotiation 2 is not in the collection of Loan Request 1")
+	}
+
+	s.in("write without salt")
+	s.stub.TransientMap = map[string][]byte{LN_AmountColName: []byte("150 M USD"), PrivateValueSaltName: []byte("short")}
+	arg, _ := json.Marshal(map[string]string{LN_LoanNegotiationIDColName: "2", LN_AmountColName: ""})
+	_, err := s.tryInvoke("patchLoanNegotiation", []string{string(arg)})
+	s.stub.TransientMap = nil
+	s.checkCode(err, ErrCodeInvalidArgument, "patchLoanNegotiation")
+
+	s.in("move negotiation")
+	hash, _ := getTableColValueByKey(s.stub, LoanNegotiationsTableName, "2", LN_AmountColName)
+	s.invoke("patchLoanNegotiation", map[string]string{LN_LoanNegotiationIDColName: "2", LN_LoanRequestIDColName: "2"})
+	if getCollectionValue(s.stub, "1", LoanNegotiationsTableName, "2", LN_AmountColName) != nil ||
+		getCollectionValue(s.stub, "2", LoanNegotiationsTableName, "2", LN_AmountColName) == nil {
+		s.fail("Amount of the moved Loan Negotiation is not moved to the collection of Loan Request 2")
+	}
+	s.checkRow("getLoanNegotiationByKey", "2", map[string]string{LN_AmountColName: hash})
+	s.as(dnbIdentity)
+	s.checkRow("getLoanNegotiationByKey", "2", map[string]string{LN_AmountColName: "100 M USD"})
+
+	s.as(assignerIdentity).in("move term")
+	s.invoke("addLoanTerm", map[string]string{LT_LoanRequestIDColName: "1", LT_ParagraphNumberColName: "1",
+		LT_LoanTermTextColName: "Tenor is 5 years", LT_LoanTermStatusColName: "DRAFT"})
+	s.invoke("addLoanTermProposal", map[string]string{LTP_LoanTermIDColName: "1", LTP_LoanTermProposalTextColName: "Tenor is 7 years"})
+	s.invoke("patchLoanTerm", map[string]string{LT_LoanTermIDColName: "1", LT_LoanRequestIDColName: "2"})
+	if getCollectionValue(s.stub, "1", LoanTermProposalTableName, "1", LTP_LoanTermProposalTextColName) != nil ||
+		getCollectionValue(s.stub, "2", LoanTermProposalTableName, "1", LTP_LoanTermProposalTextColName) == nil {
+		s.fail("Text of the proposal of the moved Loan Term is not moved to the collection of Loan Request 2")
+	}
+	s.as(dnbIdentity)
+	s.checkRow("getLoanTermProposalByKey", "1", map[string]string{LTP_LoanTermProposalTextColName: "Tenor is 7 years"})
+
+	s.as(assignerIdentity).in("invite bank without MSP ID")
+	s.invoke("addLoanNegotiation", map[string]string{LN_LoanRequestIDColName: "1", LN_ParticipantBankIDColName: "8",
+		LN_NegotiationStatusColName: LN_StatusInvited})
+	s.queryFails(ErrCodeInvalidState, "getDealCollectionsConfig")
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	//"errors"
+	//"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Function modes
+const FM_Read = "read"
+const FM_Write = "write"
+
+//Roles required by functions
+const FR_Assigner = "assigner"
+const FR_Bank = "bank"
+
+//Argument formats
+const AF_Positional = "positional"
+const AF_Object = "object"
+
+//Keys of JSON object arguments with table columns
+const AK_Generated = "generated"
+const AK_Optional = "optional"
+const AK_Required = "required"
+
+//Function results
+const RT_None = "none"
+const RT_Text = "text"
+const RT_Rows = "rows"
+const RT_JSON = "json"
+
+type argumentDefinition struct {
+	Name        string
+	IsOptional  bool   `json:",omitempty"`
+	Description string `json:",omitempty"`
+}
+
+type functionHandler func(shim.ChaincodeStubInterface, []string) ([]byte, error)
+
+type functionDefinition struct {
+	Name        string
+	Mode        string
+	Role        string `json:",omitempty"`
+	ArgsFormat  string
+	Table       string `json:",omitempty"`
+	Key         string `json:",omitempty"`
+	Args        []argumentDefinition
+	Result      string
+	Description string
+
+	// Functions which are recorded in MaintenanceLog
+	isLogged bool
+	// Administration functions skip entity permission checks, so their role is checked even if authentication is disabled
+	isMaintenance bool
+	// Checks arguments before the handler is called, e.g. maintenance allowlists
+	check   func(shim.ChaincodeStubInterface, []string) error
+	handler functionHandler
+}
+
+// Functions and columns of their tables returned by describeAPI
+type apiCatalogue struct {
+	Functions []functionDefinition
+	Tables    map[string][]string
+}
+
+// Functions available to Invoke by name, entity files register their functions in init()
+var functionRegistry = make(map[string]*functionDefinition)
+
+// Last argument of list and quantity queries
+var includeDeletedArg = argumentDefinition{Name: "option", IsOptional: true, Description: "'" + IncludeDeletedOption + "' to include deleted rows"}
+
+// ============================================================================================================================
+// Invoke runs write functions and queries of read functions from the registry. Role and number of positional arguments
+// are checked before the function is called, checks which depend on ledger data are made by functions themselves.
+// Functions with JSON object arguments of a table get table columns as arguments, positional arguments of them
+// are checked by functions while they are enabled.
+// describeAPI query returns all functions, so clients can be generated from it: the catalogue is kept in api/catalogue.json
+// and client/slsclient and client/ts are generated from it by clientgen.
+// ============================================================================================================================
+
+//go:generate go test -run TestSLSChaincode_APICatalogue -update-catalogue
+//go:generate go run ./clientgen
+
+func registerFunction(d functionDefinition) {
+	if _, ok := functionRegistry[d.Name]; ok {
+		panic("Function '" + d.Name + "' is registered twice")
+	}
+	if d.ArgsFormat == "" {
+		d.ArgsFormat = AF_Positional
+	}
+	// Write functions return nothing and read functions of a table return its rows unless the result is given
+	if d.Result == "" {
+		switch {
+		case d.Mode == FM_Write:
+			d.Result = RT_None
+		case d.Table != "":
+			d.Result = RT_Rows
+		default:
+			d.Result = RT_JSON
+		}
+	}
+	functionRegistry[d.Name] = &d
+}
+
+func checkFunctionArgs(d *functionDefinition, args []string) error {
+	if d.ArgsFormat == AF_Object {
+		return nil
+	}
+	var required int
+	for _, a := range d.Args {
+		if !a.IsOptional {
+			required++
+		}
+	}
+	if len(args) < required || len(args) > len(d.Args) {
+		expected := strconv.Itoa(required)
+		if required != len(d.Args) {
+			expected += " to " + strconv.Itoa(len(d.Args))
+		}
+		return newError(ErrCodeInvalidArgument, "Incorrect number of arguments in "+d.Name+" func. Expecting "+expected)
+	}
+	return nil
+}
+
+// Returns the mode of the function, unknown functions are run by Invoke as write functions and fail there
+func getFunctionMode(function string) string {
+	if d, ok := functionRegistry[function]; ok {
+		return d.Mode
+	}
+	return FM_Write
+}
+
+// Runs the function of the mode, Invoke runs write functions and queries run read ones
+func runFunction(stub shim.ChaincodeStubInterface, mode, function string, args []string) ([]byte, error) {
+	d, ok := functionRegistry[function]
+	if !ok || d.Mode != mode {
+		logWarning(stub, "Unknown "+mode+" function", nil)
+		if mode == FM_Write {
+			return nil, newError(ErrCodeInvalidArgument, "Received unknown function invocation")
+		}
+		return nil, newError(ErrCodeInvalidArgument, "Received unknown function query")
+	}
+
+	if d.Role != "" {
+		checkRole := checkAttribute
+		if d.isMaintenance {
+			checkRole = checkCallerAttribute
+		}
+		check, err := checkRole(stub, CA_Role, d.Role)
+		if !check {
+			return nil, wrapError(err, "Function '"+function+"' is available to '"+d.Role+"' role only: ")
+		}
+	}
+	err := checkFunctionArgs(d, args)
+	if err != nil {
+		return nil, err
+	}
+	if d.check != nil {
+		err = d.check(stub, args)
+		if err != nil {
+			return nil, wrapError(err, "Error in function '"+function+"': ")
+		}
+	}
+
+	result, err := d.handler(stub, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.isLogged {
+		err = addMaintenanceLog(stub, function, args)
+		if err != nil {
+			return nil, wrapError(err, "Failed recording maintenance function '"+function+"': ")
+		}
+	}
+	return result, nil
+}
+
+// Returns arguments of the function, columns of the table are arguments of JSON object functions
+func getFunctionArgs(stub shim.ChaincodeStubInterface, d *functionDefinition) ([]argumentDefinition, error) {
+	if d.ArgsFormat != AF_Object || d.Table == "" {
+		return d.Args, nil
+	}
+
+	names, err := getTableColumnNames(stub, d.Table)
+	if err != nil {
+		return nil, err
+	}
+	var args []argumentDefinition
+	for i, name := range names {
+		if i == 0 && d.Key == AK_Generated {
+			continue
+		}
+		arg := argumentDefinition{Name: name, IsOptional: i > 0 || d.Key != AK_Required}
+		if value, ok := columnDefaults[d.Table][name]; ok {
+			arg.Description = "Default is '" + value + "'"
+		}
+		args = append(args, arg)
+	}
+	return append(args, d.Args...), nil
+}
+
+// Returns definitions of all functions sorted by name and columns of their tables
+func describeAPI(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var names []string
+	for name := range functionRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	catalogue := apiCatalogue{Tables: make(map[string][]string)}
+	for _, name := range names {
+		d := *functionRegistry[name]
+		var err error
+		d.Args, err = getFunctionArgs(stub, &d)
+		if err != nil {
+			return nil, wrapError(err, "Error in describeAPI func: ")
+		}
+		if d.Args == nil {
+			d.Args = []argumentDefinition{}
+		}
+		catalogue.Functions = append(catalogue.Functions, d)
+
+		if _, ok := catalogue.Tables[d.Table]; d.Table != "" && !ok {
+			catalogue.Tables[d.Table], err = getTableColumnNames(stub, d.Table)
+			if err != nil {
+				return nil, wrapError(err, "Error in describeAPI func: ")
+			}
+		}
+	}
+
+	b, err := json.Marshal(catalogue)
+	if err != nil {
+		return nil, wrapError(err, "Error in describeAPI func: ")
+	}
+	return b, nil
+}
+
+func init() {
+	registerFunction(functionDefinition{Name: "describeAPI", Mode: FM_Read, handler: describeAPI,
+		Description: "Returns definitions of all functions and columns of their tables"})
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	//"errors"
+	//"fmt"
+	"sort"
+	//"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// ============================================================================================================================
+// Storage of tables. Business logic reads and writes tables through the table functions of SLSTables.go, which check
+// tables and rows and keep them in the repository of the transaction. Repositories only store them:
+// the composite key layout is used by the chaincode, the layout of the v0.6 table API is read by the migration of
+// legacy ledgers and the in-memory store lets tests run table functions without the shim. Rules which read tables only
+// take the Repository itself, e.g. getLoanRequestStatusByNegotiations, and are tested on the in-memory store.
+// ============================================================================================================================
+
+type Repository interface {
+	// Returns NOT_FOUND error if the table does not exist
+	GetTable(tableName string) (*Table, error)
+	PutTable(tbl *Table) error
+	// Deletes the definition of the table, its rows are deleted before
+	DeleteTable(tableName string) error
+
+	// Returns the row by key value, columns of the returned row are nil if the row does not exist
+	Get(tableName, keyValue string) (Row, error)
+	// Saves the row under the value of its first column
+	Put(tableName string, row Row) error
+	Delete(tableName, keyValue string) error
+	// Returns rows for which match returns true in key order, all rows if match is nil
+	Query(tableName string, match func(Row) bool) ([]Row, error)
+	// Calls visit for rows in key order until it returns false
+	Iterate(tableName string, visit func(Row) bool) error
+}
+
+// Repositories are created for every call, so implementations keep nothing but the stub
+var getRepository = getCompositeKeyRepository
+
+// Query of repositories which have Iterate only
+func queryByIterate(r Repository, tableName string, match func(Row) bool) ([]Row, error) {
+	var rows []Row
+	err := r.Iterate(tableName, func(row Row) bool {
+		if match == nil || match(row) {
+			rows = append(rows, row)
+		}
+		return true
+	})
+	return rows, err
+}
+
+// Key values are ordered by their length first, as keys of the composite key layout
+func isKeyValueLess(a, b string) bool {
+	la, lb := getKeyValueLength(a), getKeyValueLength(b)
+	if la != lb {
+		return la < lb
+	}
+	return a < b
+}
+
+// Rows and tables are copied, because callers change rows they get before they put them back
+func copyRow(row Row) Row {
+	if row.Columns == nil {
+		return row
+	}
+	columns := make([]*Column, len(row.Columns))
+	for i, c := range row.Columns {
+		columns[i] = &Column{Value: c.Value}
+	}
+	return Row{Columns: columns}
+}
+
+func copyTable(tbl *Table) *Table {
+	copied := &Table{Name: tbl.Name}
+	for _, cd := range tbl.ColumnDefinitions {
+		copied.ColumnDefinitions = append(copied.ColumnDefinitions, &ColumnDefinition{Name: cd.Name, Key: cd.Key})
+	}
+	return copied
+}
+
+// Repository which keeps tables in maps, it is not bound to a transaction
+type memoryRepository struct {
+	tables map[string]*Table
+	rows   map[string]map[string]Row
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{tables: make(map[string]*Table), rows: make(map[string]map[string]Row)}
+}
+
+// Returns the repository for every stub, tests replace getRepository with it
+func (r *memoryRepository) getRepository(stub shim.ChaincodeStubInterface) Repository {
+	return r
+}
+
+func (r *memoryRepository) GetTable(tableName string) (*Table, error) {
+	tbl, ok := r.tables[tableName]
+	if !ok {
+		return nil, newError(ErrCodeNotFound, "Table '"+tableName+"' does not exist")
+	}
+	return copyTable(tbl), nil
+}
+
+func (r *memoryRepository) PutTable(tbl *Table) error {
+	r.tables[tbl.Name] = copyTable(tbl)
+	return nil
+}
+
+func (r *memoryRepository) DeleteTable(tableName string) error {
+	delete(r.tables, tableName)
+	delete(r.rows, tableName)
+	return nil
+}
+
+func (r *memoryRepository) Get(tableName, keyValue string) (Row, error) {
+	return copyRow(r.rows[tableName][keyValue]), nil
+}
+
+func (r *memoryRepository) Put(tableName string, row Row) error {
+	rows, ok := r.rows[tableName]
+	if !ok {
+		rows = make(map[string]Row)
+		r.rows[tableName] = rows
+	}
+	rows[row.Columns[0].Value] = copyRow(row)
+	return nil
+}
+
+func (r *memoryRepository) Delete(tableName, keyValue string) error {
+	delete(r.rows[tableName], keyValue)
+	return nil
+}
+
+func (r *memoryRepository) Query(tableName string, match func(Row) bool) ([]Row, error) {
+	return queryByIterate(r, tableName, match)
+}
+
+func (r *memoryRepository) Iterate(tableName string, visit func(Row) bool) error {
+	var keys []string
+	for key := range r.rows[tableName] {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return isKeyValueLess(keys[i], keys[j]) })
+
+	// Rows may be deleted by visit
+	for _, key := range keys {
+		row, ok := r.rows[tableName][key]
+		if ok && !visit(copyRow(row)) {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+func newTestRow(values ...string) Row {
+	var row Row
+	for _, v := range values {
+		row.Columns = append(row.Columns, &Column{Value: v})
+	}
+	return row
+}
+
+func getTestKeys(rows []Row) []string {
+	var keys []string
+	for _, row := range rows {
+		keys = append(keys, row.Columns[0].Value)
+	}
+	return keys
+}
+
+// Every repository keeps tables and rows the same way, the transaction of stubs is open until the test ends
+func TestSLSRepository_Implementations(t *testing.T) {
+	stub := shimtest.NewMockStub("repository", new(SimpleChaincode))
+	stub.MockTransactionStart("repository")
+	defer endTxContext(stub)
+
+	for name, r := range map[string]Repository{
+		"memory":        newMemoryRepository(),
+		"composite key": getCompositeKeyRepository(stub),
+		"legacy table":  getLegacyTableRepository(stub),
+	} {
+		_, err := r.GetTable(testTableName)
+		if getErrorCode(err) != ErrCodeNotFound {
+			fmt.Println(name, "returned not existing table", err)
+			t.FailNow()
+		}
+		err = r.PutTable(&Table{Name: testTableName, ColumnDefinitions: []*ColumnDefinition{{Name: "TestRowID", Key: true},
+			{Name: "GroupName"}}})
+		if err != nil {
+			fmt.Println(name, "failed putting table", err)
+			t.FailNow()
+		}
+		tbl, err := r.GetTable(testTableName)
+		if err != nil || tbl.Name != testTableName || len(tbl.ColumnDefinitions) != 2 || !tbl.ColumnDefinitions[0].Key ||
+			tbl.ColumnDefinitions[1].Key || tbl.ColumnDefinitions[1].Name != "GroupName" {
+			fmt.Println(name, "returned wrong table", tbl, err)
+			t.FailNow()
+		}
+
+		for _, row := range []Row{newTestRow("10", "A"), newTestRow("2", "B"), newTestRow("1", "A"), newTestRow("", "")} {
+			err = r.Put(testTableName, row)
+			if err != nil {
+				fmt.Println(name, "failed putting row", err)
+				t.FailNow()
+			}
+		}
+		err = r.Put(testTableName, newTestRow("2", "A"))
+		if err != nil {
+			fmt.Println(name, "failed replacing row", err)
+			t.FailNow()
+		}
+
+		// Returned rows can be changed without changing stored ones
+		row, err := r.Get(testTableName, "2")
+		if err != nil || len(row.Columns) != 2 || row.Columns[1].Value != "A" {
+			fmt.Println(name, "returned wrong row", row, err)
+			t.FailNow()
+		}
+		row.Columns[1].Value = "C"
+		row, _ = r.Get(testTableName, "2")
+		if row.Columns[1].Value != "A" {
+			fmt.Println(name, "returned stored row")
+			t.FailNow()
+		}
+		row, err = r.Get(testTableName, "3")
+		if err != nil || row.Columns != nil {
+			fmt.Println(name, "returned not existing row", row, err)
+			t.FailNow()
+		}
+
+		// Integer keys are in numeric order
+		rows, err := r.Query(testTableName, nil)
+		if err != nil || fmt.Sprint(getTestKeys(rows)) != "[ 1 2 10]" {
+			fmt.Println(name, "returned rows in wrong order", getTestKeys(rows), err)
+			t.FailNow()
+		}
+		rows, err = r.Query(testTableName, func(row Row) bool { return row.Columns[1].Value == "A" })
+		if err != nil || fmt.Sprint(getTestKeys(rows)) != "[1 2 10]" {
+			fmt.Println(name, "returned wrong matching rows", getTestKeys(rows), err)
+			t.FailNow()
+		}
+		var visited int
+		err = r.Iterate(testTableName, func(row Row) bool {
+			visited++
+			return visited < 2
+		})
+		if err != nil || visited != 2 {
+			fmt.Println(name, "did not stop iteration", visited, err)
+			t.FailNow()
+		}
+
+		err = r.Delete(testTableName, "2")
+		if err == nil {
+			rows, err = r.Query(testTableName, nil)
+		}
+		if err != nil || fmt.Sprint(getTestKeys(rows)) != "[ 1 10]" {
+			fmt.Println(name, "returned deleted row", getTestKeys(rows), err)
+			t.FailNow()
+		}
+		err = r.DeleteTable(testTableName)
+		if err == nil {
+			_, err = r.GetTable(testTableName)
+		}
+		if getErrorCode(err) != ErrCodeNotFound {
+			fmt.Println(name, "returned deleted table", err)
+			t.FailNow()
+		}
+	}
+}
+
+// Keys of up to 10 digits keep their numeric order, the legacy table layout is not checked as v0.6 ledgers did not keep it
+func TestSLSRepository_LongKeys(t *testing.T) {
+	stub := shimtest.NewMockStub("repository", new(SimpleChaincode))
+	stub.MockTransactionStart("repository")
+	defer endTxContext(stub)
+
+	for name, r := range map[string]Repository{
+		"memory":        newMemoryRepository(),
+		"composite key": getCompositeKeyRepository(stub),
+	} {
+		err := r.PutTable(&Table{Name: testTableName, ColumnDefinitions: []*ColumnDefinition{{Name: "TestRowID", Key: true}}})
+		for _, keyValue := range []string{"1234567890", "10", "9"} {
+			if err == nil {
+				err = r.Put(testTableName, newTestRow(keyValue))
+			}
+		}
+		var rows []Row
+		if err == nil {
+			rows, err = r.Query(testTableName, nil)
+		}
+		if err != nil || fmt.Sprint(getTestKeys(rows)) != "[9 10 1234567890]" {
+			fmt.Println(name, "returned rows in wrong order", getTestKeys(rows), err)
+			t.FailNow()
+		}
+	}
+
+	err := getCompositeKeyRepository(stub).Put(testTableName, newTestRow(strings.Repeat("1", MaxKeyValueLength+1)))
+	if getErrorCode(err) != ErrCodeInvalidArgument {
+		fmt.Println("Too long key was accepted", err)
+		t.FailNow()
+	}
+}
+
+// Table rules run on the in-memory repository without a stub
+func TestSLSRepository_RulesWithoutShim(t *testing.T) {
+	discardLogs(t)
+	r := newMemoryRepository()
+	getRepository = r.getRepository
+	defer func() { getRepository = getCompositeKeyRepository }()
+
+	err := createTable(nil, testTableName, testTableColumns)
+	if err == nil {
+		err = CreateDeletedRowsTable(nil)
+	}
+	if err != nil {
+		fmt.Println("Failed creating tables", err)
+		t.FailNow()
+	}
+	for _, values := range [][]string{{"1", "A", "First"}, {"2", "B", "Second"}, {"3", "A", "Third"}} {
+		ok, err := insertRow(nil, testTableName, newTestRow(values...))
+		if !ok || err != nil {
+			fmt.Println("Failed inserting row", values, err)
+			t.FailNow()
+		}
+	}
+
+	// Rows are inserted once and replaced only if they exist, with all columns
+	ok, err := insertRow(nil, testTableName, newTestRow("1", "C", "Again"))
+	if ok || err != nil {
+		fmt.Println("Existing row is inserted again", err)
+		t.FailNow()
+	}
+	ok, err = replaceRow(nil, testTableName, newTestRow("4", "C", "Missing"))
+	if ok || err != nil {
+		fmt.Println("Not existing row is replaced", err)
+		t.FailNow()
+	}
+	_, err = replaceRow(nil, testTableName, newTestRow("1", "C"))
+	if getErrorCode(err) != ErrCodeInvalidArgument {
+		fmt.Println("Row without a column is accepted", err)
+		t.FailNow()
+	}
+
+	// Deleted rows are not returned by filters and counts, but their keys are not reused
+	ok, err = insertRow(nil, DeletedRowsTableName, newTestRow(getDeletedRowID(testTableName, "3"), testTableName, "3", "6", "1", "", "tx"))
+	if !ok || err != nil {
+		fmt.Println("Failed marking row deleted", err)
+		t.FailNow()
+	}
+	_, rows, err := getRowsByColumnValue(nil, []string{testTableName, "GroupName", "A"})
+	if err != nil || fmt.Sprint(getTestKeys(rows)) != "[1]" {
+		fmt.Println("Filter returned wrong rows", getTestKeys(rows), err)
+		t.FailNow()
+	}
+	_, rows, err = getRowsByColumnValue(nil, []string{testTableName, "GroupName", "A", IncludeDeletedOption})
+	if err != nil || fmt.Sprint(getTestKeys(rows)) != "[1 3]" {
+		fmt.Println("Filter with deleted rows returned wrong rows", getTestKeys(rows), err)
+		t.FailNow()
+	}
+	count, err := countTableRowsInt(nil, testTableName, false)
+	if err != nil || count != 2 {
+		fmt.Println("Wrong count of rows", count, err)
+		t.FailNow()
+	}
+	maxKey, err := getTableMaxKey(nil, testTableName)
+	if err != nil || string(maxKey) != "3" {
+		fmt.Println("Wrong max key", string(maxKey), err)
+		t.FailNow()
+	}
+}
+
+// Loan Request status rule reads Loan Negotiations and deletion marks from the repository, so it runs without a stub
+func TestSLSRepository_LoanRequestStatusRule(t *testing.T) {
+	discardLogs(t)
+	r := newMemoryRepository()
+	getRepository = r.getRepository
+	defer func() { getRepository = getCompositeKeyRepository }()
+
+	err := CreateLoanNegotiationTable(nil)
+	if err == nil {
+		err = CreateDeletedRowsTable(nil)
+	}
+	if err != nil {
+		fmt.Println("Failed creating tables", err)
+		t.FailNow()
+	}
+	tbl, _ := getTable(nil, LoanNegotiationsTableName)
+	putNegotiation := func(key, loanRequestID, status string) {
+		row := newTestRow(make([]string, len(tbl.ColumnDefinitions))...)
+		row.Columns[0].Value = key
+		row.Columns[getColumnIndex(tbl, LN_LoanRequestIDColName)].Value = loanRequestID
+		row.Columns[getColumnIndex(tbl, LN_NegotiationStatusColName)].Value = status
+		ok, err := insertRow(nil, LoanNegotiationsTableName, row)
+		if err == nil && !ok {
+			ok, err = replaceRow(nil, LoanNegotiationsTableName, row)
+		}
+		if !ok || err != nil {
+			fmt.Println("Failed saving Loan Negotiation", key, err)
+			t.FailNow()
+		}
+	}
+	checkStatus := func(expected string, expectedCount int) {
+		status, count, err := getLoanRequestStatusByNegotiations(r, "1")
+		if err != nil || status != expected || count != expectedCount {
+			fmt.Println("Expected status", expected, "of", expectedCount, "negotiations but got", status, count, err)
+			t.FailNow()
+		}
+	}
+
+	checkStatus("Draft", 0)
+	putNegotiation("1", "1", LN_StatusInvited)
+	putNegotiation("2", "2", "DECLINED")
+	checkStatus("Invitation Sent", 1)
+	putNegotiation("3", "1", "INTERESTED")
+	checkStatus("Negotiation Started", 2)
+	putNegotiation("1", "1", LN_StatusExpired)
+	checkStatus("Negotiation Completed", 2)
+
+	// Deleted negotiations are not counted
+	putNegotiation("4", "1", LN_StatusInvited)
+	checkStatus("Negotiation Started", 3)
+	ok, err := insertRow(nil, DeletedRowsTableName, newTestRow(getDeletedRowID(LoanNegotiationsTableName, "4"),
+		LoanNegotiationsTableName, "4", "6", "1", "", "tx"))
+	if !ok || err != nil {
+		fmt.Println("Failed marking row deleted", err)
+		t.FailNow()
+	}
+	checkStatus("Negotiation Completed", 2)
+}
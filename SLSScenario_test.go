@@ -0,0 +1,363 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+// Banks of the demo data in scenarios
+var (
+	arrangerIdentity   = bank6UserIdentity
+	dnbIdentity        = bank7UserIdentity
+	nationwideIdentity = bank8UserIdentity
+	// JPMorgan takes part in other deals of the demo data
+	jpmorganIdentity = testIdentity{CA_Role: FR_Bank, CA_BankID: "9", CA_UserID: "13"}
+)
+
+// Scenario runs transactions of different callers against one ledger with authentication enabled.
+// Every transaction gets the next txid, failures are reported with the step name.
+type scenario struct {
+	t    *testing.T
+	cc   *SimpleChaincode
+	stub *shimtest.MockStub
+	step string
+	tx   int
+	// Time of following transactions, zero for the current time
+	now time.Time
+}
+
+func newScenario(t *testing.T, initArgs []string) *scenario {
+	cc := new(SimpleChaincode)
+	s := &scenario{t: t, cc: cc, stub: shimtest.NewMockStub("scenario", cc)}
+
+	s.as(assignerIdentity).in("init")
+	_, err := mockInit(s.stub, s.nextTxID(), initArgs)
+	if err != nil {
+		s.fail("Init failed", err)
+	}
+	return s
+}
+
+func (s *scenario) close() {
+	stopActing()
+}
+
+func (s *scenario) fail(a ...interface{}) {
+	fmt.Println(append([]interface{}{"Step '" + s.step + "':"}, a...)...)
+	s.t.FailNow()
+}
+
+func (s *scenario) as(caller testIdentity) *scenario {
+	actAs(caller)
+	return s
+}
+
+func (s *scenario) in(step string) *scenario {
+	s.step = step
+	return s
+}
+
+func (s *scenario) at(now time.Time) *scenario {
+	s.now = now
+	return s
+}
+
+func (s *scenario) nextTxID() string {
+	s.tx++
+	return "tx" + strconv.Itoa(s.tx)
+}
+
+func (s *scenario) tryInvoke(function string, args []string) ([]byte, error) {
+	if !s.now.IsZero() {
+		return mockInvokeAt(s.stub, s.nextTxID(), s.now, function, args)
+	}
+	return mockInvoke(s.stub, s.nextTxID(), function, args)
+}
+
+// Invokes the function with named arguments, private values are passed in the transient map
+func (s *scenario) tryInvokeNamed(function string, values map[string]string) ([]byte, error) {
+	args, transient := getTransientArgs(values)
+	arg, _ := json.Marshal(args)
+	s.stub.TransientMap = transient
+	defer func() {
+		s.stub.TransientMap = nil
+	}()
+	return s.tryInvoke(function, []string{string(arg)})
+}
+
+func (s *scenario) invoke(function string, values map[string]string) []byte {
+	result, err := s.tryInvokeNamed(function, values)
+	if err != nil {
+		s.fail("Invoke", function, "failed", err)
+	}
+	return result
+}
+
+func (s *scenario) invokeFails(code string, function string, values map[string]string) {
+	_, err := s.tryInvokeNamed(function, values)
+	s.checkCode(err, code, function)
+}
+
+func (s *scenario) checkCode(err error, code string, function string) {
+	if err == nil {
+		s.fail("Expected", code, "error of", function)
+	}
+	var e chaincodeError
+	if json.Unmarshal([]byte(err.Error()), &e) != nil || e.Code != code {
+		s.fail("Expected", code, "error of", function, "but got", err)
+	}
+}
+
+func (s *scenario) query(function string, args ...string) []byte {
+	result, err := mockQuery(s.stub, function, args)
+	if err != nil {
+		s.fail("Query", function, args, "failed", err)
+	}
+	return result
+}
+
+func (s *scenario) queryFails(code string, function string, args ...string) {
+	_, err := mockQuery(s.stub, function, args)
+	s.checkCode(err, code, function)
+}
+
+func (s *scenario) rows(function string, args ...string) []map[string]string {
+	result := s.query(function, args...)
+	var rows []map[string]string
+	err := json.Unmarshal(result, &rows)
+	if err != nil {
+		s.fail("Query", function, "returned wrong JSON", string(result))
+	}
+	return rows
+}
+
+// Checks that the row returned by the ByKey query has the values
+func (s *scenario) checkRow(function string, key string, values map[string]string) {
+	rows := s.rows(function, key)
+	if len(rows) != 1 {
+		s.fail("Expected one row of", function, key, "but got", rows)
+	}
+	for column, value := range values {
+		if rows[0][column] != value {
+			s.fail("Wrong", column, "of", function, key, "- got", rows[0][column], "expected", value)
+		}
+	}
+}
+
+func (s *scenario) checkText(function string, args []string, expected string) {
+	result := string(s.query(function, args...))
+	if result != expected {
+		s.fail("Query", function, args, "returned", result, "expected", expected)
+	}
+}
+
+// ============================================================================================================================
+// A whole deal: the arranger bank creates a Loan Request and invites banks, banks respond, the assigner records terms,
+// proposals and votes of the banks, the arranger adopts the term. The chaincode has no separate signing and repayment
+// functions, the deal is finished by the arranger with Closed (signed) and Repaid statuses and archived.
+// ============================================================================================================================
+
+func TestSLSChaincode_DealScenario(t *testing.T) {
+	s := newScenario(t, []string{"mode=demo"})
+	defer s.close()
+
+	s.as(arrangerIdentity).in("create request")
+	s.invoke("addLoanRequest", map[string]string{LR_BorrowerIDColName: "Equinor", LR_ArrangerBankIDColName: "6",
+		LR_LoanSharesAmountColName: "300M", LR_ProjectNameColName: "Hywind Tampen", LR_RequestDateColName: "2017-03-01",
+		LR_StatusColName: "Draft", LR_CurrencyColName: "NOK"})
+	s.checkText("getLoanRequestsMaxKey", nil, "3")
+	s.checkRow("getLoanRequestByKey", "3", map[string]string{LR_BorrowerIDColName: "Equinor", LR_ArrangerBankIDColName: "6",
+		LR_StatusColName: "Draft", LR_CurrencyColName: "NOK", LR_WebsiteColName: ""})
+	projects := s.rows("getProjectsList")
+	if len(projects) != 2 || projects[1][LR_LoanRequestIDColName] != "3" {
+		s.fail("Arranger should see its projects 1 and 3, got", projects)
+	}
+
+	s.in("invite banks")
+	for _, bankID := range []string{"7", "8"} {
+		s.invoke("addLoanNegotiation", map[string]string{LN_LoanRequestIDColName: "3", LN_ParticipantBankIDColName: bankID,
+			LN_AmountColName: "100M", LN_NegotiationStatusColName: "INVITED", LN_DateColName: "2017-03-02"})
+	}
+	s.checkRow("getLoanNegotiationByKey", "7", map[string]string{LN_LoanRequestIDColName: "3", LN_ParticipantBankIDColName: "7"})
+	s.checkRow("getLoanNegotiationByKey", "8", map[string]string{LN_LoanRequestIDColName: "3", LN_ParticipantBankIDColName: "8"})
+	s.checkRow("getLoanRequestByKey", "3", map[string]string{LR_StatusColName: "Invitation Sent"})
+
+	s.as(dnbIdentity).in("DNB responds")
+	s.invoke("updateLoanNegotiationStatus", map[string]string{LN_LoanNegotiationIDColName: "7", LN_NegotiationStatusColName: "INTERESTED"})
+	s.invoke("updateParticipantBankComment", map[string]string{LN_LoanNegotiationIDColName: "7", LN_ParticipantBankCommentColName: "Up to 150M"})
+	s.checkRow("getLoanNegotiationByKey", "7", map[string]string{LN_NegotiationStatusColName: "INTERESTED", LN_ParticipantBankCommentColName: "Up to 150M"})
+	s.checkRow("getLoanRequestByKey", "3", map[string]string{LR_StatusColName: "Negotiation Started"})
+
+	amountSalt := string(s.query("getPrivateValueSalt", LoanNegotiationsTableName, "7", LN_AmountColName))
+	commentSalt := string(s.query("getPrivateValueSalt", LoanNegotiationsTableName, "7", LN_ParticipantBankCommentColName))
+
+	s.as(jpmorganIdentity).in("outside bank reads the deal")
+	s.checkRow("getLoanNegotiationByKey", "7", map[string]string{LN_NegotiationStatusColName: "INTERESTED",
+		LN_AmountColName: getPrivateValueHash(amountSalt, "100M"), LN_ParticipantBankCommentColName: getPrivateValueHash(commentSalt, "Up to 150M")})
+	if row := s.rows("getLoanNegotiationByKey", "7")[0]; row[LN_AmountColName] == getPrivateValueHash("", "100M") {
+		s.fail("Amount is hashed without salt", row[LN_AmountColName])
+	}
+	s.queryFails(ErrCodePermissionDenied, "getPrivateValueSalt", LoanNegotiationsTableName, "7", LN_AmountColName)
+	s.checkText("verifyPrivateValue", []string{LoanNegotiationsTableName, "7", LN_ParticipantBankCommentColName, "Up to 150M", commentSalt}, "true")
+	s.checkText("verifyPrivateValue", []string{LoanNegotiationsTableName, "7", LN_AmountColName, "150M", amountSalt}, "false")
+	s.checkText("verifyPrivateValue", []string{LoanNegotiationsTableName, "7", LN_AmountColName, "100M", ""}, "false")
+	s.queryFails(ErrCodeInvalidArgument, "verifyPrivateValue", LoanNegotiationsTableName, "7", LN_NegotiationStatusColName, "INTERESTED", "")
+	s.as(arrangerIdentity)
+	s.checkRow("getLoanNegotiationByKey", "7", map[string]string{LN_AmountColName: "100M", LN_ParticipantBankCommentColName: "Up to 150M"})
+
+	s.as(nationwideIdentity).in("Nationwide responds")
+	s.invoke("updateLoanNegotiationStatus", map[string]string{LN_LoanNegotiationIDColName: "8", LN_NegotiationStatusColName: "DECLINED"})
+	s.checkRow("getLoanRequestByKey", "3", map[string]string{LR_StatusColName: "Negotiation Completed"})
+
+	s.as(assignerIdentity).in("propose terms")
+	s.invoke("addLoanTerm", map[string]string{LT_LoanRequestIDColName: "3", LT_ParagraphNumberColName: "1",
+		LT_LoanTermTextColName: "Tenor is 5 years", LT_LoanTermStatusColName: "DRAFT"})
+	s.invoke("addLoanTermProposal", map[string]string{LTP_LoanTermIDColName: "1", LTP_ParagraphNumberColName: "1",
+		LTP_LoanTermProposalTextColName: "Tenor is 7 years"})
+	s.as(nationwideIdentity)
+	s.checkRow("getLoanTermProposalByKey", "1", map[string]string{LTP_LoanTermProposalTextColName: "Tenor is 7 years"})
+	textSalt := string(s.query("getPrivateValueSalt", LoanTermProposalTableName, "1", LTP_LoanTermProposalTextColName))
+	s.as(jpmorganIdentity)
+	s.checkRow("getLoanTermProposalByKey", "1", map[string]string{LTP_LoanTermIDColName: "1",
+		LTP_LoanTermProposalTextColName: getPrivateValueHash(textSalt, "Tenor is 7 years")})
+	s.as(assignerIdentity)
+
+	s.in("vote")
+	for _, bankID := range []string{"6", "7"} {
+		s.invoke("addLoanTermVote", map[string]string{LTV_LoanTermProposalIDColName: "1", LTV_BankIDColName: bankID,
+			LTV_LoanTermVoteStatusColName: "ACCEPTED"})
+	}
+	votes := s.rows("getLoanTermVoteList")
+	if len(votes) != 2 || votes[0][LTV_LoanTermVoteStatusColName] != "ACCEPTED" || votes[1][LTV_BankIDColName] != "7" {
+		s.fail("Wrong votes", votes)
+	}
+
+	s.as(arrangerIdentity).in("adopt")
+	s.invoke("updateLoanTerm", map[string]string{LT_LoanTermIDColName: "1", LT_LoanTermTextColName: "Tenor is 7 years",
+		LT_LoanTermStatusColName: "ADOPTED"})
+	s.checkRow("getLoanTermByKey", "1", map[string]string{LT_LoanTermStatusColName: "ADOPTED", LT_ParagraphNumberColName: "1"})
+	s.invoke("patchLoanTermProposal", map[string]string{LTP_LoanTermProposalIDColName: "1", LTP_LoanTermProposalStatusColName: LTP_StatusAdopted})
+	s.checkRow("getLoanTermProposalByKey", "1", map[string]string{LTP_LoanTermProposalStatusColName: LTP_StatusAdopted})
+
+	s.in("publish proposals")
+	s.as(dnbIdentity).invokeFails(ErrCodePermissionDenied, "publishLoanTermProposals", map[string]string{LTP_LoanTermIDColName: "1"})
+	s.as(arrangerIdentity).invoke("publishLoanTermProposals", map[string]string{LTP_LoanTermIDColName: "1"})
+	s.as(jpmorganIdentity)
+	s.checkRow("getLoanTermProposalByKey", "1", map[string]string{LTP_LoanTermProposalTextColName: "Tenor is 7 years"})
+	s.queryFails(ErrCodeInvalidState, "verifyPrivateValue", LoanTermProposalTableName, "1", LTP_LoanTermProposalTextColName, "Tenor is 7 years", textSalt)
+	s.as(arrangerIdentity).in("sign")
+	s.invoke("updateLoanRequest", map[string]string{LR_LoanRequestIDColName: "3", LR_StatusColName: LR_StatusClosed})
+	s.checkRow("getLoanRequestByKey", "3", map[string]string{LR_StatusColName: LR_StatusClosed, LR_CurrencyColName: "NOK"})
+
+	s.in("repay")
+	s.invoke("updateLoanRequest", map[string]string{LR_LoanRequestIDColName: "3", LR_StatusColName: LR_StatusRepaid})
+	_, err := s.tryInvoke("archiveLoanRequest", []string{"3"})
+	if err != nil {
+		s.fail("Failed archiving", err)
+	}
+	s.checkRow("getArchivedLoanRequestByKey", "3", map[string]string{LR_StatusColName: LR_StatusRepaid})
+	s.queryFails(ErrCodeNotFound, "getLoanRequestByKey", "3")
+	s.checkText("getLoanTermQuantity", nil, "0")
+
+	s.as(assignerIdentity).in("audit")
+	entries := s.rows("getAuditLogByActor", "7")
+	if len(entries) == 0 {
+		s.fail("Changes of DNB are not in audit log")
+	}
+	for _, e := range entries {
+		if e[AL_UserIDColName] != "5" {
+			s.fail("Wrong actor of audit entry", e)
+		}
+	}
+}
+
+// Callers without the required role or bank get PERMISSION_DENIED and nothing is changed
+func TestSLSChaincode_PermissionScenario(t *testing.T) {
+	s := newScenario(t, []string{"mode=demo"})
+	defer s.close()
+
+	s.as(dnbIdentity).in("create request of another arranger")
+	s.invokeFails(ErrCodePermissionDenied, "addLoanRequest", map[string]string{LR_BorrowerIDColName: "Equinor", LR_ArrangerBankIDColName: "6"})
+	s.checkText("getLoanRequestsMaxKey", nil, "2")
+
+	s.in("change request of another arranger")
+	s.invokeFails(ErrCodePermissionDenied, "updateLoanRequest", map[string]string{LR_LoanRequestIDColName: "1", LR_StatusColName: LR_StatusClosed})
+	s.invokeFails(ErrCodePermissionDenied, "patchLoanRequest", map[string]string{LR_LoanRequestIDColName: "1", LR_ProjectNameColName: "Castberg"})
+	_, err := s.tryInvoke("archiveLoanRequest", []string{"1"})
+	s.checkCode(err, ErrCodePermissionDenied, "archiveLoanRequest")
+	s.checkRow("getLoanRequestByKey", "1", map[string]string{LR_StatusColName: "Invitation Sent", LR_ProjectNameColName: "Statoil ASA project"})
+
+	s.in("invite to request of another arranger")
+	s.invokeFails(ErrCodePermissionDenied, "addLoanNegotiation", map[string]string{LN_LoanRequestIDColName: "1", LN_ParticipantBankIDColName: "7"})
+
+	s.in("respond for another bank")
+	s.invokeFails(ErrCodePermissionDenied, "updateLoanNegotiationStatus", map[string]string{LN_LoanNegotiationIDColName: "1", LN_NegotiationStatusColName: "DECLINED"})
+	s.checkRow("getLoanNegotiationByKey", "1", map[string]string{LN_NegotiationStatusColName: "INVITED"})
+
+	s.in("assigner functions")
+	s.invokeFails(ErrCodePermissionDenied, "addLoanTerm", map[string]string{LT_LoanRequestIDColName: "2", LT_LoanTermTextColName: "Tenor is 5 years"})
+	s.invokeFails(ErrCodePermissionDenied, "addLoanTermVote", map[string]string{LTV_BankIDColName: "7", LTV_LoanTermVoteStatusColName: "ACCEPTED"})
+	_, err = s.tryInvoke("deleteRow", []string{LoanRequestsTableName, "2"})
+	s.checkCode(err, ErrCodePermissionDenied, "deleteRow")
+	s.queryFails(ErrCodePermissionDenied, "getAuditLogByActor", "6")
+
+	s.as(anonymousIdentity).in("caller without attributes")
+	s.invokeFails(ErrCodePermissionDenied, "updateLoanNegotiationStatus", map[string]string{LN_LoanNegotiationIDColName: "4", LN_NegotiationStatusColName: "INTERESTED"})
+	s.invokeFails(ErrCodePermissionDenied, "addUser", map[string]string{U_ParticipantIDColName: "6", U_UserNameColName: "intruder"})
+
+	s.as(dnbIdentity).in("own negotiation")
+	s.invoke("updateLoanNegotiationStatus", map[string]string{LN_LoanNegotiationIDColName: "4", LN_NegotiationStatusColName: "INTERESTED"})
+	s.checkRow("getLoanRequestByKey", "2", map[string]string{LR_StatusColName: "Negotiation Started"})
+
+	s.as(assignerIdentity).in("assigner acts for every bank")
+	s.invoke("updateLoanNegotiationStatus", map[string]string{LN_LoanNegotiationIDColName: "1", LN_NegotiationStatusColName: "DECLINED"})
+	s.checkRow("getLoanNegotiationByKey", "1", map[string]string{LN_NegotiationStatusColName: "DECLINED"})
+}
+
+// A negotiation moved to another Loan Request changes statuses of both requests.
+// Only callers with rights over both requests and the new bank can move it.
+func TestSLSChaincode_MoveNegotiationScenario(t *testing.T) {
+	s := newScenario(t, []string{"mode=demo"})
+	defer s.close()
+
+	s.as(arrangerIdentity).in("new request")
+	s.invoke("addLoanRequest", map[string]string{LR_BorrowerIDColName: "Equinor", LR_ArrangerBankIDColName: "6",
+		LR_ProjectNameColName: "Hywind Tampen", LR_StatusColName: "Draft"})
+
+	s.in("hand request to another arranger")
+	s.invokeFails(ErrCodePermissionDenied, "updateLoanRequest", map[string]string{LR_LoanRequestIDColName: "3", LR_ArrangerBankIDColName: "7"})
+	s.invokeFails(ErrCodePermissionDenied, "patchLoanRequest", map[string]string{LR_LoanRequestIDColName: "3", LR_ArrangerBankIDColName: "7"})
+	s.checkRow("getLoanRequestByKey", "3", map[string]string{LR_ArrangerBankIDColName: "6"})
+
+	s.as(jpmorganIdentity).in("move negotiation to another bank")
+	s.invokeFails(ErrCodePermissionDenied, "updateLoanNegotiation", map[string]string{LN_LoanNegotiationIDColName: "5", LN_ParticipantBankIDColName: "8"})
+	s.invokeFails(ErrCodePermissionDenied, "patchLoanNegotiation", map[string]string{LN_LoanNegotiationIDColName: "5", LN_ParticipantBankIDColName: "8"})
+
+	s.in("move negotiation to another request")
+	s.invokeFails(ErrCodePermissionDenied, "patchLoanNegotiation", map[string]string{LN_LoanNegotiationIDColName: "5", LN_LoanRequestIDColName: "3"})
+	s.as(dnbIdentity)
+	s.invokeFails(ErrCodePermissionDenied, "updateLoanNegotiation", map[string]string{LN_LoanNegotiationIDColName: "4", LN_LoanRequestIDColName: "3"})
+	s.invokeFails(ErrCodePermissionDenied, "patchLoanNegotiation", map[string]string{LN_LoanNegotiationIDColName: "4", LN_LoanRequestIDColName: "3"})
+	s.checkRow("getLoanNegotiationByKey", "4", map[string]string{LN_LoanRequestIDColName: "2", LN_ParticipantBankIDColName: "7"})
+	s.checkRow("getLoanNegotiationByKey", "5", map[string]string{LN_LoanRequestIDColName: "2", LN_ParticipantBankIDColName: "9"})
+
+	s.as(jpmorganIdentity).in("own negotiation")
+	s.invoke("patchLoanNegotiation", map[string]string{LN_LoanNegotiationIDColName: "5", LN_ParticipantBankIDColName: "9", LN_LoanRequestIDColName: "2"})
+
+	s.as(assignerIdentity).in("banks decline")
+	for _, loanNegotiationID := range []string{"5", "6"} {
+		s.invoke("updateLoanNegotiationStatus", map[string]string{LN_LoanNegotiationIDColName: loanNegotiationID, LN_NegotiationStatusColName: "DECLINED"})
+	}
+	s.checkRow("getLoanRequestByKey", "2", map[string]string{LR_StatusColName: "Negotiation Started"})
+
+	s.in("move invitation")
+	s.invoke("updateLoanNegotiation", map[string]string{LN_LoanNegotiationIDColName: "4", LN_LoanRequestIDColName: "3"})
+	s.checkRow("getLoanNegotiationByKey", "4", map[string]string{LN_LoanRequestIDColName: "3", LN_NegotiationStatusColName: "INVITED"})
+	s.checkRow("getLoanRequestByKey", "2", map[string]string{LR_StatusColName: "Negotiation Completed"})
+	s.checkRow("getLoanRequestByKey", "3", map[string]string{LR_StatusColName: "Invitation Sent"})
+}
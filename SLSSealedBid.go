@@ -0,0 +1,461 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	//"errors"
+	//"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Entity names
+const SealedBidRoundsTableName = "SealedBidRounds"
+const SealedBidsTableName = "SealedBids"
+
+//Column names
+const SBR_LoanRequestIDColName = "LoanRequestID"
+const SBR_CommitmentDeadlineColName = "CommitmentDeadline"
+const SBR_RoundStatusColName = "RoundStatus"
+const SBR_RevealDeadlineColName = "RevealDeadline"
+
+const SB_LoanNegotiationIDColName = "LoanNegotiationID"
+const SB_LoanRequestIDColName = "LoanRequestID"
+const SB_CommitmentColName = "Commitment"
+const SB_BidStatusColName = "BidStatus"
+const SB_DateColName = "Date"
+
+//Arguments of reveals which are not columns
+const SB_SaltArgName = "Salt"
+
+//Round statuses
+const SBR_StatusOpen = "OPEN"
+const SBR_StatusAllocated = "ALLOCATED"
+
+//Phases of rounds
+const SBP_Commit = "commit"
+const SBP_Reveal = "reveal"
+const SBP_Allocate = "allocate"
+
+//Bid statuses
+const SB_StatusCommitted = "COMMITTED"
+const SB_StatusRevealed = "REVEALED"
+const SB_StatusExcluded = "EXCLUDED"
+
+// ============================================================================================================================
+// Sealed-bid mode of a Loan Request. The arranger bank starts a round with commitment and reveal deadlines before amounts
+// are known. Until the commitment deadline participant banks commit hex SHA-256 hashes of the JSON array [amount, salt]
+// and can replace them, so no bank sees amounts of others. Between the deadlines banks reveal amounts and salts, each
+// reveal is checked against the commitment and the amount is written to the Loan Negotiation. The arranger allocates
+// the round after the reveal deadline, so every bank had the whole reveal period: bids which were not revealed are
+// excluded and the round is closed. Amounts of Loan Negotiations of an open round are written by reveals only.
+// Rounds started before reveal deadlines were added have empty ones, they are revealed until they are allocated.
+// Times are taken from the transaction timestamp, amounts and salts of reveals are passed in the transient map.
+// ============================================================================================================================
+
+func CreateSealedBidRoundTable(stub shim.ChaincodeStubInterface) error {
+	columnNames := []string{SBR_LoanRequestIDColName, SBR_CommitmentDeadlineColName, SBR_RoundStatusColName, SBR_RevealDeadlineColName}
+	return createTable(stub, SealedBidRoundsTableName, columnNames)
+}
+
+func CreateSealedBidTable(stub shim.ChaincodeStubInterface) error {
+	columnNames := []string{SB_LoanNegotiationIDColName, SB_LoanRequestIDColName, SB_CommitmentColName, SB_BidStatusColName, SB_DateColName}
+	return createTable(stub, SealedBidsTableName, columnNames)
+}
+
+func init() {
+	registerFunction(functionDefinition{Name: "startSealedBidRound", Mode: FM_Write, Role: FR_Bank, ArgsFormat: AF_Object,
+		Args: []argumentDefinition{{Name: SBR_LoanRequestIDColName}, {Name: SBR_CommitmentDeadlineColName, Description: "RFC3339 date"},
+			{Name: SBR_RevealDeadlineColName, Description: "RFC3339 date after the commitment deadline"}},
+		handler: startSealedBidRound, Description: "Switches Loan Request without amounts to sealed-bid mode until allocation"})
+	registerFunction(functionDefinition{Name: "commitSealedBid", Mode: FM_Write, Role: FR_Bank, ArgsFormat: AF_Object,
+		Args: []argumentDefinition{{Name: SB_LoanNegotiationIDColName},
+			{Name: SB_CommitmentColName, Description: "hex SHA-256 of the JSON array [amount, salt]"}},
+		handler: commitSealedBid, Description: "Commits or replaces the sealed bid of Loan Negotiation before the deadline"})
+	registerFunction(functionDefinition{Name: "revealSealedBid", Mode: FM_Write, Role: FR_Bank, ArgsFormat: AF_Object,
+		Args: []argumentDefinition{{Name: SB_LoanNegotiationIDColName},
+			{Name: LN_AmountColName, Description: "passed in the transient map, the argument should be empty"},
+			{Name: SB_SaltArgName, Description: "passed in the transient map, the argument should be empty"}},
+		handler: revealSealedBid, Description: "Checks the amount against the commitment between the deadlines and writes it to Loan Negotiation"})
+	registerFunction(functionDefinition{Name: "allocateSealedBids", Mode: FM_Write, Role: FR_Bank, ArgsFormat: AF_Object,
+		Args: []argumentDefinition{{Name: SBR_LoanRequestIDColName}}, handler: allocateSealedBids,
+		Description: "Excludes bids which were not revealed and closes the round after the reveal deadline"})
+	registerFunction(functionDefinition{Name: "getSealedBidRoundByKey", Mode: FM_Read, Table: SealedBidRoundsTableName,
+		Args: []argumentDefinition{{Name: "key"}}, handler: getSealedBidRoundByKey, Description: "Returns sealed-bid round of Loan Request with the key"})
+	registerFunction(functionDefinition{Name: "getSealedBidsByLoanRequest", Mode: FM_Read, Table: SealedBidsTableName,
+		Args: []argumentDefinition{{Name: SB_LoanRequestIDColName}}, handler: getSealedBidsByLoanRequest,
+		Description: "Returns sealed bids of Loan Request"})
+
+	foreignKeys = append(foreignKeys,
+		foreignKey{SealedBidRoundsTableName, SBR_LoanRequestIDColName, LoanRequestsTableName, FK_OnDeleteCascade},
+		foreignKey{SealedBidsTableName, SB_LoanNegotiationIDColName, LoanNegotiationsTableName, FK_OnDeleteCascade},
+		foreignKey{SealedBidsTableName, SB_LoanRequestIDColName, SealedBidRoundsTableName, FK_OnDeleteCascade},
+	)
+}
+
+// Commitment of a bid, clients calculate it the same way before they commit
+func getSealedBidCommitment(amount, salt string) string {
+	// Marshalling of a string slice does not fail
+	b, _ := json.Marshal([]string{amount, salt})
+	hash := sha256.Sum256(b)
+	return hex.EncodeToString(hash[:])
+}
+
+func isSealedBidCommitment(value string) bool {
+	b, err := hex.DecodeString(value)
+	return err == nil && len(b) == sha256.Size
+}
+
+// Returns the round of the Loan Request, columns of the returned row are nil if the Loan Request is not in sealed-bid mode
+func getSealedBidRound(stub shim.ChaincodeStubInterface, loanRequestID string) (*Table, Row, error) {
+	tbl, err := getTable(stub, SealedBidRoundsTableName)
+	if err != nil {
+		return nil, Row{}, err
+	}
+	row, err := getRowByKeyValue(stub, SealedBidRoundsTableName, loanRequestID)
+	if getErrorCode(err) == ErrCodeNotFound {
+		return tbl, Row{}, nil
+	}
+	return tbl, row, err
+}
+
+// Checks that the round is open and the transaction is made in the phase: commitments before the commitment deadline,
+// reveals between the deadlines and the allocation after the reveal deadline
+func checkSealedBidRound(stub shim.ChaincodeStubInterface, loanRequestID string, phase string) error {
+	tbl, round, err := getSealedBidRound(stub, loanRequestID)
+	if err != nil {
+		return wrapError(err, "Error getting sealed-bid round: ")
+	}
+	if round.Columns == nil {
+		return newError(ErrCodeInvalidState, "Loan Request '"+loanRequestID+"' is not in sealed-bid mode")
+	}
+	if status := getRowColumnValue(tbl, round, SBR_RoundStatusColName); status != SBR_StatusOpen {
+		return newError(ErrCodeInvalidState, "Sealed-bid round of Loan Request '"+loanRequestID+"' is "+status)
+	}
+
+	deadlineValue := getRowColumnValue(tbl, round, SBR_CommitmentDeadlineColName)
+	deadline, err := parseDeadline(SBR_CommitmentDeadlineColName, deadlineValue)
+	if err != nil {
+		return err
+	}
+	now, err := getTxTime(stub)
+	if err != nil {
+		return err
+	}
+	if phase == SBP_Commit && !now.Before(deadline) {
+		return newError(ErrCodeInvalidState, "Commitment deadline "+deadlineValue+" of Loan Request '"+loanRequestID+"' has passed")
+	}
+	if phase != SBP_Commit && now.Before(deadline) {
+		return newError(ErrCodeInvalidState, "Commitment deadline "+deadlineValue+" of Loan Request '"+loanRequestID+"' has not passed")
+	}
+
+	revealDeadlineValue := getRowColumnValue(tbl, round, SBR_RevealDeadlineColName)
+	if phase == SBP_Commit || revealDeadlineValue == "" {
+		return nil
+	}
+	revealDeadline, err := parseDeadline(SBR_RevealDeadlineColName, revealDeadlineValue)
+	if err != nil {
+		return err
+	}
+	if phase == SBP_Reveal && !now.Before(revealDeadline) {
+		return newError(ErrCodeInvalidState, "Reveal deadline "+revealDeadlineValue+" of Loan Request '"+loanRequestID+"' has passed")
+	}
+	if phase == SBP_Allocate && now.Before(revealDeadline) {
+		return newError(ErrCodeInvalidState, "Reveal deadline "+revealDeadlineValue+" of Loan Request '"+loanRequestID+"' has not passed")
+	}
+	return nil
+}
+
+// Amounts of Loan Negotiations of open sealed-bid rounds are written by reveals only, values which are already written are
+// kept. Allocated rounds do not lock amounts any more. Amounts are taken from the transient map, as private values are.
+func checkSealedBidAmount(stub shim.ChaincodeStubInterface, loanRequestID, currentAmount, amount string) error {
+	var err error
+	if !isPrivateValueHash(amount) {
+		amount, err = getTransientValue(stub, LN_AmountColName, amount)
+	}
+	if err != nil || amount == "" || amount == currentAmount {
+		return err
+	}
+	tbl, round, err := getSealedBidRound(stub, loanRequestID)
+	if err != nil {
+		return wrapError(err, "Error getting sealed-bid round: ")
+	}
+	if round.Columns != nil && getRowColumnValue(tbl, round, SBR_RoundStatusColName) == SBR_StatusOpen {
+		return newFieldError(ErrCodeInvalidState, LN_AmountColName, "Amounts of Loan Request '"+loanRequestID+
+			"' in sealed-bid mode are written by revealSealedBid")
+	}
+	return nil
+}
+
+// Checks the changed amount of the Loan Negotiation against the round of its Loan Request
+func checkLoanNegotiationAmountChange(stub shim.ChaincodeStubInterface, loanNegotiationID string, changes map[string]string) error {
+	amount, ok := changes[LN_AmountColName]
+	if !ok {
+		return nil
+	}
+	tbl, err := getTable(stub, LoanNegotiationsTableName)
+	if err != nil {
+		return err
+	}
+	row, err := getRow(stub, LoanNegotiationsTableName, loanNegotiationID)
+	if err != nil || row.Columns == nil {
+		return err
+	}
+	loanRequestID := getRowColumnValue(tbl, row, LN_LoanRequestIDColName)
+	currentAmount := getRowColumnValue(tbl, row, LN_AmountColName)
+	if isPrivateValueHash(amount) && amount == currentAmount {
+		return nil
+	}
+	if isPrivateValueHash(currentAmount) {
+		currentAmount, err = getPrivateValue(stub, getDealCollectionName(loanRequestID), LoanNegotiationsTableName, loanNegotiationID,
+			LN_AmountColName, currentAmount)
+		if err != nil {
+			return err
+		}
+	}
+	if newLoanRequestID, ok := changes[LN_LoanRequestIDColName]; ok {
+		loanRequestID = newLoanRequestID
+	}
+	return checkSealedBidAmount(stub, loanRequestID, currentAmount, amount)
+}
+
+// Invoke: JSON object with the Loan Request ID, the commitment deadline and the reveal deadline
+func startSealedBidRound(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getNamedArgValues(stub, args, []string{SBR_LoanRequestIDColName, SBR_CommitmentDeadlineColName, SBR_RevealDeadlineColName})
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 3 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 3")
+	}
+	loanRequestID, deadlineValue, revealDeadlineValue := args[0], args[1], args[2]
+
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanRequestRowPermissionsByBankId(stub, loanRequestID)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in startSealedBidRound func or returned false: ")
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	deadline, err := parseDeadline(SBR_CommitmentDeadlineColName, deadlineValue)
+	if err != nil {
+		return nil, err
+	}
+	now, err := getTxTime(stub)
+	if err != nil {
+		return nil, wrapError(err, "Error in startSealedBidRound func: ")
+	}
+	if !now.Before(deadline) {
+		return nil, newFieldError(ErrCodeInvalidArgument, SBR_CommitmentDeadlineColName, "Commitment deadline "+deadlineValue+" has passed")
+	}
+	revealDeadline, err := parseDeadline(SBR_RevealDeadlineColName, revealDeadlineValue)
+	if err != nil {
+		return nil, err
+	}
+	if !deadline.Before(revealDeadline) {
+		return nil, newFieldError(ErrCodeInvalidArgument, SBR_RevealDeadlineColName, "Reveal deadline "+revealDeadlineValue+
+			" is not after the commitment deadline")
+	}
+
+	// Amounts which were written before can not be sealed
+	amounts, err := getTableColValuesInSlice(stub, []string{LoanNegotiationsTableName, LN_AmountColName, LN_LoanRequestIDColName, loanRequestID})
+	if err != nil {
+		return nil, wrapError(err, "Error in startSealedBidRound func: ")
+	}
+	for _, amount := range amounts {
+		if amount != "" {
+			return nil, newError(ErrCodeInvalidState, "Loan Request '"+loanRequestID+"' has Loan Negotiations with amounts")
+		}
+	}
+
+	err = addRow(stub, SealedBidRoundsTableName, []string{loanRequestID, deadline.UTC().Format(time.RFC3339), SBR_StatusOpen,
+		revealDeadline.UTC().Format(time.RFC3339)}, true)
+	if err != nil {
+		return nil, wrapError(err, "Error in startSealedBidRound func: ")
+	}
+	return nil, nil
+}
+
+// Invoke: JSON object with the Loan Negotiation ID and the commitment
+func commitSealedBid(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getNamedArgValues(stub, args, []string{SB_LoanNegotiationIDColName, SB_CommitmentColName})
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 2 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 2")
+	}
+	loanNegotiationID, commitment := args[0], args[1]
+
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanNegotiationRowPermissionsByBankId(stub, loanNegotiationID)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in commitSealedBid func or returned false: ")
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	if !isSealedBidCommitment(commitment) {
+		return nil, newFieldError(ErrCodeInvalidArgument, SB_CommitmentColName, "Commitment '"+commitment+"' is not a hex SHA-256 hash")
+	}
+	loanRequestID, err := getTableColValueByKey(stub, LoanNegotiationsTableName, loanNegotiationID, LN_LoanRequestIDColName)
+	if err != nil {
+		return nil, wrapError(err, "Error in commitSealedBid func: ")
+	}
+	err = checkSealedBidRound(stub, loanRequestID, SBP_Commit)
+	if err != nil {
+		return nil, wrapError(err, "Error in commitSealedBid func: ")
+	}
+	date, err := getTxTimestampString(stub)
+	if err != nil {
+		return nil, wrapError(err, "Error in commitSealedBid func: ")
+	}
+
+	// Commitments are replaced until the deadline
+	exists, err := isRowExisting(stub, SealedBidsTableName, loanNegotiationID)
+	if err != nil {
+		return nil, wrapError(err, "Error in commitSealedBid func: ")
+	}
+	if exists {
+		_, err = patchRow(stub, SealedBidsTableName, loanNegotiationID, map[string]string{SB_CommitmentColName: commitment, SB_DateColName: date}, "")
+	} else {
+		err = addRow(stub, SealedBidsTableName, []string{loanNegotiationID, loanRequestID, commitment, SB_StatusCommitted, date}, true)
+	}
+	if err != nil {
+		return nil, wrapError(err, "Error in commitSealedBid func: ")
+	}
+	return nil, nil
+}
+
+// Invoke: JSON object with the Loan Negotiation ID, the amount and the salt
+func revealSealedBid(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getNamedArgValues(stub, args, []string{SB_LoanNegotiationIDColName, LN_AmountColName, SB_SaltArgName})
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 3 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 3")
+	}
+	loanNegotiationID := args[0]
+	amount, err := getTransientValue(stub, LN_AmountColName, args[1])
+	if err == nil {
+		args[2], err = getTransientValue(stub, SB_SaltArgName, args[2])
+	}
+	if err != nil {
+		return nil, wrapError(err, "Error in revealSealedBid func: ")
+	}
+	salt := args[2]
+
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanNegotiationRowPermissionsByBankId(stub, loanNegotiationID)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in revealSealedBid func or returned false: ")
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	tbl, err := getTable(stub, SealedBidsTableName)
+	if err != nil {
+		return nil, wrapError(err, "Error in revealSealedBid func: ")
+	}
+	bid, err := getRowByKeyValue(stub, SealedBidsTableName, loanNegotiationID)
+	if getErrorCode(err) == ErrCodeNotFound {
+		return nil, newError(ErrCodeNotFound, "Loan Negotiation '"+loanNegotiationID+"' has no sealed bid")
+	}
+	if err != nil {
+		return nil, wrapError(err, "Error in revealSealedBid func: ")
+	}
+	loanRequestID := getRowColumnValue(tbl, bid, SB_LoanRequestIDColName)
+	commitment := getRowColumnValue(tbl, bid, SB_CommitmentColName)
+	status := getRowColumnValue(tbl, bid, SB_BidStatusColName)
+	err = checkSealedBidRound(stub, loanRequestID, SBP_Reveal)
+	if err != nil {
+		return nil, wrapError(err, "Error in revealSealedBid func: ")
+	}
+	if status != SB_StatusCommitted {
+		return nil, newError(ErrCodeInvalidState, "Sealed bid of Loan Negotiation '"+loanNegotiationID+"' is "+status)
+	}
+	if amount == "" || getSealedBidCommitment(amount, salt) != commitment {
+		return nil, newError(ErrCodeInvalidArgument, "Amount and salt do not match the commitment of Loan Negotiation '"+loanNegotiationID+"'")
+	}
+
+	// The revealed amount is sealed as private values of arguments are
+	hash, err := sealPrivateValue(stub, getDealCollectionName(loanRequestID), LoanNegotiationsTableName, loanNegotiationID,
+		LN_AmountColName, amount)
+	if err != nil {
+		return nil, wrapError(err, "Error in revealSealedBid func: ")
+	}
+	_, err = updateTableField(stub, []string{LoanNegotiationsTableName, loanNegotiationID, LN_AmountColName, hash})
+	if err != nil {
+		return nil, wrapError(err, "Error in revealSealedBid func: ")
+	}
+	_, err = updateTableField(stub, []string{SealedBidsTableName, loanNegotiationID, SB_BidStatusColName, SB_StatusRevealed})
+	if err != nil {
+		return nil, wrapError(err, "Error in revealSealedBid func: ")
+	}
+	return nil, nil
+}
+
+// Invoke: JSON object with the Loan Request ID
+func allocateSealedBids(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getNamedArgValues(stub, args, []string{SBR_LoanRequestIDColName})
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 1 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 1")
+	}
+	loanRequestID := args[0]
+
+	///////////////////////////Security check////////////////////////////
+	check, err := checkLoanRequestRowPermissionsByBankId(stub, loanRequestID)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in allocateSealedBids func or returned false: ")
+	}
+	/////////////////////////////////////////////////////////////////////
+
+	err = checkSealedBidRound(stub, loanRequestID, SBP_Allocate)
+	if err != nil {
+		return nil, wrapError(err, "Error in allocateSealedBids func: ")
+	}
+
+	tbl, bids, err := getRowsByColumnValue(stub, []string{SealedBidsTableName, SB_LoanRequestIDColName, loanRequestID})
+	if err != nil {
+		return nil, wrapError(err, "Error in allocateSealedBids func: ")
+	}
+	var excluded int
+	for _, bid := range bids {
+		if getRowColumnValue(tbl, bid, SB_BidStatusColName) != SB_StatusCommitted {
+			continue
+		}
+		bidKey := getRowColumnValue(tbl, bid, SB_LoanNegotiationIDColName)
+		_, err = updateTableField(stub, []string{SealedBidsTableName, bidKey, SB_BidStatusColName, SB_StatusExcluded})
+		if err != nil {
+			return nil, wrapError(err, "Error in allocateSealedBids func: ")
+		}
+		excluded++
+	}
+
+	_, err = updateTableField(stub, []string{SealedBidRoundsTableName, loanRequestID, SBR_RoundStatusColName, SBR_StatusAllocated})
+	if err != nil {
+		return nil, wrapError(err, "Error in allocateSealedBids func: ")
+	}
+	logInfo(stub, "Sealed bids are allocated", logFields{"key": loanRequestID, "count": strconv.Itoa(len(bids) - excluded)})
+	return nil, nil
+}
+
+func getSealedBidRoundByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 1")
+	}
+	keyValue := args[0]
+	return filterTableByKey(stub, SealedBidRoundsTableName, keyValue)
+}
+
+func getSealedBidsByLoanRequest(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 1")
+	}
+	return filterTableByValue(stub, []string{SealedBidsTableName, SB_LoanRequestIDColName, args[0]})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// A sealed-bid round of a new Loan Request: DNB and Nationwide commit before the deadline, Nationwide replaces its
+// commitment and reveals between the deadlines, DNB does not reveal in time and is excluded by the allocation, which is
+// rejected until the reveal deadline.
+func TestSLSChaincode_SealedBidScenario(t *testing.T) {
+	s := newScenario(t, []string{"mode=demo"})
+	defer s.close()
+
+	opened := time.Date(2030, 3, 1, 9, 0, 0, 0, time.UTC)
+	deadline := opened.Add(24 * time.Hour)
+	revealDeadline := deadline.Add(24 * time.Hour)
+
+	s.as(arrangerIdentity).at(opened).in("invite banks")
+	s.invoke("addLoanRequest", map[string]string{LR_BorrowerIDColName: "Equinor", LR_ArrangerBankIDColName: "6",
+		LR_ProjectNameColName: "Hywind Tampen", LR_StatusColName: "Draft"})
+	for _, bankID := range []string{"7", "8"} {
+		s.invoke("addLoanNegotiation", map[string]string{LN_LoanRequestIDColName: "3", LN_ParticipantBankIDColName: bankID,
+			LN_NegotiationStatusColName: "INVITED", LN_DateColName: "2030-03-01"})
+	}
+
+	s.in("start round")
+	s.invokeFails(ErrCodeInvalidArgument, "startSealedBidRound", map[string]string{SBR_LoanRequestIDColName: "3",
+		SBR_CommitmentDeadlineColName: opened.Add(-time.Hour).Format(time.RFC3339), SBR_RevealDeadlineColName: revealDeadline.Format(time.RFC3339)})
+	s.invokeFails(ErrCodeInvalidArgument, "startSealedBidRound", map[string]string{SBR_LoanRequestIDColName: "3",
+		SBR_CommitmentDeadlineColName: deadline.Format(time.RFC3339), SBR_RevealDeadlineColName: deadline.Format(time.RFC3339)})
+	s.invokeFails(ErrCodeInvalidArgument, "startSealedBidRound", map[string]string{SBR_LoanRequestIDColName: "3",
+		SBR_CommitmentDeadlineColName: deadline.Format(time.RFC3339)})
+	s.invokeFails(ErrCodeInvalidState, "startSealedBidRound", map[string]string{SBR_LoanRequestIDColName: "1",
+		SBR_CommitmentDeadlineColName: deadline.Format(time.RFC3339), SBR_RevealDeadlineColName: revealDeadline.Format(time.RFC3339)})
+	s.as(dnbIdentity).invokeFails(ErrCodePermissionDenied, "startSealedBidRound", map[string]string{SBR_LoanRequestIDColName: "3",
+		SBR_CommitmentDeadlineColName: deadline.Format(time.RFC3339), SBR_RevealDeadlineColName: revealDeadline.Format(time.RFC3339)})
+	s.as(arrangerIdentity).invoke("startSealedBidRound", map[string]string{SBR_LoanRequestIDColName: "3",
+		SBR_CommitmentDeadlineColName: deadline.Format(time.RFC3339), SBR_RevealDeadlineColName: revealDeadline.Format(time.RFC3339)})
+	s.checkRow("getSealedBidRoundByKey", "3", map[string]string{SBR_CommitmentDeadlineColName: "2030-03-02T09:00:00Z",
+		SBR_RevealDeadlineColName: "2030-03-03T09:00:00Z", SBR_RoundStatusColName: SBR_StatusOpen})
+	s.invokeFails(ErrCodeInvalidState, "addLoanNegotiation", map[string]string{LN_LoanRequestIDColName: "3",
+		LN_ParticipantBankIDColName: "9", LN_AmountColName: "50M", LN_NegotiationStatusColName: "INVITED"})
+
+	s.as(dnbIdentity).at(opened.Add(time.Hour)).in("commit")
+	s.invoke("commitSealedBid", map[string]string{SB_LoanNegotiationIDColName: "7", SB_CommitmentColName: getSealedBidCommitment("150M", "dnb")})
+	s.invokeFails(ErrCodeInvalidState, "patchLoanNegotiation", map[string]string{LN_LoanNegotiationIDColName: "7", LN_AmountColName: "150M"})
+	s.invokeFails(ErrCodePermissionDenied, "commitSealedBid", map[string]string{SB_LoanNegotiationIDColName: "8",
+		SB_CommitmentColName: getSealedBidCommitment("1M", "dnb")})
+	s.invokeFails(ErrCodeInvalidArgument, "commitSealedBid", map[string]string{SB_LoanNegotiationIDColName: "7", SB_CommitmentColName: "150M"})
+	s.invokeFails(ErrCodeInvalidState, "revealSealedBid", map[string]string{SB_LoanNegotiationIDColName: "7", LN_AmountColName: "150M",
+		SB_SaltArgName: "dnb"})
+
+	s.as(nationwideIdentity).at(opened.Add(2 * time.Hour))
+	s.invoke("commitSealedBid", map[string]string{SB_LoanNegotiationIDColName: "8", SB_CommitmentColName: getSealedBidCommitment("100M", "first")})
+	s.invoke("commitSealedBid", map[string]string{SB_LoanNegotiationIDColName: "8", SB_CommitmentColName: getSealedBidCommitment("120M", "second")})
+	bids := s.rows("getSealedBidsByLoanRequest", "3")
+	if len(bids) != 2 || bids[1][SB_CommitmentColName] != getSealedBidCommitment("120M", "second") ||
+		bids[1][SB_BidStatusColName] != SB_StatusCommitted || bids[1][SB_DateColName] != "2030-03-01T11:00:00Z" {
+		s.fail("Wrong bids", bids)
+	}
+
+	s.at(deadline).in("reveal")
+	s.invokeFails(ErrCodeInvalidState, "commitSealedBid", map[string]string{SB_LoanNegotiationIDColName: "8",
+		SB_CommitmentColName: getSealedBidCommitment("200M", "late")})
+	s.invokeFails(ErrCodeInvalidArgument, "revealSealedBid", map[string]string{SB_LoanNegotiationIDColName: "8", LN_AmountColName: "100M",
+		SB_SaltArgName: "first"})
+	s.invoke("revealSealedBid", map[string]string{SB_LoanNegotiationIDColName: "8", LN_AmountColName: "120M", SB_SaltArgName: "second"})
+	s.invokeFails(ErrCodeInvalidState, "revealSealedBid", map[string]string{SB_LoanNegotiationIDColName: "8", LN_AmountColName: "120M",
+		SB_SaltArgName: "second"})
+	s.checkRow("getLoanNegotiationByKey", "8", map[string]string{LN_AmountColName: "120M"})
+	s.as(arrangerIdentity).in("allocate before the reveal deadline")
+	s.invokeFails(ErrCodeInvalidState, "allocateSealedBids", map[string]string{SBR_LoanRequestIDColName: "3"})
+	s.checkRow("getSealedBidRoundByKey", "3", map[string]string{SBR_RoundStatusColName: SBR_StatusOpen})
+
+	s.as(dnbIdentity).at(revealDeadline).in("reveal after the reveal deadline")
+	s.invokeFails(ErrCodeInvalidState, "revealSealedBid", map[string]string{SB_LoanNegotiationIDColName: "7", LN_AmountColName: "150M",
+		SB_SaltArgName: "dnb"})
+
+	s.in("allocate")
+	s.invokeFails(ErrCodePermissionDenied, "allocateSealedBids", map[string]string{SBR_LoanRequestIDColName: "3"})
+	s.as(arrangerIdentity).invoke("allocateSealedBids", map[string]string{SBR_LoanRequestIDColName: "3"})
+	s.checkRow("getSealedBidRoundByKey", "3", map[string]string{SBR_RoundStatusColName: SBR_StatusAllocated})
+	bids = s.rows("getSealedBidsByLoanRequest", "3")
+	if len(bids) != 2 || bids[0][SB_BidStatusColName] != SB_StatusExcluded || bids[1][SB_BidStatusColName] != SB_StatusRevealed {
+		s.fail("Wrong bids after allocation", bids)
+	}
+	s.checkRow("getLoanNegotiationByKey", "7", map[string]string{LN_AmountColName: ""})
+	s.checkRow("getLoanNegotiationByKey", "8", map[string]string{LN_AmountColName: "120M"})
+
+	s.as(dnbIdentity).in("reveal after allocation")
+	s.invokeFails(ErrCodeInvalidState, "revealSealedBid", map[string]string{SB_LoanNegotiationIDColName: "7", LN_AmountColName: "150M",
+		SB_SaltArgName: "dnb"})
+
+	s.in("amounts are not locked after allocation")
+	s.invoke("patchLoanNegotiation", map[string]string{LN_LoanNegotiationIDColName: "7", LN_AmountColName: "150M"})
+	s.checkRow("getLoanNegotiationByKey", "7", map[string]string{LN_AmountColName: "150M"})
+}
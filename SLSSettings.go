@@ -0,0 +1,109 @@
+package main
+
+import (
+	//"encoding/json"
+	//"errors"
+	//"fmt"
+	//"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Entity names
+const SettingsTableName = "Settings"
+
+//Column names
+const S_SettingNameColName = "SettingName"
+const S_SettingValueColName = "SettingValue"
+
+//Column quantity
+const SettingsTableColsQty = 2
+
+//Setting names
+const ModeSettingName = "mode"
+
+//Mode setting values
+const ModeDemo = "demo"
+const ModeProduction = "production"
+
+// ============================================================================================================================
+// Settings are passed to Init as "name=value" arguments and kept in the ledger.
+// ============================================================================================================================
+
+func CreateSettingsTable(stub shim.ChaincodeStubInterface) error {
+	S_ColumnNames := []string{S_SettingNameColName, S_SettingValueColName}
+	return createTable(stub, SettingsTableName, S_ColumnNames)
+}
+
+func init() {
+	registerFunction(functionDefinition{Name: "getSettingsList", Mode: FM_Read, Role: FR_Assigner, isMaintenance: true, Table: SettingsTableName,
+		handler: getSettingsList, Description: "Returns all settings"})
+}
+
+// Parses "name=value" Init arguments, empty arguments are skipped
+func parseInitArgs(args []string) (map[string]string, error) {
+	settings := make(map[string]string)
+	for _, arg := range args {
+		if arg == "" {
+			continue
+		}
+		i := strings.Index(arg, "=")
+		if i <= 0 {
+			return nil, newError(ErrCodeInvalidArgument, "Init argument '" + arg + "' should have 'name=value' format")
+		}
+		settings[arg[:i]] = arg[i+1:]
+	}
+	return settings, nil
+}
+
+func getSetting(stub shim.ChaincodeStubInterface, settingName, defaultValue string) (string, error) {
+	row, err := getRow(stub, SettingsTableName, settingName)
+	if err != nil {
+		return "", wrapError(err, "Error getting setting '" + settingName + "': ")
+	}
+	if row.Columns == nil {
+		return defaultValue, nil
+	}
+	return row.Columns[1].Value, nil
+}
+
+func setSetting(stub shim.ChaincodeStubInterface, settingName, settingValue string) error {
+	oldValue, err := getSetting(stub, settingName, "")
+	if err != nil {
+		return err
+	}
+
+	row := Row{Columns: []*Column{{Value: settingName}, {Value: settingValue}}}
+
+	ok, err := insertRow(stub, SettingsTableName, row)
+	if err != nil {
+		return wrapError(err, "Error setting '" + settingName + "': ")
+	}
+	if ok {
+		return recordRowChange(stub, SettingsTableName, settingName, EA_Created,
+			map[string]string{S_SettingNameColName: settingName, S_SettingValueColName: settingValue}, nil)
+	}
+
+	_, err = replaceRow(stub, SettingsTableName, row)
+	if err != nil {
+		return wrapError(err, "Error setting '" + settingName + "': ")
+	}
+	if oldValue == settingValue {
+		return nil
+	}
+	return recordRowChange(stub, SettingsTableName, settingName, EA_Updated,
+		map[string]string{S_SettingValueColName: settingValue}, map[string]string{S_SettingValueColName: oldValue})
+}
+
+func isProductionMode(stub shim.ChaincodeStubInterface) (bool, error) {
+	mode, err := getSetting(stub, ModeSettingName, ModeDemo)
+	if err != nil {
+		return false, err
+	}
+	return mode == ModeProduction, nil
+}
+
+func getSettingsList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	return filterTableByValue(stub, []string{SettingsTableName})
+}
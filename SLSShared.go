@@ -1,47 +1,52 @@
 package main
 
 import (
-	//"encoding/json"
-	"errors"
-	"fmt"
+	"bytes"
+	"encoding/json"
+	//"errors"
+	//"fmt"
 	"strconv"
+	"time"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
 // !!! This function allows only single column key. Consider making it multicolumns in the future. !!!
-func getRowByKeyValue(stub shim.ChaincodeStubInterface, tableName, keyValue string) (shim.Row, error) {
-	var row shim.Row
-
-	var cols []shim.Column
-	col := shim.Column{Value: &shim.Column_String_{String_: keyValue}}
-	cols = append(cols, col)
-
-	row, err := stub.GetRow(tableName, cols)
+func getRowByKeyValue(stub shim.ChaincodeStubInterface, tableName, keyValue string) (Row, error) {
+	row, err := getRow(stub, tableName, keyValue)
 	if err != nil {
-		return row, errors.New("An error occured while getting row in getRowByKeyValue func: " + err.Error())
+		return row, wrapError(err, "An error occured while getting row in getRowByKeyValue func: ")
 	}
 
-	fmt.Printf("\n tableName: %v, keyValue: %v", tableName, keyValue)
+	if row.Columns == nil {
+		return row, newError(ErrCodeNotFound, "An error occured while getting row in getRowByKeyValue func: Key value not found")
+	}
 
-	if row.GetColumns() == nil {
-		return row, errors.New("An error occured while getting row in getRowByKeyValue func: Key value not found")
+	isDeleted, err := isRowDeleted(stub, tableName, keyValue)
+	if err != nil {
+		return row, wrapError(err, "An error occured while getting row in getRowByKeyValue func: ")
+	}
+	if isDeleted {
+		return row, newError(ErrCodeNotFound, "An error occured while getting row in getRowByKeyValue func: Key value not found")
 	}
 	return row, nil
 }
 
 // This function filters by one column value only
-func getRowsByColumnValue(stub shim.ChaincodeStubInterface, args []string) (*shim.Table, []shim.Row, error) {
+func getRowsByColumnValue(stub shim.ChaincodeStubInterface, args []string) (*Table, []Row, error) {
 
 	// 1 or 3 arguments should be provided:
 	// 1 when filter is not needed: table name
 	// 3 when filter is need: table name, filter column, filter value
+	// includeDeleted option can be added as the last argument to get deleted rows as well
 
 	var tableName, filterColumn, filterValue string
 	var isFiltered bool
 
-	var tbl *shim.Table
-	var rows []shim.Row
+	var tbl *Table
+	var rows []Row
+
+	args, includeDeleted := parseIncludeDeletedOption(args)
 
 	switch l := len(args); l {
 	case 1:
@@ -51,19 +56,15 @@ func getRowsByColumnValue(stub shim.ChaincodeStubInterface, args []string) (*shi
 		tableName, filterColumn, filterValue = args[0], args[1], args[2]
 		isFiltered = true
 	default:
-		return tbl, rows, errors.New("Incorrect number of arguments in getRowsByColumnValue func. Expecting: 1 or 3")
+		return tbl, rows, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getRowsByColumnValue func. Expecting: 1 or 3")
 	}
 
-	tbl, err := stub.GetTable(tableName)
+	tbl, err := getTable(stub, tableName)
 	if err != nil {
-		return tbl, rows, errors.New("Error in getRowsByColumnValue func: " + err.Error())
+		return tbl, rows, wrapError(err, "Error in getRowsByColumnValue func: ")
 	}
 
-	var cols []shim.Column
-
-	rowChan, _ := stub.GetRows(tableName, cols)
-	row, ok := <-rowChan
-
+	var match func(Row) bool
 	if isFiltered {
 		var columnNumber int
 		var isColumnFound bool
@@ -78,25 +79,37 @@ func getRowsByColumnValue(stub shim.ChaincodeStubInterface, args []string) (*shi
 		}
 
 		if !isColumnFound {
-			return tbl, rows, errors.New("Column is not found in getRowsByColumnValue func: " + err.Error())
+			return tbl, rows, newFieldError(ErrCodeInvalidArgument, filterColumn, "Column '"+filterColumn+
+				"' is not found in '"+tableName+"' table in getRowsByColumnValue func")
 		}
 
-		var i int
-		for ok {
-			if row.Columns[columnNumber].GetString_() == filterValue {
-				rows = append(rows, row)
-				i++
-			}
-			row, ok = <-rowChan
+		match = func(row Row) bool {
+			return row.Columns[columnNumber].Value == filterValue
 		}
-	} else {
-		for ok {
-			rows = append(rows, row)
-			row, ok = <-rowChan
+	}
+
+	rows, err = queryRows(stub, tableName, match)
+	if err != nil {
+		return tbl, rows, wrapError(err, "Error in getRowsByColumnValue func: ")
+	}
+
+	if includeDeleted {
+		return tbl, rows, nil
+	}
+
+	// Deleted rows are excluded
+	var notDeletedRows []Row
+	for _, row := range rows {
+		isDeleted, err := isRowDeleted(stub, tableName, row.Columns[0].Value)
+		if err != nil {
+			return tbl, rows, wrapError(err, "Error in getRowsByColumnValue func: ")
+		}
+		if !isDeleted {
+			notDeletedRows = append(notDeletedRows, row)
 		}
 	}
 
-	return tbl, rows, nil
+	return tbl, notDeletedRows, nil
 }
 
 // This function filters and deletes all rows by one column value only
@@ -104,17 +117,20 @@ func deleteRowsByColumnValue(stub shim.ChaincodeStubInterface, args []string) ([
 	_, rows, err := getRowsByColumnValue(stub, args)
 
 	if err != nil {
-		return nil, errors.New("Error in deleteRowsByColumnValue func: " + err.Error())
+		return nil, wrapError(err, "Error in deleteRowsByColumnValue func: ")
 	}
 
 	tableName := args[0]
 
 	if err != nil {
-		return nil, errors.New("Error in deleteRowsByColumnValue func: " + err.Error())
+		return nil, wrapError(err, "Error in deleteRowsByColumnValue func: ")
 	}
 
 	for _, row := range rows {
-		deleteRow(stub, []string{tableName, row.Columns[0].GetString_()})
+		_, err = deleteRow(stub, []string{tableName, row.Columns[0].Value})
+		if err != nil {
+			return nil, wrapError(err, "Error in deleteRowsByColumnValue func: ")
+		}
 	}
 
 	return nil, nil
@@ -123,27 +139,27 @@ func deleteRowsByColumnValue(stub shim.ChaincodeStubInterface, args []string) ([
 func getTableColValueByKey(stub shim.ChaincodeStubInterface, tableName, keyValue, columnName string) (string, error) {
 	row, err := getRowByKeyValue(stub, tableName, keyValue)
 	if err != nil {
-		return "", errors.New("An error occured in getTableColValueByKey func: " + err.Error())
+		return "", wrapError(err, "An error occured in getTableColValueByKey func: ")
 	}
 
-	tbl, err := stub.GetTable(tableName)
+	tbl, err := getTable(stub, tableName)
 	if err != nil {
-		return "", errors.New("An error occured while getting table in func getTableColValueByKey: " + err.Error())
+		return "", wrapError(err, "An error occured while getting table in func getTableColValueByKey: ")
 	}
 
 	var columnValue string
 	var f bool
 
-	for i, c := range row.GetColumns() {
+	for i, c := range row.Columns {
 		if tbl.ColumnDefinitions[i].Name == columnName {
-			columnValue = c.GetString_()
+			columnValue = c.Value
 			f = true
 			break
 		}
 	}
 
 	if !f {
-		return "", errors.New("Error in getTableColValueByKey func: Column '" + columnName + "' is missing")
+		return "", newFieldError(ErrCodeInvalidArgument, columnName, "Error in getTableColValueByKey func: Column '"+columnName+"' is missing")
 	}
 
 	return columnValue, nil
@@ -155,103 +171,134 @@ func getTableColValueByKey(stub shim.ChaincodeStubInterface, tableName, keyValue
 func updateTableField(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 
 	if len(args) != 4 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 4")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting 4")
 	}
 
 	tableName, keyValue, columnName, columnNewValue := args[0], args[1], args[2], args[3]
 
 	row, err := getRowByKeyValue(stub, tableName, keyValue)
 	if err != nil {
-		return nil, errors.New("An error occured in func updateTableField: " + err.Error())
+		return nil, wrapError(err, "An error occured in func updateTableField: ")
 	}
 
 	var f bool
 	var columnOldValue string
 
-	tbl, err := stub.GetTable(tableName)
+	tbl, err := getTable(stub, tableName)
+	if err != nil {
+		return nil, wrapError(err, "An error occured while getting table in getRowByKeyValue func: ")
+	}
+	columnNewValue, err = getPrivateColumnArg(stub, tbl, row, columnName, columnNewValue)
 	if err != nil {
-		return nil, errors.New("An error occured while getting table in getRowByKeyValue func: " + err.Error())
+		return nil, wrapError(err, "An error occured in func updateTableField: ")
+	}
+	oldRow := copyRow(row)
+	oldLoanRequestID, err := getRowDealID(stub, tbl, row)
+	if err != nil {
+		return nil, wrapError(err, "An error occured in func updateTableField: ")
 	}
 
-	// This Println is temporary for logging purposes
-	fmt.Println("====== Before update =========================")
-	for i, c := range row.GetColumns() {
-		// This Printf is temporary for logging purposes
-		fmt.Printf("Column number: '%v', Column name: '%v', Column value: '%v'\n", i, tbl.ColumnDefinitions[i].Name, c.GetString_())
+	for i, c := range row.Columns {
 		if tbl.ColumnDefinitions[i].Name == columnName {
-			columnOldValue = c.GetString_()
+			columnOldValue = c.Value
 			// Consider replace row.Columns[i] = ... with c = ...
-			row.Columns[i] = &shim.Column{Value: &shim.Column_String_{String_: columnNewValue}}
+			row.Columns[i] = &Column{Value: columnNewValue}
 			f = true
 			// Consider add break
 		}
 	}
 
-	// This Println is temporary for logging purposes
-	fmt.Println("====== After update =========================")
-	// This Printf is temporary for logging purposes
-	for i, c := range row.GetColumns() {
-		fmt.Printf("Column number: '%v', Column name: '%v', Column value: '%v'\n", i, tbl.ColumnDefinitions[i].Name, c.GetString_())
+	if !f {
+		return nil, newFieldError(ErrCodeInvalidArgument, columnName, "Column '"+columnName+"' is missing")
 	}
-	// This Println is temporary for logging purposes
-	fmt.Println("=============================================")
 
-	if !f {
-		return nil, errors.New("Column '" + columnName + "' is missing")
+	err = checkColumnType(tableName, columnName, columnNewValue)
+	if err != nil {
+		return nil, wrapError(err, "An error occured in func updateTableField: ")
+	}
+	err = checkForeignKeyValue(stub, tableName, columnName, columnNewValue)
+	if err != nil {
+		return nil, wrapError(err, "An error occured in func updateTableField: ")
+	}
+	loanRequestID, err := getRowDealID(stub, tbl, row)
+	if err != nil {
+		return nil, wrapError(err, "An error occured in func updateTableField: ")
+	}
+	err = moveDealPrivateValues(stub, tbl, oldRow, oldLoanRequestID, loanRequestID)
+	if err != nil {
+		return nil, wrapError(err, "An error occured in func updateTableField: ")
 	}
+	// Private values are logged and recorded as their hashes
+	err = sealPrivateColumns(stub, tbl, row)
+	if err != nil {
+		return nil, wrapError(err, "An error occured in func updateTableField: ")
+	}
+	columnNewValue = getRowColumnValue(tbl, row, columnName)
 
-	ok, errreplace := stub.ReplaceRow(tableName, row)
+	ok, errreplace := replaceRow(stub, tableName, row)
 	if errreplace != nil {
-		return nil, errors.New("An error occured while running updateTableField func: " + errreplace.Error())
+		return nil, wrapError(errreplace, "An error occured while running updateTableField func: ")
 	}
 	//This check might be redundant.
 	if !ok {
-		return nil, errors.New("A row does not exist the given key")
+		return nil, newError(ErrCodeNotFound, "A row does not exist the given key")
 	}
 
-	fmt.Printf("Column '%v' of the row of key value '%v' in the table '%v' has been successfuly updated from value '%v' to value '%v'\n", columnName, keyValue, tableName, columnOldValue, columnNewValue)
+	logInfo(stub, "Row is updated", logFields{"table": tableName, "key": keyValue, "column": columnName,
+		"oldValue": columnOldValue, "newValue": columnNewValue})
+
+	if columnOldValue != columnNewValue {
+		err = recordRowChange(stub, tableName, keyValue, EA_Updated,
+			map[string]string{columnName: columnNewValue}, map[string]string{columnName: columnOldValue})
+		if err != nil {
+			return nil, wrapError(err, "An error occured in func updateTableField: ")
+		}
+	}
 
 	return nil, nil
 }
 
 func countTableRows(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 
+	args, includeDeleted := parseIncludeDeletedOption(args)
+
 	var numberOfArgs int = 1
 	if len(args) != numberOfArgs {
-		return nil, errors.New("Incorrect number of arguments. Expecting: " + strconv.Itoa(numberOfArgs))
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting: " + strconv.Itoa(numberOfArgs))
 	}
 
 	tableName := args[0]
 
-	q, err := countTableRowsInt(stub, tableName)
+	q, err := countTableRowsInt(stub, tableName, includeDeleted)
 	if err != nil {
-		return nil, errors.New("Failed to get rows quantity for table '" + tableName + "': " + err.Error())
+		return nil, wrapError(err, "Failed to get rows quantity for table '" + tableName + "': ")
 	}
 
-	fmt.Printf("Quantity of rows for table %v is %v\n", tableName, q)
+	logDebug(stub, "Rows are counted", logFields{"table": tableName, "count": strconv.Itoa(q)})
 	return []byte(strconv.Itoa(q)), nil
 }
 
-func countTableRowsInt(stub shim.ChaincodeStubInterface, tableName string) (int, error) {
-	// The function hangs for about 10 seconds if table Name does not exist
-	// consider a fix !!!!!!!!!!!!!!
-
-	// Use emty columns slice to get all rows for count
-	var cols []shim.Column
-
-	rowChan, err := stub.GetRows(tableName, cols)
+func countTableRowsInt(stub shim.ChaincodeStubInterface, tableName string, includeDeleted bool) (int, error) {
+	var q int
+	var errDeleted error
+	err := iterateRows(stub, tableName, func(row Row) bool {
+		isDeleted := false
+		if !includeDeleted {
+			isDeleted, errDeleted = isRowDeleted(stub, tableName, row.Columns[0].Value)
+			if errDeleted != nil {
+				return false
+			}
+		}
+		if !isDeleted {
+			q++
+		}
+		return true
+	})
 	if err != nil {
 		return 0, err
 	}
-
-	_, ok := <-rowChan
-
-	var q int
-	for ok {
-		//		fmt.Printf("ok: %v\n", ok)
-		//		fmt.Printf("Rows to string: %v\n", row2)
-		q++
-		_, ok = <-rowChan
+	if errDeleted != nil {
+		return 0, errDeleted
 	}
 
 	return q, nil
@@ -260,7 +307,7 @@ func countTableRowsInt(stub shim.ChaincodeStubInterface, tableName string) (int,
 func filterTableByValue(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	tbl, rows, err := getRowsByColumnValue(stub, args)
 	if err != nil {
-		return nil, errors.New("Error in filterTableByValue func: " + err.Error())
+		return nil, wrapError(err, "Error in filterTableByValue func: ")
 	}
 
 	return recordsetToJson(stub, tbl, rows)
@@ -269,14 +316,14 @@ func filterTableByValue(stub shim.ChaincodeStubInterface, args []string) ([]byte
 func filterTableByKey(stub shim.ChaincodeStubInterface, tableName, keyValue string) ([]byte, error) {
 	row, err := getRowByKeyValue(stub, tableName, keyValue)
 	if err != nil {
-		return nil, errors.New("An error in filterTableByKey func: " + err.Error())
+		return nil, wrapError(err, "An error in filterTableByKey func: ")
 	}
-	tbl, err := stub.GetTable(tableName)
+	tbl, err := getTable(stub, tableName)
 	if err != nil {
-		return nil, errors.New("An error occured while running filterTableByKey: " + err.Error())
+		return nil, wrapError(err, "An error occured while running filterTableByKey: ")
 	}
 
-	var rows []shim.Row
+	var rows []Row
 	rows = append(rows, row)
 
 	return recordsetToJson(stub, tbl, rows)
@@ -289,8 +336,8 @@ func getTableColValuesInSlice(stub shim.ChaincodeStubInterface, args []string) (
 	// 4 - filter is need: tableName, columnName, filterColumn, filterValue
 
 	var tableName, columnName, filterColumn, filterValue string
-	var tbl *shim.Table
-	var rows []shim.Row
+	var tbl *Table
+	var rows []Row
 	var err error
 
 	switch l := len(args); l {
@@ -301,12 +348,12 @@ func getTableColValuesInSlice(stub shim.ChaincodeStubInterface, args []string) (
 		tableName, columnName, filterColumn, filterValue = args[0], args[1], args[2], args[3]
 		tbl, rows, err = getRowsByColumnValue(stub, []string{tableName, filterColumn, filterValue})
 	default:
-		return nil, errors.New("Incorrect number of arguments in getTableColValuesInSlice func. Expecting: 2 or 4, " +
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getTableColValuesInSlice func. Expecting: 2 or 4, " +
 			"provided: " + strconv.Itoa(l))
 	}
 
 	if err != nil {
-		return nil, errors.New("Error in getTableColValuesInSlice func: " + err.Error())
+		return nil, wrapError(err, "Error in getTableColValuesInSlice func: ")
 	}
 
 	var colID int
@@ -319,79 +366,105 @@ func getTableColValuesInSlice(stub shim.ChaincodeStubInterface, args []string) (
 		}
 	}
 	if !isColFound {
-		return nil, errors.New("Error in getTableColValuesInSlice func: Column '" + columnName + "' is not found in '" + tableName + "' table")
+		return nil, newFieldError(ErrCodeInvalidArgument, columnName, "Error in getTableColValuesInSlice func: Column '"+columnName+
+			"' is not found in '"+tableName+"' table")
 	}
 
 	var colValues []string
 	for _, row := range rows {
-		colValues = append(colValues, row.Columns[colID].GetString_())
+		colValues = append(colValues, row.Columns[colID].Value)
 	}
 	return colValues, nil
 }
 
-func recordsetToJson(stub shim.ChaincodeStubInterface, tbl *shim.Table, rows []shim.Row) ([]byte, error) {
+func recordsetToJson(stub shim.ChaincodeStubInterface, tbl *Table, rows []Row) ([]byte, error) {
+
+	rows, err := unsealPrivateColumns(stub, tbl, rows)
+	if err != nil {
+		return nil, wrapError(err, "Error in recordsetToJson func: ")
+	}
 
 	var ColumnNames []string
 	for _, cd := range tbl.ColumnDefinitions {
 		ColumnNames = append(ColumnNames, cd.Name)
 	}
 
-	var s string
-	s = "["
+	// Result is written to a buffer, because concatenation of large results takes quadratic time
+	var s bytes.Buffer
+	s.WriteString("[")
 
-	for _, r := range rows {
-		s += "{"
+	// Separators are added before items, so empty results and rows are valid JSON as well
+	for i, r := range rows {
+		if i > 0 {
+			s.WriteString(",")
+		}
+		s.WriteString("{")
 		for m, c := range r.Columns {
+			if m > 0 {
+				s.WriteString(",")
+			}
 
 			columnName := tbl.ColumnDefinitions[m].Name
-			columnValue := c.GetString_()
-			s += "\"" + columnName + "\":\"" + columnValue + "\","
+			// Values are escaped, because they may contain quotes, e.g. JSON arguments in MaintenanceLog
+			columnValue, err := json.Marshal(c.Value)
+			if err != nil {
+				return nil, wrapError(err, "Error in recordsetToJson func: ")
+			}
+			s.WriteString("\"" + columnName + "\":")
+			s.Write(columnValue)
 		}
-		s = s[0:len(s)-1] + "},"
+		s.WriteString("}")
 	}
 
-	s = s[0:len(s)-1] + "]"
+	s.WriteString("]")
 
-	return []byte(s), nil
+	return s.Bytes(), nil
 }
 
+// Existing tables are kept with their rows, schema changes of existing tables are made by migrations
 func createTable(stub shim.ChaincodeStubInterface, tableName string, columns []string) error {
-	//not to forget delete table is it already exists
-	stub.DeleteTable(tableName)
+	_, err := getTable(stub, tableName)
+	if err == nil {
+		logDebug(stub, "Table already exists and is kept", logFields{"table": tableName})
+		return nil
+	}
+	if getErrorCode(err) != ErrCodeNotFound {
+		return err
+	}
 
-	var colDefs []*shim.ColumnDefinition
+	var colDefs []*ColumnDefinition
 
 	for _, colName := range columns {
-		colDefs = append(colDefs, &shim.ColumnDefinition{Name: colName, Type: shim.ColumnDefinition_STRING, Key: false})
+		colDefs = append(colDefs, &ColumnDefinition{Name: colName, Key: false})
 	}
 	colDefs[0].Key = true
 
-	err := stub.CreateTable(tableName, colDefs)
+	err = putTable(stub, &Table{Name: tableName, ColumnDefinitions: colDefs})
 	if err != nil {
-		return errors.New("Failed to add table '" + tableName + "' to state: " + err.Error())
+		return wrapError(err, "Failed to add table '" + tableName + "' to state: ")
 	}
-	fmt.Println("Table '" + tableName + "' created")
+	logInfo(stub, "Table is created", logFields{"table": tableName})
 	return nil
 }
 
 func addRow(stub shim.ChaincodeStubInterface, tableName string, args []string, isKeyInserted bool) error {
 
-	tbl, err := stub.GetTable(tableName)
+	tbl, err := getTable(stub, tableName)
 	if err != nil {
-		return errors.New("Failed to add row to '" + tableName + "' table: " + err.Error())
+		return wrapError(err, "Failed to add row to '" + tableName + "' table: ")
 	}
 	colDefs := tbl.ColumnDefinitions
 	colsQty := len(colDefs)
 	argsQty := len(args)
 
-	var cols []*shim.Column
+	var cols []*Column
 	var keyValue string
 
 	// If the first arg IS or IS NOT table key value, which should be specified in isKeyInserted
 	if isKeyInserted {
 
 		if argsQty != colsQty {
-			return errors.New("Wrong number of members in args parameter. " +
+			return newError(ErrCodeInvalidArgument, "Wrong number of members in args parameter. " +
 				"Provided '" + strconv.Itoa(argsQty) + "', expected '" + strconv.Itoa(colsQty) + "'")
 		}
 
@@ -399,44 +472,69 @@ func addRow(stub shim.ChaincodeStubInterface, tableName string, args []string, i
 	} else {
 
 		if argsQty != colsQty-1 {
-			return errors.New("Wrong number of members in args parameter. " +
+			return newError(ErrCodeInvalidArgument, "Wrong number of members in args parameter. " +
 				"Provided '" + strconv.Itoa(argsQty) + "', expected '" + strconv.Itoa(colsQty-1) + "'")
 		}
 
 		q, err := getTableMaxKey(stub, tableName)
 		if err != nil {
-			return errors.New("Failed to add row to '" + tableName + "' table: " + err.Error())
+			return wrapError(err, "Failed to add row to '" + tableName + "' table: ")
 		}
 		qint, err := strconv.Atoi(string(q))
 		if err != nil {
-			return errors.New("Failed to add row to '" + tableName + "' table: " + err.Error())
+			return wrapError(err, "Failed to add row to '" + tableName + "' table: ")
 		}
 		qint++
 		keyValue = strconv.Itoa(qint)
 
-		cols = append(cols, &shim.Column{Value: &shim.Column_String_{String_: keyValue}})
+		cols = append(cols, &Column{Value: keyValue})
 	}
 
 	for i := 0; i <= argsQty-1; i++ {
-		cols = append(cols, &shim.Column{Value: &shim.Column_String_{String_: args[i]}})
+		cols = append(cols, &Column{Value: args[i]})
+	}
+	for i, cd := range colDefs {
+		cols[i].Value, err = getPrivateColumnArg(stub, tbl, Row{Columns: cols}, cd.Name, cols[i].Value)
+		if err != nil {
+			return wrapError(err, "Failed to add row to '" + tableName + "' table: ")
+		}
+	}
+
+	err = checkColumnTypes(tableName, colDefs, cols)
+	if err != nil {
+		return wrapError(err, "Failed to add row to '" + tableName + "' table: ")
+	}
+	err = checkForeignKeys(stub, tableName, colDefs, cols)
+	if err != nil {
+		return wrapError(err, "Failed to add row to '" + tableName + "' table: ")
+	}
+	err = checkLateChanges(stub, tbl, Row{Columns: cols}, nil)
+	if err != nil {
+		return wrapError(err, "Failed to add row to '" + tableName + "' table: ")
+	}
+	err = sealPrivateColumns(stub, tbl, Row{Columns: cols})
+	if err != nil {
+		return wrapError(err, "Failed to add row to '" + tableName + "' table: ")
 	}
 
 	var ok bool
-	ok, err = stub.InsertRow(tableName, shim.Row{Columns: cols})
+	ok, err = insertRow(stub, tableName, Row{Columns: cols})
 	if err != nil {
-		return errors.New("Failed to add row to '" + tableName + "' table: " + err.Error())
+		return wrapError(err, "Failed to add row to '" + tableName + "' table: ")
 	}
 	if !ok {
-		return errors.New("Row with key '" + keyValue + "' is already assigned in table '" + tableName + "'")
+		return newError(ErrCodeConflict, "Row with key '" + keyValue + "' is already assigned in table '" + tableName + "'")
 	}
 
-	s := "The row has been added to table '" + tableName + "' in ledger: \n"
+	changedFields := make(map[string]string)
+	fields := logFields{"table": tableName}
 	for i, cd := range colDefs {
-		s += cd.Name + " : " + cols[i].GetString_() + "\n"
+		changedFields[cd.Name] = cols[i].Value
+		fields[cd.Name] = cols[i].Value
 	}
-
-	fmt.Println(s)
-	return nil
+	logInfo(stub, "Row is added", logFields{"table": tableName, "key": keyValue})
+	logDebug(stub, "Values of added row", fields)
+	return recordRowChange(stub, tableName, keyValue, EA_Created, changedFields, nil)
 
 }
 
@@ -444,159 +542,101 @@ func addRow(stub shim.ChaincodeStubInterface, tableName string, args []string, i
 func deleteRow(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	var numberOfArgs int = 2
 	if len(args) != numberOfArgs {
-		return nil, errors.New("Incorrect number of arguments. Expecting: " + strconv.Itoa(numberOfArgs))
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments. Expecting: " + strconv.Itoa(numberOfArgs))
 	}
 
 	tableName, keyValue := args[0], args[1]
-	var cols []shim.Column
-	col := shim.Column{Value: &shim.Column_String_{String_: keyValue}}
-	cols = append(cols, col)
-
-	err := stub.DeleteRow(tableName, cols)
 
+	// Referencing rows are handled according to foreign key delete rules
+	err := deleteRowWithReferences(stub, tableName, keyValue)
 	if err != nil {
-		return nil, errors.New("Failed to delete row with key '" + keyValue + "' from '" + tableName + "' table: " + err.Error())
+		return nil, wrapError(err, "Error in deleteRow func: ")
 	}
 
-	fmt.Println("Successfuly deleted row with key '" + keyValue + "' from '" + tableName + "' table if any exists")
 	return nil, nil
 }
 
-func isCaller(stub shim.ChaincodeStubInterface, certificate []byte) (bool, error) {
-
-	fmt.Println("Check caller...")
-
-	// In order to enforce access control, we require that the
-	// metadata contains the signature under the signing key corresponding
-	// to the verification key inside certificate of
-	// the payload of the transaction (namely, function name and args) and
-	// the transaction binding (to avoid copying attacks)
-
-	// Verify \sigma=Sign(certificate.sk, tx.Payload||tx.Binding) against certificate.vk
-	// \sigma is in the metadata
-
-	sigma, err := stub.GetCallerMetadata()
-	if err != nil {
-		return false, errors.New("Failed getting metadata")
-	}
-	payload, err := stub.GetPayload()
-	if err != nil {
-		return false, errors.New("Failed getting payload")
-	}
-	binding, err := stub.GetBinding()
-	if err != nil {
-		return false, errors.New("Failed getting binding")
-	}
-
-	fmt.Printf("passed certificate [% x]\n", certificate)
-	fmt.Printf("passed sigma [% x]\n", sigma)
-	fmt.Printf("passed payload [% x]\n", payload)
-	fmt.Printf("passed binding [% x]\n", binding)
-
-	ok, err := stub.VerifySignature(
-		certificate,
-		sigma,
-		append(payload, binding...),
-	)
-	if err != nil {
-		fmt.Println("Failed checking signature [%s]", err)
-		return ok, err
-	}
-	if !ok {
-		fmt.Println("Invalid signature")
-	}
-
-	fmt.Println("Check caller...Verified!")
-
-	return ok, err
-}
-
 func checkRowPermissionsByBankId(stub shim.ChaincodeStubInterface, arrangerBankId string) (bool, error) {
 	//Admin security check
-	checkPermissionsAssigner, _ := checkAttribute(stub, "role", "assigner")
+	checkPermissionsAssigner, _ := checkAttribute(stub, CA_Role, FR_Assigner)
 	if checkPermissionsAssigner {
 		return true, nil
 	}
 
 	//Check bank role
-	checkPermissions, err := checkAttribute(stub, "role", "bank")
+	checkPermissions, err := checkAttribute(stub, CA_Role, FR_Bank)
 	if !checkPermissions {
-		return false, errors.New("'role' attribute check failed or returned false: " + err.Error())
+		return false, wrapError(err, "'role' attribute check failed or returned false: ")
 	}
 
 	//Check if Arranger bank id is correct
-	checkPermissions, err = checkAttribute(stub, "bankid", arrangerBankId)
+	checkPermissions, err = checkAttribute(stub, CA_BankID, arrangerBankId)
 	if !checkPermissions {
-		return false, errors.New("'bankid' attribute check failed or returned false: " + err.Error())
+		return false, wrapError(err, "'bankid' attribute check failed or returned false: ")
 	}
 
 	return true, nil
 }
 
 // This function assumes that key is single column, which is first in the table
+// Deleted and archived rows are taken into account, so their keys are never reused
 func getTableMaxKey(stub shim.ChaincodeStubInterface, tableName string) ([]byte, error) {
-	// Use emty columns slice to get all rows for count
-	var cols []shim.Column
-
 	var key string
 	key = "0"
 	keyint, _ := strconv.Atoi(key)
 
-	rowChan, err := stub.GetRows(tableName, cols)
-	if err != nil {
-		return []byte(key), err
+	tableNames := []string{tableName}
+	if isArchivedTable(tableName) {
+		tableNames = append(tableNames, getArchiveTableName(tableName))
 	}
 
-	row, ok := <-rowChan
-
-	for ok {
-		// Key column should be the first and table key should be single-column key
-		key = row.GetColumns()[0].GetString_()
-		keyintc, _ := strconv.Atoi(key)
-		if keyintc > keyint {
-			keyint = keyintc
+	for _, tn := range tableNames {
+		err := iterateRows(stub, tn, func(row Row) bool {
+			// Key column should be the first and table key should be single-column key
+			key = row.Columns[0].Value
+			keyintc, _ := strconv.Atoi(key)
+			if keyintc > keyint {
+				keyint = keyintc
+			}
+			return true
+		})
+		if err != nil {
+			return []byte(key), err
 		}
-		row, ok = <-rowChan
 	}
 
 	return []byte(strconv.Itoa(keyint)), nil
 }
 
-/*func printCallerCertificate(stub shim.ChaincodeStubInterface) ([]byte, error) {
-	// Verify the identity of the caller
-	// Only an administrator can add Participant
-	//###########################################
-
-	certificate, err := stub.GetCallerCertificate()
+// Returns transaction timestamp in RFC 3339 format or empty string if the peer does not provide it
+func getTxTimestampString(stub shim.ChaincodeStubInterface) (string, error) {
+	timestamp, err := stub.GetTxTimestamp()
 	if err != nil {
-		return nil, errors.New("Failed retrieving Certificate: " + err.Error())
+		return "", wrapError(err, "Failed retrieving transaction timestamp: ")
 	}
-	fmt.Printf("\n\nCertificate: %v\n\n", string(certificate))
-
-	callerMetadata, err := stub.GetCallerMetadata()
-	if err != nil {
-		return nil, errors.New("Failed retrieving Caller Metadata: " + err.Error())
+	if timestamp == nil {
+		return "", nil
 	}
-	fmt.Printf("Caller Metadata: %v\nCaller Metadata length:%v\n\n", string(callerMetadata), len(callerMetadata))
+	return time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC().Format(time.RFC3339), nil
+}
 
-	payload, err := stub.GetPayload()
+// Returns transaction timestamp, deadlines are checked against it, so every endorsing peer gets the same result
+func getTxTime(stub shim.ChaincodeStubInterface) (time.Time, error) {
+	timestamp, err := stub.GetTxTimestamp()
 	if err != nil {
-		return nil, errors.New("Failed retrieving Payload: " + err.Error())
+		return time.Time{}, wrapError(err, "Failed retrieving transaction timestamp: ")
 	}
-	fmt.Printf("Payload: %v\n\n", string(payload))
-
-	binding, err := stub.GetBinding()
-	if err != nil {
-		return nil, errors.New("Failed retrieving Binding: " + err.Error())
+	if timestamp == nil {
+		return time.Time{}, newError(ErrCodeInvalidState, "Transaction timestamp is not provided by the peer")
 	}
-	fmt.Printf("Binding: %v\n\n", string(binding))
+	return time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC(), nil
+}
 
-	timestamp, err := stub.GetTxTimestamp()
+// Deadlines are RFC 3339 dates
+func parseDeadline(columnName, value string) (time.Time, error) {
+	deadline, err := time.Parse(time.RFC3339, value)
 	if err != nil {
-		return nil, errors.New("Failed retrieving Timestamp': " + err.Error())
+		return deadline, newFieldError(ErrCodeInvalidArgument, columnName, "Deadline '"+value+"' is not an RFC 3339 date")
 	}
-	fmt.Printf("Timestamp: %v\n\n", timestamp)
-
-	//###########################################
-	return []byte("Caller Metadata: " + string(callerMetadata)), nil
-}*/
+	return deadline, nil
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/quick"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+// Properties of the table layer are checked on a table which is not referenced by other tables
+const testTableName = "TestRows"
+
+var testTableColumns = []string{"TestRowID", "GroupName", "RowText"}
+
+// Returns a stub of an empty production ledger with the test table and an open transaction
+func newTableTestStub(t *testing.T) *shimtest.MockStub {
+	stub := shimtest.NewMockStub("table", new(SimpleChaincode))
+	checkInit(t, stub, []string{"mode=production"})
+	stub.MockTransactionStart("table")
+	// Transactions of earlier test stubs had the same ID
+	endTxContext(stub)
+	err := createTable(stub, testTableName, testTableColumns)
+	if err != nil {
+		fmt.Println("Failed creating test table", err)
+		t.FailNow()
+	}
+	return stub
+}
+
+// Decodes rows returned by recordsetToJson, failing on malformed JSON
+func decodeTestRows(t *testing.T, result []byte) []map[string]string {
+	var rows []map[string]string
+	err := json.Unmarshal(result, &rows)
+	if err != nil {
+		fmt.Println("Malformed JSON of rows", strconv.Quote(string(result)), err)
+		t.FailNow()
+	}
+	return rows
+}
+
+func getTestRows(t *testing.T, stub *shimtest.MockStub, args ...string) []map[string]string {
+	result, err := filterTableByValue(stub, append([]string{testTableName}, args...))
+	if err != nil {
+		fmt.Println("Failed filtering test table by", args, err)
+		t.FailNow()
+	}
+	return decodeTestRows(t, result)
+}
+
+func getTestRowKeys(rows []map[string]string) []string {
+	keys := []string{}
+	for _, row := range rows {
+		keys = append(keys, row["TestRowID"])
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func getTestMaxKey(t *testing.T, stub *shimtest.MockStub) int {
+	maxKey, err := getTableMaxKey(stub, testTableName)
+	if err != nil {
+		fmt.Println("getTableMaxKey failed", err)
+		t.FailNow()
+	}
+	key, err := strconv.Atoi(string(maxKey))
+	if err != nil {
+		fmt.Println("getTableMaxKey returned not a number", string(maxKey))
+		t.FailNow()
+	}
+	return key
+}
+
+// Fuzzing runs thousands of transactions, their logs are discarded
+func discardLogs(f testing.TB) {
+	logOutput = ioutil.Discard
+	f.Cleanup(func() {
+		logOutput = os.Stdout
+	})
+}
+
+func TestSLSChaincode_RecordsetToJsonEmpty(t *testing.T) {
+	stub := newTableTestStub(t)
+	rows := getTestRows(t, stub)
+	if len(rows) != 0 {
+		fmt.Println("Empty table returned rows", rows)
+		t.FailNow()
+	}
+	rows = getTestRows(t, stub, "GroupName", "missing")
+	if len(rows) != 0 {
+		fmt.Println("Filter without matches returned rows", rows)
+		t.FailNow()
+	}
+}
+
+// Values of every column are returned as they are inserted, whatever characters they have
+func FuzzRecordsetToJson(f *testing.F) {
+	f.Add("1", "Group", "Text", 1)
+	f.Add("", "", "", 0)
+	f.Add("2", `"quoted", {braces} and \ backslash`, "line\nbreak\ttab", 3)
+	f.Add("3", "Ünïcödé 日本語 🏦", "  \x00\x1f", 2)
+	f.Add("4", "\xff\xfe", "]", 1)
+	f.Fuzz(func(t *testing.T, key, group, text string, count int) {
+		count = count % 5
+		if count < 0 {
+			count = -count
+		}
+		tbl := &Table{Name: testTableName}
+		for i, name := range testTableColumns {
+			tbl.ColumnDefinitions = append(tbl.ColumnDefinitions, &ColumnDefinition{Name: name, Key: i == 0})
+		}
+		var rows []Row
+		for i := 0; i < count; i++ {
+			var cols []*Column
+			for _, value := range []string{key + strconv.Itoa(i), group, text} {
+				cols = append(cols, &Column{Value: value})
+			}
+			rows = append(rows, Row{Columns: cols})
+		}
+
+		result, err := recordsetToJson(nil, tbl, rows)
+		if err != nil {
+			fmt.Println("recordsetToJson failed", err)
+			t.FailNow()
+		}
+		decoded := decodeTestRows(t, result)
+		if len(decoded) != count {
+			fmt.Println("Expected", count, "rows but got", len(decoded), string(result))
+			t.FailNow()
+		}
+		for i, row := range decoded {
+			for m, value := range []string{key + strconv.Itoa(i), group, text} {
+				// JSON strings can not have invalid UTF-8, it is replaced
+				if utf8.ValidString(value) && row[testTableColumns[m]] != value {
+					fmt.Println("Column", testTableColumns[m], "of row", i, "is", strconv.Quote(row[testTableColumns[m]]), "but not", strconv.Quote(value))
+					t.FailNow()
+				}
+			}
+		}
+	})
+}
+
+// Added rows are returned by key and filters with the same values, or the row is rejected with an error
+func FuzzAddRow(f *testing.F) {
+	discardLogs(f)
+	f.Add("1", "Group", "Text")
+	f.Add("", "", "")
+	f.Add("007", "include_deleted", `{"json":"argument"}`)
+	f.Add("-1", "日本語", "Comment with emoji 🏦 and\nnew lines")
+	f.Add("9999999999999999999999", "\x00", "\xc3\x28")
+	f.Fuzz(func(t *testing.T, key, group, text string) {
+		stub := newTableTestStub(t)
+		err := addRow(stub, testTableName, []string{key, group, text}, true)
+		if err != nil {
+			return
+		}
+		if !utf8.ValidString(key) || !utf8.ValidString(group) || !utf8.ValidString(text) {
+			return
+		}
+
+		row, err := getRowByKeyValue(stub, testTableName, key)
+		if err != nil {
+			fmt.Println("Added row", strconv.Quote(key), "is not found", err)
+			t.FailNow()
+		}
+		for i, value := range []string{key, group, text} {
+			if row.Columns[i].Value != value {
+				fmt.Println("Column", testTableColumns[i], "is", strconv.Quote(row.Columns[i].Value), "but not", strconv.Quote(value))
+				t.FailNow()
+			}
+		}
+
+		// A group equal to the include deleted option is taken as the option, not as a filter value
+		if group != IncludeDeletedOption {
+			rows := getTestRows(t, stub, "GroupName", group)
+			if len(rows) != 1 || rows[0]["TestRowID"] != key || rows[0]["RowText"] != text {
+				fmt.Println("Filter by group", strconv.Quote(group), "returned", rows)
+				t.FailNow()
+			}
+		}
+		rows := getTestRows(t, stub, "RowText", text+"x")
+		if len(rows) != 0 {
+			fmt.Println("Filter by another text returned", rows)
+			t.FailNow()
+		}
+
+		result, err := filterTableByKey(stub, testTableName, key)
+		if err != nil {
+			fmt.Println("filterTableByKey failed", err)
+			t.FailNow()
+		}
+		rows = decodeTestRows(t, result)
+		if len(rows) != 1 || rows[0]["GroupName"] != group {
+			fmt.Println("filterTableByKey returned", rows)
+			t.FailNow()
+		}
+	})
+}
+
+// Updated values are read back exactly and old values are not found by filters
+func FuzzUpdateTableField(f *testing.F) {
+	discardLogs(f)
+	f.Add("Text", "New text")
+	f.Add("", "")
+	f.Add("Same", "Same")
+	f.Add("Plain", "Ünïcödé \"quoted\" \\ 🏦 ")
+	f.Fuzz(func(t *testing.T, oldText, newText string) {
+		if !utf8.ValidString(oldText) || !utf8.ValidString(newText) {
+			return
+		}
+		stub := newTableTestStub(t)
+		err := addRow(stub, testTableName, []string{"1", "Group", oldText}, true)
+		if err != nil {
+			fmt.Println("addRow failed", err)
+			t.FailNow()
+		}
+		_, err = updateTableField(stub, []string{testTableName, "1", "RowText", newText})
+		if err != nil {
+			fmt.Println("updateTableField failed", err)
+			t.FailNow()
+		}
+		value, err := getTableColValueByKey(stub, testTableName, "1", "RowText")
+		if err != nil || value != newText {
+			fmt.Println("Updated value is", strconv.Quote(value), "but not", strconv.Quote(newText), err)
+			t.FailNow()
+		}
+		value, err = getTableColValueByKey(stub, testTableName, "1", "GroupName")
+		if err != nil || value != "Group" {
+			fmt.Println("Not updated column is changed to", strconv.Quote(value), err)
+			t.FailNow()
+		}
+		if oldText != newText && oldText != IncludeDeletedOption {
+			rows := getTestRows(t, stub, "RowText", oldText)
+			if len(rows) != 0 {
+				fmt.Println("Old value is still found", rows)
+				t.FailNow()
+			}
+		}
+	})
+}
+
+// Operations of the max key property: add with a generated key, add with the given key or delete the last added row
+type maxKeyOperation struct {
+	Kind uint8
+	Key  uint16
+}
+
+// Max key never decreases, is not less than any key, and generated keys are the next ones after it.
+// Deleted rows keep their keys.
+func TestSLSChaincode_MaxKeyIsMonotonic(t *testing.T) {
+	property := func(operations []maxKeyOperation) bool {
+		stub := newTableTestStub(t)
+		maxKey := 0
+		var keys []string
+		for _, o := range operations {
+			switch o.Kind % 3 {
+			case 0:
+				err := addRow(stub, testTableName, []string{"Group", "Text"}, false)
+				if err != nil {
+					fmt.Println("addRow with generated key failed", err)
+					return false
+				}
+				key := strconv.Itoa(maxKey + 1)
+				_, err = getRowByKeyValue(stub, testTableName, key)
+				if err != nil {
+					fmt.Println("Generated key is not", key, err)
+					return false
+				}
+				keys = append(keys, key)
+			case 1:
+				key := strconv.Itoa(int(o.Key))
+				if addRow(stub, testTableName, []string{key, "Group", "Text"}, true) == nil {
+					keys = append(keys, key)
+				}
+			case 2:
+				if len(keys) > 0 {
+					_, err := deleteRow(stub, []string{testTableName, keys[len(keys)-1]})
+					if err != nil {
+						fmt.Println("deleteRow failed", err)
+						return false
+					}
+					keys = keys[:len(keys)-1]
+				}
+			}
+
+			newMaxKey := getTestMaxKey(t, stub)
+			if newMaxKey < maxKey {
+				fmt.Println("Max key decreased from", maxKey, "to", newMaxKey)
+				return false
+			}
+			for _, key := range keys {
+				k, _ := strconv.Atoi(key)
+				if k > newMaxKey {
+					fmt.Println("Max key", newMaxKey, "is less than key", key)
+					return false
+				}
+			}
+			maxKey = newMaxKey
+		}
+		return true
+	}
+	err := quick.Check(property, &quick.Config{MaxCount: 30})
+	if err != nil {
+		fmt.Println(err)
+		t.FailNow()
+	}
+}
+
+// Filters return exactly the not deleted rows having the value, include deleted option adds deleted ones
+func TestSLSChaincode_FilterReturnsMatchingRows(t *testing.T) {
+	groups := []string{"A", "B", "a", "A ", ""}
+	property := func(rowGroups []uint8, deleted []bool) bool {
+		stub := newTableTestStub(t)
+		expected := make(map[string][]string)
+		expectedWithDeleted := make(map[string][]string)
+		for i, g := range rowGroups {
+			key := strconv.Itoa(i + 1)
+			group := groups[int(g)%len(groups)]
+			err := addRow(stub, testTableName, []string{key, group, "Text " + key}, true)
+			if err != nil {
+				fmt.Println("addRow failed", err)
+				return false
+			}
+			expectedWithDeleted[group] = append(expectedWithDeleted[group], key)
+			if i < len(deleted) && deleted[i] {
+				_, err = deleteRow(stub, []string{testTableName, key})
+				if err != nil {
+					fmt.Println("deleteRow failed", err)
+					return false
+				}
+			} else {
+				expected[group] = append(expected[group], key)
+			}
+		}
+
+		for _, group := range groups {
+			for _, check := range []struct {
+				args []string
+				keys []string
+			}{
+				{[]string{"GroupName", group}, expected[group]},
+				{[]string{"GroupName", group, IncludeDeletedOption}, expectedWithDeleted[group]},
+			} {
+				keys := getTestRowKeys(getTestRows(t, stub, check.args...))
+				sort.Strings(check.keys)
+				if fmt.Sprint(keys) != fmt.Sprint(check.keys) {
+					fmt.Println("Filter", check.args, "returned keys", keys, "expected", check.keys)
+					return false
+				}
+			}
+		}
+		return true
+	}
+	err := quick.Check(property, &quick.Config{MaxCount: 30})
+	if err != nil {
+		fmt.Println(err)
+		t.FailNow()
+	}
+}
+
+// Transactions run in their own goroutines, pending writes, events, audit entries and log contexts of one
+// transaction do not mix with others
+func TestSLSChaincode_ConcurrentTransactions(t *testing.T) {
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		go func(txID string) {
+			stub := shimtest.NewMockStub("concurrent", new(SimpleChaincode))
+			_, err := mockInit(stub, txID, []string{"mode=production"})
+			for j := 0; j < 20 && err == nil; j++ {
+				key := strconv.Itoa(100 + j)
+				// Events are read before mock results, which drop them
+				response := stub.MockInvoke(txID+"-"+key, getMockArgs("addParticipant", []string{key, "Bank " + key, "Bank"}))
+				select {
+				case e := <-stub.ChaincodeEventsChannel:
+					if !strings.Contains(string(e.Payload), `"TxID":"`+txID+"-"+key+`"`) || strings.Count(string(e.Payload), "TxID") != 1 {
+						err = fmt.Errorf("transaction %s-%s sent events %s", txID, key, e.Payload)
+					}
+				default:
+					err = fmt.Errorf("transaction %s-%s sent no events", txID, key)
+				}
+				if _, e := getMockResult(stub, response); e != nil {
+					err = e
+				}
+			}
+			if err == nil {
+				var count []byte
+				count, err = mockInvoke(stub, txID+"-count", "getParticipantsQuantity", nil)
+				if err == nil && string(count) != "20" {
+					err = fmt.Errorf("transaction %s counted %s participants", txID, count)
+				}
+			}
+			errs <- err
+		}("concurrent" + strconv.Itoa(i))
+	}
+	for i := 0; i < 8; i++ {
+		err := <-errs
+		if err != nil {
+			fmt.Println(err)
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,561 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+var simulationReport = flag.Bool("simulation-report", false, "print conflicts of the simulated multi-bank workload")
+var simulationBanks = flag.Int("simulation-banks", 10, "number of banks in the simulated workload, demo data has users of 10 banks")
+var simulationBlocks = flag.Int("simulation-blocks", 50, "number of blocks in the simulated workload")
+var simulationSeed = flag.Int64("simulation-seed", 1, "seed of the simulated workload")
+
+// ============================================================================================================================
+// Deterministic simulation of concurrent transactions. Peers endorse transactions of a block in parallel against the same
+// committed state, then transactions are validated in block order: a transaction is invalidated with an MVCC conflict if
+// rows it read, or rows of tables it scanned, were changed by a transaction committed after its simulation.
+// Reads and writes are recorded per state key, keys of table rows and table definitions are reported as entries of tables.
+// ============================================================================================================================
+
+//Results of simulated transactions
+const SR_Committed = "committed"
+const SR_Failed = "failed"
+const SR_Conflict = "conflict"
+
+// Row of a table, schema of the table or another state key, versions of entries are changed by committed writes
+type ledgerEntry struct {
+	Table  string
+	Key    string
+	Schema bool
+}
+
+func (e ledgerEntry) String() string {
+	switch {
+	case e.Schema:
+		return e.Table + " schema"
+	case e.Table == "":
+		return e.Key
+	}
+	return e.Table + "[" + e.Key + "]"
+}
+
+// Composite keys of rows have the length of the key value and the value as attributes
+func getLedgerEntry(stub shim.ChaincodeStubInterface, key string) ledgerEntry {
+	objectType, attributes, err := stub.SplitCompositeKey(key)
+	switch {
+	case err != nil || objectType == "":
+		return ledgerEntry{Key: key}
+	case objectType == TableDefinitionObjectType && len(attributes) == 1:
+		return ledgerEntry{Table: attributes[0], Schema: true}
+	case len(attributes) == 2:
+		return ledgerEntry{Table: objectType, Key: attributes[1]}
+	}
+	return ledgerEntry{Key: key}
+}
+
+// Rows of the table with versions seen by the transaction
+type rangeRead struct {
+	Table    string
+	Versions map[string]int
+}
+
+func (r rangeRead) String() string {
+	return r.Table + "[*]"
+}
+
+type simulatedLedger struct {
+	stub *shimtest.MockStub
+	// Entries which were never written by simulated transactions have version 0
+	versions map[ledgerEntry]int
+	version  int
+}
+
+func (l *simulatedLedger) getRangeVersions(tableName string) map[string]int {
+	versions := make(map[string]int)
+	for e, v := range l.versions {
+		if !e.Schema && e.Table == tableName {
+			versions[e.Key] = v
+		}
+	}
+	return versions
+}
+
+// Transaction is simulated on a copy of the committed state
+func (l *simulatedLedger) newTxStub(cc *SimpleChaincode, txID string, args [][]byte) *simulatedTxStub {
+	stub := shimtest.NewMockStub(l.stub.Name, cc)
+	snapshot := make(map[string][]byte)
+	for k, v := range l.stub.State {
+		stub.State[k] = v
+		snapshot[k] = v
+	}
+	for e := l.stub.Keys.Front(); e != nil; e = e.Next() {
+		stub.Keys.PushBack(e.Value)
+	}
+	copyPrivateState(stub.PvtState, l.stub.PvtState)
+	stub.MockTransactionStart(txID)
+	return &simulatedTxStub{MockStub: stub, ledger: l, args: args, reads: make(map[ledgerEntry]int),
+		writes: make(map[ledgerEntry]bool), snapshot: snapshot}
+}
+
+// Returns entries read by the transaction which were changed after its simulation
+func (l *simulatedLedger) getConflicts(tx *simulatedTxStub) []string {
+	conflicts := make(map[string]bool)
+	for e, v := range tx.reads {
+		if l.versions[e] != v {
+			conflicts["read "+e.String()] = true
+		}
+	}
+	for _, r := range tx.ranges {
+		if fmt.Sprint(l.getRangeVersions(r.Table)) != fmt.Sprint(r.Versions) {
+			conflicts["range "+r.String()] = true
+		}
+	}
+	var entries []string
+	for c := range conflicts {
+		entries = append(entries, c)
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+func (l *simulatedLedger) commit(tx *simulatedTxStub) {
+	changes := tx.getChanges()
+	var keys []string
+	for k := range changes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	l.stub.MockTransactionStart(tx.TxID)
+	for _, k := range keys {
+		if changes[k] == nil {
+			l.stub.DelState(k)
+		} else {
+			l.stub.PutState(k, changes[k])
+		}
+	}
+	l.stub.MockTransactionEnd(tx.TxID)
+	copyPrivateState(l.stub.PvtState, tx.PvtState)
+
+	l.version++
+	for e := range tx.writes {
+		l.versions[e] = l.version
+	}
+}
+
+// Private data is committed with transactions, versions of its keys are not checked
+func copyPrivateState(to, from map[string]map[string][]byte) {
+	for collection, values := range from {
+		copied := make(map[string][]byte)
+		for k, v := range values {
+			copied[k] = v
+		}
+		to[collection] = copied
+	}
+}
+
+// Stub of a simulated transaction records reads and writes of state keys. Reads of keys written by the transaction
+// itself are not recorded, the chaincode returns them from its pending writes.
+type simulatedTxStub struct {
+	*shimtest.MockStub
+	ledger *simulatedLedger
+	// Arguments of the invoke, the mock stub keeps them unexported
+	args   [][]byte
+	reads  map[ledgerEntry]int
+	ranges []rangeRead
+	writes map[ledgerEntry]bool
+	// State of the committed ledger when the transaction was simulated
+	snapshot map[string][]byte
+}
+
+func (s *simulatedTxStub) read(e ledgerEntry) {
+	if s.writes[e] {
+		return
+	}
+	if _, ok := s.reads[e]; !ok {
+		s.reads[e] = s.ledger.versions[e]
+	}
+}
+
+// Returns state keys changed by the transaction, deleted keys have nil values
+func (s *simulatedTxStub) getChanges() map[string][]byte {
+	changes := make(map[string][]byte)
+	for k, v := range s.State {
+		if old, ok := s.snapshot[k]; !ok || !bytes.Equal(old, v) {
+			changes[k] = v
+		}
+	}
+	for k := range s.snapshot {
+		if _, ok := s.State[k]; !ok {
+			changes[k] = nil
+		}
+	}
+	return changes
+}
+
+func (s *simulatedTxStub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s *simulatedTxStub) GetStringArgs() []string {
+	var args []string
+	for _, a := range s.args {
+		args = append(args, string(a))
+	}
+	return args
+}
+
+func (s *simulatedTxStub) GetFunctionAndParameters() (string, []string) {
+	args := s.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+	return args[0], args[1:]
+}
+
+func (s *simulatedTxStub) GetState(key string) ([]byte, error) {
+	s.read(getLedgerEntry(s, key))
+	return s.MockStub.GetState(key)
+}
+
+func (s *simulatedTxStub) PutState(key string, value []byte) error {
+	err := s.MockStub.PutState(key, value)
+	if err == nil {
+		s.writes[getLedgerEntry(s, key)] = true
+	}
+	return err
+}
+
+func (s *simulatedTxStub) DelState(key string) error {
+	err := s.MockStub.DelState(key)
+	if err == nil {
+		s.writes[getLedgerEntry(s, key)] = true
+	}
+	return err
+}
+
+// Table functions scan all rows of a table
+func (s *simulatedTxStub) GetStateByPartialCompositeKey(objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	s.ranges = append(s.ranges, rangeRead{Table: objectType, Versions: s.ledger.getRangeVersions(objectType)})
+	return s.MockStub.GetStateByPartialCompositeKey(objectType, attributes)
+}
+
+// Invoke with named arguments submitted by the caller
+type simulatedTx struct {
+	caller   testIdentity
+	function string
+	args     map[string]string
+}
+
+type simulatedResult struct {
+	Status    string
+	Conflicts []string
+	Error     error
+}
+
+// Counts of transactions of a function and entries their conflicts were caused by
+type functionConflicts struct {
+	Submitted int
+	Committed int
+	Failed    int
+	Conflicts int
+	Entries   map[string]int
+}
+
+type simulation struct {
+	cc     *SimpleChaincode
+	ledger *simulatedLedger
+	block  int
+	stats  map[string]*functionConflicts
+}
+
+// Starts the simulation on a demo ledger, logs of simulated transactions are discarded
+func newSimulation(t *testing.T) *simulation {
+	cc := new(SimpleChaincode)
+	s := &simulation{cc: cc, ledger: &simulatedLedger{stub: shimtest.NewMockStub("simulation", cc), versions: make(map[ledgerEntry]int)},
+		stats: make(map[string]*functionConflicts)}
+	logOutput = ioutil.Discard
+	checkInit(t, s.ledger.stub, []string{"mode=demo"})
+	return s
+}
+
+func (s *simulation) close() {
+	logOutput = os.Stdout
+	stopActing()
+}
+
+// Runs the transaction alone before the simulated workload, it is not counted in statistics
+func (s *simulation) prepare(t *testing.T, tx simulatedTx) []byte {
+	actAs(tx.caller)
+	defer stopActing()
+	s.block++
+	result, err := mockInvokeWithTransient(s.ledger.stub, "prepare"+strconv.Itoa(s.block), tx.function, tx.args)
+	if err != nil {
+		fmt.Println("Preparing", tx.function, "failed", err)
+		t.FailNow()
+	}
+	return result
+}
+
+// Simulates all transactions against the committed state, then validates and commits them in the given order
+func (s *simulation) runBlock(txs []simulatedTx) []simulatedResult {
+	s.block++
+	stubs := make([]*simulatedTxStub, len(txs))
+	results := make([]simulatedResult, len(txs))
+	for i, tx := range txs {
+		args, transient := getTransientArgs(tx.args)
+		arg, _ := json.Marshal(args)
+		txID := "b" + strconv.Itoa(s.block) + "t" + strconv.Itoa(i)
+		stubs[i] = s.ledger.newTxStub(s.cc, txID, getMockArgs(tx.function, []string{string(arg)}))
+		stubs[i].TransientMap = withTestSalt(transient, txID)
+		actAs(tx.caller)
+		response := s.cc.Invoke(stubs[i])
+		if response.Status != shim.OK {
+			results[i].Error = errors.New(response.Message)
+		}
+		stopActing()
+	}
+
+	for i, tx := range txs {
+		stats, ok := s.stats[tx.function]
+		if !ok {
+			stats = &functionConflicts{Entries: make(map[string]int)}
+			s.stats[tx.function] = stats
+		}
+		stats.Submitted++
+
+		switch {
+		case results[i].Error != nil:
+			results[i].Status = SR_Failed
+			stats.Failed++
+		default:
+			results[i].Conflicts = s.ledger.getConflicts(stubs[i])
+			if len(results[i].Conflicts) > 0 {
+				results[i].Status = SR_Conflict
+				stats.Conflicts++
+				for _, c := range results[i].Conflicts {
+					stats.Entries[c]++
+				}
+			} else {
+				results[i].Status = SR_Committed
+				stats.Committed++
+				s.ledger.commit(stubs[i])
+			}
+		}
+	}
+	return results
+}
+
+// Number of the most frequent conflicting entries reported for every function
+const reportedConflictEntries = 5
+
+func sumConflictEntries(entries map[string]int, names []string) int {
+	var sum int
+	for _, e := range names {
+		sum += entries[e]
+	}
+	return sum
+}
+
+// Returns counts of transactions by function, followed by entries which caused conflicts, the most frequent first
+func (s *simulation) report() string {
+	var functions []string
+	for f := range s.stats {
+		functions = append(functions, f)
+	}
+	sort.Strings(functions)
+
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "Function\tSubmitted\tCommitted\tFailed\tConflicts\tConflict rate")
+	for _, f := range functions {
+		st := s.stats[f]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d%%\n", f, st.Submitted, st.Committed, st.Failed, st.Conflicts, st.Conflicts*100/st.Submitted)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Function\tConflicting entry\tTransactions")
+	for _, f := range functions {
+		entries := s.stats[f].Entries
+		var names []string
+		for e := range entries {
+			names = append(names, e)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if entries[names[i]] != entries[names[j]] {
+				return entries[names[i]] > entries[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		for i, e := range names {
+			if i == reportedConflictEntries {
+				fmt.Fprintf(w, "%s\t%d other entries\t%d\n", f, len(names)-i, sumConflictEntries(entries, names[i:]))
+				break
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\n", f, e, entries[e])
+		}
+	}
+	w.Flush()
+	return b.String()
+}
+
+// Bank 6 of the demo data has users 1-4, bank 7 users 5-8 and so on
+func getSimulatedBankIdentity(bankID int) testIdentity {
+	return testIdentity{CA_Role: FR_Bank, CA_BankID: strconv.Itoa(bankID), CA_UserID: strconv.Itoa((bankID-6)*4 + 1)}
+}
+
+// In every block each bank submits one transaction: it responds to the invitation to Loan Request 1, comments its
+// negotiation or comments the loan term, such comments are added by the assigner on behalf of the bank.
+// Transactions are ordered randomly, the same seed gives the same blocks.
+func runSimulationWorkload(t *testing.T, banks, blocks int, seed int64) *simulation {
+	s := newSimulation(t)
+	rng := rand.New(rand.NewSource(seed))
+
+	s.prepare(t, simulatedTx{assignerIdentity, "addLoanTerm", map[string]string{LT_LoanRequestIDColName: "1",
+		LT_ParagraphNumberColName: "1", LT_LoanTermTextColName: "Margin", LT_LoanTermStatusColName: "Draft"}})
+	// Bank 6 arranges Loan Request 1 and takes part in it with Loan Negotiation 1
+	negotiations := map[int]string{6: "1"}
+	for bankID := 7; bankID < 6+banks; bankID++ {
+		s.prepare(t, simulatedTx{bank6UserIdentity, "addLoanNegotiation", map[string]string{LN_LoanRequestIDColName: "1",
+			LN_ParticipantBankIDColName: strconv.Itoa(bankID), LN_NegotiationStatusColName: "INVITED"}})
+		maxKey, err := getTableMaxKey(s.ledger.stub, LoanNegotiationsTableName)
+		if err != nil {
+			fmt.Println("Failed getting Loan Negotiation of bank", bankID, err)
+			t.FailNow()
+		}
+		negotiations[bankID] = string(maxKey)
+	}
+
+	statuses := []string{"INVITED", "INTERESTED", "DECLINED"}
+	for block := 0; block < blocks; block++ {
+		var txs []simulatedTx
+		for _, i := range rng.Perm(banks) {
+			bankID := 6 + i
+			bank := getSimulatedBankIdentity(bankID)
+			text := "Block " + strconv.Itoa(block)
+			switch rng.Intn(3) {
+			case 0:
+				txs = append(txs, simulatedTx{bank, "updateLoanNegotiationStatus", map[string]string{
+					LN_LoanNegotiationIDColName: negotiations[bankID], LN_NegotiationStatusColName: statuses[rng.Intn(len(statuses))]}})
+			case 1:
+				txs = append(txs, simulatedTx{bank, "updateParticipantBankComment", map[string]string{
+					LN_LoanNegotiationIDColName: negotiations[bankID], LN_ParticipantBankCommentColName: text}})
+			case 2:
+				txs = append(txs, simulatedTx{assignerIdentity, "addLoanTermComment", map[string]string{LTC_LoanTermIDColName: "1",
+					LTC_UserIDColName: bank[CA_UserID], LTC_BankIDColName: bank[CA_BankID], LTC_CommentTextColName: text}})
+			}
+		}
+		s.runBlock(txs)
+	}
+	return s
+}
+
+func checkSimulatedResult(t *testing.T, results []simulatedResult, i int, status string, conflict string) {
+	r := results[i]
+	if r.Status != status {
+		fmt.Println("Transaction", i, "is", r.Status, "but not", status, r.Conflicts, r.Error)
+		t.FailNow()
+	}
+	if conflict == "" {
+		return
+	}
+	for _, c := range r.Conflicts {
+		if c == conflict {
+			return
+		}
+	}
+	fmt.Println("Conflicts of transaction", i, "are", r.Conflicts, "expected", conflict)
+	t.FailNow()
+}
+
+func TestSLSChaincode_SimulationConflicts(t *testing.T) {
+	s := newSimulation(t)
+	defer s.close()
+	comment := func(bank testIdentity, negotiationID, text string) simulatedTx {
+		return simulatedTx{bank, "updateParticipantBankComment", map[string]string{LN_LoanNegotiationIDColName: negotiationID,
+			LN_ParticipantBankCommentColName: text}}
+	}
+
+	// Loan Negotiation 1 is of bank 6, Loan Negotiation 4 of bank 7
+	results := s.runBlock([]simulatedTx{comment(bank6UserIdentity, "1", "First"), comment(bank7UserIdentity, "4", "Second")})
+	checkSimulatedResult(t, results, 0, SR_Committed, "")
+	checkSimulatedResult(t, results, 1, SR_Committed, "")
+
+	// The second update of the row was simulated before the first one is committed
+	results = s.runBlock([]simulatedTx{comment(bank6UserIdentity, "1", "Third"), comment(bank6UserIdentity, "1", "Fourth")})
+	checkSimulatedResult(t, results, 0, SR_Committed, "")
+	checkSimulatedResult(t, results, 1, SR_Conflict, "read LoanNegotiations[1]")
+	value, _ := getTableColValueByKey(s.ledger.stub, LoanNegotiationsTableName, "1", LN_ParticipantBankCommentColName)
+	if isPrivateValueHash(value) {
+		value, _ = getPrivateValue(s.ledger.stub, getDealCollectionName("1"), LoanNegotiationsTableName, "1", LN_ParticipantBankCommentColName, value)
+	}
+	if value != "Third" {
+		fmt.Println("Comment of conflicting transaction is committed:", value)
+		t.FailNow()
+	}
+
+	// Status of the Loan Request is calculated from all negotiations, so statuses of different requests conflict
+	results = s.runBlock([]simulatedTx{
+		{bank6UserIdentity, "updateLoanNegotiationStatus", map[string]string{LN_LoanNegotiationIDColName: "1", LN_NegotiationStatusColName: "INTERESTED"}},
+		{bank7UserIdentity, "updateLoanNegotiationStatus", map[string]string{LN_LoanNegotiationIDColName: "4", LN_NegotiationStatusColName: "INTERESTED"}},
+	})
+	checkSimulatedResult(t, results, 0, SR_Committed, "")
+	checkSimulatedResult(t, results, 1, SR_Conflict, "range LoanNegotiations[*]")
+
+	// Failed transactions are not validated
+	results = s.runBlock([]simulatedTx{comment(bank7UserIdentity, "1", "Denied"), comment(bank6UserIdentity, "1", "Fifth")})
+	checkSimulatedResult(t, results, 0, SR_Failed, "")
+	checkSimulatedResult(t, results, 1, SR_Committed, "")
+}
+
+func TestSLSChaincode_SimulationWorkload(t *testing.T) {
+	s := runSimulationWorkload(t, 5, 10, 1)
+	report := s.report()
+	s.close()
+
+	again := runSimulationWorkload(t, 5, 10, 1)
+	if again.report() != report {
+		fmt.Println("Simulation with the same seed gave another report:\n" + report + "\n" + again.report())
+		t.FailNow()
+	}
+	again.close()
+
+	// Negotiation statuses rewrite Loan Request 1, comments take the next key after the max key
+	for function, entry := range map[string]string{
+		"updateLoanNegotiationStatus": "read LoanRequests[1]",
+		"addLoanTermComment":          "range LoanTermComments[*]",
+	} {
+		if s.stats[function] == nil || s.stats[function].Entries[entry] == 0 {
+			fmt.Println("Expected conflicts of", function, "on", entry, "in report:\n"+report)
+			t.FailNow()
+		}
+	}
+
+	// A single bank never conflicts with itself, because it submits one transaction per block
+	single := runSimulationWorkload(t, 1, 10, 1)
+	defer single.close()
+	for function, st := range single.stats {
+		if st.Conflicts != 0 || st.Failed != 0 {
+			fmt.Println("Transactions of", function, "of a single bank conflicted or failed:\n"+single.report())
+			t.FailNow()
+		}
+	}
+
+	if *simulationReport {
+		r := runSimulationWorkload(t, *simulationBanks, *simulationBlocks, *simulationSeed)
+		defer r.close()
+		fmt.Printf("Simulated %d banks in %d blocks with seed %d\n\n%s", *simulationBanks, *simulationBlocks, *simulationSeed, r.report())
+	}
+}
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Object type of composite keys of table definitions, rows have table names as object types
+const TableDefinitionObjectType = "TableDefinition"
+
+//Composite keys start with it, so they are not returned by range queries of simple keys
+const compositeKeyNamespace = "\x00"
+
+//Lengths of longer key values would have more digits than the zero-padded lengths in row keys
+const MaxKeyValueLength = 9999
+
+// Columns of tables are strings, the first column is the single column key
+type ColumnDefinition struct {
+	Name string
+	Key  bool
+}
+
+type Table struct {
+	Name              string
+	ColumnDefinitions []*ColumnDefinition
+}
+
+type Column struct {
+	Value string
+}
+
+// Row of a table, its columns are nil if the row is not found
+type Row struct {
+	Columns []*Column
+}
+
+// ============================================================================================================================
+// The repository of the chaincode keeps tables in the world state with composite keys, because the table API of the
+// v0.6 shim does not exist in later Fabric versions. Definitions of tables are kept under their names and rows under the table name and
+// the key value, values are JSON. Key values are preceded by their length, so rows are read by partial key queries
+// in the order of the v0.6 table layout, i.e. integer keys in numeric order.
+// Reads of a transaction do not return its own writes in Fabric, but table functions read rows they have just written,
+// e.g. Init creates tables and loads rows into them. So writes are kept in the context of the transaction until it ends
+// and reads return them.
+// ============================================================================================================================
+
+func getState(stub shim.ChaincodeStubInterface, key string) ([]byte, error) {
+	if value, ok := getTxContext(stub).writes[key]; ok {
+		return value, nil
+	}
+	return stub.GetState(key)
+}
+
+func putState(stub shim.ChaincodeStubInterface, key string, value []byte) error {
+	err := stub.PutState(key, value)
+	if err != nil {
+		return err
+	}
+	getTxContext(stub).writes[key] = value
+	return nil
+}
+
+func delState(stub shim.ChaincodeStubInterface, key string) error {
+	err := stub.DelState(key)
+	if err != nil {
+		return err
+	}
+	getTxContext(stub).writes[key] = nil
+	return nil
+}
+
+// Returns values of the keys of the object type in key order including writes of the transaction
+func getStateByObjectType(stub shim.ChaincodeStubInterface, objectType string) ([]string, map[string][]byte, error) {
+	iterator, err := stub.GetStateByPartialCompositeKey(objectType, []string{})
+	if err != nil {
+		return nil, nil, err
+	}
+	prefix, err := stub.CreateCompositeKey(objectType, []string{})
+	if err != nil {
+		iterator.Close()
+		return nil, nil, err
+	}
+	return readPendingIterator(stub, iterator, func(key string) bool { return strings.HasPrefix(key, prefix) })
+}
+
+// Returns values of simple keys from the start key to the end key, which is excluded, including writes of the transaction
+func getStateByRange(stub shim.ChaincodeStubInterface, startKey, endKey string) ([]string, map[string][]byte, error) {
+	iterator, err := stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Empty end key is the end of simple keys, composite keys are not in ranges
+	return readPendingIterator(stub, iterator, func(key string) bool {
+		return key >= startKey && (endKey == "" || key < endKey) && !strings.HasPrefix(key, compositeKeyNamespace)
+	})
+}
+
+// Reads and closes the iterator, then adds pending writes of keys in its range
+func readPendingIterator(stub shim.ChaincodeStubInterface, iterator shim.StateQueryIteratorInterface,
+	isInRange func(key string) bool) ([]string, map[string][]byte, error) {
+	defer iterator.Close()
+
+	var keys []string
+	values := make(map[string][]byte)
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, kv.Key)
+		values[kv.Key] = kv.Value
+	}
+
+	var isWritten bool
+	for key, value := range getTxContext(stub).writes {
+		if !isInRange(key) {
+			continue
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = value
+		isWritten = true
+	}
+	if isWritten {
+		sort.Strings(keys)
+	}
+	return keys, values, nil
+}
+
+// Repository of the composite key layout
+type compositeKeyRepository struct {
+	stub shim.ChaincodeStubInterface
+}
+
+func getCompositeKeyRepository(stub shim.ChaincodeStubInterface) Repository {
+	return compositeKeyRepository{stub}
+}
+
+func getTableDefinitionKey(stub shim.ChaincodeStubInterface, tableName string) (string, error) {
+	return stub.CreateCompositeKey(TableDefinitionObjectType, []string{tableName})
+}
+
+// Lengths of key values are zero-padded, so rows are ordered by the length of the key value first,
+// e.g. key "10" follows key "9"
+func getKeyValueLength(keyValue string) string {
+	return fmt.Sprintf("%04d", len(keyValue))
+}
+
+func getRowKey(stub shim.ChaincodeStubInterface, tableName, keyValue string) (string, error) {
+	if len(keyValue) > MaxKeyValueLength {
+		return "", errors.New("key value is longer than " + strconv.Itoa(MaxKeyValueLength) + " characters")
+	}
+	return stub.CreateCompositeKey(tableName, []string{getKeyValueLength(keyValue), keyValue})
+}
+
+// Rows written before lengths of key values were zero-padded are moved to their current keys. Settings like the
+// schema version are rows too, so it runs before Init reads any row.
+func migrateRowKeys(stub shim.ChaincodeStubInterface) error {
+	tableKeys, tables, err := getStateByObjectType(stub, TableDefinitionObjectType)
+	if err != nil {
+		return wrapError(err, "Error getting tables in migrateRowKeys func: ")
+	}
+
+	var rowQty int
+	for _, tableKey := range tableKeys {
+		if tables[tableKey] == nil {
+			continue
+		}
+		var tbl Table
+		err = json.Unmarshal(tables[tableKey], &tbl)
+		if err != nil {
+			return wrapError(err, "Failed decoding table in migrateRowKeys func: ")
+		}
+		keys, values, err := getStateByObjectType(stub, tbl.Name)
+		if err != nil {
+			return wrapError(err, "Error getting rows of '"+tbl.Name+"' table in migrateRowKeys func: ")
+		}
+		for _, key := range keys {
+			if values[key] == nil {
+				continue
+			}
+			_, attributes, err := stub.SplitCompositeKey(key)
+			if err != nil {
+				return wrapError(err, "Wrong row key of '"+tbl.Name+"' table in migrateRowKeys func: ")
+			}
+			if len(attributes) != 2 || attributes[0] == getKeyValueLength(attributes[1]) {
+				continue
+			}
+			newKey, err := getRowKey(stub, tbl.Name, attributes[1])
+			if err == nil {
+				err = putState(stub, newKey, values[key])
+			}
+			if err == nil {
+				err = delState(stub, key)
+			}
+			if err != nil {
+				return wrapError(err, "Error moving row '"+attributes[1]+"' of '"+tbl.Name+"' table in migrateRowKeys func: ")
+			}
+			rowQty++
+		}
+	}
+
+	if rowQty > 0 {
+		logInfo(stub, "Row keys are migrated", logFields{"rows": strconv.Itoa(rowQty)})
+	}
+	return nil
+}
+
+func (r compositeKeyRepository) GetTable(tableName string) (*Table, error) {
+	key, err := getTableDefinitionKey(r.stub, tableName)
+	if err != nil {
+		return nil, newError(ErrCodeInvalidArgument, "Wrong table name '"+tableName+"': "+err.Error())
+	}
+	b, err := getState(r.stub, key)
+	if err != nil {
+		return nil, wrapError(err, "Failed getting table '"+tableName+"': ")
+	}
+	if b == nil {
+		return nil, newError(ErrCodeNotFound, "Table '"+tableName+"' does not exist")
+	}
+
+	var tbl Table
+	err = json.Unmarshal(b, &tbl)
+	if err != nil {
+		return nil, wrapError(err, "Failed decoding table '"+tableName+"': ")
+	}
+	return &tbl, nil
+}
+
+func (r compositeKeyRepository) PutTable(tbl *Table) error {
+	key, err := getTableDefinitionKey(r.stub, tbl.Name)
+	if err != nil {
+		return newError(ErrCodeInvalidArgument, "Wrong table name '"+tbl.Name+"': "+err.Error())
+	}
+	b, err := json.Marshal(tbl)
+	if err != nil {
+		return wrapError(err, "Failed encoding table '"+tbl.Name+"': ")
+	}
+	return putState(r.stub, key, b)
+}
+
+func (r compositeKeyRepository) DeleteTable(tableName string) error {
+	key, err := getTableDefinitionKey(r.stub, tableName)
+	if err != nil {
+		return newError(ErrCodeInvalidArgument, "Wrong table name '"+tableName+"': "+err.Error())
+	}
+	return delState(r.stub, key)
+}
+
+func decodeRow(b []byte) (Row, error) {
+	var row Row
+	var values []string
+	err := json.Unmarshal(b, &values)
+	if err != nil {
+		return row, err
+	}
+	for _, v := range values {
+		row.Columns = append(row.Columns, &Column{Value: v})
+	}
+	return row, nil
+}
+
+func encodeRow(row Row) ([]byte, error) {
+	values := make([]string, len(row.Columns))
+	for i, c := range row.Columns {
+		values[i] = c.Value
+	}
+	return json.Marshal(values)
+}
+
+func (r compositeKeyRepository) Get(tableName, keyValue string) (Row, error) {
+	var row Row
+	key, err := getRowKey(r.stub, tableName, keyValue)
+	if err != nil {
+		return row, newError(ErrCodeInvalidArgument, "Wrong key '"+keyValue+"' of '"+tableName+"' table: "+err.Error())
+	}
+	b, err := getState(r.stub, key)
+	if err != nil {
+		return row, wrapError(err, "Failed getting row of '"+tableName+"' table: ")
+	}
+	if b == nil {
+		return row, nil
+	}
+	row, err = decodeRow(b)
+	if err != nil {
+		return row, wrapError(err, "Failed decoding row '"+keyValue+"' of '"+tableName+"' table: ")
+	}
+	return row, nil
+}
+
+func (r compositeKeyRepository) Put(tableName string, row Row) error {
+	keyValue := row.Columns[0].Value
+	key, err := getRowKey(r.stub, tableName, keyValue)
+	if err != nil {
+		return newError(ErrCodeInvalidArgument, "Wrong key '"+keyValue+"' of '"+tableName+"' table: "+err.Error())
+	}
+	b, err := encodeRow(row)
+	if err != nil {
+		return wrapError(err, "Failed encoding row '"+keyValue+"' of '"+tableName+"' table: ")
+	}
+	err = putState(r.stub, key, b)
+	if err != nil {
+		return wrapError(err, "Failed saving row '"+keyValue+"' of '"+tableName+"' table: ")
+	}
+	return nil
+}
+
+func (r compositeKeyRepository) Delete(tableName, keyValue string) error {
+	key, err := getRowKey(r.stub, tableName, keyValue)
+	if err != nil {
+		return newError(ErrCodeInvalidArgument, "Wrong key '"+keyValue+"' of '"+tableName+"' table: "+err.Error())
+	}
+	return delState(r.stub, key)
+}
+
+func (r compositeKeyRepository) Query(tableName string, match func(Row) bool) ([]Row, error) {
+	return queryByIterate(r, tableName, match)
+}
+
+func (r compositeKeyRepository) Iterate(tableName string, visit func(Row) bool) error {
+	keys, values, err := getStateByObjectType(r.stub, tableName)
+	if err != nil {
+		return wrapError(err, "Failed getting rows of '"+tableName+"' table: ")
+	}
+	for _, key := range keys {
+		if values[key] == nil {
+			continue
+		}
+		row, err := decodeRow(values[key])
+		if err != nil {
+			return wrapError(err, "Failed decoding row of '"+tableName+"' table: ")
+		}
+		if !visit(row) {
+			break
+		}
+	}
+	return nil
+}
+
+// Table functions used by business logic check tables and rows, the repository of the transaction stores them
+
+// Returns NOT_FOUND error if the table does not exist
+func getTable(stub shim.ChaincodeStubInterface, tableName string) (*Table, error) {
+	return getRepository(stub).GetTable(tableName)
+}
+
+// Saves the table definition, rows of an existing table are kept
+func putTable(stub shim.ChaincodeStubInterface, tbl *Table) error {
+	if tbl.Name == "" || len(tbl.ColumnDefinitions) == 0 {
+		return newError(ErrCodeInvalidArgument, "Table should have a name and columns")
+	}
+	return getRepository(stub).PutTable(tbl)
+}
+
+// Deletes the table with its rows
+func deleteTable(stub shim.ChaincodeStubInterface, tableName string) error {
+	rows, err := getRows(stub, tableName)
+	if err != nil {
+		return err
+	}
+	r := getRepository(stub)
+	for _, row := range rows {
+		err = r.Delete(tableName, row.Columns[0].Value)
+		if err != nil {
+			return err
+		}
+	}
+	return r.DeleteTable(tableName)
+}
+
+// Returns the row by key value, columns of the returned row are nil if the row does not exist.
+// The table is not read, so reads of rows do not depend on table definitions.
+func getRow(stub shim.ChaincodeStubInterface, tableName, keyValue string) (Row, error) {
+	return getRepository(stub).Get(tableName, keyValue)
+}
+
+// Returns all rows of the table in key order
+func getRows(stub shim.ChaincodeStubInterface, tableName string) ([]Row, error) {
+	return queryRows(stub, tableName, nil)
+}
+
+// Returns rows of the table for which match returns true in key order
+func queryRows(stub shim.ChaincodeStubInterface, tableName string, match func(Row) bool) ([]Row, error) {
+	r := getRepository(stub)
+	_, err := r.GetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	return r.Query(tableName, match)
+}
+
+// Calls visit for rows of the table in key order until it returns false
+func iterateRows(stub shim.ChaincodeStubInterface, tableName string, visit func(Row) bool) error {
+	r := getRepository(stub)
+	_, err := r.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+	return r.Iterate(tableName, visit)
+}
+
+// Inserts the row if there is no row with its key or replaces the existing one.
+// Returns false without writing the row if the existence of the row is not the expected one.
+func putRow(stub shim.ChaincodeStubInterface, tableName string, row Row, isExisting bool) (bool, error) {
+	r := getRepository(stub)
+	tbl, err := r.GetTable(tableName)
+	if err != nil {
+		return false, err
+	}
+	if len(row.Columns) != len(tbl.ColumnDefinitions) {
+		return false, newError(ErrCodeInvalidArgument, "Row of '"+tableName+"' table has "+strconv.Itoa(len(row.Columns))+
+			" columns, expected "+strconv.Itoa(len(tbl.ColumnDefinitions)))
+	}
+
+	existing, err := r.Get(tableName, row.Columns[0].Value)
+	if err != nil {
+		return false, err
+	}
+	if (existing.Columns != nil) != isExisting {
+		return false, nil
+	}
+	err = r.Put(tableName, row)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Returns false if a row with the same key exists
+func insertRow(stub shim.ChaincodeStubInterface, tableName string, row Row) (bool, error) {
+	return putRow(stub, tableName, row, false)
+}
+
+// Returns false if the row does not exist
+func replaceRow(stub shim.ChaincodeStubInterface, tableName string, row Row) (bool, error) {
+	return putRow(stub, tableName, row, true)
+}
+
+func deleteTableRow(stub shim.ChaincodeStubInterface, tableName, keyValue string) error {
+	return getRepository(stub).Delete(tableName, keyValue)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	//"errors"
+	//"fmt"
+	"sync"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// State of a running Init or Invoke
+type txContext struct {
+	// Values written by the transaction, deleted keys have nil values
+	writes map[string][]byte
+	// Row events of the invoke, nil when events are not collected
+	events []*chaincodeEvent
+	// Number of audit entries made by the transaction
+	auditLogSequence int
+	// Function, caller and log level of Init or Invoke, nil outside of them
+	log *logContext
+}
+
+// Contexts of running transactions by transaction ID
+var txContexts = make(map[string]*txContext)
+var txContextsMutex sync.Mutex
+
+// ============================================================================================================================
+// The shim runs every Init and Invoke in its own goroutine. The map of contexts is shared by transactions, so it is
+// guarded by the mutex, while a context is used only by the goroutine of its transaction and is not locked.
+// ============================================================================================================================
+
+// Returns the context of the running transaction, the first call creates it
+func getTxContext(stub shim.ChaincodeStubInterface) *txContext {
+	txContextsMutex.Lock()
+	defer txContextsMutex.Unlock()
+	ctx, ok := txContexts[stub.GetTxID()]
+	if !ok {
+		ctx = &txContext{writes: make(map[string][]byte)}
+		txContexts[stub.GetTxID()] = ctx
+	}
+	return ctx
+}
+
+// Should be called when the transaction ends
+func endTxContext(stub shim.ChaincodeStubInterface) {
+	txContextsMutex.Lock()
+	defer txContextsMutex.Unlock()
+	delete(txContexts, stub.GetTxID())
+}
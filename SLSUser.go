@@ -1,23 +1,22 @@
+// Code generated by entitygen from api/entities.json. DO NOT EDIT.
+
 package main
 
 import (
-	//"encoding/json"
-	"errors"
-	//"fmt"
 	"strconv"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
-//Entity names
+// Entity names
 const UserTableName = "Users"
 
-//Column names
+// Column names
 const U_UserIDColName = "UserID"
 const U_ParticipantIDColName = "ParticipantID"
 const U_UserNameColName = "UserName"
 
-//Column quantity
+// Column quantity
 const UserTableColsQty = 3
 
 // ============================================================================================================================
@@ -25,17 +24,35 @@ const UserTableColsQty = 3
 // ============================================================================================================================
 
 func CreateUserTable(stub shim.ChaincodeStubInterface) error {
-	U_ColumnNames := []string{U_UserIDColName, U_ParticipantIDColName, U_UserNameColName}
-	return createTable(stub, UserTableName, U_ColumnNames)
+	columnNames := []string{U_UserIDColName, U_ParticipantIDColName, U_UserNameColName}
+	return createTable(stub, UserTableName, columnNames)
 }
 
-func addUser(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+func init() {
+	registerFunction(functionDefinition{Name: "addUser", Mode: FM_Write, Role: FR_Assigner, ArgsFormat: AF_Object, Table: UserTableName, Key: AK_Optional,
+		handler: addUser, Description: "Adds User"})
+	registerFunction(functionDefinition{Name: "updateUser", Mode: FM_Write, ArgsFormat: AF_Object, Table: UserTableName, Key: AK_Required,
+		handler: updateUser, Description: "Updates User, omitted columns keep current values"})
+	registerFunction(functionDefinition{Name: "patchUser", Mode: FM_Write, ArgsFormat: AF_Object, Table: UserTableName, Key: AK_Required,
+		Result: RT_Text, Args: []argumentDefinition{patchETagArg}, handler: patchUser, Description: "Changes given columns of User and returns its new ETag"})
+	registerFunction(functionDefinition{Name: "getUserQuantity", Mode: FM_Read, Result: RT_Text, Table: UserTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getUserQuantity, Description: "Returns number of Users"})
+	registerFunction(functionDefinition{Name: "getUserList", Mode: FM_Read, Table: UserTableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: getUserList, Description: "Returns all Users"})
+	registerFunction(functionDefinition{Name: "getUserByKey", Mode: FM_Read, Table: UserTableName,
+		Args: []argumentDefinition{{Name: "key"}}, handler: getUserByKey, Description: "Returns User with the key"})
+	registerFunction(functionDefinition{Name: "getUserMaxKey", Mode: FM_Read, Result: RT_Text, Table: UserTableName,
+		handler: getUserMaxKey, Description: "Returns the greatest key of Users"})
+
+	foreignKeys = append(foreignKeys,
+		foreignKey{UserTableName, U_ParticipantIDColName, ParticipantsTableName, FK_OnDeleteRestrict},
+	)
+}
 
-	attrName := "role"
-	attrValue := "assigner"
-	checkPermissionsAssigner, errA := checkAttribute(stub, attrName, attrValue)
-	if !checkPermissionsAssigner {
-		return nil, errors.New("Error checking permission in addUser: " + errA.Error())
+func addUser(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getAddArgs(stub, UserTableName, args)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(args) == UserTableColsQty {
@@ -45,22 +62,22 @@ func addUser(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 		return nil, addRow(stub, UserTableName, args, false)
 	}
 
-	return nil, errors.New("Incorrect number of arguments. " +
-		"Provided " + strconv.Itoa(len(args)) + "Expecting " + strconv.Itoa(UserTableColsQty-1) +
-		" or " + strconv.Itoa(UserTableColsQty))
+	return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in addUser func. "+
+		"Provided "+strconv.Itoa(len(args))+", expecting "+strconv.Itoa(UserTableColsQty-1)+
+		" or "+strconv.Itoa(UserTableColsQty))
 }
 
 func getUserQuantity(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return countTableRows(stub, []string{UserTableName})
+	return countTableRows(stub, passIncludeDeletedOption([]string{UserTableName}, args))
 }
 
 func getUserList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	return filterTableByValue(stub, []string{UserTableName})
+	return filterTableByValue(stub, passIncludeDeletedOption([]string{UserTableName}, args))
 }
 
 func getUserByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	if len(args) != 1 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 1")
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in getUserByKey func. Expecting 1")
 	}
 	keyValue := args[0]
 	return filterTableByKey(stub, UserTableName, keyValue)
@@ -69,27 +86,39 @@ func getUserByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, erro
 func getUserMaxKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	maxKey, err := getTableMaxKey(stub, UserTableName)
 	if err != nil {
-		return nil, errors.New("Error in getUserMaxKey func: " + err.Error())
+		return nil, wrapError(err, "Error in getUserMaxKey func: ")
 	}
 	return maxKey, nil
 }
 
 func updateUser(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getUpdateArgs(stub, UserTableName, args)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(args) != UserTableColsQty {
-		return nil, errors.New("Incorrect number of arguments in updateUser func. Expecting " + strconv.Itoa(UserTableColsQty))
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in updateUser func. Expecting "+strconv.Itoa(UserTableColsQty))
 	}
 
-	tbl, err := stub.GetTable(UserTableName)
+	changes, err := getUpdateChanges(stub, UserTableName, args)
 	if err != nil {
-		return nil, errors.New("An error occured while running updateUser: " + err.Error())
+		return nil, wrapError(err, "An error occured while running updateUser: ")
 	}
 
-	for i, cd := range tbl.ColumnDefinitions {
-		_, err := updateTableField(stub, []string{UserTableName, args[0], cd.Name, args[i]}) //args[0] is hardcoded as row id
-		if err != nil {
-			return nil, errors.New("Failed updating field '" + cd.Name + "' in updateUser func: " + err.Error())
-		}
+	_, err = patchRow(stub, UserTableName, args[0], changes, "")
+	if err != nil {
+		return nil, wrapError(err, "Failed updating row in updateUser func: ")
 	}
 
 	return nil, nil
 }
+
+// Changes only given columns: JSON object with the key, changed columns and optional ETag. Returns the new ETag.
+func patchUser(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	keyValue, changes, eTag, err := parsePatchArgs(stub, UserTableName, args)
+	if err != nil {
+		return nil, err
+	}
+	return patchRow(stub, UserTableName, keyValue, changes, eTag)
+}
@@ -0,0 +1,1373 @@
+// Code generated by clientgen from api/catalogue.json. DO NOT EDIT.
+
+package slsclient
+
+import "encoding/json"
+
+// ArchivedLoanRequest is a row of ArchivedLoanRequests table
+type ArchivedLoanRequest struct {
+	LoanRequestID        string
+	BorrowerID           string
+	ArrangerBankID       string
+	LoanSharesAmount     string
+	ProjectRevenue       string
+	ProjectName          string
+	ProjectInformation   string
+	Company              string
+	Website              string
+	ContactPersonName    string
+	ContactPersonSurname string
+	RequestDate          string
+	Status               string
+	MarketAndIndustry    string
+	LoanTerm             string
+	Assets               string
+	Convenants           string
+	InterestRate         string
+	Currency             string
+}
+
+// AuditLog is a row of AuditLog table
+type AuditLog struct {
+	AuditLogID string
+	TableName  string
+	RowKey     string
+	Action     string
+	ColumnName string
+	OldValue   string
+	NewValue   string
+	BankID     string
+	UserID     string
+	TxID       string
+	Date       string
+}
+
+// DeletedRow is a row of DeletedRows table
+type DeletedRow struct {
+	DeletedRowID    string
+	TableName       string
+	RowKey          string
+	DeletedByBankID string
+	DeletedByUserID string
+	DeletedDate     string
+	DeleteTxID      string
+}
+
+// LoanNegotiation is a row of LoanNegotiations table
+type LoanNegotiation struct {
+	LoanNegotiationID      string
+	LoanRequestID          string
+	ParticipantBankID      string
+	Amount                 string
+	NegotiationStatus      string
+	ParticipantBankComment string
+	Date                   string
+	ResponseDeadline       string
+}
+
+// LoanNegotiationFields are columns of LoanNegotiations table given to write functions, nil columns are omitted
+type LoanNegotiationFields struct {
+	LoanNegotiationID      *string `json:",omitempty"`
+	LoanRequestID          *string `json:",omitempty"`
+	ParticipantBankID      *string `json:",omitempty"`
+	Amount                 *string `json:",omitempty"`
+	NegotiationStatus      *string `json:",omitempty"`
+	ParticipantBankComment *string `json:",omitempty"`
+	Date                   *string `json:",omitempty"`
+	ResponseDeadline       *string `json:",omitempty"`
+}
+
+// LoanRequest is a row of LoanRequests table
+type LoanRequest struct {
+	LoanRequestID        string
+	BorrowerID           string
+	ArrangerBankID       string
+	LoanSharesAmount     string
+	ProjectRevenue       string
+	ProjectName          string
+	ProjectInformation   string
+	Company              string
+	Website              string
+	ContactPersonName    string
+	ContactPersonSurname string
+	RequestDate          string
+	Status               string
+	MarketAndIndustry    string
+	LoanTerm             string
+	Assets               string
+	Convenants           string
+	InterestRate         string
+	Currency             string
+}
+
+// LoanRequestFields are columns of LoanRequests table given to write functions, nil columns are omitted
+type LoanRequestFields struct {
+	LoanRequestID        *string `json:",omitempty"`
+	BorrowerID           *string `json:",omitempty"`
+	ArrangerBankID       *string `json:",omitempty"`
+	LoanSharesAmount     *string `json:",omitempty"`
+	ProjectRevenue       *string `json:",omitempty"`
+	ProjectName          *string `json:",omitempty"`
+	ProjectInformation   *string `json:",omitempty"`
+	Company              *string `json:",omitempty"`
+	Website              *string `json:",omitempty"`
+	ContactPersonName    *string `json:",omitempty"`
+	ContactPersonSurname *string `json:",omitempty"`
+	RequestDate          *string `json:",omitempty"`
+	Status               *string `json:",omitempty"`
+	MarketAndIndustry    *string `json:",omitempty"`
+	LoanTerm             *string `json:",omitempty"`
+	Assets               *string `json:",omitempty"`
+	Convenants           *string `json:",omitempty"`
+	InterestRate         *string `json:",omitempty"`
+	Currency             *string `json:",omitempty"`
+}
+
+// LoanTermComment is a row of LoanTermComments table
+type LoanTermComment struct {
+	LoanTermCommentID       string
+	ParentLoanTermCommentID string
+	LoanTermID              string
+	UserID                  string
+	BankID                  string
+	CommentText             string
+	LoanTermCommentDate     string
+}
+
+// LoanTermCommentFields are columns of LoanTermComments table given to write functions, nil columns are omitted
+type LoanTermCommentFields struct {
+	LoanTermCommentID       *string `json:",omitempty"`
+	ParentLoanTermCommentID *string `json:",omitempty"`
+	LoanTermID              *string `json:",omitempty"`
+	UserID                  *string `json:",omitempty"`
+	BankID                  *string `json:",omitempty"`
+	CommentText             *string `json:",omitempty"`
+	LoanTermCommentDate     *string `json:",omitempty"`
+}
+
+// LoanTermProposal is a row of LoanTermProposals table
+type LoanTermProposal struct {
+	LoanTermProposalID      string
+	LoanTermID              string
+	ParagraphNumber         string
+	LoanTermProposalText    string
+	LoanTermProposalExpTime string
+	LoanTermProposalStatus  string
+}
+
+// LoanTermProposalFields are columns of LoanTermProposals table given to write functions, nil columns are omitted
+type LoanTermProposalFields struct {
+	LoanTermProposalID      *string `json:",omitempty"`
+	LoanTermID              *string `json:",omitempty"`
+	ParagraphNumber         *string `json:",omitempty"`
+	LoanTermProposalText    *string `json:",omitempty"`
+	LoanTermProposalExpTime *string `json:",omitempty"`
+	LoanTermProposalStatus  *string `json:",omitempty"`
+}
+
+// LoanTermVote is a row of LoanTermVotes table
+type LoanTermVote struct {
+	LoanTermVoteID       string
+	LoanTermProposalID   string
+	BankID               string
+	LoanTermVoteStatus   string
+	LoanTermVoteDeadline string
+}
+
+// LoanTermVoteFields are columns of LoanTermVotes table given to write functions, nil columns are omitted
+type LoanTermVoteFields struct {
+	LoanTermVoteID       *string `json:",omitempty"`
+	LoanTermProposalID   *string `json:",omitempty"`
+	BankID               *string `json:",omitempty"`
+	LoanTermVoteStatus   *string `json:",omitempty"`
+	LoanTermVoteDeadline *string `json:",omitempty"`
+}
+
+// LoanTerm is a row of LoanTerms table
+type LoanTerm struct {
+	LoanTermID      string
+	LoanRequestID   string
+	ParagraphNumber string
+	LoanTermText    string
+	LoanTermStatus  string
+}
+
+// LoanTermFields are columns of LoanTerms table given to write functions, nil columns are omitted
+type LoanTermFields struct {
+	LoanTermID      *string `json:",omitempty"`
+	LoanRequestID   *string `json:",omitempty"`
+	ParagraphNumber *string `json:",omitempty"`
+	LoanTermText    *string `json:",omitempty"`
+	LoanTermStatus  *string `json:",omitempty"`
+}
+
+// MaintenanceLog is a row of MaintenanceLog table
+type MaintenanceLog struct {
+	MaintenanceLogID string
+	Function         string
+	Arguments        string
+	BankID           string
+	UserID           string
+	TxID             string
+	Date             string
+}
+
+// Participant is a row of Participants table
+type Participant struct {
+	ParticipantKey  string
+	ParticipantName string
+	ParticipantType string
+}
+
+// ParticipantFields are columns of Participants table given to write functions, nil columns are omitted
+type ParticipantFields struct {
+	ParticipantKey  *string `json:",omitempty"`
+	ParticipantName *string `json:",omitempty"`
+	ParticipantType *string `json:",omitempty"`
+}
+
+// SealedBidRound is a row of SealedBidRounds table
+type SealedBidRound struct {
+	LoanRequestID      string
+	CommitmentDeadline string
+	RoundStatus        string
+	RevealDeadline     string
+}
+
+// SealedBid is a row of SealedBids table
+type SealedBid struct {
+	LoanNegotiationID string
+	LoanRequestID     string
+	Commitment        string
+	BidStatus         string
+	Date              string
+}
+
+// Setting is a row of Settings table
+type Setting struct {
+	SettingName  string
+	SettingValue string
+}
+
+// User is a row of Users table
+type User struct {
+	UserID        string
+	ParticipantID string
+	UserName      string
+}
+
+// UserFields are columns of Users table given to write functions, nil columns are omitted
+type UserFields struct {
+	UserID        *string `json:",omitempty"`
+	ParticipantID *string `json:",omitempty"`
+	UserName      *string `json:",omitempty"`
+}
+
+// AddLoanNegotiation adds Loan Negotiation.
+func (c *Client) AddLoanNegotiation(fields LoanNegotiationFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("addLoanNegotiation", args)
+	return err
+}
+
+// AddLoanRequest adds Loan Request.
+func (c *Client) AddLoanRequest(fields LoanRequestFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("addLoanRequest", args)
+	return err
+}
+
+// AddLoanTerm adds Loan Term. Requires 'assigner' role.
+func (c *Client) AddLoanTerm(fields LoanTermFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("addLoanTerm", args)
+	return err
+}
+
+// AddLoanTermComment adds Loan Term Comment. Requires 'assigner' role.
+func (c *Client) AddLoanTermComment(fields LoanTermCommentFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("addLoanTermComment", args)
+	return err
+}
+
+// AddLoanTermProposal adds Loan Term Proposal. Requires 'assigner' role.
+func (c *Client) AddLoanTermProposal(fields LoanTermProposalFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("addLoanTermProposal", args)
+	return err
+}
+
+// AddLoanTermVote adds Loan Term Vote. Requires 'assigner' role.
+func (c *Client) AddLoanTermVote(fields LoanTermVoteFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("addLoanTermVote", args)
+	return err
+}
+
+// AddParticipant adds Participant. Requires 'assigner' role.
+func (c *Client) AddParticipant(fields ParticipantFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("addParticipant", args)
+	return err
+}
+
+// AddUser adds User. Requires 'assigner' role.
+func (c *Client) AddUser(fields UserFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("addUser", args)
+	return err
+}
+
+// AllocateSealedBids excludes bids which were not revealed and closes the round after the reveal deadline. Requires 'bank' role.
+func (c *Client) AllocateSealedBids(loanRequestID string) error {
+	args, err := objectArgs(nil, map[string]string{"LoanRequestID": loanRequestID})
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("allocateSealedBids", args)
+	return err
+}
+
+// ArchiveLoanRequest moves closed or repaid Loan Request and its rows to archive tables.
+func (c *Client) ArchiveLoanRequest(loanRequestID string) error {
+	args := positionalArgs([]string{loanRequestID}, 1)
+	_, err := c.invoke("archiveLoanRequest", args)
+	return err
+}
+
+// CommitSealedBid commits or replaces the sealed bid of Loan Negotiation before the deadline. Requires 'bank' role.
+// commitment: hex SHA-256 of the JSON array [amount, salt]
+func (c *Client) CommitSealedBid(loanNegotiationID string, commitment string) error {
+	args, err := objectArgs(nil, map[string]string{"LoanNegotiationID": loanNegotiationID, "Commitment": commitment})
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("commitSealedBid", args)
+	return err
+}
+
+// CountTableRows returns number of rows of the table. Requires 'assigner' role.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) CountTableRows(table string, option string) (string, error) {
+	args := positionalArgs([]string{table, option}, 1)
+	result, err := c.query("countTableRows", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// DeleteRow marks the row and rows referencing it deleted. Requires 'assigner' role.
+func (c *Client) DeleteRow(table string, key string) error {
+	args := positionalArgs([]string{table, key}, 2)
+	_, err := c.invoke("deleteRow", args)
+	return err
+}
+
+// DeleteRowsByColumnValue marks rows with the column value deleted, all rows of the table if column is not given. Requires 'assigner' role.
+func (c *Client) DeleteRowsByColumnValue(table string, column string, value string) error {
+	args := positionalArgs([]string{table, column, value}, 1)
+	_, err := c.invoke("deleteRowsByColumnValue", args)
+	return err
+}
+
+// DescribeAPI returns definitions of all functions and columns of their tables.
+func (c *Client) DescribeAPI() (json.RawMessage, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("describeAPI", args)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(result), nil
+}
+
+// ExpireOverdue moves invited Loan Negotiations after their response deadlines to EXPIRED and open Loan Term Proposals after their expiration times to LAPSED, returns numbers of changed rows.
+func (c *Client) ExpireOverdue() (json.RawMessage, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.invoke("expireOverdue", args)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(result), nil
+}
+
+// FilterArchiveTableByValue returns archived rows of the table with the column value. Requires 'assigner' role.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) FilterArchiveTableByValue(table string, column string, value string, option string) (json.RawMessage, error) {
+	args := positionalArgs([]string{table, column, value, option}, 1)
+	result, err := c.query("filterArchiveTableByValue", args)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(result), nil
+}
+
+// FilterTableByValue returns rows with the column value. Requires 'assigner' role.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) FilterTableByValue(table string, column string, value string, option string) (json.RawMessage, error) {
+	args := positionalArgs([]string{table, column, value, option}, 1)
+	result, err := c.query("filterTableByValue", args)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(result), nil
+}
+
+// GetArchivedLoanRequestByKey returns archived Loan Request with the key.
+func (c *Client) GetArchivedLoanRequestByKey(key string) ([]ArchivedLoanRequest, error) {
+	args := positionalArgs([]string{key}, 1)
+	result, err := c.query("getArchivedLoanRequestByKey", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []ArchivedLoanRequest
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetArchivedLoanRequestsList returns all archived Loan Requests.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetArchivedLoanRequestsList(option string) ([]ArchivedLoanRequest, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getArchivedLoanRequestsList", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []ArchivedLoanRequest
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetAuditLogByActor returns changes made by the bank or its user. Requires 'assigner' role.
+func (c *Client) GetAuditLogByActor(bankid string, userid string) ([]AuditLog, error) {
+	args := positionalArgs([]string{bankid, userid}, 1)
+	result, err := c.query("getAuditLogByActor", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []AuditLog
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetAuditLogByEntity returns history of the row. Requires 'assigner' role.
+func (c *Client) GetAuditLogByEntity(table string, key string) ([]AuditLog, error) {
+	args := positionalArgs([]string{table, key}, 2)
+	result, err := c.query("getAuditLogByEntity", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []AuditLog
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetAuditLogByTimeRange returns changes made in the time range, empty dates are not limited. Requires 'assigner' role.
+// from: RFC3339 date, included
+// to: RFC3339 date, excluded
+func (c *Client) GetAuditLogByTimeRange(from string, to string) ([]AuditLog, error) {
+	args := positionalArgs([]string{from, to}, 2)
+	result, err := c.query("getAuditLogByTimeRange", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []AuditLog
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetBankId returns bankid of the caller.
+func (c *Client) GetBankId() (string, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getBankId", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetCertAttribute returns certificate attribute of the caller.
+func (c *Client) GetCertAttribute(name string) (string, error) {
+	args := positionalArgs([]string{name}, 1)
+	result, err := c.query("getCertAttribute", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetDealCollectionsConfig returns the collection configuration of the chaincode with the private data collection of every deal. Requires 'assigner' role.
+func (c *Client) GetDealCollectionsConfig() (json.RawMessage, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getDealCollectionsConfig", args)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(result), nil
+}
+
+// GetDeletedRowsList returns deletion marks of all rows or rows of the table. Requires 'assigner' role.
+func (c *Client) GetDeletedRowsList(table string) ([]DeletedRow, error) {
+	args := positionalArgs([]string{table}, 0)
+	result, err := c.query("getDeletedRowsList", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []DeletedRow
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetEventCatalogueList returns all event types with descriptions.
+func (c *Client) GetEventCatalogueList() (json.RawMessage, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getEventCatalogueList", args)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(result), nil
+}
+
+// GetLoanNegotiationByKey returns Loan Negotiation with the key.
+func (c *Client) GetLoanNegotiationByKey(key string) ([]LoanNegotiation, error) {
+	args := positionalArgs([]string{key}, 1)
+	result, err := c.query("getLoanNegotiationByKey", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LoanNegotiation
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLoanNegotiationsList returns all Loan Negotiations.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetLoanNegotiationsList(option string) ([]LoanNegotiation, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getLoanNegotiationsList", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LoanNegotiation
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLoanNegotiationsMaxKey returns the greatest key of Loan Negotiations.
+func (c *Client) GetLoanNegotiationsMaxKey() (string, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getLoanNegotiationsMaxKey", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetLoanNegotiationsQuantity returns number of Loan Negotiations.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetLoanNegotiationsQuantity(option string) (string, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getLoanNegotiationsQuantity", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetLoanRequestByKey returns Loan Request with the key.
+func (c *Client) GetLoanRequestByKey(key string) ([]LoanRequest, error) {
+	args := positionalArgs([]string{key}, 1)
+	result, err := c.query("getLoanRequestByKey", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LoanRequest
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLoanRequestsList returns all Loan Requests.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetLoanRequestsList(option string) ([]LoanRequest, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getLoanRequestsList", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LoanRequest
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLoanRequestsMaxKey returns the greatest key of Loan Requests.
+func (c *Client) GetLoanRequestsMaxKey() (string, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getLoanRequestsMaxKey", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetLoanRequestsQuantity returns number of Loan Requests.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetLoanRequestsQuantity(option string) (string, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getLoanRequestsQuantity", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetLoanTermByKey returns Loan Term with the key.
+func (c *Client) GetLoanTermByKey(key string) ([]LoanTerm, error) {
+	args := positionalArgs([]string{key}, 1)
+	result, err := c.query("getLoanTermByKey", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LoanTerm
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLoanTermCommentByKey returns Loan Term Comment with the key.
+func (c *Client) GetLoanTermCommentByKey(key string) ([]LoanTermComment, error) {
+	args := positionalArgs([]string{key}, 1)
+	result, err := c.query("getLoanTermCommentByKey", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LoanTermComment
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLoanTermCommentList returns all Loan Term Comments.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetLoanTermCommentList(option string) ([]LoanTermComment, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getLoanTermCommentList", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LoanTermComment
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLoanTermCommentMaxKey returns the greatest key of Loan Term Comments.
+func (c *Client) GetLoanTermCommentMaxKey() (string, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getLoanTermCommentMaxKey", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetLoanTermCommentQuantity returns number of Loan Term Comments.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetLoanTermCommentQuantity(option string) (string, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getLoanTermCommentQuantity", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetLoanTermList returns all Loan Terms.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetLoanTermList(option string) ([]LoanTerm, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getLoanTermList", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LoanTerm
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLoanTermMaxKey returns the greatest key of Loan Terms.
+func (c *Client) GetLoanTermMaxKey() (string, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getLoanTermMaxKey", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetLoanTermProposalByKey returns Loan Term Proposal with the key.
+func (c *Client) GetLoanTermProposalByKey(key string) ([]LoanTermProposal, error) {
+	args := positionalArgs([]string{key}, 1)
+	result, err := c.query("getLoanTermProposalByKey", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LoanTermProposal
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLoanTermProposalList returns all Loan Term Proposals.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetLoanTermProposalList(option string) ([]LoanTermProposal, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getLoanTermProposalList", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LoanTermProposal
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLoanTermProposalMaxKey returns the greatest key of Loan Term Proposals.
+func (c *Client) GetLoanTermProposalMaxKey() (string, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getLoanTermProposalMaxKey", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetLoanTermProposalQuantity returns number of Loan Term Proposals.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetLoanTermProposalQuantity(option string) (string, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getLoanTermProposalQuantity", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetLoanTermQuantity returns number of Loan Terms.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetLoanTermQuantity(option string) (string, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getLoanTermQuantity", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetLoanTermVoteByKey returns Loan Term Vote with the key.
+func (c *Client) GetLoanTermVoteByKey(key string) ([]LoanTermVote, error) {
+	args := positionalArgs([]string{key}, 1)
+	result, err := c.query("getLoanTermVoteByKey", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LoanTermVote
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLoanTermVoteList returns all Loan Term Votes.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetLoanTermVoteList(option string) ([]LoanTermVote, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getLoanTermVoteList", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LoanTermVote
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLoanTermVoteMaxKey returns the greatest key of Loan Term Votes.
+func (c *Client) GetLoanTermVoteMaxKey() (string, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getLoanTermVoteMaxKey", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetLoanTermVoteQuantity returns number of Loan Term Votes.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetLoanTermVoteQuantity(option string) (string, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getLoanTermVoteQuantity", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetMaintenanceLogList returns all maintenance invokes. Requires 'assigner' role.
+func (c *Client) GetMaintenanceLogList() ([]MaintenanceLog, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getMaintenanceLogList", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []MaintenanceLog
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetParticipantsByKey returns Participant with the key.
+func (c *Client) GetParticipantsByKey(key string) ([]Participant, error) {
+	args := positionalArgs([]string{key}, 1)
+	result, err := c.query("getParticipantsByKey", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []Participant
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetParticipantsByType returns Participants of the type.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetParticipantsByType(participantType string, option string) ([]Participant, error) {
+	args := positionalArgs([]string{participantType, option}, 1)
+	result, err := c.query("getParticipantsByType", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []Participant
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetParticipantsList returns all Participants.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetParticipantsList(option string) ([]Participant, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getParticipantsList", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []Participant
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetParticipantsMaxKey returns the greatest key of Participants.
+func (c *Client) GetParticipantsMaxKey() (string, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getParticipantsMaxKey", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetParticipantsQuantity returns number of Participants.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetParticipantsQuantity(option string) (string, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getParticipantsQuantity", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetPrivateValueSalt returns the salt of the private column for readers of its value, so they can disclose the value.
+func (c *Client) GetPrivateValueSalt(table string, key string, column string) (string, error) {
+	args := positionalArgs([]string{table, key, column}, 3)
+	result, err := c.query("getPrivateValueSalt", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetProjectsList returns Loan Requests arranged by the bank of the caller.
+func (c *Client) GetProjectsList() ([]LoanRequest, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getProjectsList", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LoanRequest
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetRowETag returns ETag of the row.
+func (c *Client) GetRowETag(table string, key string) (string, error) {
+	args := positionalArgs([]string{table, key}, 2)
+	result, err := c.query("getRowETag", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetSchemaVersion returns schema version of the ledger.
+func (c *Client) GetSchemaVersion() (string, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getSchemaVersion", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetSealedBidRoundByKey returns sealed-bid round of Loan Request with the key.
+func (c *Client) GetSealedBidRoundByKey(key string) ([]SealedBidRound, error) {
+	args := positionalArgs([]string{key}, 1)
+	result, err := c.query("getSealedBidRoundByKey", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []SealedBidRound
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetSealedBidsByLoanRequest returns sealed bids of Loan Request.
+func (c *Client) GetSealedBidsByLoanRequest(loanRequestID string) ([]SealedBid, error) {
+	args := positionalArgs([]string{loanRequestID}, 1)
+	result, err := c.query("getSealedBidsByLoanRequest", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []SealedBid
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetSettingsList returns all settings. Requires 'assigner' role.
+func (c *Client) GetSettingsList() ([]Setting, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getSettingsList", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []Setting
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetUserByKey returns User with the key.
+func (c *Client) GetUserByKey(key string) ([]User, error) {
+	args := positionalArgs([]string{key}, 1)
+	result, err := c.query("getUserByKey", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []User
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetUserId returns userid of the caller.
+func (c *Client) GetUserId() (string, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getUserId", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetUserList returns all Users.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetUserList(option string) ([]User, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getUserList", args)
+	if err != nil {
+		return nil, err
+	}
+	var rows []User
+	err = decodeRows(result, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetUserMaxKey returns the greatest key of Users.
+func (c *Client) GetUserMaxKey() (string, error) {
+	args := positionalArgs([]string{}, 0)
+	result, err := c.query("getUserMaxKey", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// GetUserQuantity returns number of Users.
+// option: 'includeDeleted' to include deleted rows
+func (c *Client) GetUserQuantity(option string) (string, error) {
+	args := positionalArgs([]string{option}, 0)
+	result, err := c.query("getUserQuantity", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// PatchLoanNegotiation changes given columns of Loan Negotiation and returns its new ETag.
+// eTag: ETag of the row the changes are based on, the patch fails with CONFLICT if the row was changed since then
+func (c *Client) PatchLoanNegotiation(fields LoanNegotiationFields, eTag string) (string, error) {
+	args, err := objectArgs(fields, map[string]string{"ETag": eTag})
+	if err != nil {
+		return "", err
+	}
+	result, err := c.invoke("patchLoanNegotiation", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// PatchLoanRequest changes given columns of Loan Request and returns its new ETag.
+// eTag: ETag of the row the changes are based on, the patch fails with CONFLICT if the row was changed since then
+func (c *Client) PatchLoanRequest(fields LoanRequestFields, eTag string) (string, error) {
+	args, err := objectArgs(fields, map[string]string{"ETag": eTag})
+	if err != nil {
+		return "", err
+	}
+	result, err := c.invoke("patchLoanRequest", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// PatchLoanTerm changes given columns of Loan Term and returns its new ETag.
+// eTag: ETag of the row the changes are based on, the patch fails with CONFLICT if the row was changed since then
+func (c *Client) PatchLoanTerm(fields LoanTermFields, eTag string) (string, error) {
+	args, err := objectArgs(fields, map[string]string{"ETag": eTag})
+	if err != nil {
+		return "", err
+	}
+	result, err := c.invoke("patchLoanTerm", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// PatchLoanTermComment changes given columns of Loan Term Comment and returns its new ETag.
+// eTag: ETag of the row the changes are based on, the patch fails with CONFLICT if the row was changed since then
+func (c *Client) PatchLoanTermComment(fields LoanTermCommentFields, eTag string) (string, error) {
+	args, err := objectArgs(fields, map[string]string{"ETag": eTag})
+	if err != nil {
+		return "", err
+	}
+	result, err := c.invoke("patchLoanTermComment", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// PatchLoanTermProposal changes given columns of Loan Term Proposal and returns its new ETag.
+// eTag: ETag of the row the changes are based on, the patch fails with CONFLICT if the row was changed since then
+func (c *Client) PatchLoanTermProposal(fields LoanTermProposalFields, eTag string) (string, error) {
+	args, err := objectArgs(fields, map[string]string{"ETag": eTag})
+	if err != nil {
+		return "", err
+	}
+	result, err := c.invoke("patchLoanTermProposal", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// PatchLoanTermVote changes given columns of Loan Term Vote and returns its new ETag.
+// eTag: ETag of the row the changes are based on, the patch fails with CONFLICT if the row was changed since then
+func (c *Client) PatchLoanTermVote(fields LoanTermVoteFields, eTag string) (string, error) {
+	args, err := objectArgs(fields, map[string]string{"ETag": eTag})
+	if err != nil {
+		return "", err
+	}
+	result, err := c.invoke("patchLoanTermVote", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// PatchUser changes given columns of User and returns its new ETag.
+// eTag: ETag of the row the changes are based on, the patch fails with CONFLICT if the row was changed since then
+func (c *Client) PatchUser(fields UserFields, eTag string) (string, error) {
+	args, err := objectArgs(fields, map[string]string{"ETag": eTag})
+	if err != nil {
+		return "", err
+	}
+	result, err := c.invoke("patchUser", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// PopulateInitialData loads fixture in demo mode. Requires 'assigner' role.
+// fixture: Fixture JSON, demo fixture is loaded if it is not given
+func (c *Client) PopulateInitialData(fixture string) error {
+	args := positionalArgs([]string{fixture}, 0)
+	_, err := c.invoke("populateInitialData", args)
+	return err
+}
+
+// PublishLoanTermProposals writes texts of proposals of the adopted Loan Term to their rows and removes them from the collection of the deal.
+func (c *Client) PublishLoanTermProposals(loanTermID string) error {
+	args, err := objectArgs(nil, map[string]string{"LoanTermID": loanTermID})
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("publishLoanTermProposals", args)
+	return err
+}
+
+// RestoreRow restores the deleted row and rows deleted with it. Requires 'assigner' role.
+func (c *Client) RestoreRow(table string, key string) error {
+	args := positionalArgs([]string{table, key}, 2)
+	_, err := c.invoke("restoreRow", args)
+	return err
+}
+
+// RevealSealedBid checks the amount against the commitment between the deadlines and writes it to Loan Negotiation. Requires 'bank' role.
+// amount: passed in the transient map, the argument should be empty
+// salt: passed in the transient map, the argument should be empty
+func (c *Client) RevealSealedBid(loanNegotiationID string, amount string, salt string) error {
+	args, err := objectArgs(nil, map[string]string{"LoanNegotiationID": loanNegotiationID, "Amount": amount, "Salt": salt})
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("revealSealedBid", args)
+	return err
+}
+
+// StartSealedBidRound switches Loan Request without amounts to sealed-bid mode until allocation. Requires 'bank' role.
+// commitmentDeadline: RFC3339 date
+// revealDeadline: RFC3339 date after the commitment deadline
+func (c *Client) StartSealedBidRound(loanRequestID string, commitmentDeadline string, revealDeadline string) error {
+	args, err := objectArgs(nil, map[string]string{"LoanRequestID": loanRequestID, "CommitmentDeadline": commitmentDeadline, "RevealDeadline": revealDeadline})
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("startSealedBidRound", args)
+	return err
+}
+
+// UpdateLoanNegotiation updates Loan Negotiation, omitted columns keep current values.
+func (c *Client) UpdateLoanNegotiation(fields LoanNegotiationFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("updateLoanNegotiation", args)
+	return err
+}
+
+// UpdateLoanNegotiationStatus sets status of Loan Negotiation.
+func (c *Client) UpdateLoanNegotiationStatus(loanNegotiationID string, negotiationStatus string) error {
+	args, err := objectArgs(nil, map[string]string{"LoanNegotiationID": loanNegotiationID, "NegotiationStatus": negotiationStatus})
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("updateLoanNegotiationStatus", args)
+	return err
+}
+
+// UpdateLoanRequest updates Loan Request, omitted columns keep current values.
+func (c *Client) UpdateLoanRequest(fields LoanRequestFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("updateLoanRequest", args)
+	return err
+}
+
+// UpdateLoanTerm updates Loan Term, omitted columns keep current values.
+func (c *Client) UpdateLoanTerm(fields LoanTermFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("updateLoanTerm", args)
+	return err
+}
+
+// UpdateLoanTermComment updates Loan Term Comment, omitted columns keep current values.
+func (c *Client) UpdateLoanTermComment(fields LoanTermCommentFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("updateLoanTermComment", args)
+	return err
+}
+
+// UpdateLoanTermProposal updates Loan Term Proposal, omitted columns keep current values.
+func (c *Client) UpdateLoanTermProposal(fields LoanTermProposalFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("updateLoanTermProposal", args)
+	return err
+}
+
+// UpdateLoanTermVote updates Loan Term Vote, omitted columns keep current values.
+func (c *Client) UpdateLoanTermVote(fields LoanTermVoteFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("updateLoanTermVote", args)
+	return err
+}
+
+// UpdateParticipantBankComment sets comment of the participant bank on Loan Negotiation.
+func (c *Client) UpdateParticipantBankComment(loanNegotiationID string, participantBankComment string) error {
+	args, err := objectArgs(nil, map[string]string{"LoanNegotiationID": loanNegotiationID, "ParticipantBankComment": participantBankComment})
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("updateParticipantBankComment", args)
+	return err
+}
+
+// UpdateTableField sets value of an allowed column. Requires 'assigner' role.
+func (c *Client) UpdateTableField(table string, key string, column string, value string) error {
+	args := positionalArgs([]string{table, key, column, value}, 4)
+	_, err := c.invoke("updateTableField", args)
+	return err
+}
+
+// UpdateUser updates User, omitted columns keep current values.
+func (c *Client) UpdateUser(fields UserFields) error {
+	args, err := objectArgs(fields, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.invoke("updateUser", args)
+	return err
+}
+
+// VerifyPrivateValue returns true if the disclosed value and salt of the private column match the hash in the row, otherwise false.
+func (c *Client) VerifyPrivateValue(table string, key string, column string, value string, salt string) (string, error) {
+	args := positionalArgs([]string{table, key, column, value, salt}, 5)
+	result, err := c.query("verifyPrivateValue", args)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
@@ -0,0 +1,90 @@
+package slsclient
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type fakeTransport struct {
+	function string
+	args     []string
+	result   string
+	err      error
+}
+
+func (f *fakeTransport) Invoke(function string, args []string) ([]byte, error) {
+	f.function, f.args = function, args
+	return []byte(f.result), f.err
+}
+
+func (f *fakeTransport) Query(function string, args []string) ([]byte, error) {
+	return f.Invoke(function, args)
+}
+
+func TestClient_ObjectArgs(t *testing.T) {
+	transport := &fakeTransport{result: "etag2"}
+	c := New(transport)
+
+	id, name := "1", "Solar park"
+	eTag, err := c.PatchLoanRequest(LoanRequestFields{LoanRequestID: &id, ProjectName: &name}, "etag1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eTag != "etag2" {
+		t.Errorf("Expected new ETag, got %s", eTag)
+	}
+	expected := []string{`{"ETag":"etag1","LoanRequestID":"1","ProjectName":"Solar park"}`}
+	if transport.function != "patchLoanRequest" || !reflect.DeepEqual(transport.args, expected) {
+		t.Errorf("Unexpected invoke %s %v", transport.function, transport.args)
+	}
+}
+
+func TestClient_PositionalArgs(t *testing.T) {
+	transport := &fakeTransport{result: "[]"}
+	c := New(transport)
+
+	_, err := c.FilterTableByValue("Users", "ParticipantID", "6", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(transport.args, []string{"Users", "ParticipantID", "6"}) {
+		t.Errorf("Empty optional arguments at the end should be omitted, got %v", transport.args)
+	}
+}
+
+func TestClient_Rows(t *testing.T) {
+	transport := &fakeTransport{result: `[{"UserID":"1","ParticipantID":"6"}]`}
+	c := New(transport)
+
+	users, err := c.GetUserList("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].UserID != "1" || users[0].ParticipantID != "6" {
+		t.Errorf("Unexpected rows %v", users)
+	}
+
+	transport.result = "]"
+	users, err = c.GetUserList("")
+	if err != nil || len(users) != 0 {
+		t.Errorf("Expected no rows, got %v %v", users, err)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	transport := &fakeTransport{err: errors.New(`{"Code":"CONFLICT","Message":"Row was changed"}`)}
+	c := New(transport)
+
+	_, err := c.PatchUser(UserFields{}, "etag")
+	e, ok := err.(*Error)
+	if !ok || e.Code != "CONFLICT" {
+		t.Errorf("Expected CONFLICT error, got %v", err)
+	}
+
+	transport.err = errors.New("connection refused")
+	_, err = c.GetBankId()
+	if _, ok := err.(*Error); ok || err == nil {
+		t.Errorf("Transport errors should be kept, got %v", err)
+	}
+}
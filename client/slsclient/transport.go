@@ -0,0 +1,105 @@
+// Package slsclient calls functions of the syndicated loans chaincode.
+//
+// Row types and methods of Client in client.go are generated by clientgen from api/catalogue.json,
+// this file has the transport and helpers used by them.
+package slsclient
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Transport sends invokes and queries to the chaincode, e.g. through a Fabric gateway.
+// Queries are invokes which are evaluated but not submitted for ordering, the chaincode runs read functions for them.
+// Errors of the chaincode are returned as is, their messages are JSON objects with error codes.
+type Transport interface {
+	Invoke(function string, args []string) ([]byte, error)
+	Query(function string, args []string) ([]byte, error)
+}
+
+type Client struct {
+	transport Transport
+}
+
+func New(transport Transport) *Client {
+	return &Client{transport: transport}
+}
+
+// Error returned by the chaincode, Code is one of codes like NOT_FOUND or CONFLICT
+type Error struct {
+	Code    string
+	Message string
+	Details []ErrorDetail `json:",omitempty"`
+}
+
+type ErrorDetail struct {
+	Field   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func (c *Client) invoke(function string, args []string) ([]byte, error) {
+	result, err := c.transport.Invoke(function, args)
+	return result, toError(err)
+}
+
+func (c *Client) query(function string, args []string) ([]byte, error) {
+	result, err := c.transport.Query(function, args)
+	return result, toError(err)
+}
+
+// Converts chaincode errors to *Error, other errors of the transport are kept
+func toError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var e Error
+	if json.Unmarshal([]byte(err.Error()), &e) != nil || e.Code == "" {
+		return err
+	}
+	return &e
+}
+
+// Returns the single JSON object argument of write functions: given fields and non-empty extra arguments
+func objectArgs(fields interface{}, extra map[string]string) ([]string, error) {
+	object := make(map[string]interface{})
+	if fields != nil {
+		b, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(b, &object)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for name, value := range extra {
+		if value != "" {
+			object[name] = value
+		}
+	}
+	b, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	return []string{string(b)}, nil
+}
+
+// Returns positional arguments without empty optional arguments at the end
+func positionalArgs(args []string, required int) []string {
+	for len(args) > required && args[len(args)-1] == "" {
+		args = args[:len(args)-1]
+	}
+	return args
+}
+
+// Decodes rows returned by queries, older chaincode versions return "]" when there are no rows
+func decodeRows(result []byte, rows interface{}) error {
+	if s := strings.TrimSpace(string(result)); s == "" || s == "]" {
+		return nil
+	}
+	return json.Unmarshal(result, rows)
+}
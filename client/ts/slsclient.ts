@@ -0,0 +1,685 @@
+// Code generated by clientgen from api/catalogue.json. DO NOT EDIT.
+
+/** Sends invokes and queries to the chaincode, errors of the chaincode are JSON objects with error codes */
+export interface Transport {
+  invoke(fn: string, args: string[]): Promise<string>;
+  query(fn: string, args: string[]): Promise<string>;
+}
+
+/** Returns positional arguments without omitted optional arguments at the end */
+function positionalArgs(args: (string | undefined)[], required: number): string[] {
+  while (args.length > required && !args[args.length - 1]) {
+    args.pop();
+  }
+  return args.map((a) => a ?? "");
+}
+
+/** Decodes rows returned by queries, older chaincode versions return "]" when there are no rows */
+function decodeRows<T>(result: string): T[] {
+  const s = result.trim();
+  return s === "" || s === "]" ? [] : (JSON.parse(s) as T[]);
+}
+
+/** Row of ArchivedLoanRequests table */
+export interface ArchivedLoanRequest {
+  LoanRequestID: string;
+  BorrowerID: string;
+  ArrangerBankID: string;
+  LoanSharesAmount: string;
+  ProjectRevenue: string;
+  ProjectName: string;
+  ProjectInformation: string;
+  Company: string;
+  Website: string;
+  ContactPersonName: string;
+  ContactPersonSurname: string;
+  RequestDate: string;
+  Status: string;
+  MarketAndIndustry: string;
+  LoanTerm: string;
+  Assets: string;
+  Convenants: string;
+  InterestRate: string;
+  Currency: string;
+}
+
+/** Row of AuditLog table */
+export interface AuditLog {
+  AuditLogID: string;
+  TableName: string;
+  RowKey: string;
+  Action: string;
+  ColumnName: string;
+  OldValue: string;
+  NewValue: string;
+  BankID: string;
+  UserID: string;
+  TxID: string;
+  Date: string;
+}
+
+/** Row of DeletedRows table */
+export interface DeletedRow {
+  DeletedRowID: string;
+  TableName: string;
+  RowKey: string;
+  DeletedByBankID: string;
+  DeletedByUserID: string;
+  DeletedDate: string;
+  DeleteTxID: string;
+}
+
+/** Row of LoanNegotiations table */
+export interface LoanNegotiation {
+  LoanNegotiationID: string;
+  LoanRequestID: string;
+  ParticipantBankID: string;
+  Amount: string;
+  NegotiationStatus: string;
+  ParticipantBankComment: string;
+  Date: string;
+  ResponseDeadline: string;
+}
+
+/** Columns of LoanNegotiations table given to write functions, omitted columns are not sent */
+export type LoanNegotiationFields = Partial<LoanNegotiation>;
+
+/** Row of LoanRequests table */
+export interface LoanRequest {
+  LoanRequestID: string;
+  BorrowerID: string;
+  ArrangerBankID: string;
+  LoanSharesAmount: string;
+  ProjectRevenue: string;
+  ProjectName: string;
+  ProjectInformation: string;
+  Company: string;
+  Website: string;
+  ContactPersonName: string;
+  ContactPersonSurname: string;
+  RequestDate: string;
+  Status: string;
+  MarketAndIndustry: string;
+  LoanTerm: string;
+  Assets: string;
+  Convenants: string;
+  InterestRate: string;
+  Currency: string;
+}
+
+/** Columns of LoanRequests table given to write functions, omitted columns are not sent */
+export type LoanRequestFields = Partial<LoanRequest>;
+
+/** Row of LoanTermComments table */
+export interface LoanTermComment {
+  LoanTermCommentID: string;
+  ParentLoanTermCommentID: string;
+  LoanTermID: string;
+  UserID: string;
+  BankID: string;
+  CommentText: string;
+  LoanTermCommentDate: string;
+}
+
+/** Columns of LoanTermComments table given to write functions, omitted columns are not sent */
+export type LoanTermCommentFields = Partial<LoanTermComment>;
+
+/** Row of LoanTermProposals table */
+export interface LoanTermProposal {
+  LoanTermProposalID: string;
+  LoanTermID: string;
+  ParagraphNumber: string;
+  LoanTermProposalText: string;
+  LoanTermProposalExpTime: string;
+  LoanTermProposalStatus: string;
+}
+
+/** Columns of LoanTermProposals table given to write functions, omitted columns are not sent */
+export type LoanTermProposalFields = Partial<LoanTermProposal>;
+
+/** Row of LoanTermVotes table */
+export interface LoanTermVote {
+  LoanTermVoteID: string;
+  LoanTermProposalID: string;
+  BankID: string;
+  LoanTermVoteStatus: string;
+  LoanTermVoteDeadline: string;
+}
+
+/** Columns of LoanTermVotes table given to write functions, omitted columns are not sent */
+export type LoanTermVoteFields = Partial<LoanTermVote>;
+
+/** Row of LoanTerms table */
+export interface LoanTerm {
+  LoanTermID: string;
+  LoanRequestID: string;
+  ParagraphNumber: string;
+  LoanTermText: string;
+  LoanTermStatus: string;
+}
+
+/** Columns of LoanTerms table given to write functions, omitted columns are not sent */
+export type LoanTermFields = Partial<LoanTerm>;
+
+/** Row of MaintenanceLog table */
+export interface MaintenanceLog {
+  MaintenanceLogID: string;
+  Function: string;
+  Arguments: string;
+  BankID: string;
+  UserID: string;
+  TxID: string;
+  Date: string;
+}
+
+/** Row of Participants table */
+export interface Participant {
+  ParticipantKey: string;
+  ParticipantName: string;
+  ParticipantType: string;
+}
+
+/** Columns of Participants table given to write functions, omitted columns are not sent */
+export type ParticipantFields = Partial<Participant>;
+
+/** Row of SealedBidRounds table */
+export interface SealedBidRound {
+  LoanRequestID: string;
+  CommitmentDeadline: string;
+  RoundStatus: string;
+  RevealDeadline: string;
+}
+
+/** Row of SealedBids table */
+export interface SealedBid {
+  LoanNegotiationID: string;
+  LoanRequestID: string;
+  Commitment: string;
+  BidStatus: string;
+  Date: string;
+}
+
+/** Row of Settings table */
+export interface Setting {
+  SettingName: string;
+  SettingValue: string;
+}
+
+/** Row of Users table */
+export interface User {
+  UserID: string;
+  ParticipantID: string;
+  UserName: string;
+}
+
+/** Columns of Users table given to write functions, omitted columns are not sent */
+export type UserFields = Partial<User>;
+
+export class SLSClient {
+  constructor(private readonly transport: Transport) {}
+
+  /** Adds Loan Negotiation */
+  async addLoanNegotiation(fields: LoanNegotiationFields): Promise<void> {
+    await this.transport.invoke("addLoanNegotiation", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Adds Loan Request */
+  async addLoanRequest(fields: LoanRequestFields): Promise<void> {
+    await this.transport.invoke("addLoanRequest", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Adds Loan Term. Requires 'assigner' role */
+  async addLoanTerm(fields: LoanTermFields): Promise<void> {
+    await this.transport.invoke("addLoanTerm", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Adds Loan Term Comment. Requires 'assigner' role */
+  async addLoanTermComment(fields: LoanTermCommentFields): Promise<void> {
+    await this.transport.invoke("addLoanTermComment", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Adds Loan Term Proposal. Requires 'assigner' role */
+  async addLoanTermProposal(fields: LoanTermProposalFields): Promise<void> {
+    await this.transport.invoke("addLoanTermProposal", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Adds Loan Term Vote. Requires 'assigner' role */
+  async addLoanTermVote(fields: LoanTermVoteFields): Promise<void> {
+    await this.transport.invoke("addLoanTermVote", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Adds Participant. Requires 'assigner' role */
+  async addParticipant(fields: ParticipantFields): Promise<void> {
+    await this.transport.invoke("addParticipant", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Adds User. Requires 'assigner' role */
+  async addUser(fields: UserFields): Promise<void> {
+    await this.transport.invoke("addUser", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Excludes bids which were not revealed and closes the round after the reveal deadline. Requires 'bank' role */
+  async allocateSealedBids(loanRequestID: string): Promise<void> {
+    await this.transport.invoke("allocateSealedBids", [JSON.stringify({ LoanRequestID: loanRequestID })]);
+  }
+
+  /** Moves closed or repaid Loan Request and its rows to archive tables */
+  async archiveLoanRequest(loanRequestID: string): Promise<void> {
+    await this.transport.invoke("archiveLoanRequest", positionalArgs([loanRequestID], 1));
+  }
+
+  /** Commits or replaces the sealed bid of Loan Negotiation before the deadline. Requires 'bank' role */
+  async commitSealedBid(loanNegotiationID: string, commitment: string): Promise<void> {
+    await this.transport.invoke("commitSealedBid", [JSON.stringify({ LoanNegotiationID: loanNegotiationID, Commitment: commitment })]);
+  }
+
+  /** Returns number of rows of the table. Requires 'assigner' role */
+  async countTableRows(table: string, option?: string): Promise<string> {
+    return await this.transport.query("countTableRows", positionalArgs([table, option], 1));
+  }
+
+  /** Marks the row and rows referencing it deleted. Requires 'assigner' role */
+  async deleteRow(table: string, key: string): Promise<void> {
+    await this.transport.invoke("deleteRow", positionalArgs([table, key], 2));
+  }
+
+  /** Marks rows with the column value deleted, all rows of the table if column is not given. Requires 'assigner' role */
+  async deleteRowsByColumnValue(table: string, column?: string, value?: string): Promise<void> {
+    await this.transport.invoke("deleteRowsByColumnValue", positionalArgs([table, column, value], 1));
+  }
+
+  /** Returns definitions of all functions and columns of their tables */
+  async describeAPI(): Promise<unknown> {
+    return JSON.parse(await this.transport.query("describeAPI", positionalArgs([], 0)));
+  }
+
+  /** Moves invited Loan Negotiations after their response deadlines to EXPIRED and open Loan Term Proposals after their expiration times to LAPSED, returns numbers of changed rows */
+  async expireOverdue(): Promise<unknown> {
+    return JSON.parse(await this.transport.invoke("expireOverdue", positionalArgs([], 0)));
+  }
+
+  /** Returns archived rows of the table with the column value. Requires 'assigner' role */
+  async filterArchiveTableByValue(table: string, column?: string, value?: string, option?: string): Promise<unknown> {
+    return JSON.parse(await this.transport.query("filterArchiveTableByValue", positionalArgs([table, column, value, option], 1)));
+  }
+
+  /** Returns rows with the column value. Requires 'assigner' role */
+  async filterTableByValue(table: string, column?: string, value?: string, option?: string): Promise<unknown> {
+    return JSON.parse(await this.transport.query("filterTableByValue", positionalArgs([table, column, value, option], 1)));
+  }
+
+  /** Returns archived Loan Request with the key */
+  async getArchivedLoanRequestByKey(key: string): Promise<ArchivedLoanRequest[]> {
+    return decodeRows<ArchivedLoanRequest>(await this.transport.query("getArchivedLoanRequestByKey", positionalArgs([key], 1)));
+  }
+
+  /** Returns all archived Loan Requests */
+  async getArchivedLoanRequestsList(option?: string): Promise<ArchivedLoanRequest[]> {
+    return decodeRows<ArchivedLoanRequest>(await this.transport.query("getArchivedLoanRequestsList", positionalArgs([option], 0)));
+  }
+
+  /** Returns changes made by the bank or its user. Requires 'assigner' role */
+  async getAuditLogByActor(bankid: string, userid?: string): Promise<AuditLog[]> {
+    return decodeRows<AuditLog>(await this.transport.query("getAuditLogByActor", positionalArgs([bankid, userid], 1)));
+  }
+
+  /** Returns history of the row. Requires 'assigner' role */
+  async getAuditLogByEntity(table: string, key: string): Promise<AuditLog[]> {
+    return decodeRows<AuditLog>(await this.transport.query("getAuditLogByEntity", positionalArgs([table, key], 2)));
+  }
+
+  /** Returns changes made in the time range, empty dates are not limited. Requires 'assigner' role */
+  async getAuditLogByTimeRange(from: string, to: string): Promise<AuditLog[]> {
+    return decodeRows<AuditLog>(await this.transport.query("getAuditLogByTimeRange", positionalArgs([from, to], 2)));
+  }
+
+  /** Returns bankid of the caller */
+  async getBankId(): Promise<string> {
+    return await this.transport.query("getBankId", positionalArgs([], 0));
+  }
+
+  /** Returns certificate attribute of the caller */
+  async getCertAttribute(name: string): Promise<string> {
+    return await this.transport.query("getCertAttribute", positionalArgs([name], 1));
+  }
+
+  /** Returns the collection configuration of the chaincode with the private data collection of every deal. Requires 'assigner' role */
+  async getDealCollectionsConfig(): Promise<unknown> {
+    return JSON.parse(await this.transport.query("getDealCollectionsConfig", positionalArgs([], 0)));
+  }
+
+  /** Returns deletion marks of all rows or rows of the table. Requires 'assigner' role */
+  async getDeletedRowsList(table?: string): Promise<DeletedRow[]> {
+    return decodeRows<DeletedRow>(await this.transport.query("getDeletedRowsList", positionalArgs([table], 0)));
+  }
+
+  /** Returns all event types with descriptions */
+  async getEventCatalogueList(): Promise<unknown> {
+    return JSON.parse(await this.transport.query("getEventCatalogueList", positionalArgs([], 0)));
+  }
+
+  /** Returns Loan Negotiation with the key */
+  async getLoanNegotiationByKey(key: string): Promise<LoanNegotiation[]> {
+    return decodeRows<LoanNegotiation>(await this.transport.query("getLoanNegotiationByKey", positionalArgs([key], 1)));
+  }
+
+  /** Returns all Loan Negotiations */
+  async getLoanNegotiationsList(option?: string): Promise<LoanNegotiation[]> {
+    return decodeRows<LoanNegotiation>(await this.transport.query("getLoanNegotiationsList", positionalArgs([option], 0)));
+  }
+
+  /** Returns the greatest key of Loan Negotiations */
+  async getLoanNegotiationsMaxKey(): Promise<string> {
+    return await this.transport.query("getLoanNegotiationsMaxKey", positionalArgs([], 0));
+  }
+
+  /** Returns number of Loan Negotiations */
+  async getLoanNegotiationsQuantity(option?: string): Promise<string> {
+    return await this.transport.query("getLoanNegotiationsQuantity", positionalArgs([option], 0));
+  }
+
+  /** Returns Loan Request with the key */
+  async getLoanRequestByKey(key: string): Promise<LoanRequest[]> {
+    return decodeRows<LoanRequest>(await this.transport.query("getLoanRequestByKey", positionalArgs([key], 1)));
+  }
+
+  /** Returns all Loan Requests */
+  async getLoanRequestsList(option?: string): Promise<LoanRequest[]> {
+    return decodeRows<LoanRequest>(await this.transport.query("getLoanRequestsList", positionalArgs([option], 0)));
+  }
+
+  /** Returns the greatest key of Loan Requests */
+  async getLoanRequestsMaxKey(): Promise<string> {
+    return await this.transport.query("getLoanRequestsMaxKey", positionalArgs([], 0));
+  }
+
+  /** Returns number of Loan Requests */
+  async getLoanRequestsQuantity(option?: string): Promise<string> {
+    return await this.transport.query("getLoanRequestsQuantity", positionalArgs([option], 0));
+  }
+
+  /** Returns Loan Term with the key */
+  async getLoanTermByKey(key: string): Promise<LoanTerm[]> {
+    return decodeRows<LoanTerm>(await this.transport.query("getLoanTermByKey", positionalArgs([key], 1)));
+  }
+
+  /** Returns Loan Term Comment with the key */
+  async getLoanTermCommentByKey(key: string): Promise<LoanTermComment[]> {
+    return decodeRows<LoanTermComment>(await this.transport.query("getLoanTermCommentByKey", positionalArgs([key], 1)));
+  }
+
+  /** Returns all Loan Term Comments */
+  async getLoanTermCommentList(option?: string): Promise<LoanTermComment[]> {
+    return decodeRows<LoanTermComment>(await this.transport.query("getLoanTermCommentList", positionalArgs([option], 0)));
+  }
+
+  /** Returns the greatest key of Loan Term Comments */
+  async getLoanTermCommentMaxKey(): Promise<string> {
+    return await this.transport.query("getLoanTermCommentMaxKey", positionalArgs([], 0));
+  }
+
+  /** Returns number of Loan Term Comments */
+  async getLoanTermCommentQuantity(option?: string): Promise<string> {
+    return await this.transport.query("getLoanTermCommentQuantity", positionalArgs([option], 0));
+  }
+
+  /** Returns all Loan Terms */
+  async getLoanTermList(option?: string): Promise<LoanTerm[]> {
+    return decodeRows<LoanTerm>(await this.transport.query("getLoanTermList", positionalArgs([option], 0)));
+  }
+
+  /** Returns the greatest key of Loan Terms */
+  async getLoanTermMaxKey(): Promise<string> {
+    return await this.transport.query("getLoanTermMaxKey", positionalArgs([], 0));
+  }
+
+  /** Returns Loan Term Proposal with the key */
+  async getLoanTermProposalByKey(key: string): Promise<LoanTermProposal[]> {
+    return decodeRows<LoanTermProposal>(await this.transport.query("getLoanTermProposalByKey", positionalArgs([key], 1)));
+  }
+
+  /** Returns all Loan Term Proposals */
+  async getLoanTermProposalList(option?: string): Promise<LoanTermProposal[]> {
+    return decodeRows<LoanTermProposal>(await this.transport.query("getLoanTermProposalList", positionalArgs([option], 0)));
+  }
+
+  /** Returns the greatest key of Loan Term Proposals */
+  async getLoanTermProposalMaxKey(): Promise<string> {
+    return await this.transport.query("getLoanTermProposalMaxKey", positionalArgs([], 0));
+  }
+
+  /** Returns number of Loan Term Proposals */
+  async getLoanTermProposalQuantity(option?: string): Promise<string> {
+    return await this.transport.query("getLoanTermProposalQuantity", positionalArgs([option], 0));
+  }
+
+  /** Returns number of Loan Terms */
+  async getLoanTermQuantity(option?: string): Promise<string> {
+    return await this.transport.query("getLoanTermQuantity", positionalArgs([option], 0));
+  }
+
+  /** Returns Loan Term Vote with the key */
+  async getLoanTermVoteByKey(key: string): Promise<LoanTermVote[]> {
+    return decodeRows<LoanTermVote>(await this.transport.query("getLoanTermVoteByKey", positionalArgs([key], 1)));
+  }
+
+  /** Returns all Loan Term Votes */
+  async getLoanTermVoteList(option?: string): Promise<LoanTermVote[]> {
+    return decodeRows<LoanTermVote>(await this.transport.query("getLoanTermVoteList", positionalArgs([option], 0)));
+  }
+
+  /** Returns the greatest key of Loan Term Votes */
+  async getLoanTermVoteMaxKey(): Promise<string> {
+    return await this.transport.query("getLoanTermVoteMaxKey", positionalArgs([], 0));
+  }
+
+  /** Returns number of Loan Term Votes */
+  async getLoanTermVoteQuantity(option?: string): Promise<string> {
+    return await this.transport.query("getLoanTermVoteQuantity", positionalArgs([option], 0));
+  }
+
+  /** Returns all maintenance invokes. Requires 'assigner' role */
+  async getMaintenanceLogList(): Promise<MaintenanceLog[]> {
+    return decodeRows<MaintenanceLog>(await this.transport.query("getMaintenanceLogList", positionalArgs([], 0)));
+  }
+
+  /** Returns Participant with the key */
+  async getParticipantsByKey(key: string): Promise<Participant[]> {
+    return decodeRows<Participant>(await this.transport.query("getParticipantsByKey", positionalArgs([key], 1)));
+  }
+
+  /** Returns Participants of the type */
+  async getParticipantsByType(participantType: string, option?: string): Promise<Participant[]> {
+    return decodeRows<Participant>(await this.transport.query("getParticipantsByType", positionalArgs([participantType, option], 1)));
+  }
+
+  /** Returns all Participants */
+  async getParticipantsList(option?: string): Promise<Participant[]> {
+    return decodeRows<Participant>(await this.transport.query("getParticipantsList", positionalArgs([option], 0)));
+  }
+
+  /** Returns the greatest key of Participants */
+  async getParticipantsMaxKey(): Promise<string> {
+    return await this.transport.query("getParticipantsMaxKey", positionalArgs([], 0));
+  }
+
+  /** Returns number of Participants */
+  async getParticipantsQuantity(option?: string): Promise<string> {
+    return await this.transport.query("getParticipantsQuantity", positionalArgs([option], 0));
+  }
+
+  /** Returns the salt of the private column for readers of its value, so they can disclose the value */
+  async getPrivateValueSalt(table: string, key: string, column: string): Promise<string> {
+    return await this.transport.query("getPrivateValueSalt", positionalArgs([table, key, column], 3));
+  }
+
+  /** Returns Loan Requests arranged by the bank of the caller */
+  async getProjectsList(): Promise<LoanRequest[]> {
+    return decodeRows<LoanRequest>(await this.transport.query("getProjectsList", positionalArgs([], 0)));
+  }
+
+  /** Returns ETag of the row */
+  async getRowETag(table: string, key: string): Promise<string> {
+    return await this.transport.query("getRowETag", positionalArgs([table, key], 2));
+  }
+
+  /** Returns schema version of the ledger */
+  async getSchemaVersion(): Promise<string> {
+    return await this.transport.query("getSchemaVersion", positionalArgs([], 0));
+  }
+
+  /** Returns sealed-bid round of Loan Request with the key */
+  async getSealedBidRoundByKey(key: string): Promise<SealedBidRound[]> {
+    return decodeRows<SealedBidRound>(await this.transport.query("getSealedBidRoundByKey", positionalArgs([key], 1)));
+  }
+
+  /** Returns sealed bids of Loan Request */
+  async getSealedBidsByLoanRequest(loanRequestID: string): Promise<SealedBid[]> {
+    return decodeRows<SealedBid>(await this.transport.query("getSealedBidsByLoanRequest", positionalArgs([loanRequestID], 1)));
+  }
+
+  /** Returns all settings. Requires 'assigner' role */
+  async getSettingsList(): Promise<Setting[]> {
+    return decodeRows<Setting>(await this.transport.query("getSettingsList", positionalArgs([], 0)));
+  }
+
+  /** Returns User with the key */
+  async getUserByKey(key: string): Promise<User[]> {
+    return decodeRows<User>(await this.transport.query("getUserByKey", positionalArgs([key], 1)));
+  }
+
+  /** Returns userid of the caller */
+  async getUserId(): Promise<string> {
+    return await this.transport.query("getUserId", positionalArgs([], 0));
+  }
+
+  /** Returns all Users */
+  async getUserList(option?: string): Promise<User[]> {
+    return decodeRows<User>(await this.transport.query("getUserList", positionalArgs([option], 0)));
+  }
+
+  /** Returns the greatest key of Users */
+  async getUserMaxKey(): Promise<string> {
+    return await this.transport.query("getUserMaxKey", positionalArgs([], 0));
+  }
+
+  /** Returns number of Users */
+  async getUserQuantity(option?: string): Promise<string> {
+    return await this.transport.query("getUserQuantity", positionalArgs([option], 0));
+  }
+
+  /** Changes given columns of Loan Negotiation and returns its new ETag */
+  async patchLoanNegotiation(fields: LoanNegotiationFields, eTag?: string): Promise<string> {
+    return await this.transport.invoke("patchLoanNegotiation", [JSON.stringify({ ...fields, ETag: eTag })]);
+  }
+
+  /** Changes given columns of Loan Request and returns its new ETag */
+  async patchLoanRequest(fields: LoanRequestFields, eTag?: string): Promise<string> {
+    return await this.transport.invoke("patchLoanRequest", [JSON.stringify({ ...fields, ETag: eTag })]);
+  }
+
+  /** Changes given columns of Loan Term and returns its new ETag */
+  async patchLoanTerm(fields: LoanTermFields, eTag?: string): Promise<string> {
+    return await this.transport.invoke("patchLoanTerm", [JSON.stringify({ ...fields, ETag: eTag })]);
+  }
+
+  /** Changes given columns of Loan Term Comment and returns its new ETag */
+  async patchLoanTermComment(fields: LoanTermCommentFields, eTag?: string): Promise<string> {
+    return await this.transport.invoke("patchLoanTermComment", [JSON.stringify({ ...fields, ETag: eTag })]);
+  }
+
+  /** Changes given columns of Loan Term Proposal and returns its new ETag */
+  async patchLoanTermProposal(fields: LoanTermProposalFields, eTag?: string): Promise<string> {
+    return await this.transport.invoke("patchLoanTermProposal", [JSON.stringify({ ...fields, ETag: eTag })]);
+  }
+
+  /** Changes given columns of Loan Term Vote and returns its new ETag */
+  async patchLoanTermVote(fields: LoanTermVoteFields, eTag?: string): Promise<string> {
+    return await this.transport.invoke("patchLoanTermVote", [JSON.stringify({ ...fields, ETag: eTag })]);
+  }
+
+  /** Changes given columns of User and returns its new ETag */
+  async patchUser(fields: UserFields, eTag?: string): Promise<string> {
+    return await this.transport.invoke("patchUser", [JSON.stringify({ ...fields, ETag: eTag })]);
+  }
+
+  /** Loads fixture in demo mode. Requires 'assigner' role */
+  async populateInitialData(fixture?: string): Promise<void> {
+    await this.transport.invoke("populateInitialData", positionalArgs([fixture], 0));
+  }
+
+  /** Writes texts of proposals of the adopted Loan Term to their rows and removes them from the collection of the deal */
+  async publishLoanTermProposals(loanTermID: string): Promise<void> {
+    await this.transport.invoke("publishLoanTermProposals", [JSON.stringify({ LoanTermID: loanTermID })]);
+  }
+
+  /** Restores the deleted row and rows deleted with it. Requires 'assigner' role */
+  async restoreRow(table: string, key: string): Promise<void> {
+    await this.transport.invoke("restoreRow", positionalArgs([table, key], 2));
+  }
+
+  /** Checks the amount against the commitment between the deadlines and writes it to Loan Negotiation. Requires 'bank' role */
+  async revealSealedBid(loanNegotiationID: string, amount: string, salt: string): Promise<void> {
+    await this.transport.invoke("revealSealedBid", [JSON.stringify({ LoanNegotiationID: loanNegotiationID, Amount: amount, Salt: salt })]);
+  }
+
+  /** Switches Loan Request without amounts to sealed-bid mode until allocation. Requires 'bank' role */
+  async startSealedBidRound(loanRequestID: string, commitmentDeadline: string, revealDeadline: string): Promise<void> {
+    await this.transport.invoke("startSealedBidRound", [JSON.stringify({ LoanRequestID: loanRequestID, CommitmentDeadline: commitmentDeadline, RevealDeadline: revealDeadline })]);
+  }
+
+  /** Updates Loan Negotiation, omitted columns keep current values */
+  async updateLoanNegotiation(fields: LoanNegotiationFields): Promise<void> {
+    await this.transport.invoke("updateLoanNegotiation", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Sets status of Loan Negotiation */
+  async updateLoanNegotiationStatus(loanNegotiationID: string, negotiationStatus: string): Promise<void> {
+    await this.transport.invoke("updateLoanNegotiationStatus", [JSON.stringify({ LoanNegotiationID: loanNegotiationID, NegotiationStatus: negotiationStatus })]);
+  }
+
+  /** Updates Loan Request, omitted columns keep current values */
+  async updateLoanRequest(fields: LoanRequestFields): Promise<void> {
+    await this.transport.invoke("updateLoanRequest", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Updates Loan Term, omitted columns keep current values */
+  async updateLoanTerm(fields: LoanTermFields): Promise<void> {
+    await this.transport.invoke("updateLoanTerm", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Updates Loan Term Comment, omitted columns keep current values */
+  async updateLoanTermComment(fields: LoanTermCommentFields): Promise<void> {
+    await this.transport.invoke("updateLoanTermComment", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Updates Loan Term Proposal, omitted columns keep current values */
+  async updateLoanTermProposal(fields: LoanTermProposalFields): Promise<void> {
+    await this.transport.invoke("updateLoanTermProposal", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Updates Loan Term Vote, omitted columns keep current values */
+  async updateLoanTermVote(fields: LoanTermVoteFields): Promise<void> {
+    await this.transport.invoke("updateLoanTermVote", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Sets comment of the participant bank on Loan Negotiation */
+  async updateParticipantBankComment(loanNegotiationID: string, participantBankComment: string): Promise<void> {
+    await this.transport.invoke("updateParticipantBankComment", [JSON.stringify({ LoanNegotiationID: loanNegotiationID, ParticipantBankComment: participantBankComment })]);
+  }
+
+  /** Sets value of an allowed column. Requires 'assigner' role */
+  async updateTableField(table: string, key: string, column: string, value: string): Promise<void> {
+    await this.transport.invoke("updateTableField", positionalArgs([table, key, column, value], 4));
+  }
+
+  /** Updates User, omitted columns keep current values */
+  async updateUser(fields: UserFields): Promise<void> {
+    await this.transport.invoke("updateUser", [JSON.stringify({ ...fields })]);
+  }
+
+  /** Returns true if the disclosed value and salt of the private column match the hash in the row, otherwise false */
+  async verifyPrivateValue(table: string, key: string, column: string, value: string, salt: string): Promise<string> {
+    return await this.transport.query("verifyPrivateValue", positionalArgs([table, key, column, value, salt], 5));
+  }
+}
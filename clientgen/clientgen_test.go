@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+// Committed clients should be generated from the committed catalogue, run go generate after API changes
+func TestClientgen_GeneratedClientsAreUpToDate(t *testing.T) {
+	catalogue, err := loadCatalogue("../api/catalogue.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goClient, err := generateGoClient(catalogue, "api/catalogue.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGenerated(t, "../client/slsclient/client.go", goClient)
+	checkGenerated(t, "../client/ts/slsclient.ts", generateTypeScriptClient(catalogue, "api/catalogue.json"))
+}
+
+func checkGenerated(t *testing.T, path string, generated []byte) {
+	committed, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(committed, generated) {
+		t.Errorf("%s is out of date, run go generate", path)
+	}
+}
+
+func TestClientgen_ExtraArgs(t *testing.T) {
+	catalogue := &apiCatalogue{Tables: map[string][]string{"Users": {"UserID", "UserName"}}}
+	d := functionDefinition{Name: "patchUser", ArgsFormat: AF_Object, Table: "Users",
+		Args: []argumentDefinition{{Name: "UserID"}, {Name: "UserName", IsOptional: true}, {Name: "ETag", IsOptional: true}}}
+
+	args := getExtraArgs(d, catalogue)
+	if len(args) != 1 || args[0].Name != "ETag" {
+		t.Errorf("Expected ETag extra argument, got %v", args)
+	}
+	if name := getRowTypeName("Users"); name != "User" {
+		t.Errorf("Expected User row type, got %s", name)
+	}
+}
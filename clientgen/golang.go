@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+// Generates client/slsclient/client.go, helpers of generated methods are in client/slsclient/transport.go
+func generateGoClient(catalogue *apiCatalogue, cataloguePath string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by clientgen from %s. DO NOT EDIT.\n\n", cataloguePath)
+	b.WriteString("package slsclient\n\n")
+	b.WriteString("import \"encoding/json\"\n")
+
+	var tables []string
+	for table := range catalogue.Tables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	fieldsTables := getFieldsTables(catalogue)
+
+	for _, table := range tables {
+		rowType := getRowTypeName(table)
+		fmt.Fprintf(&b, "\n// %s is a row of %s table\n", rowType, table)
+		fmt.Fprintf(&b, "type %s struct {\n", rowType)
+		for _, c := range catalogue.Tables[table] {
+			fmt.Fprintf(&b, "%s string\n", c)
+		}
+		b.WriteString("}\n")
+
+		if fieldsTables[table] {
+			fmt.Fprintf(&b, "\n// %sFields are columns of %s table given to write functions, nil columns are omitted\n", rowType, table)
+			fmt.Fprintf(&b, "type %sFields struct {\n", rowType)
+			for _, c := range catalogue.Tables[table] {
+				fmt.Fprintf(&b, "%s *string `json:\",omitempty\"`\n", c)
+			}
+			b.WriteString("}\n")
+		}
+	}
+
+	for _, d := range catalogue.Functions {
+		writeGoMethod(&b, d, catalogue)
+	}
+
+	return format.Source(b.Bytes())
+}
+
+func writeGoMethod(b *bytes.Buffer, d functionDefinition, catalogue *apiCatalogue) {
+	extraArgs := getExtraArgs(d, catalogue)
+
+	var params []string
+	if d.ArgsFormat == AF_Object && d.Table != "" {
+		params = append(params, "fields "+getRowTypeName(d.Table)+"Fields")
+	}
+	for _, a := range extraArgs {
+		params = append(params, lowerFirst(a.Name)+" string")
+	}
+
+	var results, zero string
+	switch d.Result {
+	case RT_None:
+		results = "error"
+	case RT_Text:
+		results, zero = "(string, error)", `""`
+	case RT_Rows:
+		results, zero = "([]"+getRowTypeName(d.Table)+", error)", "nil"
+	default:
+		results, zero = "(json.RawMessage, error)", "nil"
+	}
+	returnError := "return err"
+	if zero != "" {
+		returnError = "return " + zero + ", err"
+	}
+
+	method := upperFirst(d.Name)
+	fmt.Fprintf(b, "\n// %s %s.\n", method, lowerFirst(getDescription(d)))
+	for _, a := range extraArgs {
+		if a.Description != "" {
+			fmt.Fprintf(b, "// %s: %s\n", lowerFirst(a.Name), a.Description)
+		}
+	}
+	fmt.Fprintf(b, "func (c *Client) %s(%s) %s {\n", method, strings.Join(params, ", "), results)
+
+	if d.ArgsFormat == AF_Object {
+		var fields = "nil"
+		if d.Table != "" {
+			fields = "fields"
+		}
+		extra := "nil"
+		if len(extraArgs) > 0 {
+			var values []string
+			for _, a := range extraArgs {
+				values = append(values, fmt.Sprintf("%q: %s", a.Name, lowerFirst(a.Name)))
+			}
+			extra = "map[string]string{" + strings.Join(values, ", ") + "}"
+		}
+		fmt.Fprintf(b, "args, err := objectArgs(%s, %s)\n", fields, extra)
+		fmt.Fprintf(b, "if err != nil {\n%s\n}\n", returnError)
+	} else {
+		var required int
+		var names []string
+		for _, a := range extraArgs {
+			if !a.IsOptional {
+				required++
+			}
+			names = append(names, lowerFirst(a.Name))
+		}
+		fmt.Fprintf(b, "args := positionalArgs([]string{%s}, %d)\n", strings.Join(names, ", "), required)
+	}
+
+	call := "c.query"
+	if d.Mode == FM_Write {
+		call = "c.invoke"
+	}
+	assign := ":="
+	if d.ArgsFormat == AF_Object && d.Result == RT_None {
+		assign = "="
+	}
+	switch d.Result {
+	case RT_None:
+		fmt.Fprintf(b, "_, err %s %s(%q, args)\nreturn err\n", assign, call, d.Name)
+	case RT_Text:
+		fmt.Fprintf(b, "result, err %s %s(%q, args)\nif err != nil {\n%s\n}\nreturn string(result), nil\n", assign, call, d.Name, returnError)
+	case RT_Rows:
+		fmt.Fprintf(b, "result, err %s %s(%q, args)\nif err != nil {\n%s\n}\n", assign, call, d.Name, returnError)
+		fmt.Fprintf(b, "var rows []%s\nerr = decodeRows(result, &rows)\nif err != nil {\n%s\n}\nreturn rows, nil\n",
+			getRowTypeName(d.Table), returnError)
+	default:
+		fmt.Fprintf(b, "result, err %s %s(%q, args)\nif err != nil {\n%s\n}\nreturn json.RawMessage(result), nil\n", assign, call, d.Name, returnError)
+	}
+	b.WriteString("}\n")
+}
@@ -0,0 +1,140 @@
+// Command clientgen generates chaincode clients from the API catalogue returned by describeAPI query.
+//
+// The catalogue is kept in api/catalogue.json and is updated by the chaincode tests, so clients
+// are regenerated together with it:
+//
+//	go generate
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+//Function modes
+const FM_Write = "write"
+
+//Argument formats
+const AF_Object = "object"
+
+//Function results
+const RT_None = "none"
+const RT_Text = "text"
+const RT_Rows = "rows"
+const RT_JSON = "json"
+
+// Same as definitions of the chaincode registry
+type argumentDefinition struct {
+	Name        string
+	IsOptional  bool
+	Description string
+}
+
+type functionDefinition struct {
+	Name        string
+	Mode        string
+	Role        string
+	ArgsFormat  string
+	Table       string
+	Key         string
+	Args        []argumentDefinition
+	Result      string
+	Description string
+}
+
+type apiCatalogue struct {
+	Functions []functionDefinition
+	Tables    map[string][]string
+}
+
+func main() {
+	cataloguePath := flag.String("catalogue", "api/catalogue.json", "API catalogue returned by describeAPI query")
+	goPath := flag.String("go", "client/slsclient/client.go", "generated Go client")
+	tsPath := flag.String("ts", "client/ts/slsclient.ts", "generated TypeScript client")
+	flag.Parse()
+
+	catalogue, err := loadCatalogue(*cataloguePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	goClient, err := generateGoClient(catalogue, *cataloguePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = ioutil.WriteFile(*goPath, goClient, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(*tsPath, generateTypeScriptClient(catalogue, *cataloguePath), 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func loadCatalogue(path string) (*apiCatalogue, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var catalogue apiCatalogue
+	err = json.Unmarshal(b, &catalogue)
+	if err != nil {
+		return nil, err
+	}
+	return &catalogue, nil
+}
+
+// Row type of the table: LoanRequests table has LoanRequest rows
+func getRowTypeName(tableName string) string {
+	return strings.TrimSuffix(tableName, "s")
+}
+
+func upperFirst(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func lowerFirst(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// Arguments which are not table columns: positional arguments or JSON object fields in addition to columns
+func getExtraArgs(d functionDefinition, catalogue *apiCatalogue) []argumentDefinition {
+	if d.ArgsFormat != AF_Object || d.Table == "" {
+		return d.Args
+	}
+	columns := make(map[string]bool)
+	for _, c := range catalogue.Tables[d.Table] {
+		columns[c] = true
+	}
+	var args []argumentDefinition
+	for _, a := range d.Args {
+		if !columns[a.Name] {
+			args = append(args, a)
+		}
+	}
+	return args
+}
+
+// Tables which are given as JSON objects to write functions
+func getFieldsTables(catalogue *apiCatalogue) map[string]bool {
+	tables := make(map[string]bool)
+	for _, d := range catalogue.Functions {
+		if d.ArgsFormat == AF_Object && d.Table != "" {
+			tables[d.Table] = true
+		}
+	}
+	return tables
+}
+
+func getDescription(d functionDefinition) string {
+	description := d.Description
+	if d.Role != "" {
+		description += ". Requires '" + d.Role + "' role"
+	}
+	return description
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Generates client/ts/slsclient.ts with row interfaces and SLSClient class
+func generateTypeScriptClient(catalogue *apiCatalogue, cataloguePath string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by clientgen from %s. DO NOT EDIT.\n\n", cataloguePath)
+	b.WriteString(typeScriptHeader)
+
+	var tables []string
+	for table := range catalogue.Tables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	fieldsTables := getFieldsTables(catalogue)
+
+	for _, table := range tables {
+		rowType := getRowTypeName(table)
+		fmt.Fprintf(&b, "\n/** Row of %s table */\nexport interface %s {\n", table, rowType)
+		for _, c := range catalogue.Tables[table] {
+			fmt.Fprintf(&b, "  %s: string;\n", c)
+		}
+		b.WriteString("}\n")
+		if fieldsTables[table] {
+			fmt.Fprintf(&b, "\n/** Columns of %s table given to write functions, omitted columns are not sent */\n", table)
+			fmt.Fprintf(&b, "export type %sFields = Partial<%s>;\n", rowType, rowType)
+		}
+	}
+
+	b.WriteString("\nexport class SLSClient {\n  constructor(private readonly transport: Transport) {}\n")
+	for _, d := range catalogue.Functions {
+		writeTypeScriptMethod(&b, d, catalogue)
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+const typeScriptHeader = `/** Sends invokes and queries to the chaincode, errors of the chaincode are JSON objects with error codes */
+export interface Transport {
+  invoke(fn: string, args: string[]): Promise<string>;
+  query(fn: string, args: string[]): Promise<string>;
+}
+
+/** Returns positional arguments without omitted optional arguments at the end */
+function positionalArgs(args: (string | undefined)[], required: number): string[] {
+  while (args.length > required && !args[args.length - 1]) {
+    args.pop();
+  }
+  return args.map((a) => a ?? "");
+}
+
+/** Decodes rows returned by queries, older chaincode versions return "]" when there are no rows */
+function decodeRows<T>(result: string): T[] {
+  const s = result.trim();
+  return s === "" || s === "]" ? [] : (JSON.parse(s) as T[]);
+}
+`
+
+func writeTypeScriptMethod(b *bytes.Buffer, d functionDefinition, catalogue *apiCatalogue) {
+	extraArgs := getExtraArgs(d, catalogue)
+
+	var params []string
+	if d.ArgsFormat == AF_Object && d.Table != "" {
+		params = append(params, "fields: "+getRowTypeName(d.Table)+"Fields")
+	}
+	for _, a := range extraArgs {
+		if a.IsOptional {
+			params = append(params, lowerFirst(a.Name)+"?: string")
+		} else {
+			params = append(params, lowerFirst(a.Name)+": string")
+		}
+	}
+
+	var result string
+	switch d.Result {
+	case RT_None:
+		result = "void"
+	case RT_Text:
+		result = "string"
+	case RT_Rows:
+		result = getRowTypeName(d.Table) + "[]"
+	default:
+		result = "unknown"
+	}
+
+	fmt.Fprintf(b, "\n  /** %s */\n", getDescription(d))
+	fmt.Fprintf(b, "  async %s(%s): Promise<%s> {\n", d.Name, strings.Join(params, ", "), result)
+
+	var args string
+	if d.ArgsFormat == AF_Object {
+		var members []string
+		if d.Table != "" {
+			members = append(members, "...fields")
+		}
+		for _, a := range extraArgs {
+			members = append(members, a.Name+": "+lowerFirst(a.Name))
+		}
+		args = "[JSON.stringify({ " + strings.Join(members, ", ") + " })]"
+	} else {
+		var required int
+		var names []string
+		for _, a := range extraArgs {
+			if !a.IsOptional {
+				required++
+			}
+			names = append(names, lowerFirst(a.Name))
+		}
+		args = fmt.Sprintf("positionalArgs([%s], %d)", strings.Join(names, ", "), required)
+	}
+
+	call := "this.transport.query"
+	if d.Mode == FM_Write {
+		call = "this.transport.invoke"
+	}
+	call = fmt.Sprintf("await %s(%q, %s)", call, d.Name, args)
+
+	switch d.Result {
+	case RT_None:
+		fmt.Fprintf(b, "    %s;\n", call)
+	case RT_Text:
+		fmt.Fprintf(b, "    return %s;\n", call)
+	case RT_Rows:
+		fmt.Fprintf(b, "    return decodeRows<%s>(%s);\n", getRowTypeName(d.Table), call)
+	default:
+		fmt.Fprintf(b, "    return JSON.parse(%s);\n", call)
+	}
+	b.WriteString("  }\n")
+}
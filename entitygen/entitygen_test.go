@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Committed entity files should be generated from the committed spec, run go generate after spec changes
+func TestEntitygen_GeneratedFilesAreUpToDate(t *testing.T) {
+	s, err := loadSpec("../api/entities.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := generate(s, "api/entities.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, generated := range files {
+		committed, err := ioutil.ReadFile(filepath.Join("..", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(committed, generated) {
+			t.Errorf("%s is out of date, run go generate", name)
+		}
+	}
+}
+
+func TestEntitygen_ValidateSpec(t *testing.T) {
+	s := &spec{Entities: []entitySpec{
+		{Name: "Note", Table: "Notes", Prefix: "N", Title: "Note", Key: "NoteID",
+			Permissions: map[string]string{"delete": "assigner", "add": "owner", "update": "owner"},
+			Columns: []columnSpec{{Name: "NoteID"}, {Name: "Size", Type: "float"},
+				{Name: "AuthorID", References: "Authors", OnDelete: "CASCADE"}}},
+		{Name: "Page", Table: "Pages", Prefix: "P", Title: "Page", Key: "PageID", Owner: "BankID",
+			Columns: []columnSpec{{Name: "PageID"}, {Name: "Text"}}},
+		{Name: "Line", Table: "Lines", Prefix: "L", Title: "Line", Key: "LineID", Owner: "Text",
+			Columns: []columnSpec{{Name: "LineID"}, {Name: "Text"}}},
+	}}
+
+	err := validateSpec(s)
+	if err == nil {
+		t.Fatal("Expected errors of wrong spec")
+	}
+	for _, expected := range []string{"unknown permission operation 'delete'", "unknown type 'float'", "referenced table 'Authors'",
+		"'add' operation can not have owner role", "owner column of 'update' operation is not set", "owner column 'BankID' is not a column",
+		"owner column 'Text' does not reference a table with owners"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error %q, got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+// Command entitygen generates chaincode files of simple entities from api/entities.json.
+//
+// Every entity gets SLS<Name>.go with its table, create, add, update, patch, get and count functions, their
+// registration, foreign keys, column types and checks of owner banks. SLSEntities_test.go tests all of them. Run from the chaincode directory:
+//
+//	go generate
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//Permission operations
+const PO_Add = "add"
+const PO_Update = "update"
+const PO_Patch = "patch"
+const PO_Read = "read"
+
+//Role of update and patch operations which are allowed to the owner of the row referenced by the owner column and to the assigner
+const PR_Owner = "owner"
+
+// Go constants of values used in the spec
+var roleConstants = map[string]string{"assigner": "FR_Assigner", "bank": "FR_Bank"}
+var onDeleteConstants = map[string]string{"RESTRICT": "FK_OnDeleteRestrict", "CASCADE": "FK_OnDeleteCascade", "SOFTDELETE": "FK_OnDeleteSoftDelete"}
+var typeConstants = map[string]string{"string": "CT_String", "int": "CT_Int", "time": "CT_Time"}
+
+// Table which is not generated, but is referenced by generated entities. TestKey is a row of demo data.
+// OwnerCheck is the function which checks that the caller owns a row of the table, TestOwner is the bank
+// which owns the TestKey row and OtherTestKey is a demo row of another bank.
+type externalTable struct {
+	Table        string
+	Constant     string
+	TestKey      string
+	OwnerCheck   string
+	TestOwner    string
+	OtherTestKey string
+}
+
+type columnSpec struct {
+	Name       string
+	Type       string
+	Default    string
+	References string
+	OnDelete   string
+}
+
+type entitySpec struct {
+	Name        string
+	Table       string
+	Prefix      string
+	Title       string
+	Key         string
+	Owner       string
+	Permissions map[string]string
+	Columns     []columnSpec
+}
+
+type spec struct {
+	ExternalTables []externalTable
+	Entities       []entitySpec
+}
+
+func main() {
+	specPath := flag.String("spec", "api/entities.json", "entity spec")
+	dir := flag.String("dir", ".", "chaincode directory")
+	flag.Parse()
+
+	s, err := loadSpec(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	files, err := generate(s, *specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(*dir, name), content, 0644)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func loadSpec(path string) (*spec, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s spec
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return nil, err
+	}
+	return &s, validateSpec(&s)
+}
+
+// Entities are tested in spec order, so references should point to external tables, earlier entities or the entity itself
+func validateSpec(s *spec) error {
+	tables := make(map[string]bool)
+	ownedTables := make(map[string]bool)
+	for _, t := range s.ExternalTables {
+		tables[t.Table] = true
+		ownedTables[t.Table] = t.OwnerCheck != ""
+	}
+	var errs []string
+	for _, e := range s.Entities {
+		if e.Name == "" || e.Table == "" || e.Prefix == "" || e.Title == "" {
+			errs = append(errs, "entity '"+e.Name+"': name, table, prefix and title are required")
+		}
+		if len(e.Columns) < 2 || e.Columns[0].Name != e.Key {
+			errs = append(errs, "entity '"+e.Name+"': key should be the first of at least two columns")
+		}
+		for op, role := range e.Permissions {
+			if op != PO_Add && op != PO_Update && op != PO_Patch && op != PO_Read {
+				errs = append(errs, "entity '"+e.Name+"': unknown permission operation '"+op+"'")
+			}
+			if role == "" {
+				errs = append(errs, "entity '"+e.Name+"': role of '"+op+"' operation is empty")
+			}
+			if role == PR_Owner && op != PO_Update && op != PO_Patch {
+				errs = append(errs, "entity '"+e.Name+"': '"+op+"' operation can not have owner role")
+			}
+			if role == PR_Owner && e.Owner == "" {
+				errs = append(errs, "entity '"+e.Name+"': owner column of '"+op+"' operation is not set")
+			}
+		}
+		if e.Owner != "" && !hasColumn(e, e.Owner) {
+			errs = append(errs, "entity '"+e.Name+"': owner column '"+e.Owner+"' is not a column")
+		}
+		if c, ok := getColumn(e, e.Owner); ok && !ownedTables[c.References] {
+			errs = append(errs, "entity '"+e.Name+"': owner column '"+e.Owner+"' does not reference a table with owners")
+		}
+		for _, c := range e.Columns {
+			if _, ok := typeConstants[c.Type]; c.Type != "" && !ok {
+				errs = append(errs, "column '"+e.Name+"."+c.Name+"': unknown type '"+c.Type+"'")
+			}
+			if c.References == "" {
+				continue
+			}
+			if !tables[c.References] && c.References != e.Table {
+				errs = append(errs, "column '"+e.Name+"."+c.Name+"': referenced table '"+c.References+"' is not defined before")
+			}
+			if _, ok := onDeleteConstants[c.OnDelete]; !ok {
+				errs = append(errs, "column '"+e.Name+"."+c.Name+"': unknown delete rule '"+c.OnDelete+"'")
+			}
+		}
+		tables[e.Table] = true
+		ownedTables[e.Table] = e.Owner != ""
+	}
+	if len(errs) > 0 {
+		return errors.New("Wrong entity spec: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func hasColumn(e entitySpec, name string) bool {
+	_, ok := getColumn(e, name)
+	return ok
+}
+
+func getColumn(e entitySpec, name string) (columnSpec, bool) {
+	for _, c := range e.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return columnSpec{}, false
+}
+
+// Returns generated files by their names
+func generate(s *spec, specPath string) (map[string][]byte, error) {
+	tableConstants := make(map[string]string)
+	testKeys := make(map[string]string)
+	owners := make(map[string]tableOwner)
+	for _, t := range s.ExternalTables {
+		tableConstants[t.Table] = t.Constant
+		testKeys[t.Table] = t.TestKey
+		if t.OwnerCheck != "" {
+			owners[t.Table] = tableOwner{t.OwnerCheck, t.TestOwner, t.OtherTestKey}
+		}
+	}
+
+	files := make(map[string][]byte)
+	var tests []entityTestView
+	for _, e := range s.Entities {
+		tableConstants[e.Table] = e.Name + "TableName"
+		v := newEntityView(e, tableConstants, owners)
+		b, err := execute(entityTemplate, struct {
+			SpecPath string
+			entityView
+		}{specPath, v})
+		if err != nil {
+			return nil, errors.New("Failed generating entity '" + e.Name + "': " + err.Error())
+		}
+		files["SLS"+e.Name+".go"] = b
+
+		t := newEntityTestView(v, e, testKeys)
+		if e.Owner != "" {
+			c, _ := getColumn(e, e.Owner)
+			owner := owners[c.References]
+			t.OwnerBank, t.OtherKey, t.OtherOwner = owner.TestOwner, entityOtherTestKey, owner.OtherTestKey
+			owners[e.Table] = tableOwner{"check" + e.Name + "RowPermissionsByBankId", owner.TestOwner, entityOtherTestKey}
+		}
+		tests = append(tests, t)
+		testKeys[e.Table] = entityTestKey
+	}
+
+	b, err := execute(testTemplate, struct {
+		SpecPath string
+		Tests    []entityTestView
+	}{specPath, tests})
+	if err != nil {
+		return nil, errors.New("Failed generating tests: " + err.Error())
+	}
+	files["SLSEntities_test.go"] = b
+	return files, nil
+}
+
+func execute(t *template.Template, data interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	err := t.Execute(&b, data)
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(b.Bytes())
+}
+
+// Rows of a table are owned by banks, the check function returns whether the caller owns a row.
+// Test rows are owned by TestOwner, the OtherTestKey row is owned by another bank.
+type tableOwner struct {
+	Check        string
+	TestOwner    string
+	OtherTestKey string
+}
+
+type foreignKeyView struct {
+	Column   string
+	RefTable string
+	OnDelete string
+}
+
+type columnView struct {
+	Const string
+	Name  string
+}
+
+type constantView struct {
+	Column string
+	Value  string
+}
+
+type entityView struct {
+	Name        string
+	Table       string
+	Title       string
+	Columns     []columnView
+	Roles       map[string]string
+	Owner       string
+	OwnerCheck  string
+	OwnerChecks map[string]bool
+	ForeignKeys []foreignKeyView
+	Types       []constantView
+	Defaults    []constantView
+}
+
+// Names in the view are Go expressions: constants of tables, columns, roles and rules
+func newEntityView(e entitySpec, tableConstants map[string]string, owners map[string]tableOwner) entityView {
+	v := entityView{Name: e.Name, Table: e.Table, Title: e.Title, Roles: make(map[string]string), OwnerChecks: make(map[string]bool)}
+	if c, ok := getColumn(e, e.Owner); ok {
+		v.Owner = e.Prefix + "_" + e.Owner + "ColName"
+		v.OwnerCheck = owners[c.References].Check
+	}
+	for op, role := range e.Permissions {
+		if role == PR_Owner {
+			v.OwnerChecks[op] = true
+		} else if constant, ok := roleConstants[role]; ok {
+			v.Roles[op] = constant
+		} else {
+			v.Roles[op] = `"` + role + `"`
+		}
+	}
+	for _, c := range e.Columns {
+		column := e.Prefix + "_" + c.Name + "ColName"
+		v.Columns = append(v.Columns, columnView{column, c.Name})
+		if c.References != "" {
+			v.ForeignKeys = append(v.ForeignKeys, foreignKeyView{column, tableConstants[c.References], onDeleteConstants[c.OnDelete]})
+		}
+		if c.Type != "" && c.Type != "string" {
+			v.Types = append(v.Types, constantView{column, typeConstants[c.Type]})
+		}
+		if c.Default != "" {
+			v.Defaults = append(v.Defaults, constantView{column, `"` + c.Default + `"`})
+		}
+	}
+	return v
+}
+
+// Key of rows added by tests, generated keys of following rows are greater
+const entityTestKey = "90"
+
+// Key of rows of another bank added by tests of entities with owners
+const entityOtherTestKey = "80"
+
+type entityTestView struct {
+	Name         string
+	Key          string
+	Values       []constantView
+	UpdateColumn string
+	IntColumn    string
+	RefColumn    string
+	OwnerColumn  string
+	OwnerBank    string
+	OtherKey     string
+	OtherOwner   string
+}
+
+// Test row references rows added by tests of earlier entities or demo rows of external tables
+func newEntityTestView(v entityView, e entitySpec, testKeys map[string]string) entityTestView {
+	t := entityTestView{Name: v.Name, Key: v.Columns[0].Const}
+	if v.OwnerChecks[PO_Update] && v.OwnerChecks[PO_Patch] {
+		t.OwnerColumn = v.Owner
+	}
+	for i, c := range e.Columns {
+		value := "Test " + c.Name
+		switch {
+		case i == 0:
+			value = entityTestKey
+		case c.References != "":
+			// Self references are empty, because the row does not exist yet
+			value = testKeys[c.References]
+			if t.RefColumn == "" {
+				t.RefColumn = v.Columns[i].Const
+			}
+		case c.Type == "int":
+			value = "1"
+			if t.IntColumn == "" {
+				t.IntColumn = v.Columns[i].Const
+			}
+		case c.Type == "time":
+			// Far in the future, so that deadlines of test rows do not pass
+			value = "2099-12-31T00:00:00Z"
+		case t.UpdateColumn == "":
+			t.UpdateColumn = v.Columns[i].Const
+		}
+		t.Values = append(t.Values, constantView{v.Columns[i].Const, `"` + value + `"`})
+	}
+	return t
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"text/template"
+)
+
+var entityTemplate = template.Must(template.New("entity").Parse(`// Code generated by entitygen from {{.SpecPath}}. DO NOT EDIT.
+
+package main
+
+import (
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+//Entity names
+const {{.Name}}TableName = "{{.Table}}"
+
+//Column names
+{{range .Columns}}const {{.Const}} = "{{.Name}}"
+{{end}}
+//Column quantity
+const {{.Name}}TableColsQty = {{len .Columns}}
+
+// ============================================================================================================================
+//
+// ============================================================================================================================
+
+func Create{{.Name}}Table(stub shim.ChaincodeStubInterface) error {
+	columnNames := []string{ {{- range $i, $c := .Columns}}{{if $i}}, {{end}}{{$c.Const}}{{end -}} }
+	return createTable(stub, {{.Name}}TableName, columnNames)
+}
+
+func init() {
+	registerFunction(functionDefinition{Name: "add{{.Name}}", Mode: FM_Write, {{with .Roles.add}}Role: {{.}}, {{end}}ArgsFormat: AF_Object, Table: {{.Name}}TableName, Key: AK_Optional,
+		handler: add{{.Name}}, Description: "Adds {{.Title}}"})
+	registerFunction(functionDefinition{Name: "update{{.Name}}", Mode: FM_Write, {{with .Roles.update}}Role: {{.}}, {{end}}ArgsFormat: AF_Object, Table: {{.Name}}TableName, Key: AK_Required,
+		handler: update{{.Name}}, Description: "Updates {{.Title}}, omitted columns keep current values"})
+	registerFunction(functionDefinition{Name: "patch{{.Name}}", Mode: FM_Write, {{with .Roles.patch}}Role: {{.}}, {{end}}ArgsFormat: AF_Object, Table: {{.Name}}TableName, Key: AK_Required,
+		Result: RT_Text, Args: []argumentDefinition{patchETagArg}, handler: patch{{.Name}}, Description: "Changes given columns of {{.Title}} and returns its new ETag"})
+	registerFunction(functionDefinition{Name: "get{{.Name}}Quantity", Mode: FM_Read, {{with .Roles.read}}Role: {{.}}, {{end}}Result: RT_Text, Table: {{.Name}}TableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: get{{.Name}}Quantity, Description: "Returns number of {{.Title}}s"})
+	registerFunction(functionDefinition{Name: "get{{.Name}}List", Mode: FM_Read, {{with .Roles.read}}Role: {{.}}, {{end}}Table: {{.Name}}TableName,
+		Args: []argumentDefinition{includeDeletedArg}, handler: get{{.Name}}List, Description: "Returns all {{.Title}}s"})
+	registerFunction(functionDefinition{Name: "get{{.Name}}ByKey", Mode: FM_Read, {{with .Roles.read}}Role: {{.}}, {{end}}Table: {{.Name}}TableName,
+		Args: []argumentDefinition{ {Name: "key"} }, handler: get{{.Name}}ByKey, Description: "Returns {{.Title}} with the key"})
+	registerFunction(functionDefinition{Name: "get{{.Name}}MaxKey", Mode: FM_Read, {{with .Roles.read}}Role: {{.}}, {{end}}Result: RT_Text, Table: {{.Name}}TableName,
+		handler: get{{.Name}}MaxKey, Description: "Returns the greatest key of {{.Title}}s"})
+{{if .ForeignKeys}}
+	foreignKeys = append(foreignKeys,
+{{- range .ForeignKeys}}
+		foreignKey{ {{- $.Name}}TableName, {{.Column}}, {{.RefTable}}, {{.OnDelete -}} },
+{{- end}}
+	)
+{{- end}}
+{{- if .Types}}
+	columnTypes[{{.Name}}TableName] = map[string]string{ {{- range $i, $t := .Types}}{{if $i}}, {{end}}{{$t.Column}}: {{$t.Value}}{{end -}} }
+{{- end}}
+{{- if .Defaults}}
+	columnDefaults[{{.Name}}TableName] = map[string]string{ {{- range $i, $d := .Defaults}}{{if $i}}, {{end}}{{$d.Column}}: {{$d.Value}}{{end -}} }
+{{- end}}
+}
+
+func add{{.Name}}(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getAddArgs(stub, {{.Name}}TableName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == {{.Name}}TableColsQty {
+		return nil, addRow(stub, {{.Name}}TableName, args, true)
+	}
+	if len(args) == {{.Name}}TableColsQty-1 {
+		return nil, addRow(stub, {{.Name}}TableName, args, false)
+	}
+
+	return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in add{{.Name}} func. "+
+		"Provided "+strconv.Itoa(len(args))+", expecting "+strconv.Itoa({{.Name}}TableColsQty-1)+
+		" or "+strconv.Itoa({{.Name}}TableColsQty))
+}
+
+func get{{.Name}}Quantity(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	return countTableRows(stub, passIncludeDeletedOption([]string{ {{- .Name}}TableName}, args))
+}
+
+func get{{.Name}}List(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	return filterTableByValue(stub, passIncludeDeletedOption([]string{ {{- .Name}}TableName}, args))
+}
+
+func get{{.Name}}ByKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in get{{.Name}}ByKey func. Expecting 1")
+	}
+	keyValue := args[0]
+	return filterTableByKey(stub, {{.Name}}TableName, keyValue)
+}
+
+func get{{.Name}}MaxKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	maxKey, err := getTableMaxKey(stub, {{.Name}}TableName)
+	if err != nil {
+		return nil, wrapError(err, "Error in get{{.Name}}MaxKey func: ")
+	}
+	return maxKey, nil
+}
+
+func update{{.Name}}(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	args, err := getUpdateArgs(stub, {{.Name}}TableName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) != {{.Name}}TableColsQty {
+		return nil, newError(ErrCodeInvalidArgument, "Incorrect number of arguments in update{{.Name}} func. Expecting "+strconv.Itoa({{.Name}}TableColsQty))
+	}
+
+	changes, err := getUpdateChanges(stub, {{.Name}}TableName, args)
+	if err != nil {
+		return nil, wrapError(err, "An error occured while running update{{.Name}}: ")
+	}
+{{- if .OwnerChecks.update}}
+
+	///////////////////////////Security check////////////////////////////
+	check, err := check{{.Name}}RowPermissionsByBankId(stub, args[0])
+	if !check {
+		return nil, wrapError(err, "Failed checking security in update{{.Name}} or returned false: ")
+	}
+	// Rows can not be moved to other banks
+	if ownerKey, ok := changes[{{.Owner}}]; ok {
+		check, err = {{.OwnerCheck}}(stub, ownerKey)
+		if !check {
+			return nil, wrapError(err, "Failed checking security in update{{.Name}} or returned false: ")
+		}
+	}
+	/////////////////////////////////////////////////////////////////////
+{{- end}}
+
+	_, err = patchRow(stub, {{.Name}}TableName, args[0], changes, "")
+	if err != nil {
+		return nil, wrapError(err, "Failed updating row in update{{.Name}} func: ")
+	}
+
+	return nil, nil
+}
+
+// Changes only given columns: JSON object with the key, changed columns and optional ETag. Returns the new ETag.
+func patch{{.Name}}(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	keyValue, changes, eTag, err := parsePatchArgs(stub, {{.Name}}TableName, args)
+	if err != nil {
+		return nil, err
+	}
+{{- if .OwnerChecks.patch}}
+
+	///////////////////////////Security check////////////////////////////
+	check, err := check{{.Name}}RowPermissionsByBankId(stub, keyValue)
+	if !check {
+		return nil, wrapError(err, "Failed checking security in patch{{.Name}} or returned false: ")
+	}
+	// Rows can not be moved to other banks
+	if ownerKey, ok := changes[{{.Owner}}]; ok {
+		check, err = {{.OwnerCheck}}(stub, ownerKey)
+		if !check {
+			return nil, wrapError(err, "Failed checking security in patch{{.Name}} or returned false: ")
+		}
+	}
+	/////////////////////////////////////////////////////////////////////
+{{end}}
+	return patchRow(stub, {{.Name}}TableName, keyValue, changes, eTag)
+}
+{{- if .Owner}}
+
+// Banks change only their own {{.Title}}s, the assigner changes {{.Title}}s of every bank
+func check{{.Name}}RowPermissionsByBankId(stub shim.ChaincodeStubInterface, keyValue string) (bool, error) {
+	ownerKey, err := getTableColValueByKey(stub, {{.Name}}TableName, keyValue, {{.Owner}})
+	if err != nil {
+		return false, wrapError(err, "Error getting owner in check{{.Name}}RowPermissionsByBankId func: ")
+	}
+
+	check, err := {{.OwnerCheck}}(stub, ownerKey)
+	if !check {
+		return false, wrapError(err, "Failed checking security in check{{.Name}}RowPermissionsByBankId func or returned false: ")
+	}
+
+	return true, nil
+}
+{{- end}}
+`))
+
+var testTemplate = template.Must(template.New("test").Parse(`// Code generated by entitygen from {{.SpecPath}}. DO NOT EDIT.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+type entityTest struct {
+	Name string
+	Key  string
+	// Row added with the key, its references are rows of earlier entities or demo rows
+	Values       map[string]string
+	UpdateColumn string
+	IntColumn    string
+	RefColumn    string
+	// Update and patch are allowed to the owner bank of the row referenced by the column,
+	// OtherKey row references a row of another bank
+	OwnerColumn string
+	OwnerBank   string
+	OtherKey    string
+	OtherOwner  string
+}
+
+var entityTests = []entityTest{
+{{- range .Tests}}
+	{Name: "{{.Name}}", Key: {{.Key}},
+		Values: map[string]string{
+{{- range .Values}}
+			{{.Column}}: {{.Value}},
+{{- end}}
+		},
+		{{- with .UpdateColumn}}
+		UpdateColumn: {{.}},{{end}}
+		{{- with .IntColumn}}
+		IntColumn: {{.}},{{end}}
+		{{- with .RefColumn}}
+		RefColumn: {{.}},{{end}}
+		{{- if .OwnerColumn}}
+		OwnerColumn: {{.OwnerColumn}}, OwnerBank: "{{.OwnerBank}}", OtherKey: "{{.OtherKey}}", OtherOwner: "{{.OtherOwner}}",{{end}}
+	},
+{{- end}}
+}
+
+func getEntityTestRow(t *testing.T, stub *shimtest.MockStub, e entityTest, keyValue string) map[string]string {
+	bytes, err := mockQuery(stub, "get"+e.Name+"ByKey", []string{keyValue})
+	if err != nil {
+		fmt.Println("Failed getting", e.Name, keyValue, err)
+		t.FailNow()
+	}
+	var rows []map[string]string
+	err = json.Unmarshal(bytes, &rows)
+	if err != nil || len(rows) != 1 {
+		fmt.Println("Wrong rows of", e.Name, keyValue, string(bytes))
+		t.FailNow()
+	}
+	return rows[0]
+}
+
+// Invokes the function with named arguments, private values are passed in the transient map
+func invokeEntityTest(stub *shimtest.MockStub, function string, txID string, values map[string]string) error {
+	_, err := mockInvokeWithTransient(stub, txID, function, values)
+	return err
+}
+
+// Entities are tested in spec order, so rows referenced by an entity are added before it
+func TestSLSChaincode_Entities(t *testing.T) {
+	scc := new(SimpleChaincode)
+	stub := shimtest.NewMockStub("ex02", scc)
+
+	checkInit(t, stub, []string{""})
+
+	for _, e := range entityTests {
+		bytes, err := mockQuery(stub, "get"+e.Name+"Quantity", nil)
+		quantity, _ := strconv.Atoi(string(bytes))
+		if err != nil {
+			fmt.Println("Failed counting", e.Name, err)
+			t.FailNow()
+		}
+
+		err = invokeEntityTest(stub, "add"+e.Name, e.Name+"-add", e.Values)
+		if err != nil {
+			fmt.Println("Failed adding", e.Name, err)
+			t.FailNow()
+		}
+		keyValue := e.Values[e.Key]
+		row := getEntityTestRow(t, stub, e, keyValue)
+		for column, value := range e.Values {
+			if row[column] != value {
+				fmt.Println("Wrong", e.Name, column, row[column], "expected", value)
+				t.FailNow()
+			}
+		}
+		checkQuery(t, stub, "get"+e.Name+"Quantity", nil, strconv.Itoa(quantity+1))
+		checkQuery(t, stub, "get"+e.Name+"MaxKey", nil, keyValue)
+
+		// Omitted key is generated
+		values := make(map[string]string)
+		for column, value := range e.Values {
+			if column != e.Key {
+				values[column] = value
+			}
+		}
+		err = invokeEntityTest(stub, "add"+e.Name, e.Name+"-generated", values)
+		if err != nil {
+			fmt.Println("Failed adding", e.Name, "without key", err)
+			t.FailNow()
+		}
+		generatedKey, _ := strconv.Atoi(keyValue)
+		checkQuery(t, stub, "get"+e.Name+"MaxKey", nil, strconv.Itoa(generatedKey+1))
+
+		if e.UpdateColumn != "" {
+			err = invokeEntityTest(stub, "update"+e.Name, e.Name+"-update", map[string]string{e.Key: keyValue, e.UpdateColumn: "Updated"})
+			if err != nil {
+				fmt.Println("Failed updating", e.Name, err)
+				t.FailNow()
+			}
+			row = getEntityTestRow(t, stub, e, keyValue)
+			if row[e.UpdateColumn] != "Updated" || len(row) != len(e.Values) {
+				fmt.Println("Wrong updated", e.Name, row)
+				t.FailNow()
+			}
+
+			err = invokeEntityTest(stub, "patch"+e.Name, e.Name+"-patch", map[string]string{e.Key: keyValue, e.UpdateColumn: "Patched", PatchETagArgName: "stale"})
+			checkErrorCode(t, err, ErrCodeConflict, "")
+		}
+
+		// Banks change only their own rows and can not move them to other banks
+		if e.OwnerColumn != "" {
+			values = make(map[string]string)
+			for column, value := range e.Values {
+				values[column] = value
+			}
+			values[e.Key], values[e.OwnerColumn] = e.OtherKey, e.OtherOwner
+			err = invokeEntityTest(stub, "add"+e.Name, e.Name+"-other-add", values)
+			if err != nil {
+				fmt.Println("Failed adding", e.Name, "of another bank", err)
+				t.FailNow()
+			}
+
+			actAs(testIdentity{CA_Role: FR_Bank, CA_BankID: "0"})
+			err = invokeEntityTest(stub, "update"+e.Name, e.Name+"-other-update", map[string]string{e.Key: keyValue, e.UpdateColumn: "Other"})
+			checkErrorCode(t, err, ErrCodePermissionDenied, "")
+			err = invokeEntityTest(stub, "patch"+e.Name, e.Name+"-other-patch", map[string]string{e.Key: keyValue, e.UpdateColumn: "Other"})
+			checkErrorCode(t, err, ErrCodePermissionDenied, "")
+
+			actAs(testIdentity{CA_Role: FR_Bank, CA_BankID: e.OwnerBank})
+			err = invokeEntityTest(stub, "patch"+e.Name, e.Name+"-move", map[string]string{e.Key: keyValue, e.OwnerColumn: e.OtherOwner})
+			checkErrorCode(t, err, ErrCodePermissionDenied, "")
+			err = invokeEntityTest(stub, "patch"+e.Name, e.Name+"-other-patch-by-owner", map[string]string{e.Key: e.OtherKey, e.UpdateColumn: "Other"})
+			checkErrorCode(t, err, ErrCodePermissionDenied, "")
+			err = invokeEntityTest(stub, "patch"+e.Name, e.Name+"-owner-patch", map[string]string{e.Key: keyValue, e.UpdateColumn: "Patched"})
+			stopActing()
+			if err != nil {
+				fmt.Println("Owner bank failed patching", e.Name, err)
+				t.FailNow()
+			}
+		}
+
+		if e.IntColumn != "" {
+			err = invokeEntityTest(stub, "add"+e.Name, e.Name+"-int", map[string]string{e.IntColumn: "one"})
+			checkErrorCode(t, err, ErrCodeInvalidArgument, e.IntColumn)
+		}
+		if e.RefColumn != "" {
+			err = invokeEntityTest(stub, "add"+e.Name, e.Name+"-ref", map[string]string{e.RefColumn: "999"})
+			checkErrorCode(t, err, ErrCodeInvalidArgument, e.RefColumn)
+		}
+	}
+}
+`))
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Events remembered for dedupe, older ones are forgotten
+const checkpointMaxEvents = 10000
+
+// Checkpoint is kept in a JSON file, so a restarted listener neither loses nor repeats notifications
+type Checkpoint struct {
+	// Position of the last completely processed envelope of a replayable source
+	Position int64
+	// Sinks which already received the event, by event ID
+	Delivered map[string][]string
+	// Event IDs in the order they were first delivered
+	Order []string
+
+	path string
+}
+
+func loadCheckpoint(path string) (*Checkpoint, error) {
+	cp := &Checkpoint{Delivered: make(map[string][]string), path: path}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, errors.New("Failed reading checkpoint: " + err.Error())
+	}
+	err = json.Unmarshal(b, cp)
+	if err != nil {
+		return nil, errors.New("Failed parsing checkpoint: " + err.Error())
+	}
+	if cp.Delivered == nil {
+		cp.Delivered = make(map[string][]string)
+	}
+	return cp, nil
+}
+
+func (cp *Checkpoint) IsDelivered(eventID, sinkName string) bool {
+	for _, s := range cp.Delivered[eventID] {
+		if s == sinkName {
+			return true
+		}
+	}
+	return false
+}
+
+func (cp *Checkpoint) SetDelivered(eventID, sinkName string) {
+	if _, ok := cp.Delivered[eventID]; !ok {
+		cp.Order = append(cp.Order, eventID)
+	}
+	cp.Delivered[eventID] = append(cp.Delivered[eventID], sinkName)
+
+	for len(cp.Order) > checkpointMaxEvents {
+		delete(cp.Delivered, cp.Order[0])
+		cp.Order = cp.Order[1:]
+	}
+}
+
+// Writes the checkpoint to a temporary file and renames it, so the file is never partially written
+func (cp *Checkpoint) Save() error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return errors.New("Failed saving checkpoint: " + err.Error())
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(cp.path), filepath.Base(cp.path)+".tmp")
+	if err != nil {
+		return errors.New("Failed saving checkpoint: " + err.Error())
+	}
+	_, err = tmp.Write(b)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), cp.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return errors.New("Failed saving checkpoint: " + err.Error())
+	}
+	return nil
+}
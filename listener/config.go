@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strconv"
+	"time"
+)
+
+// Config is read from a JSON file, see config.example.json
+type Config struct {
+	// Events are delivered if the bank made the change or is referenced by one of BankColumns
+	BankID      string
+	BankColumns []string
+
+	Source     SourceConfig
+	Sinks      map[string]SinkConfig
+	Routes     []Route
+	Retry      RetryConfig
+	Checkpoint string
+}
+
+type SourceConfig struct {
+	// "peer", "file" or "mock"
+	Type string
+	// Gateway peer address, PEM file of its TLS CA certificate and TLS server name override for "peer" source
+	PeerAddress string
+	TLSCertPath string
+	ServerName  string
+	// Channel and chaincode of the events
+	ChannelName string
+	ChaincodeID string
+	// Identity of the listener: MSP ID, PEM files of the certificate and the private key
+	MSPID    string
+	CertPath string
+	KeyPath  string
+	// JSON lines file for "file" source, mock source reads it once without following
+	Path         string
+	PollInterval Duration
+}
+
+type SinkConfig struct {
+	// "webhook", "email" or "queue"
+	Type string
+	// Webhook URL
+	URL     string
+	Timeout Duration
+	// SMTP server address, sender and recipients
+	SMTPAddress string
+	From        string
+	To          []string
+	// Spool directory of the queue, every message is a separate file
+	Dir string
+}
+
+// Route sends events of the types to the sinks, empty EventTypes match all events
+type Route struct {
+	EventTypes []string
+	Sinks      []string
+}
+
+type RetryConfig struct {
+	Attempts int
+	Backoff  Duration
+}
+
+// Duration is a time.Duration written as a string like "5s" in JSON
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+	d.Duration, err = time.ParseDuration(s)
+	return err
+}
+
+var defaultBankColumns = []string{"BankID", "ArrangerBankID", "ParticipantBankID", "ParticipantID"}
+
+func loadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("Failed reading config: " + err.Error())
+	}
+	var cfg Config
+	err = json.Unmarshal(b, &cfg)
+	if err != nil {
+		return nil, errors.New("Failed parsing config: " + err.Error())
+	}
+
+	if cfg.BankID == "" {
+		return nil, errors.New("BankID is missing in config")
+	}
+	if cfg.BankColumns == nil {
+		cfg.BankColumns = defaultBankColumns
+	}
+	if cfg.Checkpoint == "" {
+		return nil, errors.New("Checkpoint path is missing in config")
+	}
+	if cfg.Retry.Attempts <= 0 {
+		cfg.Retry.Attempts = 1
+	}
+	for i, r := range cfg.Routes {
+		for _, name := range r.Sinks {
+			if _, ok := cfg.Sinks[name]; !ok {
+				return nil, errors.New("Route " + strconv.Itoa(i) + " refers to unknown sink '" + name + "'")
+			}
+		}
+	}
+	return &cfg, nil
+}
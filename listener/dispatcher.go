@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+)
+
+// Dispatcher delivers relevant events of received envelopes to routed sinks.
+// Delivery is at least once: progress is saved to the checkpoint after every envelope
+// and every successful delivery, already delivered events are skipped.
+type Dispatcher struct {
+	BankID      string
+	BankColumns []string
+	Routes      []Route
+	Sinks       map[string]Sink
+	Retry       RetryConfig
+	Checkpoint  *Checkpoint
+
+	sleep func(time.Duration)
+}
+
+func newDispatcher(cfg *Config, sinks map[string]Sink, cp *Checkpoint) *Dispatcher {
+	return &Dispatcher{BankID: cfg.BankID, BankColumns: cfg.BankColumns, Routes: cfg.Routes, Sinks: sinks,
+		Retry: cfg.Retry, Checkpoint: cp, sleep: time.Sleep}
+}
+
+// Events made by the bank or referencing it in one of bank columns are relevant
+func (d *Dispatcher) isRelevant(e Event) bool {
+	if e.BankID == d.BankID {
+		return true
+	}
+	for _, c := range d.BankColumns {
+		if e.ChangedFields[c] == d.BankID || e.PreviousValues[c] == d.BankID {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) getSinkNames(e Event) []string {
+	var names []string
+	for _, r := range d.Routes {
+		isMatched := len(r.EventTypes) == 0
+		for _, t := range r.EventTypes {
+			if t == e.Type {
+				isMatched = true
+				break
+			}
+		}
+		if !isMatched {
+			continue
+		}
+		for _, name := range r.Sinks {
+			var isAdded bool
+			for _, n := range names {
+				if n == name {
+					isAdded = true
+					break
+				}
+			}
+			if !isAdded {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Handle returns error if a delivery failed after all retries, the envelope is then handled again after restart
+func (d *Dispatcher) Handle(env Envelope) error {
+	if env.Position > 0 && env.Position <= d.Checkpoint.Position {
+		return nil
+	}
+
+	events, err := parseEvents(env)
+	if err != nil {
+		return err
+	}
+
+	for i, e := range events {
+		if !d.isRelevant(e) {
+			continue
+		}
+		n := Notification{EventID: getEventID(env.TxID, i), BankID: d.BankID, Event: e}
+		for _, name := range d.getSinkNames(e) {
+			if d.Checkpoint.IsDelivered(n.EventID, name) {
+				continue
+			}
+			err = d.send(name, n)
+			if err != nil {
+				return errors.New("Failed delivering event '" + n.EventID + "' to sink '" + name + "': " + err.Error())
+			}
+			d.Checkpoint.SetDelivered(n.EventID, name)
+			err = d.Checkpoint.Save()
+			if err != nil {
+				return err
+			}
+			log.Printf("Event %v %v delivered to sink '%v'", n.EventID, e.Type, name)
+		}
+	}
+
+	if env.Position > 0 {
+		d.Checkpoint.Position = env.Position
+		return d.Checkpoint.Save()
+	}
+	return nil
+}
+
+// Retries are made with exponentially growing backoff
+func (d *Dispatcher) send(sinkName string, n Notification) error {
+	backoff := d.Retry.Backoff.Duration
+	var err error
+	for attempt := 1; attempt <= d.Retry.Attempts; attempt++ {
+		err = d.Sinks[sinkName].Send(n)
+		if err == nil {
+			return nil
+		}
+		log.Printf("Attempt %v of delivering event %v to sink '%v' failed: %v", attempt, n.EventID, sinkName, err)
+		if attempt < d.Retry.Attempts {
+			d.sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return err
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// Event mirrors a chaincode row event, see getEventCatalogueList chaincode query
+type Event struct {
+	Type           string
+	Entity         string
+	ID             string
+	Action         string
+	BankID         string
+	UserID         string
+	TxID           string
+	Date           string
+	ChangedFields  map[string]string `json:",omitempty"`
+	PreviousValues map[string]string `json:",omitempty"`
+}
+
+// Envelope is a chaincode event as received from a source. Payload is a JSON array of events.
+// Position is the place of the envelope in a replayable source: line of a file or block of the peer source.
+// Envelopes without position are not checkpointed.
+type Envelope struct {
+	Position  int64
+	TxID      string
+	EventName string
+	Payload   json.RawMessage
+}
+
+// Notification is sent to sinks for every relevant event
+type Notification struct {
+	EventID string
+	BankID  string
+	Event   Event
+}
+
+func parseEvents(env Envelope) ([]Event, error) {
+	var events []Event
+	err := json.Unmarshal(env.Payload, &events)
+	if err != nil {
+		return nil, errors.New("Failed parsing payload of transaction '" + env.TxID + "': " + err.Error())
+	}
+	return events, nil
+}
+
+// Events of a transaction are always sent in the same order, so the index identifies an event
+func getEventID(txID string, index int) string {
+	return txID + ":" + strconv.Itoa(index)
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testEvents = `{"TxID": "tx1", "EventName": "LoanNegotiationCreated", "Payload": [{"Type": "LoanNegotiationCreated", "Entity": "LoanNegotiation", "ID": "1", "Action": "Created", "BankID": "7", "TxID": "tx1", "ChangedFields": {"LoanRequestID": "1", "ParticipantBankID": "6"}}]}
+{"TxID": "tx2", "EventName": "LoanRequestCreated", "Payload": [{"Type": "LoanRequestCreated", "Entity": "LoanRequest", "ID": "2", "Action": "Created", "BankID": "8", "TxID": "tx2", "ChangedFields": {"ArrangerBankID": "8"}}]}
+{"TxID": "tx3", "EventName": "NegotiationResponded,LoanRequestStatusChanged", "Payload": [{"Type": "NegotiationResponded", "Entity": "LoanNegotiation", "ID": "1", "Action": "Updated", "BankID": "6", "TxID": "tx3", "ChangedFields": {"NegotiationStatus": "INTERESTED"}, "PreviousValues": {"NegotiationStatus": "INVITED"}}, {"Type": "LoanRequestStatusChanged", "Entity": "LoanRequest", "ID": "1", "Action": "Updated", "BankID": "6", "TxID": "tx3", "ChangedFields": {"Status": "Negotiation Started"}, "PreviousValues": {"Status": "Invitation Sent"}}]}
+`
+
+// Minimal SMTP stand-in which accepts every mail and passes its data to the channel
+func startSMTPStandIn(t *testing.T, mails chan<- string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				conn.Write([]byte("220 localhost\r\n"))
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					cmd := strings.ToUpper(strings.TrimSpace(line))
+					switch {
+					case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+						conn.Write([]byte("250 localhost\r\n"))
+					case cmd == "DATA":
+						conn.Write([]byte("354 go ahead\r\n"))
+						var data []string
+						for {
+							l, err := r.ReadString('\n')
+							if err != nil {
+								return
+							}
+							if l == ".\r\n" {
+								break
+							}
+							data = append(data, l)
+						}
+						mails <- strings.Join(data, "")
+						conn.Write([]byte("250 ok\r\n"))
+					case cmd == "QUIT":
+						conn.Write([]byte("221 bye\r\n"))
+						return
+					default:
+						conn.Write([]byte("250 ok\r\n"))
+					}
+				}
+			}(conn)
+		}
+	}()
+	t.Cleanup(func() { l.Close() })
+	return l.Addr().String()
+}
+
+func TestListener(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sls-listener")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	eventsPath := filepath.Join(dir, "events.jsonl")
+	err = ioutil.WriteFile(eventsPath, []byte(testEvents), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Webhook fails once to check retries
+	var webhookRequests []Notification
+	var webhookFailures int
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if webhookFailures == 0 {
+			webhookFailures++
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var n Notification
+		json.NewDecoder(r.Body).Decode(&n)
+		webhookRequests = append(webhookRequests, n)
+	}))
+	defer webhook.Close()
+
+	mails := make(chan string, 10)
+	smtpAddress := startSMTPStandIn(t, mails)
+
+	cfg := `{
+		"BankID": "6",
+		"Source": {"Type": "mock", "Path": "` + eventsPath + `"},
+		"Sinks": {
+			"backoffice": {"Type": "webhook", "URL": "` + webhook.URL + `"},
+			"mail": {"Type": "email", "SMTPAddress": "` + smtpAddress + `", "From": "sls@bank.example", "To": ["loans@bank.example"]},
+			"queue": {"Type": "queue", "Dir": "` + filepath.Join(dir, "queue") + `"}
+		},
+		"Routes": [
+			{"Sinks": ["queue"]},
+			{"EventTypes": ["LoanNegotiationCreated", "NegotiationResponded"], "Sinks": ["mail"]},
+			{"EventTypes": ["LoanRequestStatusChanged"], "Sinks": ["backoffice"]}
+		],
+		"Retry": {"Attempts": 3, "Backoff": "1ms"},
+		"Checkpoint": "` + filepath.Join(dir, "checkpoint.json") + `"
+	}`
+	cfgPath := filepath.Join(dir, "listener.json")
+	err = ioutil.WriteFile(cfgPath, []byte(cfg), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = run(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Event of tx2 does not concern bank 6
+	queued, err := filepath.Glob(filepath.Join(dir, "queue", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 3 {
+		t.Fatalf("Expected 3 queued messages, got %v", queued)
+	}
+	if len(mails) != 2 {
+		t.Fatalf("Expected 2 mails, got %v", len(mails))
+	}
+	mail := <-mails
+	if !strings.Contains(mail, "Subject: LoanNegotiationCreated LoanNegotiation 1") || !strings.Contains(mail, "ParticipantBankID: 6") {
+		t.Fatalf("Unexpected mail %v", mail)
+	}
+	<-mails
+	if len(webhookRequests) != 1 || webhookRequests[0].EventID != "tx3:1" || webhookRequests[0].Event.ChangedFields["Status"] != "Negotiation Started" {
+		t.Fatalf("Unexpected webhook requests %v", webhookRequests)
+	}
+
+	// Restart does not repeat delivered events and continues with new ones
+	f, err := os.OpenFile(eventsPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"TxID": "tx4", "EventName": "VoteCast", "Payload": [{"Type": "VoteCast", "Entity": "LoanTermVote", "ID": "1", "Action": "Created", "BankID": "6", "TxID": "tx4"}]}` + "\n")
+	f.Close()
+
+	err = run(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queued, _ = filepath.Glob(filepath.Join(dir, "queue", "*.json"))
+	if len(queued) != 4 || len(mails) != 0 || len(webhookRequests) != 1 {
+		t.Fatalf("Events were delivered again after restart: %v queued, %v mails, %v webhook requests", len(queued), len(mails), len(webhookRequests))
+	}
+}
+
+type failingSink struct {
+	failures int
+	sent     []string
+}
+
+func (s *failingSink) Send(n Notification) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	s.sent = append(s.sent, n.EventID)
+	return nil
+}
+
+func TestDispatcher_PartialDelivery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sls-listener")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cp, err := loadCheckpoint(filepath.Join(dir, "checkpoint.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := &failingSink{}, &failingSink{failures: 2}
+	d := &Dispatcher{BankID: "6", BankColumns: defaultBankColumns, Routes: []Route{{Sinks: []string{"first", "second"}}},
+		Sinks: map[string]Sink{"first": first, "second": second}, Retry: RetryConfig{Attempts: 2}, Checkpoint: cp,
+		sleep: func(time.Duration) {}}
+
+	env := Envelope{Position: 1, TxID: "tx1", Payload: json.RawMessage(`[{"Type": "VoteCast", "BankID": "6"}]`)}
+	if d.Handle(env) == nil {
+		t.Fatal("Delivery did not fail after all retries")
+	}
+
+	// Sink which already received the event does not get it again
+	cp, err = loadCheckpoint(filepath.Join(dir, "checkpoint.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Checkpoint = cp
+	err = d.Handle(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.sent) != 1 || len(second.sent) != 1 || cp.Position != 1 {
+		t.Fatalf("Unexpected deliveries %v %v", first.sent, second.sent)
+	}
+}
+
+func TestBlockTracker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sls-listener")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cp, err := loadCheckpoint(filepath.Join(dir, "checkpoint.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp.Position = 5
+	d := &Dispatcher{BankID: "6", Checkpoint: cp, sleep: func(time.Duration) {}}
+
+	// Block 6 has two events, the checkpoint moves to it when an event of block 8 arrives
+	blocks := newBlockTracker(cp.Position)
+	var positions []int64
+	for _, block := range []int64{6, 6, 8, 9} {
+		if env, ok := blocks.Next(block); ok {
+			err = d.Handle(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			positions = append(positions, env.Position)
+		}
+	}
+	if len(positions) != 2 || positions[0] != 6 || positions[1] != 8 {
+		t.Fatalf("Unexpected checkpointed blocks %v", positions)
+	}
+	cp, err = loadCheckpoint(filepath.Join(dir, "checkpoint.json"))
+	if err != nil || cp.Position != 8 {
+		t.Fatalf("Block 8 is not checkpointed %v %v", cp, err)
+	}
+}
@@ -0,0 +1,47 @@
+// Listener receives chaincode events, filters the ones relevant to a bank and
+// delivers them to webhooks, mail and queues of the bank systems.
+package main
+
+import (
+	"flag"
+	"log"
+)
+
+func main() {
+	configPath := flag.String("config", "listener.json", "path to JSON config")
+	flag.Parse()
+
+	err := run(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(configPath string) error {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	sinks := make(map[string]Sink)
+	for name, sc := range cfg.Sinks {
+		sinks[name], err = newSink(sc)
+		if err != nil {
+			return err
+		}
+	}
+
+	source, err := newSource(cfg.Source)
+	if err != nil {
+		return err
+	}
+
+	cp, err := loadCheckpoint(cfg.Checkpoint)
+	if err != nil {
+		return err
+	}
+
+	d := newDispatcher(cfg, sinks, cp)
+	log.Printf("Listening to events of bank %v from %v source", cfg.BankID, cfg.Source.Type)
+	return source.Run(cp.Position, d.Handle)
+}
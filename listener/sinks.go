@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sink delivers notifications to a bank system. Send may be called again for the same notification
+// if delivery to another sink failed before the checkpoint was saved.
+type Sink interface {
+	Send(n Notification) error
+}
+
+func newSink(cfg SinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case "webhook":
+		if cfg.URL == "" {
+			return nil, errors.New("URL is missing in webhook sink config")
+		}
+		timeout := cfg.Timeout.Duration
+		if timeout == 0 {
+			timeout = 10 * time.Second
+		}
+		return &WebhookSink{URL: cfg.URL, Client: &http.Client{Timeout: timeout}}, nil
+	case "email":
+		if cfg.SMTPAddress == "" || cfg.From == "" || len(cfg.To) == 0 {
+			return nil, errors.New("SMTPAddress, From and To are required in email sink config")
+		}
+		return &EmailSink{Address: cfg.SMTPAddress, From: cfg.From, To: cfg.To}, nil
+	case "queue":
+		if cfg.Dir == "" {
+			return nil, errors.New("Dir is missing in queue sink config")
+		}
+		err := os.MkdirAll(cfg.Dir, 0755)
+		if err != nil {
+			return nil, errors.New("Failed creating queue directory: " + err.Error())
+		}
+		return &QueueSink{Dir: cfg.Dir}, nil
+	}
+	return nil, errors.New("Unknown sink type '" + cfg.Type + "'")
+}
+
+// WebhookSink posts notifications as JSON, EventID header allows the receiver to drop duplicates
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *WebhookSink) Send(n Notification) error {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", s.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", n.EventID)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return errors.New("Webhook request failed: " + err.Error())
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("Webhook returned status " + strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
+
+// EmailSink sends plain text mails through an SMTP server without authentication,
+// normally a local relay or a stand-in during tests
+type EmailSink struct {
+	Address string
+	From    string
+	To      []string
+}
+
+func (s *EmailSink) Send(n Notification) error {
+	e := n.Event
+	var body bytes.Buffer
+	body.WriteString("From: " + s.From + "\r\n")
+	body.WriteString("To: " + strings.Join(s.To, ", ") + "\r\n")
+	body.WriteString("Subject: " + e.Type + " " + e.Entity + " " + e.ID + "\r\n")
+	body.WriteString("Message-ID: <" + n.EventID + "." + n.BankID + "@sls-listener>\r\n")
+	body.WriteString("\r\n")
+	body.WriteString(e.Entity + " " + e.ID + " " + strings.ToLower(e.Action) + " by bank " + e.BankID +
+		" in transaction " + e.TxID + " at " + e.Date + "\r\n")
+	for _, k := range sortedKeys(e.ChangedFields) {
+		line := k + ": " + e.ChangedFields[k]
+		if old, ok := e.PreviousValues[k]; ok {
+			line += " (was " + old + ")"
+		}
+		body.WriteString(line + "\r\n")
+	}
+
+	err := smtp.SendMail(s.Address, nil, s.From, s.To, body.Bytes())
+	if err != nil {
+		return errors.New("Sending mail failed: " + err.Error())
+	}
+	return nil
+}
+
+// QueueSink writes every notification to a separate file of a spool directory.
+// Files are named by event ID and renamed into place, so consumers only see complete messages.
+type QueueSink struct {
+	Dir string
+}
+
+func (s *QueueSink) Send(n Notification) error {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	name := strings.Replace(n.EventID, ":", "_", -1) + ".json"
+	tmpPath := filepath.Join(s.Dir, "."+name+".tmp")
+	err = ioutil.WriteFile(tmpPath, b, 0644)
+	if err == nil {
+		err = os.Rename(tmpPath, filepath.Join(s.Dir, name))
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return errors.New("Writing queue message failed: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !fabric
+// +build !fabric
+
+package main
+
+import "errors"
+
+func newPeerSource(cfg SourceConfig) (Source, error) {
+	return nil, errors.New("Peer source is not available, build the listener with 'fabric' tag")
+}
@@ -0,0 +1,133 @@
+//go:build fabric
+// +build fabric
+
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// PeerSource receives chaincode events from the Fabric Gateway of a peer, positions are block numbers, see BlockTracker.
+// Without a checkpoint events are received from the next committed block.
+type PeerSource struct {
+	PeerAddress string
+	TLSCertPath string
+	ServerName  string
+	ChannelName string
+	ChaincodeID string
+	MSPID       string
+	CertPath    string
+	KeyPath     string
+}
+
+func newPeerSource(cfg SourceConfig) (Source, error) {
+	if cfg.PeerAddress == "" || cfg.TLSCertPath == "" || cfg.ChannelName == "" || cfg.ChaincodeID == "" {
+		return nil, errors.New("PeerAddress, TLSCertPath, ChannelName and ChaincodeID are required in peer source config")
+	}
+	if cfg.MSPID == "" || cfg.CertPath == "" || cfg.KeyPath == "" {
+		return nil, errors.New("MSPID, CertPath and KeyPath are required in peer source config")
+	}
+	return &PeerSource{PeerAddress: cfg.PeerAddress, TLSCertPath: cfg.TLSCertPath, ServerName: cfg.ServerName,
+		ChannelName: cfg.ChannelName, ChaincodeID: cfg.ChaincodeID, MSPID: cfg.MSPID, CertPath: cfg.CertPath, KeyPath: cfg.KeyPath}, nil
+}
+
+func (s *PeerSource) connect() (*grpc.ClientConn, *client.Gateway, error) {
+	tlsCert, err := ioutil.ReadFile(s.TLSCertPath)
+	if err != nil {
+		return nil, nil, errors.New("Failed reading TLS certificate of the peer: " + err.Error())
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(tlsCert) {
+		return nil, nil, errors.New("TLS certificate of the peer is not PEM")
+	}
+	conn, err := grpc.Dial(s.PeerAddress, grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(certPool, s.ServerName)))
+	if err != nil {
+		return nil, nil, errors.New("Failed connecting to peer: " + err.Error())
+	}
+
+	certPEM, err := ioutil.ReadFile(s.CertPath)
+	if err != nil {
+		conn.Close()
+		return nil, nil, errors.New("Failed reading certificate: " + err.Error())
+	}
+	cert, err := identity.CertificateFromPEM(certPEM)
+	if err != nil {
+		conn.Close()
+		return nil, nil, errors.New("Failed parsing certificate: " + err.Error())
+	}
+	id, err := identity.NewX509Identity(s.MSPID, cert)
+	if err != nil {
+		conn.Close()
+		return nil, nil, errors.New("Failed creating identity: " + err.Error())
+	}
+	keyPEM, err := ioutil.ReadFile(s.KeyPath)
+	if err != nil {
+		conn.Close()
+		return nil, nil, errors.New("Failed reading private key: " + err.Error())
+	}
+	key, err := identity.PrivateKeyFromPEM(keyPEM)
+	if err != nil {
+		conn.Close()
+		return nil, nil, errors.New("Failed parsing private key: " + err.Error())
+	}
+	sign, err := identity.NewPrivateKeySign(key)
+	if err != nil {
+		conn.Close()
+		return nil, nil, errors.New("Failed creating signer: " + err.Error())
+	}
+
+	gw, err := client.Connect(id, client.WithSign(sign), client.WithClientConnection(conn))
+	if err != nil {
+		conn.Close()
+		return nil, nil, errors.New("Failed connecting to gateway: " + err.Error())
+	}
+	return conn, gw, nil
+}
+
+func (s *PeerSource) Run(position int64, handle func(Envelope) error) error {
+	conn, gw, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	defer gw.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var options []client.ChaincodeEventsOption
+	if position > 0 {
+		options = append(options, client.WithStartBlock(uint64(position)+1))
+	}
+	events, err := gw.GetNetwork(s.ChannelName).ChaincodeEvents(ctx, s.ChaincodeID, options...)
+	if err != nil {
+		return errors.New("Failed receiving chaincode events: " + err.Error())
+	}
+
+	blocks := newBlockTracker(position)
+	for e := range events {
+		if env, ok := blocks.Next(int64(e.BlockNumber)); ok {
+			err = handle(env)
+			if err != nil {
+				return err
+			}
+		}
+		if !json.Valid(e.Payload) {
+			return errors.New("Payload of transaction '" + e.TransactionID + "' in block " + strconv.FormatUint(e.BlockNumber, 10) + " is not JSON")
+		}
+		err = handle(Envelope{TxID: e.TransactionID, EventName: e.EventName, Payload: e.Payload})
+		if err != nil {
+			return err
+		}
+	}
+	return errors.New("Chaincode event stream is closed")
+}
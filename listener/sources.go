@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Source calls handle for every received envelope until it fails or the source ends.
+// Replayable sources skip envelopes up to the position.
+type Source interface {
+	Run(position int64, handle func(Envelope) error) error
+}
+
+func newSource(cfg SourceConfig) (Source, error) {
+	switch cfg.Type {
+	case "peer":
+		return newPeerSource(cfg)
+	case "file", "mock":
+		if cfg.Path == "" {
+			return nil, errors.New("Path is missing in " + cfg.Type + " source config")
+		}
+		pollInterval := cfg.PollInterval.Duration
+		if pollInterval == 0 {
+			pollInterval = time.Second
+		}
+		return &FileSource{Path: cfg.Path, Follow: cfg.Type == "file", PollInterval: pollInterval}, nil
+	}
+	return nil, errors.New("Unknown source type '" + cfg.Type + "'")
+}
+
+// FileSource reads envelopes from a JSON lines file, every line is
+// {"TxID": "...", "EventName": "...", "Payload": [...]}. Position is the line number.
+// The file is followed for appended lines if Follow is set, otherwise the source ends at the end of file.
+// Mock source is a file source without following, it replays recorded events in tests and demos.
+type FileSource struct {
+	Path         string
+	Follow       bool
+	PollInterval time.Duration
+}
+
+func (s *FileSource) Run(position int64, handle func(Envelope) error) error {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return errors.New("Failed opening events file: " + err.Error())
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var line int64
+	var pending []byte
+	for {
+		b, err := r.ReadBytes('\n')
+		pending = append(pending, b...)
+		if err != nil {
+			if !s.Follow {
+				if len(pending) == 0 {
+					return nil
+				}
+			} else {
+				// Incomplete line is kept until the rest of it is written
+				time.Sleep(s.PollInterval)
+				continue
+			}
+		}
+
+		line++
+		text := pending
+		pending = nil
+		if line <= position || len(bytes.TrimSpace(text)) == 0 {
+			continue
+		}
+
+		var env Envelope
+		err = json.Unmarshal(text, &env)
+		if err != nil {
+			return errors.New("Failed parsing line " + strconv.FormatInt(line, 10) + " of events file: " + err.Error())
+		}
+		env.Position = line
+		err = handle(env)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// BlockTracker gives positions to block sources, whose positions are block numbers. Events of a block are handled
+// without position and the block is checkpointed when an event of a later block arrives. After restart events are
+// replayed from the block following the checkpoint, events of a partly handled block are skipped as delivered.
+type BlockTracker struct {
+	position int64
+	block    int64
+}
+
+func newBlockTracker(position int64) *BlockTracker {
+	return &BlockTracker{position: position, block: position}
+}
+
+// Returns the envelope which checkpoints the previous block if the event is of a later block
+func (b *BlockTracker) Next(block int64) (Envelope, bool) {
+	previous := b.block
+	b.block = block
+	if block > previous && previous > b.position {
+		return Envelope{Position: previous, Payload: json.RawMessage("[]")}, true
+	}
+	return Envelope{}, false
+}